@@ -48,6 +48,9 @@ func exitOnError(err error) {
 	if err != nil {
 		fmt.Println("Error:", err)
 
+		if exitErr, ok := err.(*cmd.ExitError); ok {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -24,6 +24,210 @@ var Resources map[string]string
 func init() {
 	Resources = make(map[string]string)
 
+	Resources["cli_download.yaml"] =
+		`
+apiVersion: console.openshift.io/v1
+kind: ConsoleCLIDownload
+metadata:
+  name: yaks-cli
+spec:
+  displayName: yaks - Yaks Command Line Interface
+  description: |
+    The yaks client tool runs tests natively on Kubernetes and OpenShift, and installs the Yaks operator.
+  links:
+  - href: https://github.com/jboss-fuse/yaks/releases
+    text: Download the yaks CLI for Linux, Mac and Windows
+
+`
+	Resources["cluster_operator_role_binding.yaml"] =
+		`
+kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: yaks-cluster-operator
+subjects:
+- kind: ServiceAccount
+  name: yaks-cluster-operator
+  # Set to the namespace the cluster-wide operator is installed in
+  namespace: yaks
+roleRef:
+  kind: ClusterRole
+  name: yaks-cluster-operator
+  apiGroup: rbac.authorization.k8s.io
+
+`
+	Resources["cluster_operator_role.yaml"] =
+		`
+# The cluster-wide operator runs the tests of all namespaces and deploys the operators of the namespaces having an
+# Instance, binding them to the yaks role, so it holds the same permissions in all namespaces
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: yaks-cluster-operator
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  - services
+  - endpoints
+  - persistentvolumeclaims
+  - configmaps
+  - secrets
+  - serviceaccounts
+  verbs:
+  - create
+  - delete
+  - deletecollection
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - pods/log
+  - pods/status
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - rbac.authorization.k8s.io
+  resources:
+  - roles
+  - rolebindings
+  verbs:
+  - create
+  - delete
+  - deletecollection
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - get
+  - list
+  - patch
+  - watch
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  - replicasets
+  - statefulsets
+  verbs:
+  - create
+  - delete
+  - deletecollection
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - batch
+  resources:
+  - jobs
+  verbs:
+  - create
+  - delete
+  - deletecollection
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - monitoring.coreos.com
+  resources:
+  - servicemonitors
+  verbs:
+  - get
+  - create
+- apiGroups:
+  - yaks.dev
+  resources:
+  - '*'
+  verbs:
+  - '*'
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - get
+- apiGroups:
+  - config.openshift.io
+  resources:
+  - ingresses
+  verbs:
+  - get
+
+`
+	Resources["cluster_operator_service_account.yaml"] =
+		`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: yaks-cluster-operator
+
+`
+	Resources["cluster_operator.yaml"] =
+		`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: yaks-cluster-operator
+  labels:
+    yaks.dev/component: cluster-operator
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      name: yaks-cluster-operator
+  template:
+    metadata:
+      labels:
+        name: yaks-cluster-operator
+    spec:
+      serviceAccountName: yaks-cluster-operator
+      containers:
+        - name: yaks
+          image: yaks/yaks:0.0.1
+          command:
+          - yaks
+          - operator
+          imagePullPolicy: IfNotPresent
+          env:
+            - name: WATCH_NAMESPACE
+              value: ""
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
+            - name: OPERATOR_NAME
+              value: "yaks-cluster-operator"
+          volumeMounts:
+            - name: podinfo
+              mountPath: /etc/yaks/podinfo
+              readOnly: true
+      volumes:
+        - name: podinfo
+          downwardAPI:
+            items:
+              - path: labels
+                fieldRef:
+                  fieldPath: metadata.labels
+
+`
 	Resources["operator.yaml"] =
 		`
 apiVersion: apps/v1
@@ -61,6 +265,17 @@ spec:
                   fieldPath: metadata.name
             - name: OPERATOR_NAME
               value: "yaks"
+          volumeMounts:
+            - name: podinfo
+              mountPath: /etc/yaks/podinfo
+              readOnly: true
+      volumes:
+        - name: podinfo
+          downwardAPI:
+            items:
+              - path: labels
+                fieldRef:
+                  fieldPath: metadata.labels
 
 `
 	Resources["role_binding.yaml"] =
@@ -133,8 +348,10 @@ rules:
   resources:
   - events
   verbs:
+  - create
   - get
   - list
+  - patch
   - watch
 - apiGroups:
   - apps
@@ -151,6 +368,19 @@ rules:
   - patch
   - update
   - watch
+- apiGroups:
+  - batch
+  resources:
+  - jobs
+  verbs:
+  - create
+  - delete
+  - deletecollection
+  - get
+  - list
+  - patch
+  - update
+  - watch
 - apiGroups:
   - monitoring.coreos.com
   resources:
@@ -283,6 +513,102 @@ metadata:
   labels:
     app: "yaks"
 
+`
+	Resources["crds/yaks_v1alpha1_instance_crd.yaml"] =
+		`
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: instances.yaks.dev
+  annotations:
+    # Increased whenever fields are added to or removed from the schema, see pkg/install/cluster.go
+    yaks.dev/crd-revision: "3"
+spec:
+  group: yaks.dev
+  names:
+    kind: Instance
+    listKind: InstanceList
+    plural: instances
+    singular: instance
+  scope: Namespaced
+  subresources:
+    status: {}
+  validation:
+    openAPIV3Schema:
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          properties:
+            config:
+              properties:
+                cleanupRules:
+                  type: string
+                env:
+                  items:
+                    type: object
+                  type: array
+                image:
+                  type: string
+                paused:
+                  type: boolean
+                timeout:
+                  type: string
+                ttl:
+                  type: string
+              type: object
+            operator:
+              properties:
+                image:
+                  type: string
+                replicas:
+                  format: int32
+                  minimum: 0
+                  type: integer
+              type: object
+          type: object
+        status:
+          properties:
+            conditions:
+              items:
+                properties:
+                  lastTransitionTime:
+                    format: date-time
+                    type: string
+                  message:
+                    type: string
+                  reason:
+                    type: string
+                  status:
+                    type: string
+                  type:
+                    type: string
+                required:
+                - type
+                - status
+                type: object
+              type: array
+            message:
+              type: string
+            phase:
+              type: string
+          type: object
+  version: v1alpha1
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+
 `
 	Resources["crds/yaks_v1alpha1_test_crd.yaml"] =
 		`
@@ -290,6 +616,9 @@ apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
 metadata:
   name: tests.yaks.dev
+  annotations:
+    # Increased whenever fields are added to or removed from the schema, see pkg/install/cluster.go
+    yaks.dev/crd-revision: "1"
 spec:
   group: yaks.dev
   names:
@@ -305,6 +634,28 @@ spec:
       type: string
       description: The test phase
       JSONPath: .status.phase
+    - name: Reason
+      type: string
+      description: The code explaining the test phase, e.g. why it has failed
+      JSONPath: .status.reason
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+    - name: Message
+      type: string
+      description: The reason of the test result, e.g. why it has been skipped
+      JSONPath: .status.message
+      priority: 1
+    - name: Started
+      type: date
+      description: When the test workload has been created
+      JSONPath: .status.timings.running
+      priority: 1
+    - name: Completed
+      type: date
+      description: When the test result has been set
+      JSONPath: .status.timings.completed
+      priority: 1
   validation:
     openAPIV3Schema:
       properties:
@@ -322,22 +673,381 @@ spec:
           type: object
         spec:
           properties:
+            assertions:
+              properties:
+                artifacts:
+                  items:
+                    properties:
+                      check:
+                        enum:
+                        - exists
+                        - nonEmpty
+                        - matches
+                        type: string
+                      path:
+                        type: string
+                      pattern:
+                        type: string
+                    required:
+                    - path
+                    type: object
+                  type: array
+              type: object
+            dependencies:
+              items:
+                properties:
+                  kind:
+                    type: string
+                  name:
+                    type: string
+                required:
+                - kind
+                - name
+                type: object
+              type: array
+            endpoints:
+              items:
+                properties:
+                  name:
+                    type: string
+                  service:
+                    properties:
+                      name:
+                        type: string
+                      namespace:
+                        type: string
+                      port:
+                        format: int32
+                        type: integer
+                    required:
+                    - name
+                    type: object
+                  url:
+                    type: string
+                required:
+                - name
+                type: object
+              type: array
+            namespace:
+              type: string
+            readinessGates:
+              items:
+                properties:
+                  condition:
+                    properties:
+                      apiVersion:
+                        type: string
+                      name:
+                        type: string
+                      resource:
+                        type: string
+                      status:
+                        type: string
+                      type:
+                        type: string
+                    required:
+                    - apiVersion
+                    - resource
+                    - name
+                    - type
+                    type: object
+                  http:
+                    properties:
+                      status:
+                        format: int32
+                        type: integer
+                      url:
+                        type: string
+                    required:
+                    - url
+                    type: object
+                  tcp:
+                    properties:
+                      host:
+                        type: string
+                      port:
+                        format: int32
+                        maximum: 65535
+                        minimum: 1
+                        type: integer
+                    required:
+                    - host
+                    - port
+                    type: object
+                  timeout:
+                    type: string
+                type: object
+              type: array
+            reports:
+              properties:
+                claimName:
+                  type: string
+                path:
+                  type: string
+              type: object
+            requires:
+              items:
+                type: string
+              type: array
             source:
               properties:
                 content:
                   type: string
+                filter:
+                  properties:
+                    line:
+                      format: int32
+                      minimum: 1
+                      type: integer
+                    scenario:
+                      type: string
+                  type: object
                 language:
                   type: string
                 name:
                   type: string
               type: object
+            sources:
+              items:
+                properties:
+                  content:
+                    type: string
+                  language:
+                    type: string
+                  name:
+                    type: string
+                type: object
+              type: array
+            startAfter:
+              type: string
+            traceId:
+              type: string
+            instance:
+              type: string
+            ephemeralSecrets:
+              items:
+                properties:
+                  name:
+                    type: string
+                  keys:
+                    items:
+                      properties:
+                        name:
+                          type: string
+                        template:
+                          type: string
+                        length:
+                          format: int32
+                          minimum: 1
+                          maximum: 256
+                          type: integer
+                      required:
+                      - name
+                      type: object
+                    minItems: 1
+                    type: array
+                required:
+                - name
+                - keys
+                type: object
+              type: array
+            operatorSelector:
+              properties:
+                matchExpressions:
+                  items:
+                    properties:
+                      key:
+                        type: string
+                      operator:
+                        type: string
+                      values:
+                        items:
+                          type: string
+                        type: array
+                    required:
+                    - key
+                    - operator
+                    type: object
+                  type: array
+                matchLabels:
+                  additionalProperties:
+                    type: string
+                  type: object
+              type: object
+            runtime:
+              properties:
+                args:
+                  items:
+                    type: string
+                  type: array
+                clusterAccess:
+                  type: boolean
+                command:
+                  items:
+                    type: string
+                  type: array
+                env:
+                  items:
+                    type: object
+                  type: array
+                debug:
+                  properties:
+                    mode:
+                      enum:
+                      - OnFailure
+                      - Always
+                      type: string
+                    timeout:
+                      type: string
+                  type: object
+                imagePullPolicy:
+                  enum:
+                  - Always
+                  - IfNotPresent
+                  - Never
+                  type: string
+                imagePullSecrets:
+                  items:
+                    properties:
+                      name:
+                        type: string
+                    type: object
+                  type: array
+                javaOptions:
+                  type: string
+                podAnnotations:
+                  additionalProperties:
+                    type: string
+                  type: object
+                podLabels:
+                  additionalProperties:
+                    type: string
+                  type: object
+                resultFormat:
+                  enum:
+                  - citrus
+                  - tap
+                  - json
+                  type: string
+                retryLimit:
+                  format: int32
+                  minimum: 0
+                  type: integer
+                retryOnFailure:
+                  items:
+                    type: string
+                  type: array
+                savePodManifest:
+                  type: boolean
+                trafficCapture:
+                  properties:
+                    filter:
+                      type: string
+                    image:
+                      type: string
+                    volume:
+                      type: string
+                  type: object
+                trustedCA:
+                  properties:
+                    name:
+                      type: string
+                  type: object
+                volumes:
+                  items:
+                    type: object
+                  type: array
+                volumeMounts:
+                  items:
+                    type: object
+                  type: array
+                workingDir:
+                  type: string
+                workload:
+                  enum:
+                  - Pod
+                  - Job
+                  type: string
+                workspaceSize:
+                  type: string
+              type: object
           type: object
         status:
           properties:
+            conditions:
+              items:
+                properties:
+                  lastTransitionTime:
+                    format: date-time
+                    type: string
+                  message:
+                    type: string
+                  reason:
+                    type: string
+                  status:
+                    type: string
+                  type:
+                    type: string
+                required:
+                - type
+                - status
+                type: object
+              type: array
+            exitCode:
+              format: int32
+              type: integer
+            failedAssertions:
+              items:
+                type: string
+              type: array
+            message:
+              type: string
             phase:
               type: string
+            podManifest:
+              type: string
+            reason:
+              type: string
+            reports:
+              type: string
+            results:
+              items:
+                properties:
+                  message:
+                    type: string
+                  name:
+                    type: string
+                  status:
+                    type: string
+                required:
+                - name
+                - status
+                type: object
+              type: array
+            retryAt:
+              format: date-time
+              type: string
+            scheduledStart:
+              format: date-time
+              type: string
             testID:
               type: string
+            traceId:
+              type: string
+            waitingFor:
+              type: string
+            timings:
+              properties:
+                completed:
+                  format: date-time
+                  type: string
+                pending:
+                  format: date-time
+                  type: string
+                running:
+                  format: date-time
+                  type: string
+              type: object
             version:
               type: string
           type: object
@@ -355,8 +1065,15 @@ kind: Test
 metadata:
   name: example-test
 spec:
-  # Add fields here
-  size: 3
+  source:
+    name: simple.feature
+    language: feature
+    content: |-
+      Feature: integration runs
+
+        Scenario:
+          Given integration simple is running
+          Then integration simple should print Hello Camel
 
 `
 
@@ -8,6 +8,7 @@ import java.security.KeyStoreException;
 import java.security.NoSuchAlgorithmException;
 import java.util.HashMap;
 import java.util.Map;
+import java.util.concurrent.ThreadLocalRandom;
 
 import com.consol.citrus.Citrus;
 import com.consol.citrus.annotations.CitrusFramework;
@@ -44,6 +45,10 @@ import org.springframework.util.StringUtils;
  */
 public class HttpClientSteps implements HttpSteps {
 
+    /** Trace ID of the test run, propagated to the requests as W3C trace context */
+    private static final String TRACE_ID_ENV = "YAKS_TRACE_ID";
+    private static final String TRACE_PARENT_HEADER = "traceparent";
+
     @CitrusResource
     private TestRunner runner;
 
@@ -80,6 +85,10 @@ public class HttpClientSteps implements HttpSteps {
         }
 
         requestHeaders = new HashMap<>();
+        String traceId = System.getenv(TRACE_ID_ENV);
+        if (StringUtils.hasText(traceId)) {
+            requestHeaders.put(TRACE_PARENT_HEADER, String.format("00-%s-%016x-01", traceId, ThreadLocalRandom.current().nextLong() | 1L));
+        }
         responseHeaders = new HashMap<>();
         requestParams = new HashMap<>();
         requestMessageType = Citrus.DEFAULT_MESSAGE_TYPE;
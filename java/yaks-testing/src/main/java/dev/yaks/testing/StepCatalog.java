@@ -0,0 +1,59 @@
+package dev.yaks.testing;
+
+import java.lang.annotation.Annotation;
+import java.lang.reflect.Method;
+import java.util.ArrayList;
+import java.util.List;
+
+import cucumber.runtime.ClassFinder;
+import cucumber.runtime.io.MultiLoader;
+import cucumber.runtime.io.ResourceLoader;
+import cucumber.runtime.io.ResourceLoaderClassFinder;
+import cucumber.runtime.java.StepDefAnnotation;
+
+/**
+ * Prints the step definitions of the glue packages of the test runner, one per line in the form
+ * STEP&lt;tab&gt;keyword&lt;tab&gt;expression&lt;tab&gt;comma separated parameter types, as read by yaks steps.
+ */
+public class StepCatalog {
+
+    /** Prefix of the lines holding a step definition, telling them apart from the other output of the container */
+    public static final String LINE_PREFIX = "STEP\t";
+
+    public static void main(String[] args) {
+        try {
+            ClassLoader classLoader = StepCatalog.class.getClassLoader();
+            ResourceLoader resourceLoader = new MultiLoader(classLoader);
+            ClassFinder classFinder = new ResourceLoaderClassFinder(resourceLoader, classLoader);
+
+            for (String glue : TestRunner.GLUE) {
+                for (Class<?> glueClass : classFinder.getDescendants(Object.class, glue)) {
+                    for (Method method : glueClass.getMethods()) {
+                        printSteps(method);
+                    }
+                }
+            }
+            System.exit(0);
+        } catch (Exception e) {
+            e.printStackTrace();
+            System.exit(TestRunner.EXIT_CODE_ERROR);
+        }
+    }
+
+    private static void printSteps(Method method) throws ReflectiveOperationException {
+        for (Annotation annotation : method.getAnnotations()) {
+            Class<? extends Annotation> type = annotation.annotationType();
+            if (!type.isAnnotationPresent(StepDefAnnotation.class)) {
+                continue;
+            }
+            String expression = (String) type.getMethod("value").invoke(annotation);
+
+            List<String> parameters = new ArrayList<>();
+            for (Class<?> parameter : method.getParameterTypes()) {
+                parameters.add(parameter.getSimpleName());
+            }
+            System.out.println(LINE_PREFIX + type.getSimpleName() + "\t" + expression.replaceAll("[\t\n]", " ")
+                    + "\t" + String.join(",", parameters));
+        }
+    }
+}
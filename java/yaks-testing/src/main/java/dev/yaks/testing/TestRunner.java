@@ -3,6 +3,7 @@ package dev.yaks.testing;
 import java.io.IOException;
 import java.io.InputStream;
 import java.util.ArrayList;
+import java.util.Arrays;
 import java.util.List;
 import java.util.Properties;
 
@@ -19,7 +20,29 @@ public class TestRunner {
     public TestRunner() {
     }
 
-    public static void main(String[] args) throws IOException {
+    /** Exit code returned when the tests could not be run, as opposed to failed test scenarios (exit code 1) */
+    public static final int EXIT_CODE_ERROR = 2;
+
+    /** Packages holding the step definitions available to the tests */
+    public static final List<String> GLUE = Arrays.asList(
+            "com.consol.citrus.cucumber.step.runner.core",
+            "dev.yaks.testing.http",
+            "dev.yaks.testing.swagger",
+            "dev.yaks.testing.camel",
+            "dev.yaks.testing.camel.k",
+            "dev.yaks.testing.jdbc",
+            "dev.yaks.testing.standard");
+
+    public static void main(String[] args) {
+        try {
+            System.exit(run());
+        } catch (Exception e) {
+            e.printStackTrace();
+            System.exit(EXIT_CODE_ERROR);
+        }
+    }
+
+    private static int run() throws IOException {
         ClassLoader classLoader = TestRunner.class.getClassLoader();
         ResourceLoader resourceLoader = new MultiLoader(classLoader);
         ClassFinder classFinder = new ResourceLoaderClassFinder(resourceLoader, classLoader);
@@ -30,26 +53,10 @@ public class TestRunner {
         }
 
         List<String> params = new ArrayList<>();
-        params.add("--glue");
-        params.add("com.consol.citrus.cucumber.step.runner.core");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.http");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.swagger");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.camel");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.camel.k");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.jdbc");
-
-        params.add("--glue");
-        params.add("dev.yaks.testing.standard");
+        for (String glue : GLUE) {
+            params.add("--glue");
+            params.add(glue);
+        }
 
         params.add("--plugin");
         params.add(TestReporter.class.getName());
@@ -65,6 +72,6 @@ public class TestRunner {
         Runtime runtime = new Runtime(resourceLoader, classFinder, classLoader, options);
         runtime.run();
 
-        System.exit(runtime.exitStatus());
+        return runtime.exitStatus();
     }
 }
@@ -0,0 +1,112 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InstanceSpec defines the desired state of Instance
+// +k8s:openapi-gen=true
+type InstanceSpec struct {
+	// Config holds the defaults applied to the tests in the namespace of the instance
+	Config InstanceConfig `json:"config,omitempty"`
+	// Operator deployed in the namespace of the instance by a cluster-wide operator, that leaves the tests of the
+	// namespace to it. The operator is kept as specified, drift included, until the instance is deleted.
+	Operator *InstanceOperatorSpec `json:"operator,omitempty"`
+}
+
+// InstanceOperatorSpec defines the operator managed for the namespace of an instance
+type InstanceOperatorSpec struct {
+	// Image of the operator, defaults to the image of the release
+	Image    string `json:"image,omitempty"`
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// InstanceConfig defines the default settings of the tests in the scope of an instance
+type InstanceConfig struct {
+	// Image used to run the tests, overriding the operator wide test base image
+	Image string `json:"image,omitempty"`
+	// Env added to the runner container, unless the test sets the same variable
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Timeout after which the runner of a test is stopped and the test fails with reason Timeout, e.g. 30m
+	Timeout string `json:"timeout,omitempty"`
+	// TTL of the completed tests, overriding the operator wide TEST_TTL, e.g. 7d
+	TTL string `json:"ttl,omitempty"`
+	// CleanupRules of the completed tests, overriding the operator wide TEST_CLEANUP_RULES
+	CleanupRules string `json:"cleanupRules,omitempty"`
+	// Paused postpones the start of the tests in the scope of the instance until it is unset, the running tests are
+	// still monitored
+	Paused bool `json:"paused,omitempty"`
+}
+
+// InstanceStatus defines the observed state of Instance
+// +k8s:openapi-gen=true
+type InstanceStatus struct {
+	// Phase of the operator managed for the instance, if any
+	Phase InstancePhase `json:"phase,omitempty"`
+	// Message describing why the operator cannot be managed
+	Message string `json:"message,omitempty"`
+	// Conditions give the latest observations of the operator managed for the instance
+	Conditions []InstanceCondition `json:"conditions,omitempty"`
+}
+
+// InstanceCondition --
+type InstanceCondition struct {
+	Type   InstanceConditionType  `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// Reason is a machine readable code for the last transition of the condition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition of the condition
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Instance is the Schema for the instances API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type Instance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InstanceSpec   `json:"spec,omitempty"`
+	Status InstanceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// InstanceList contains a list of Instance
+type InstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Instance `json:"items"`
+}
+
+const (
+	// InstanceKind --
+	InstanceKind string = "Instance"
+)
+
+// InstancePhase --
+type InstancePhase string
+
+const (
+	// InstancePhaseReady is set once the resources of the operator of the instance are in their desired state
+	InstancePhaseReady InstancePhase = "Ready"
+	// InstancePhaseError is set when the resources of the operator of the instance cannot be reconciled
+	InstancePhaseError InstancePhase = "Error"
+)
+
+// InstanceConditionType --
+type InstanceConditionType string
+
+const (
+	// InstanceConditionOperatorImageAllowed tells whether the image of the operator of the instance is one of the images
+	// allowed by the cluster-wide operator. It is false with the rejected image in the message otherwise.
+	InstanceConditionOperatorImageAllowed InstanceConditionType = "OperatorImageAllowed"
+)
+
+func init() {
+	SchemeBuilder.Register(&Instance{}, &InstanceList{})
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -15,13 +16,249 @@ type TestSpec struct {
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 
 	Source SourceSpec `json:"source,omitempty"`
+	// Sources are additional test sources, run together with the main source in the same runner
+	Sources []SourceSpec `json:"sources,omitempty"`
+	Runtime RuntimeSpec  `json:"runtime,omitempty"`
+	// Endpoints made available to the test, injected into the runner as environment variables
+	Endpoints []EndpointSpec `json:"endpoints,omitempty"`
+	// Namespace where the test creates its resources, defaults to the namespace of the test. It must be allowed
+	// by the operator wide ALLOWED_TARGET_NAMESPACES setting.
+	Namespace string `json:"namespace,omitempty"`
+	// Requires lists the API groups (e.g. route.openshift.io) or group versions (e.g. serving.knative.dev/v1alpha1)
+	// that must be available in the cluster, the test is skipped otherwise
+	Requires []string `json:"requires,omitempty"`
+	// Dependencies that must be ready, in the given order, before the test is started
+	Dependencies []DependencySpec `json:"dependencies,omitempty"`
+	// ReadinessGates are external conditions that must be met, in the given order, once the dependencies are ready
+	// and before the test is started
+	ReadinessGates []ReadinessGate `json:"readinessGates,omitempty"`
+	// Assertions evaluated once the runner has finished, failing the test when one of them is not met
+	Assertions *AssertionsSpec `json:"assertions,omitempty"`
+	// Reports configures the shared storage where the reports of the test are kept
+	Reports *ReportsSpec `json:"reports,omitempty"`
+	// StartAfter delays the start of the test, either by a duration from the time it is pending, e.g. 10m, or until an
+	// RFC 3339 timestamp, e.g. 2019-10-01T08:00:00Z
+	StartAfter string `json:"startAfter,omitempty"`
+	// TraceID correlates the requests of the test with the traces of the systems under test, as 32 lowercase hex
+	// characters. A random one is generated for each run when empty.
+	TraceID string `json:"traceId,omitempty"`
+	// Instance of the namespace whose config holds the defaults of the test, e.g. its env, instead of the instance
+	// owning the test or the first instance of the namespace
+	Instance string `json:"instance,omitempty"`
+	// EphemeralSecrets are generated with random values in the namespace of the test when it is started, for its
+	// fixtures and its runner to share them, and deleted with the test
+	EphemeralSecrets []EphemeralSecretSpec `json:"ephemeralSecrets,omitempty"`
+	// OperatorSelector restricts the operators running the test to the ones whose labels match, e.g. to run a subset of
+	// the tests on a canary operator
+	OperatorSelector *metav1.LabelSelector `json:"operatorSelector,omitempty"`
 }
 
+// EphemeralSecretSpec describes a Secret generated for the test, that the fixtures of the test reference by name
+type EphemeralSecretSpec struct {
+	Name string `json:"name"`
+	// Keys of the Secret, generated in the given order
+	Keys []EphemeralSecretKey `json:"keys"`
+}
+
+// EphemeralSecretKey describes how the value of a key of an ephemeral Secret is generated
+type EphemeralSecretKey struct {
+	Name string `json:"name"`
+	// Template of the value, where ${RANDOM} is replaced with a random alphanumeric string and ${<key>} with the
+	// value of a previous key of the Secret, e.g. postgresql://test:${password}@db:5432/test. Defaults to ${RANDOM}.
+	Template string `json:"template,omitempty"`
+	// Length of the random strings, defaults to 16
+	Length int32 `json:"length,omitempty"`
+}
+
+// ReportsSpec --
+type ReportsSpec struct {
+	// ClaimName is the persistent volume claim of the test namespace where the reports are kept, overriding the
+	// operator wide REPORT_CLAIM
+	ClaimName string `json:"claimName,omitempty"`
+	// Path is the directory where the runner writes its reports, relative to its working directory, copied to the
+	// claim once the test has run
+	Path string `json:"path,omitempty"`
+}
+
+// AssertionsSpec --
+type AssertionsSpec struct {
+	// Artifacts are checks on the files produced by the test
+	Artifacts []ArtifactAssertion `json:"artifacts,omitempty"`
+}
+
+// ArtifactAssertion checks a file produced by the test. The file must be written to the workspace or to one of the
+// runtime volumes, relative paths being resolved against the working directory of the runner.
+type ArtifactAssertion struct {
+	Path string `json:"path"`
+	// Check is one of exists (default), nonEmpty or matches
+	Check ArtifactCheck `json:"check,omitempty"`
+	// Pattern is the extended regular expression a line of the file must match, for the matches check
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ArtifactCheck --
+type ArtifactCheck string
+
+const (
+	// ArtifactCheckExists --
+	ArtifactCheckExists ArtifactCheck = "exists"
+	// ArtifactCheckNonEmpty --
+	ArtifactCheckNonEmpty ArtifactCheck = "nonEmpty"
+	// ArtifactCheckMatches --
+	ArtifactCheckMatches ArtifactCheck = "matches"
+)
+
 // SourceSpec--
 type SourceSpec struct {
 	Name     string   `json:"name,omitempty"`
 	Content  string   `json:"content,omitempty"`
 	Language Language `json:"language,omitempty"`
+	// Filter restricts the scenarios of the source that are run
+	Filter *SourceFilter `json:"filter,omitempty"`
+}
+
+// SourceFilter selects the scenarios to run, either by name or by line
+type SourceFilter struct {
+	// Scenario is the name of the scenario to run
+	Scenario string `json:"scenario,omitempty"`
+	// Line of the scenario to run
+	Line int32 `json:"line,omitempty"`
+}
+
+// RuntimeSpec defines the settings applied to the test runner pod
+type RuntimeSpec struct {
+	// Volumes added to the runner pod, e.g. a PersistentVolumeClaim holding shared test data
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts added to the runner container
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// Env added to the runner container, overriding the defaults of the instance. Values may use the ${NAMESPACE},
+	// ${TARGET_NAMESPACE}, ${TEST_NAME} and ${CLUSTER_DOMAIN} placeholders, resolved when the test is started.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// ImagePullPolicy of the runner container, one of Always, IfNotPresent (default) or Never
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// ImagePullSecrets used to pull the runner image, replacing the operator wide DEFAULT_IMAGE_PULL_SECRET
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// JavaOptions passed to the JVM of the runner, e.g. -Xmx1g or a -javaagent, replacing the operator wide
+	// DEFAULT_JAVA_OPTIONS. They only apply to the standard Java runner.
+	JavaOptions string `json:"javaOptions,omitempty"`
+	// TrustedCA references a ConfigMap containing PEM encoded CA certificates trusted by the runner
+	TrustedCA *corev1.LocalObjectReference `json:"trustedCA,omitempty"`
+	// ClusterAccess gives the tests access to the Kubernetes API with the permissions of the runner service account,
+	// through a kubeconfig file referenced by the KUBECONFIG environment variable
+	ClusterAccess bool `json:"clusterAccess,omitempty"`
+	// Workload running the test, either Pod or Job, defaults to the operator wide TEST_WORKLOAD setting
+	Workload WorkloadType `json:"workload,omitempty"`
+	// RetryLimit is the number of times a failed test is retried, only supported by the Job workload
+	RetryLimit *int32 `json:"retryLimit,omitempty"`
+	// RetryOnFailure lists the reasons of the failures the test is retried on, e.g. ImagePullError or Timeout but not the
+	// deterministic AssertionFailed. The retries are then run by the operator for any workload, up to the retry limit
+	// (1 when not set), and the failures with other reasons are not retried.
+	RetryOnFailure []TestReason `json:"retryOnFailure,omitempty"`
+	// Command overrides the entrypoint of the runner container, bypassing the standard runner when it is not invoked
+	Command []string `json:"command,omitempty"`
+	// Args passed to the command of the runner container
+	Args []string `json:"args,omitempty"`
+	// ResultFormat of the scenario results written by the runner to its termination log, one of citrus (default),
+	// tap or json
+	ResultFormat ResultFormat `json:"resultFormat,omitempty"`
+	// PodLabels added to the runner pod, e.g. to select it in network policies. They do not override the labels set
+	// by the operator.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+	// PodAnnotations added to the runner pod, e.g. to control the injection of service mesh sidecars. They do not
+	// override the annotations set by the operator.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// WorkspaceSize limits the size of the emptyDir workspace of the runner, e.g. 5Gi, defaults to 1Gi
+	WorkspaceSize string `json:"workspaceSize,omitempty"`
+	// WorkingDir of the runner container, defaults to the workspace
+	WorkingDir string `json:"workingDir,omitempty"`
+	// TrafficCapture runs a sidecar capturing the network traffic of the runner pod to a pcap file
+	TrafficCapture *TrafficCaptureSpec `json:"trafficCapture,omitempty"`
+	// Debug keeps the runner container alive once the tests have run, so that it can be inspected with a shell
+	Debug *DebugSpec `json:"debug,omitempty"`
+	// SavePodManifest keeps the manifest of the runner pod, as generated by the operator, in a ConfigMap named in the
+	// status of the test
+	SavePodManifest bool `json:"savePodManifest,omitempty"`
+}
+
+// DebugSpec --
+type DebugSpec struct {
+	// Mode tells when the runner container is kept alive, one of OnFailure (default) or Always
+	Mode DebugMode `json:"mode,omitempty"`
+	// Timeout after which the runner container exits anyway, e.g. 1h (defaults to 30m, at most 24h)
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// TrafficCaptureSpec --
+type TrafficCaptureSpec struct {
+	// Image of the capture sidecar, it must provide a shell, tcpdump and base64 (defaults to the runner base image)
+	Image string `json:"image,omitempty"`
+	// Filter is the tcpdump expression selecting the captured packets, defaults to all TCP traffic
+	Filter string `json:"filter,omitempty"`
+	// Volume is the name of one of the runtime volumes the capture is written to, e.g. a PersistentVolumeClaim.
+	// The capture is written base64 encoded to the log of the sidecar otherwise.
+	Volume string `json:"volume,omitempty"`
+}
+
+// EndpointSpec maps a logical name to either an URL or a reference to a cluster service
+type EndpointSpec struct {
+	// Name of the endpoint, exposed to the runner as the YAKS_ENDPOINT_<NAME> environment variable
+	Name string `json:"name"`
+	// URL of an external endpoint
+	URL string `json:"url,omitempty"`
+	// Service references a cluster service, resolved to its cluster DNS name
+	Service *ServiceReference `json:"service,omitempty"`
+}
+
+// DependencySpec references a resource of the target namespace of the test, that must be ready before it is started
+type DependencySpec struct {
+	// Kind of the resource, one of Deployment, StatefulSet, Pod, Job, Service or a kind added by a fixture provider
+	Kind DependencyKind `json:"kind"`
+	Name string         `json:"name"`
+}
+
+// ReadinessGate is met when its single HTTP, TCP or resource condition check succeeds
+type ReadinessGate struct {
+	HTTP      *HTTPGate      `json:"http,omitempty"`
+	TCP       *TCPGate       `json:"tcp,omitempty"`
+	Condition *ConditionGate `json:"condition,omitempty"`
+	// Timeout after which the test is set in error if the gate is not met, counted from when the test has been
+	// queued to start, or from its scheduled start when delayed. Defaults to 5m.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// HTTPGate is met when a GET request to the URL returns the expected status
+type HTTPGate struct {
+	URL string `json:"url"`
+	// Status expected in the response, defaults to 200
+	Status int32 `json:"status,omitempty"`
+}
+
+// TCPGate is met when a connection to the port of the host can be opened
+type TCPGate struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+}
+
+// ConditionGate is met when a resource of the target namespace of the test reports the condition with the expected
+// status, e.g. the Available condition of a Deployment. The operator must be allowed to read the resource.
+type ConditionGate struct {
+	// APIVersion of the resource, e.g. apps/v1
+	APIVersion string `json:"apiVersion"`
+	// Resource is the plural name of the resource type, e.g. deployments
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	// Type of the condition in the status of the resource
+	Type string `json:"type"`
+	// Status expected for the condition, defaults to True
+	Status string `json:"status,omitempty"`
+}
+
+// ServiceReference --
+type ServiceReference struct {
+	Name string `json:"name"`
+	// Namespace of the service, defaults to the namespace of the test
+	Namespace string `json:"namespace,omitempty"`
+	Port      int32  `json:"port,omitempty"`
 }
 
 // TestStatus defines the observed state of Test
@@ -35,6 +272,63 @@ type TestStatus struct {
 	TestID  string    `json:"testID,omitempty"`
 	Digest  string    `json:"digest,omitempty"`
 	Version string    `json:"version,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// ExitCode of the runner container, once terminated
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Timings records when the last run of the test entered its phases
+	Timings *TestTimings `json:"timings,omitempty"`
+	// Results of the scenarios of the last run, as reported by the runner
+	Results []ScenarioResult `json:"results,omitempty"`
+	// WaitingFor is the dependency that is not ready yet, or the readiness gate that is not met, while the test is pending
+	WaitingFor string `json:"waitingFor,omitempty"`
+	// Reason is a machine readable code explaining the current phase
+	Reason TestReason `json:"reason,omitempty"`
+	// Conditions give the latest observations of the state of the test
+	Conditions []TestCondition `json:"conditions,omitempty"`
+	// PodManifest is the ConfigMap holding the manifest of the runner pod of the last run, when saved
+	PodManifest string `json:"podManifest,omitempty"`
+	// FailedAssertions lists the assertions of the last run that have not been met
+	FailedAssertions []string `json:"failedAssertions,omitempty"`
+	// Reports is where the reports of the last run are kept, as <claim>:<directory>
+	Reports string `json:"reports,omitempty"`
+	// ScheduledStart is when the test is started, when delayed with startAfter or by the backoff of a retry
+	ScheduledStart *metav1.Time `json:"scheduledStart,omitempty"`
+	// TraceID of the last run, exposed to the runner as YAKS_TRACE_ID
+	TraceID string `json:"traceId,omitempty"`
+	// Retries is the number of times the test has been run again after a failure listed in retryOnFailure, since its
+	// spec last changed
+	Retries int32 `json:"retries,omitempty"`
+	// RetryAt is when the test retried on the reason of its last failure is started again, after a backoff
+	RetryAt *metav1.Time `json:"retryAt,omitempty"`
+}
+
+// TestCondition --
+type TestCondition struct {
+	Type   TestConditionType      `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// Reason is a machine readable code for the last transition of the condition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition of the condition
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// TestTimings --
+type TestTimings struct {
+	// Pending is when the test has been queued to start
+	Pending *metav1.Time `json:"pending,omitempty"`
+	// Running is when the test workload has been created
+	Running *metav1.Time `json:"running,omitempty"`
+	// Completed is when the test result has been set
+	Completed *metav1.Time `json:"completed,omitempty"`
+}
+
+// ScenarioResult --
+type ScenarioResult struct {
+	Name   string         `json:"name"`
+	Status ScenarioStatus `json:"status"`
+	// Message describing the cause of a failure or the reason of a skip
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -78,10 +372,159 @@ const (
 	TestPhaseFailed TestPhase = "Failed"
 	// TestPhaseError --
 	TestPhaseError TestPhase = "Error"
+	// TestPhaseSkipped --
+	TestPhaseSkipped TestPhase = "Skipped"
 	// TestPhaseDeleting --
 	TestPhaseDeleting TestPhase = "Deleting"
 )
 
+// TestReason --
+type TestReason string
+
+const (
+	// TestReasonConcurrencyLimit is set on pending tests waiting for the number of running tests to drop below the
+	// operator wide MAX_CONCURRENT_TESTS
+	TestReasonConcurrencyLimit TestReason = "ConcurrencyLimit"
+	// TestReasonCancelled is set on tests whose run has been cancelled with the TestCancelAnnotation
+	TestReasonCancelled TestReason = "Cancelled"
+	// TestReasonReadinessGateTimeout is set on tests whose readiness gate has not been met within its timeout
+	TestReasonReadinessGateTimeout TestReason = "ReadinessGateTimeout"
+	// TestReasonRBACDenied is set on tests whose runner the operator is not allowed to create in the namespace
+	TestReasonRBACDenied TestReason = "RBACDenied"
+	// TestReasonQuotaExceeded is set on tests whose runner exceeds the resource quota of the namespace
+	TestReasonQuotaExceeded TestReason = "QuotaExceeded"
+	// TestReasonAdmissionDenied is set on tests whose runner has been rejected by an admission controller, e.g. a pod
+	// security policy
+	TestReasonAdmissionDenied TestReason = "AdmissionDenied"
+	// TestReasonScheduled is set on pending tests waiting for the start time given by their startAfter
+	TestReasonScheduled TestReason = "Scheduled"
+	// TestReasonRequirementNotMet is set on tests skipped because an API they require is not available in the cluster
+	TestReasonRequirementNotMet TestReason = "RequirementNotMet"
+	// TestReasonInvalidSpec is set on tests whose spec does not pass the validation of the operator
+	TestReasonInvalidSpec TestReason = "InvalidSpec"
+	// TestReasonTargetNamespaceUnavailable is set on tests whose target namespace cannot be used by the runner
+	TestReasonTargetNamespaceUnavailable TestReason = "TargetNamespaceUnavailable"
+	// TestReasonSecretConflict is set on tests whose ephemeral secret collides with a secret not generated for them
+	TestReasonSecretConflict TestReason = "SecretConflict"
+	// TestReasonFixtureFailed is set on tests whose dependency has failed
+	TestReasonFixtureFailed TestReason = "FixtureFailed"
+	// TestReasonImagePullError is set on tests whose runner image cannot be pulled
+	TestReasonImagePullError TestReason = "ImagePullError"
+	// TestReasonTimeout is set on tests whose runner has been stopped because it exceeded its active deadline
+	TestReasonTimeout TestReason = "Timeout"
+	// TestReasonOutOfMemory is set on tests whose runner container has been killed for exceeding its memory limit
+	TestReasonOutOfMemory TestReason = "OutOfMemory"
+	// TestReasonScenarioFailed is set on tests with failed scenarios
+	TestReasonScenarioFailed TestReason = "ScenarioFailed"
+	// TestReasonAssertionFailed is set on tests whose scenarios have passed but whose assertions are not met
+	TestReasonAssertionFailed TestReason = "AssertionFailed"
+	// TestReasonRunnerError is set on tests whose runner has exited with an error other than failed scenarios
+	TestReasonRunnerError TestReason = "RunnerError"
+	// TestReasonRunnerNotFound is set on running tests whose runner pod or Job has been deleted
+	TestReasonRunnerNotFound TestReason = "RunnerNotFound"
+)
+
+// TestCancelAnnotation is set to the ID of the run of the test to cancel, so that later runs are not cancelled
+const TestCancelAnnotation = "yaks.dev/cancel"
+
+// TestGroupLabel is set on the tests created from the subdirectories of a <dir>/... argument, to the directory they
+// come from
+const TestGroupLabel = "yaks.dev/group"
+
+// TestNameLabel groups the tests that are runs of the same logical test, of which the operator keeps at most
+// MAX_HISTORY_PER_TEST completed ones
+const TestNameLabel = "yaks.dev/test-name"
+
+// TestTTLAnnotation overrides the operator wide TEST_TTL of the test, e.g. 1h or 7d
+const TestTTLAnnotation = "yaks.dev/ttl"
+
+// TestCleanupRulesAnnotation holds cleanup rules of the test, evaluated before the operator wide TEST_CLEANUP_RULES
+const TestCleanupRulesAnnotation = "yaks.dev/cleanup-rules"
+
+// TestConditionType --
+type TestConditionType string
+
+const (
+	// TestConditionFixtureReady tells whether the dependencies of the test, i.e. the fixtures it runs against, are
+	// ready. It is false with the name of the dependency in the message while one of them is not ready, or failed.
+	TestConditionFixtureReady TestConditionType = "FixtureReady"
+	// TestConditionReadinessGatesReady tells whether the readiness gates of the test are all met. It is false with
+	// the gate waited on in the message while one of them is not met.
+	TestConditionReadinessGatesReady TestConditionType = "ReadinessGatesReady"
+	// TestConditionReportStorageShared tells whether the claim holding the reports can be mounted by concurrent tests
+	// running on different nodes, i.e. whether it is ReadWriteMany.
+	TestConditionReportStorageShared TestConditionType = "ReportStorageShared"
+	// TestConditionDependencyCacheShared tells whether the claim of the dependency cache can be mounted by concurrent
+	// tests running on different nodes, i.e. whether it is ReadWriteMany.
+	TestConditionDependencyCacheShared TestConditionType = "DependencyCacheShared"
+	// TestConditionSpecValid tells whether the spec of the test only has fields known to the operator. It is false
+	// with the unknown fields, e.g. misspelled ones, in the message otherwise.
+	TestConditionSpecValid TestConditionType = "SpecValid"
+	// TestConditionEndpointsValid tells whether the endpoints of the test are valid, i.e. their URLs are absolute http
+	// or https URLs and the services they reference exist. It is false with the invalid endpoint in the message otherwise.
+	TestConditionEndpointsValid TestConditionType = "EndpointsValid"
+)
+
+// WorkloadType --
+type WorkloadType string
+
+const (
+	// WorkloadTypePod runs the test in a bare pod
+	WorkloadTypePod WorkloadType = "Pod"
+	// WorkloadTypeJob runs the test in a Job, that handles retries and completion tracking
+	WorkloadTypeJob WorkloadType = "Job"
+)
+
+// DependencyKind --
+type DependencyKind string
+
+const (
+	// DependencyKindDeployment is ready when all its replicas are available
+	DependencyKindDeployment DependencyKind = "Deployment"
+	// DependencyKindStatefulSet is ready when all its replicas are ready
+	DependencyKindStatefulSet DependencyKind = "StatefulSet"
+	// DependencyKindPod is ready when its Ready condition is true
+	DependencyKindPod DependencyKind = "Pod"
+	// DependencyKindJob is ready when it has succeeded
+	DependencyKindJob DependencyKind = "Job"
+	// DependencyKindService is ready when it has a ready endpoint
+	DependencyKindService DependencyKind = "Service"
+)
+
+// DebugMode --
+type DebugMode string
+
+const (
+	// DebugModeOnFailure keeps the runner container alive when the tests have not passed
+	DebugModeOnFailure DebugMode = "OnFailure"
+	// DebugModeAlways keeps the runner container alive whatever the result of the tests
+	DebugModeAlways DebugMode = "Always"
+)
+
+// ResultFormat --
+type ResultFormat string
+
+const (
+	// ResultFormatCitrus is the summary written by the Citrus termination log reporter
+	ResultFormatCitrus ResultFormat = "citrus"
+	// ResultFormatTAP is the Test Anything Protocol
+	ResultFormatTAP ResultFormat = "tap"
+	// ResultFormatJSON is a JSON array of scenario results
+	ResultFormatJSON ResultFormat = "json"
+)
+
+// ScenarioStatus --
+type ScenarioStatus string
+
+const (
+	// ScenarioStatusPassed --
+	ScenarioStatusPassed ScenarioStatus = "Passed"
+	// ScenarioStatusFailed --
+	ScenarioStatusFailed ScenarioStatus = "Failed"
+	// ScenarioStatusSkipped --
+	ScenarioStatusSkipped ScenarioStatus = "Skipped"
+)
+
 type Language string
 
 const (
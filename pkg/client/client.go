@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	clientscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
@@ -65,16 +66,17 @@ func (c *defaultClient) GetScheme() *runtime.Scheme {
 	return c.scheme
 }
 
-// NewOutOfClusterClient creates a new k8s client that can be used from outside the cluster
-func NewOutOfClusterClient(kubeconfig string) (Client, error) {
-	initialize(kubeconfig)
+// NewOutOfClusterClient creates a new k8s client that can be used from outside the cluster, targeting the given
+// context of the kubeconfig file, or its current context when empty
+func NewOutOfClusterClient(kubeconfig string, kubeContext string) (Client, error) {
+	initialize(kubeconfig, kubeContext)
 	return NewClient()
 }
 
 // NewClient creates a new k8s client that can be used from outside or in the cluster
 func NewClient() (Client, error) {
 	// Get a config to talk to the apiserver
-	cfg, err := config.GetConfig()
+	cfg, err := GetConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +109,18 @@ func NewClient() (Client, error) {
 	}, nil
 }
 
+// NewOfflineClient creates a client that only provides the scheme, to render the resources without connecting to any
+// cluster: it must not be used to call the API server
+func NewOfflineClient() (Client, error) {
+	scheme := clientscheme.Scheme
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return &defaultClient{
+		scheme: scheme,
+	}, nil
+}
+
 // FromManager creates a new k8s client from a manager object
 func FromManager(manager manager.Manager) (Client, error) {
 	var err error
@@ -128,12 +142,35 @@ func GetValidKubeConfig(kubeconfig string) string {
 	return kubeconfig
 }
 
+// outOfCluster is the kubeconfig file and context selected for usage outside the cluster, if any
+var outOfCluster *kubeConfigSelection
+
+type kubeConfigSelection struct {
+	kubeconfig string
+	context    string
+}
+
 // init initialize the k8s client for usage outside the cluster
-func initialize(kubeconfig string) {
+func initialize(kubeconfig string, kubeContext string) {
 	if kubeconfig == "" {
 		kubeconfig = getDefaultKubeConfigFile()
 	}
 	os.Setenv(k8sutil.KubeConfigEnvVar, kubeconfig)
+	outOfCluster = &kubeConfigSelection{
+		kubeconfig: kubeconfig,
+		context:    kubeContext,
+	}
+}
+
+// GetConfig returns the configuration of the cluster all the clients talk to: the kubeconfig file and context given to
+// NewOutOfClusterClient, or the default configuration of the controller runtime, e.g. in the cluster
+func GetConfig() (*rest.Config, error) {
+	if outOfCluster == nil {
+		return config.GetConfig()
+	}
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: outOfCluster.kubeconfig}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: outOfCluster.context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
 }
 
 func getDefaultKubeConfigFile() string {
@@ -144,8 +181,9 @@ func getDefaultKubeConfigFile() string {
 	return filepath.Join(usr.HomeDir, ".kube", "config")
 }
 
-// GetCurrentNamespace --
-func GetCurrentNamespace(kubeconfig string) (string, error) {
+// GetCurrentNamespace returns the namespace of the given context of the kubeconfig file, or of its current context
+// when empty
+func GetCurrentNamespace(kubeconfig string, kubeContext string) (string, error) {
 	if kubeconfig == "" {
 		kubeconfig = getDefaultKubeConfigFile()
 	}
@@ -153,23 +191,41 @@ func GetCurrentNamespace(kubeconfig string) (string, error) {
 		return "default", nil
 	}
 
-	data, err := ioutil.ReadFile(kubeconfig)
+	clientcmdconfig, err := loadKubeConfig(kubeconfig)
 	if err != nil {
 		return "", err
 	}
+
+	cc := clientcmd.NewDefaultClientConfig(*clientcmdconfig, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+	ns, _, err := cc.Namespace()
+	return ns, err
+}
+
+// GetCurrentContext returns the given context if any, else the current context of the kubeconfig file
+func GetCurrentContext(kubeconfig string, kubeContext string) (string, error) {
+	if kubeContext != "" {
+		return kubeContext, nil
+	}
+	clientcmdconfig, err := loadKubeConfig(GetValidKubeConfig(kubeconfig))
+	if err != nil {
+		return "", err
+	}
+	return clientcmdconfig.CurrentContext, nil
+}
+
+func loadKubeConfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	data, err := ioutil.ReadFile(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
 	conf := clientcmdapi.NewConfig()
 	if len(data) == 0 {
-		return "", errors.New("kubernetes config file is empty")
+		return nil, errors.New("kubernetes config file is empty")
 	}
 
 	decoded, _, err := clientcmdlatest.Codec.Decode(data, &schema.GroupVersionKind{Version: clientcmdlatest.Version, Kind: "Config"}, conf)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	clientcmdconfig := decoded.(*clientcmdapi.Config)
-
-	cc := clientcmd.NewDefaultClientConfig(*clientcmdconfig, &clientcmd.ConfigOverrides{})
-	ns, _, err := cc.Namespace()
-	return ns, err
+	return decoded.(*clientcmdapi.Config), nil
 }
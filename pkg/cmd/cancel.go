@@ -0,0 +1,86 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdCancel(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := cancelCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "cancel <test>",
+		Short:             "Cancel a pending or running test",
+		Long:              `Cancels the current run of a test. The runner pod is deleted and the test ends in the Error phase with the Cancelled reason.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              options.run,
+		Annotations: map[string]string{
+			completionTestNamesAnnotation: "true",
+		},
+	}
+
+	return &cmd
+}
+
+type cancelCmdOptions struct {
+	*RootCmdOptions
+}
+
+func (o *cancelCmdOptions) run(cmd *cobra.Command, args []string) error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+
+	test := v1alpha1.Test{}
+	if err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.Namespace, Name: args[0]}, &test); err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	if test.Status.Phase != v1alpha1.TestPhasePending && test.Status.Phase != v1alpha1.TestPhaseRunning {
+		return errors.New(fmt.Sprintf("test %s is not pending or running (phase %s)", test.Name, test.Status.Phase))
+	}
+	if err := cancelTest(o.Context, c, &test); err != nil {
+		return err
+	}
+
+	fmt.Printf("Test %s cancelled\n", test.Name)
+	return nil
+}
+
+// cancelTest cancels the current run of the pending or running test
+func cancelTest(ctx context.Context, c client.Client, test *v1alpha1.Test) error {
+	// The annotation holds the ID of the run, so that the test can be run again once cancelled
+	if test.Annotations == nil {
+		test.Annotations = make(map[string]string)
+	}
+	test.Annotations[v1alpha1.TestCancelAnnotation] = test.Status.TestID
+	return c.Update(ctx, test)
+}
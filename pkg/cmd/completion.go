@@ -0,0 +1,236 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// completionTestNamesAnnotation marks the commands whose arguments are completed with the names of the tests in the namespace
+const completionTestNamesAnnotation = "yaks.dev/completion-test-names"
+
+// completionCacheTTL is how long the completed values are reused between two completion requests
+const completionCacheTTL = 5 * time.Second
+
+// completeSelectorsFunction is the bash function completing the --selector values, set as cobra.BashCompCustom
+// annotation of the flags
+const completeSelectorsFunction = "__yaks_get_selectors"
+
+// bashCompletionFunctions complete the values read from the cluster with the hidden completion commands, passing them
+// the kubeconfig, context and namespace flags already on the command line
+const bashCompletionFunctions = `
+__yaks_complete() {
+    local args=()
+    local namespace="${flaghash[--namespace]:-${flaghash[-n]}}"
+    local kubeconfig="${flaghash[--kubeconfig]:-${flaghash[--config]}}"
+    [[ -n "${namespace}" ]] && args+=(--namespace "${namespace}")
+    [[ -n "${flaghash[--context]}" ]] && args+=(--context "${flaghash[--context]}")
+    [[ -n "${kubeconfig}" ]] && args+=(--kubeconfig "${kubeconfig}")
+    local yaks_out
+    if yaks_out=$(yaks completion "$1" "${args[@]}" 2>/dev/null); then
+        COMPREPLY=( $( compgen -W "${yaks_out[*]}" -- "$cur" ) )
+    fi
+}
+
+__yaks_get_tests() {
+    __yaks_complete tests
+}
+
+` + completeSelectorsFunction + `() {
+    __yaks_complete selectors
+}
+`
+
+// completeFlagWith completes the values of the flag with the given bash function
+func completeFlagWith(flags *pflag.FlagSet, flag string, function string) {
+	_ = flags.SetAnnotation(flag, cobra.BashCompCustom, []string{function})
+}
+
+func newCmdCompletion(rootCmdOptions *RootCmdOptions, root *cobra.Command) *cobra.Command {
+	options := completionCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+	cmd := cobra.Command{
+		Use:   "completion",
+		Short: "Generates completion scripts",
+		Long: `To load completion run
+
+. <(yaks completion bash)
+
+To configure your bash shell to load completions for each session add to your bashrc
+
+# ~/.bashrc or ~/.profile
+. <(yaks completion bash)
+`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "bash",
+		Short: "Generates bash completion scripts",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			root.BashCompletionFunction = bashCompletionFunction(root)
+			return root.GenBashCompletion(os.Stdout)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "tests",
+		Short:             "Prints the names of the tests in the namespace, used by the completion scripts",
+		Hidden:            true,
+		PersistentPreRunE: options.preRun,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return options.printCompletions("tests", listTestNames)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "selectors",
+		Short:             "Prints the labels of the tests in the namespace, used by the completion scripts",
+		Hidden:            true,
+		PersistentPreRunE: options.preRun,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return options.printCompletions("selectors", listTestLabels)
+		},
+	})
+	return &cmd
+}
+
+type completionCmdOptions struct {
+	*RootCmdOptions
+}
+
+// bashCompletionFunction returns the custom bash functions completing the flag values, and the test names for the
+// annotated commands
+func bashCompletionFunction(root *cobra.Command) string {
+	commands := make([]string, 0)
+	visitCommands(root, func(cmd *cobra.Command) {
+		if _, ok := cmd.Annotations[completionTestNamesAnnotation]; ok {
+			commands = append(commands, strings.Replace(cmd.CommandPath(), " ", "_", -1))
+		}
+	})
+	if len(commands) == 0 {
+		return bashCompletionFunctions
+	}
+
+	return bashCompletionFunctions + `
+__custom_func() {
+    case ${last_command} in
+        ` + strings.Join(commands, " | ") + `)
+            __yaks_get_tests
+            return
+            ;;
+        *)
+            ;;
+    esac
+}
+`
+}
+
+func visitCommands(cmd *cobra.Command, visitor func(*cobra.Command)) {
+	visitor(cmd)
+	for _, child := range cmd.Commands() {
+		visitCommands(child, visitor)
+	}
+}
+
+// completionCacheFile returns the file caching the completed values of the given kind, for the context and the
+// namespace they have been read from
+func (o *completionCmdOptions) completionCacheFile(kind string) string {
+	// The current context is resolved, so that switching contexts does not reuse the values of the previous one
+	kubeContext, _ := client.GetCurrentContext(o.KubeConfig, o.KubeContext)
+	key := client.GetValidKubeConfig(o.KubeConfig) + "\x00" + kubeContext + "\x00" + o.Namespace
+	return filepath.Join(os.TempDir(), fmt.Sprintf("yaks-completion-%s-%x", kind, sha256.Sum256([]byte(key))))
+}
+
+// printCompletions prints the values listed from the cluster, one per line
+func (o *completionCmdOptions) printCompletions(kind string, list func(*completionCmdOptions, client.Client) ([]string, error)) error {
+	// Completion is requested on each TAB, so values are cached briefly to avoid hammering the API server
+	cache := o.completionCacheFile(kind)
+	if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < completionCacheTTL {
+		if values, err := ioutil.ReadFile(cache); err == nil {
+			fmt.Print(string(values))
+			return nil
+		}
+	}
+
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	values, err := list(o, c)
+	if err != nil {
+		return err
+	}
+	sort.Strings(values)
+
+	content := strings.Join(values, "\n") + "\n"
+	// A stale cache only degrades the completion, so failures to write it are ignored
+	_ = ioutil.WriteFile(cache, []byte(content), 0600)
+	fmt.Print(content)
+	return nil
+}
+
+func listTests(o *completionCmdOptions, c client.Client) ([]v1alpha1.Test, error) {
+	tests := v1alpha1.TestList{}
+	if err := c.List(o.Context, &k8sclient.ListOptions{Namespace: o.Namespace}, &tests); err != nil {
+		return nil, err
+	}
+	return tests.Items, nil
+}
+
+func listTestNames(o *completionCmdOptions, c client.Client) ([]string, error) {
+	tests, err := listTests(o, c)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tests))
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	return names, nil
+}
+
+// listTestLabels returns the distinct labels of the tests as key=value selectors
+func listTestLabels(o *completionCmdOptions, c client.Client) ([]string, error) {
+	tests, err := listTests(o, c)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	selectors := make([]string, 0)
+	for _, test := range tests {
+		for key, value := range test.Labels {
+			if selector := key + "=" + value; !seen[selector] {
+				seen[selector] = true
+				selectors = append(selectors, selector)
+			}
+		}
+	}
+	return selectors, nil
+}
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBashCompletion(t *testing.T) {
+	root, err := NewYaksCommand(context.TODO())
+	assert.Nil(t, err)
+	root.BashCompletionFunction = bashCompletionFunction(root)
+	script := bytes.Buffer{}
+	assert.Nil(t, root.GenBashCompletion(&script))
+
+	assert.Contains(t, script.String(), "yaks_cancel | yaks_logs | yaks_promote | yaks_wait")
+	assert.Contains(t, script.String(), `flags_completion+=("`+completeSelectorsFunction+`")`)
+}
+
+func TestCompletionCacheFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaks-cmd")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	kubeConfig := filepath.Join(dir, "config")
+	assert.Nil(t, ioutil.WriteFile(kubeConfig, []byte(testKubeConfig), 0600))
+
+	options := completionCmdOptions{RootCmdOptions: &RootCmdOptions{KubeConfig: kubeConfig, Namespace: "ns"}}
+	current := options.completionCacheFile("tests")
+
+	// The current context is the default one
+	options.KubeContext = "test"
+	assert.Equal(t, current, options.completionCacheFile("tests"))
+
+	options.KubeContext = "other"
+	assert.NotEqual(t, current, options.completionCacheFile("tests"))
+
+	options.KubeContext = ""
+	options.Namespace = "other"
+	assert.NotEqual(t, current, options.completionCacheFile("tests"))
+	assert.NotEqual(t, current, options.completionCacheFile("selectors"))
+}
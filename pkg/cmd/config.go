@@ -0,0 +1,305 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// projectConfigFile is the file of the current directory holding the project defaults
+var projectConfigFile = "yaks-config.yaml"
+
+// envConfigKeyPrefix prefixes the keys of the environment variables added to the tests
+const envConfigKeyPrefix = "env."
+
+// configKeyFlags maps the keys of the project config to the flags they set the default value of
+var configKeyFlags = map[string]string{
+	"namespace":      "namespace",
+	"operator.image": "operator-image",
+}
+
+// projectConfig -- the project defaults read from the yaks-config.yaml file
+type projectConfig struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Operator  *operatorDefaults `json:"operator,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// operatorDefaults --
+type operatorDefaults struct {
+	Image string `json:"image,omitempty"`
+}
+
+func newCmdConfig(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := configCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+	cmd := cobra.Command{
+		Use:   "config",
+		Short: "Manage the project defaults of the yaks-config.yaml file",
+		Long:  `Reads and writes the keys of the yaks-config.yaml file of the current directory: namespace, operator.image and env.<NAME>, the default environment variables of the tests.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key of the project config",
+		Args:  cobra.ExactArgs(2),
+		RunE:  options.set,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a key of the project config",
+		Args:  cobra.ExactArgs(1),
+		RunE:  options.get,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a key from the project config",
+		Args:  cobra.ExactArgs(1),
+		RunE:  options.unset,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the keys set in the project config",
+		Args:  cobra.NoArgs,
+		RunE:  options.list,
+	})
+
+	return &cmd
+}
+
+type configCmdOptions struct {
+	*RootCmdOptions
+}
+
+func (o *configCmdOptions) set(cmd *cobra.Command, args []string) error {
+	config, err := loadProjectConfig(projectConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := config.set(args[0], args[1]); err != nil {
+		return err
+	}
+	return saveProjectConfig(projectConfigFile, config)
+}
+
+func (o *configCmdOptions) get(cmd *cobra.Command, args []string) error {
+	config, err := loadProjectConfig(projectConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := validateConfigKey(args[0]); err != nil {
+		return err
+	}
+	value, ok := config.get(args[0])
+	if !ok {
+		return errors.New(fmt.Sprintf("%s is not set in %s", args[0], projectConfigFile))
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), value)
+	return nil
+}
+
+func (o *configCmdOptions) unset(cmd *cobra.Command, args []string) error {
+	config, err := loadProjectConfig(projectConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := config.unset(args[0]); err != nil {
+		return err
+	}
+	return saveProjectConfig(projectConfigFile, config)
+}
+
+func (o *configCmdOptions) list(cmd *cobra.Command, _ []string) error {
+	config, err := loadProjectConfig(projectConfigFile)
+	if err != nil {
+		return err
+	}
+	values := config.values()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", key, values[key])
+	}
+	return nil
+}
+
+// validateConfigKey checks that the key is one of namespace, operator.image and env.<NAME>
+func validateConfigKey(key string) error {
+	if _, ok := configKeyFlags[key]; ok {
+		return nil
+	}
+	if strings.HasPrefix(key, envConfigKeyPrefix) {
+		name := strings.TrimPrefix(key, envConfigKeyPrefix)
+		if problems := validation.IsEnvVarName(name); len(problems) > 0 {
+			return errors.New(fmt.Sprintf("invalid environment variable name %q: %s", name, strings.Join(problems, ", ")))
+		}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("unknown key %q, expected namespace, operator.image or env.<NAME>", key))
+}
+
+// get returns the value of the key, and false when it is not set
+func (c *projectConfig) get(key string) (string, bool) {
+	value, ok := c.values()[key]
+	return value, ok
+}
+
+// set validates the key and its value and sets it
+func (c *projectConfig) set(key string, value string) error {
+	if err := validateConfigKey(key); err != nil {
+		return err
+	}
+	switch {
+	case key == "namespace":
+		if problems := validation.IsDNS1123Label(value); len(problems) > 0 {
+			return errors.New(fmt.Sprintf("invalid namespace %q: %s", value, strings.Join(problems, ", ")))
+		}
+		c.Namespace = value
+	case key == "operator.image":
+		if value == "" {
+			return errors.New("operator.image cannot be empty")
+		}
+		c.Operator = &operatorDefaults{Image: value}
+	default:
+		if c.Env == nil {
+			c.Env = make(map[string]string)
+		}
+		c.Env[strings.TrimPrefix(key, envConfigKeyPrefix)] = value
+	}
+	return nil
+}
+
+// unset removes the key, that is not an error when it is not set
+func (c *projectConfig) unset(key string) error {
+	if err := validateConfigKey(key); err != nil {
+		return err
+	}
+	switch {
+	case key == "namespace":
+		c.Namespace = ""
+	case key == "operator.image":
+		c.Operator = nil
+	default:
+		delete(c.Env, strings.TrimPrefix(key, envConfigKeyPrefix))
+		if len(c.Env) == 0 {
+			c.Env = nil
+		}
+	}
+	return nil
+}
+
+// values returns the keys set in the config with their value
+func (c *projectConfig) values() map[string]string {
+	values := make(map[string]string)
+	if c.Namespace != "" {
+		values["namespace"] = c.Namespace
+	}
+	if c.Operator != nil && c.Operator.Image != "" {
+		values["operator.image"] = c.Operator.Image
+	}
+	for name, value := range c.Env {
+		values[envConfigKeyPrefix+name] = value
+	}
+	return values
+}
+
+// loadProjectConfig reads and validates the project config, that is empty when the file does not exist
+func loadProjectConfig(file string) (projectConfig, error) {
+	var config projectConfig
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, errors.Wrapf(err, "cannot read %s", file)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, errors.Wrapf(err, "invalid project config %s", file)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return config, errors.Wrapf(err, "invalid project config %s", file)
+	}
+	for key, value := range raw {
+		switch key {
+		case "namespace", "env":
+		case "operator":
+			operator, _ := value.(map[string]interface{})
+			for name := range operator {
+				if name != "image" {
+					return config, errors.New(fmt.Sprintf("unknown key \"operator.%s\" in %s", name, file))
+				}
+			}
+		default:
+			return config, errors.New(fmt.Sprintf("unknown key %q in %s", key, file))
+		}
+	}
+	for key, value := range config.values() {
+		if err := new(projectConfig).set(key, value); err != nil {
+			return config, errors.Wrapf(err, "invalid project config %s", file)
+		}
+	}
+	return config, nil
+}
+
+// saveProjectConfig writes the project config, removing the file once it is empty
+func saveProjectConfig(file string, config projectConfig) error {
+	if len(config.values()) == 0 {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// setFlagsFromConfig sets the flags that are neither given on the command line nor from their environment variable
+// from the keys of the project config
+func setFlagsFromConfig(flags *pflag.FlagSet, config projectConfig) error {
+	for key, value := range config.values() {
+		name, ok := configKeyFlags[key]
+		if !ok {
+			continue
+		}
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return errors.Wrapf(err, "invalid value of %s in %s", key, projectConfigFile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectConfigSetAndUnset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaks-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "yaks-config.yaml")
+
+	var config projectConfig
+	assert.Nil(t, config.set("namespace", "tests"))
+	assert.Nil(t, config.set("operator.image", "quay.io/yaks/yaks:latest"))
+	assert.Nil(t, config.set("env.CITRUS_TIMEOUT", "5000"))
+	assert.NotNil(t, config.set("namespace", "Not_A_Namespace"))
+	assert.NotNil(t, config.set("env.1INVALID", "x"))
+	assert.NotNil(t, config.set("runner.image", "x"))
+	assert.Nil(t, saveProjectConfig(file, config))
+
+	loaded, err := loadProjectConfig(file)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"namespace":          "tests",
+		"operator.image":     "quay.io/yaks/yaks:latest",
+		"env.CITRUS_TIMEOUT": "5000",
+	}, loaded.values())
+
+	assert.Nil(t, loaded.unset("operator.image"))
+	_, ok := loaded.get("operator.image")
+	assert.False(t, ok)
+
+	assert.Nil(t, loaded.unset("namespace"))
+	assert.Nil(t, loaded.unset("env.CITRUS_TIMEOUT"))
+	assert.Nil(t, saveProjectConfig(file, loaded))
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLoadProjectConfigRejectsUnknownKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaks-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "yaks-config.yaml")
+
+	config, err := loadProjectConfig(file)
+	assert.Nil(t, err)
+	assert.Empty(t, config.values())
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte("operator:\n  tag: latest\n"), 0644))
+	_, err = loadProjectConfig(file)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte("namespace: Invalid_Namespace\n"), 0644))
+	_, err = loadProjectConfig(file)
+	assert.NotNil(t, err)
+}
+
+func TestEnvTakesPrecedenceOverProjectConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaks-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(file string) { projectConfigFile = file }(projectConfigFile)
+	projectConfigFile = filepath.Join(dir, "yaks-config.yaml")
+	assert.Nil(t, ioutil.WriteFile(projectConfigFile, []byte("namespace: from-project\noperator:\n  image: project/yaks:latest\n"), 0644))
+
+	options, image := runWithEnv(t, map[string]string{"YAKS_NAMESPACE": "from-env"})
+	assert.Equal(t, "from-env", options.Namespace)
+	assert.Equal(t, "project/yaks:latest", image)
+
+	options, _ = runWithEnv(t, map[string]string{})
+	assert.Equal(t, "from-project", options.Namespace)
+}
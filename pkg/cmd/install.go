@@ -18,13 +18,24 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/jboss-fuse/yaks/pkg/client"
 	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func newCmdInstall(rootCmdOptions *RootCmdOptions) *cobra.Command {
@@ -40,27 +51,115 @@ func newCmdInstall(rootCmdOptions *RootCmdOptions) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&impl.clusterSetupOnly, "cluster-setup", false, "Execute cluster-wide operations only (may require admin rights)")
+	cmd.Flags().StringVar(&impl.installMode, "install-mode", string(install.InstallModeGlobal), "One of: Global, installing the cluster-wide resources, or Namespaced, relying on custom resource definitions installed by a cluster admin")
 	cmd.Flags().BoolVar(&impl.skipOperatorSetup, "skip-operator-setup", false, "Do not install the operator in the namespace (in case there's a global one)")
 	cmd.Flags().BoolVar(&impl.skipClusterSetup, "skip-cluster-setup", false, "Skip the cluster-setup phase")
+	cmd.Flags().StringVar(&impl.save, "save", "", "Save the resources to the given file instead of installing them")
+	cmd.Flags().BoolVar(&impl.split, "split", false, "With --save, write each resource to its own file of the given directory")
+	cmd.Flags().BoolVar(&impl.explain, "explain", false, "Print a summary and the YAML of the resources that would be installed, without connecting to the cluster")
+	cmd.Flags().StringVar(&impl.clusterType, "cluster-type", string(install.ClusterTypeKubernetes), "With --explain, the type of the cluster to render the resources for, one of: Kubernetes, OpenShift")
+	cmd.Flags().BoolVar(&impl.noWait, "no-wait", false, "Do not wait for the operator to be ready before returning")
+	cmd.Flags().DurationVar(&impl.waitTimeout, "wait-timeout", 2*time.Minute, "How long to wait for the operator to be ready")
+	cmd.Flags().BoolVar(&impl.verify, "verify", false, "Run a built-in hello world test to verify the installation")
+	cmd.Flags().BoolVar(&impl.force, "force", false, "Proceed with the installation even if cluster-wide resources are managed by another installer")
+	cmd.Flags().StringVar(&impl.crdStorageVersion, "crd-storage-version", "", "Mark the given version as the storage version of the custom resource definitions, e.g. when upgrading to a new version")
+	cmd.Flags().StringVar(&impl.fieldManager, "field-manager", install.DefaultFieldManager, "Name of the field manager owning the resources applied server-side")
+	cmd.Flags().BoolVar(&impl.forceConflicts, "force-conflicts", false, "Take over the fields of the applied resources that are owned by other field managers")
+	cmd.Flags().BoolVar(&impl.instance, "instance", false, "Create an Instance asking the cluster-wide operator to deploy the operator of the namespace, instead of installing it")
+	cmd.Flags().BoolVar(&impl.clusterOperator, "cluster-operator", false, "Install the cluster-wide operator, deploying the operators of the namespaces having an Instance, instead of the operator of the namespace")
+	cmd.Flags().StringVar(&impl.operatorImage, "operator-image", "", "Set the operator container image")
+	cmd.Flags().StringArrayVar(&impl.operatorEnv, "operator-env", nil, "Set an environment variable on the operator in the form KEY=VALUE (can be repeated)")
+	cmd.Flags().StringArrayVar(&impl.operatorLabels, "operator-label", nil, "Set a label on the operator, matched against the operator selector of the tests, in the form KEY=VALUE (can be repeated). The operator gets its own deployment, next to the one without labels")
+	cmd.Flags().StringVar(&impl.serviceAccount, "service-account", "", "Run the operator with an existing service account of the namespace, bound to the operator role, instead of creating one")
+	cmd.Flags().Int32Var(&impl.operatorReplicas, "operator-replicas", 1, "Set the number of operator replicas (leader election makes only one of them active)")
+	cmd.Flags().StringVar(&impl.operatorCPU, "operator-cpu", "", "Set the CPU requested and limited for the operator container, e.g. 500m")
+	cmd.Flags().StringVar(&impl.operatorMemory, "operator-memory", "", "Set the memory requested and limited for the operator container, e.g. 256Mi")
+	cmd.Flags().BoolVar(&impl.operatorPDB, "operator-pdb", false, "Install a PodDisruptionBudget for the operator when running more than one replica")
+	cmd.Flags().StringVar(&impl.operatorPDBMinAvailable, "operator-pdb-min-available", "1", "Minimum number (or percentage) of operator pods that must stay available during disruptions")
+	cmd.Flags().BoolVar(&impl.runnerNetworkPolicy, "runner-network-policy", false, "Install a NetworkPolicy allowing the egress of the test runner pods of the namespace")
+	cmd.Flags().StringArrayVar(&impl.runnerEgressCIDRs, "runner-egress-cidr", nil, "With --runner-network-policy, restrict the egress of the runner pods to the given CIDR, besides DNS and the pods of the namespace (can be repeated)")
 
 	return &cmd
 }
 
 type installCmdOptions struct {
 	*RootCmdOptions
-	clusterSetupOnly  bool
-	skipOperatorSetup bool
-	skipClusterSetup  bool
+	clusterSetupOnly        bool
+	installMode             string
+	skipOperatorSetup       bool
+	skipClusterSetup        bool
+	force                   bool
+	crdStorageVersion       string
+	fieldManager            string
+	forceConflicts          bool
+	verify                  bool
+	noWait                  bool
+	waitTimeout             time.Duration
+	save                    string
+	split                   bool
+	explain                 bool
+	clusterType             string
+	instance                bool
+	clusterOperator         bool
+	operatorImage           string
+	serviceAccount          string
+	operatorEnv             []string
+	operatorLabels          []string
+	operatorReplicas        int32
+	operatorCPU             string
+	operatorMemory          string
+	operatorPDB             bool
+	operatorPDBMinAvailable string
+	runnerNetworkPolicy     bool
+	runnerEgressCIDRs       []string
 }
 
 // nolint: gocyclo
-func (o *installCmdOptions) install(_ *cobra.Command, _ []string) error {
+func (o *installCmdOptions) install(cmd *cobra.Command, _ []string) error {
+	mode, err := install.ParseInstallMode(o.installMode)
+	if err != nil {
+		return err
+	}
+	if mode == install.InstallModeNamespaced && o.clusterSetupOnly {
+		return errors.New("--cluster-setup installs cluster-wide resources, that the Namespaced install mode does not install")
+	}
+	if mode == install.InstallModeNamespaced && o.crdStorageVersion != "" {
+		return errors.New("--crd-storage-version applies to the custom resource definitions, that the Namespaced install mode does not install")
+	}
+	if mode == install.InstallModeNamespaced && o.clusterOperator {
+		return errors.New("--cluster-operator installs a cluster role, that the Namespaced install mode does not install")
+	}
+	if o.explain {
+		return o.explainResources(mode)
+	} else if cmd.Flags().Changed("cluster-type") {
+		return errors.New("--cluster-type requires --explain")
+	}
+	if o.save != "" {
+		return o.saveResources(mode)
+	} else if o.split {
+		return errors.New("--split requires --save")
+	}
+
+	ctx := install.WithApplyObserver(o.Context, printApplyResult)
+	ctx = install.WithCRDStorageVersion(ctx, o.crdStorageVersion)
+	ctx = install.WithServerSideApply(ctx, install.ServerSideApply{
+		FieldManager: o.fieldManager,
+		Force:        o.forceConflicts,
+	})
+
 	if !o.skipClusterSetup {
 		// Let's use a client provider during cluster installation, to eliminate the problem of CRD object caching
 		clientProvider := client.Provider{Get: o.NewCmdClient}
 
-		err := install.SetupClusterwideResourcesOrCollect(o.Context, clientProvider, nil)
-		if err != nil && k8serrors.IsForbidden(err) {
+		if mode == install.InstallModeGlobal {
+			if err := o.preflight(); err != nil {
+				return err
+			}
+		}
+
+		summary, err := install.SetupClusterwideResourcesForMode(ctx, clientProvider, mode, nil)
+		fmt.Println(summary)
+		if err != nil && mode == install.InstallModeGlobal && k8serrors.IsForbidden(err) {
 			fmt.Println("Current user is not authorized to create cluster-wide objects like custom resource definitions or cluster roles: ", err)
 
 			meg := `please login as cluster-admin and execute "yaks install --cluster-setup" to install cluster-wide resources (one-time operation)`
@@ -81,18 +180,301 @@ func (o *installCmdOptions) install(_ *cobra.Command, _ []string) error {
 		namespace := o.Namespace
 
 		if !o.skipOperatorSetup {
-			cfg := install.OperatorConfiguration{
-				Namespace: namespace,
+			cfg, err := o.operatorConfiguration()
+			if err != nil {
+				return err
+			}
+			switch {
+			case o.instance:
+				err = o.installInstance(ctx, c, cfg)
+			case o.clusterOperator:
+				err = install.ClusterOperatorOrCollect(ctx, c, cfg, nil)
+			default:
+				err = install.OperatorOrCollect(ctx, c, cfg, nil)
 			}
-			err = install.OperatorOrCollect(o.Context, c, cfg, nil)
 			if err != nil {
 				return err
 			}
-			fmt.Println("Yaks setup completed successfully")
+			if err := o.waitForOperator(ctx, c, cfg); err != nil {
+				return err
+			}
+			if o.instance {
+				fmt.Println("Yaks instance set up, its operator is deployed by the cluster-wide operator")
+			} else if o.clusterOperator {
+				fmt.Println("Yaks cluster-wide operator setup completed successfully")
+			} else {
+				fmt.Println("Yaks setup completed successfully")
+			}
 		} else {
 			fmt.Println("Yaks operator installation skipped")
 		}
+
+		if o.verify {
+			return verifyInstallation(o.Context, c, namespace)
+		}
+	}
+
+	return nil
+}
+
+// installInstance creates the Instance of the namespace, once checked that a cluster-wide operator deploys its operator
+func (o *installCmdOptions) installInstance(ctx context.Context, c client.Client, cfg install.OperatorConfiguration) error {
+	installed, err := install.IsClusterOperatorInstalled(ctx, c)
+	if err != nil && !k8serrors.IsForbidden(err) {
+		return err
+	} else if err == nil && !installed {
+		return errors.New("no cluster-wide operator deploys the operator of the instance: a cluster admin must run \"yaks install --cluster-operator\" first")
+	}
+	return install.OperatorInstance(ctx, c, cfg)
+}
+
+// waitForOperator waits for the operator of the namespace, or the cluster-wide operator, to be ready to run tests,
+// unless disabled or scaled down
+func (o *installCmdOptions) waitForOperator(ctx context.Context, c client.Client, cfg install.OperatorConfiguration) error {
+	if o.noWait || (cfg.Replicas != nil && *cfg.Replicas == 0) {
+		return nil
+	}
+	fmt.Println("Waiting for the operator to be ready")
+	if o.clusterOperator {
+		return install.WaitForClusterOperatorReady(ctx, c, cfg.Namespace, o.waitTimeout)
+	}
+	return install.WaitForOperatorReady(ctx, c, cfg, o.waitTimeout)
+}
+
+// saveResources writes the resources that would be installed to the save file, or directory when splitting them
+func (o *installCmdOptions) saveResources(mode install.InstallMode) error {
+	if o.verify {
+		return errors.New("--verify cannot be used with --save")
+	}
+	collection, err := o.collectResources(o.Context, mode, client.Provider{Get: o.NewCmdClient})
+	if err != nil {
+		return err
+	}
+
+	if o.split {
+		if err := install.WriteSplit(o.save, collection); err != nil {
+			return err
+		}
+		fmt.Printf("%d resources saved to directory %s\n", collection.Size(), o.save)
+		return nil
+	}
+	file, err := os.Create(o.save)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := install.WriteBundle(file, collection); err != nil {
+		return err
+	}
+	fmt.Printf("%d resources saved to %s\n", collection.Size(), o.save)
+	return nil
+}
+
+// explainResources prints a summary of the resources that would be installed, followed by their YAML, rendering them
+// from the embedded resources without connecting to the cluster
+func (o *installCmdOptions) explainResources(mode install.InstallMode) error {
+	if o.verify || o.save != "" {
+		return errors.New("--explain cannot be used with --verify or --save")
+	}
+	clusterType, err := install.ParseClusterType(o.clusterType)
+	if err != nil {
+		return err
+	}
+	ctx := install.WithClusterType(o.Context, clusterType)
+	collection, err := o.collectResources(ctx, mode, client.Provider{Get: client.NewOfflineClient})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Install mode: %s\n", mode)
+	fmt.Printf("Cluster type: %s\n", clusterType)
+	if mode == install.InstallModeNamespaced && !o.skipClusterSetup {
+		fmt.Println("Custom resource definitions: not installed, a cluster admin must install them beforehand")
+	}
+	fmt.Printf("%d resources would be installed\n", collection.Size())
+	if err := install.WriteExplanation(os.Stdout, o.Namespace, collection); err != nil {
+		return err
+	}
+	if collection.Size() == 0 {
+		return nil
+	}
+	fmt.Println("---")
+	return install.WriteBundle(os.Stdout, collection)
+}
+
+// collectResources returns the resources that would be installed with the options, the clients of the provider being
+// only used to load them
+func (o *installCmdOptions) collectResources(ctx context.Context, mode install.InstallMode, clientProvider client.Provider) (*kubernetes.Collection, error) {
+	c, err := clientProvider.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	collection := kubernetes.NewCollection()
+	if !o.skipClusterSetup {
+		if _, err := install.SetupClusterwideResourcesForMode(install.WithCRDStorageVersion(ctx, o.crdStorageVersion), clientProvider, mode, collection); err != nil {
+			return nil, err
+		}
+	}
+	if !o.clusterSetupOnly && !o.skipOperatorSetup {
+		cfg, err := o.operatorConfiguration()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case o.instance:
+			err = install.OperatorInstanceOrCollect(ctx, c, cfg, collection)
+		case o.clusterOperator:
+			err = install.ClusterOperatorOrCollect(ctx, c, cfg, collection)
+		default:
+			err = install.OperatorOrCollect(ctx, c, cfg, collection)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return collection, nil
+}
+
+// preflight checks that the cluster-wide resources are not managed by another installer, that the installation could fight with
+func (o *installCmdOptions) preflight() error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	conflicts, err := install.ClusterwideConflicts(o.Context, c)
+	if err != nil && k8serrors.IsForbidden(err) {
+		// The cluster setup reports the missing permissions
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Println("Warning:", conflict)
+	}
+	if o.force {
+		fmt.Println("Proceeding with the installation as requested by --force")
+		return nil
+	}
+	return errors.New("yaks is already installed by another installer, use --force to install anyway")
+}
+
+// printApplyResult reports whether each installed resource has been created, updated or left unchanged
+func printApplyResult(obj runtime.Object, result install.ApplyResult) {
+	name := ""
+	if metaObject, ok := obj.(metav1.Object); ok {
+		name = metaObject.GetName()
+	}
+	fmt.Printf("%s/%s %s\n", strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind), name, result)
+}
+
+// operatorConfiguration returns the configuration of the operator installed in the namespace
+func (o *installCmdOptions) operatorConfiguration() (install.OperatorConfiguration, error) {
+	if o.operatorReplicas < 0 {
+		return install.OperatorConfiguration{}, errors.New("--operator-replicas must not be negative")
+	}
+	if o.instance && o.clusterOperator {
+		return install.OperatorConfiguration{}, errors.New("--instance and --cluster-operator cannot be used together")
+	}
+	if o.instance && (len(o.operatorEnv) > 0 || len(o.operatorLabels) > 0 || o.operatorCPU != "" || o.operatorMemory != "" || o.operatorPDB || o.runnerNetworkPolicy || o.serviceAccount != "") {
+		return install.OperatorConfiguration{}, errors.New("only --operator-image and --operator-replicas apply to the operator of an instance")
+	}
+	if o.clusterOperator && (len(o.operatorEnv) > 0 || len(o.operatorLabels) > 0 || o.operatorCPU != "" || o.operatorMemory != "" || o.operatorPDB || o.runnerNetworkPolicy || o.serviceAccount != "") {
+		return install.OperatorConfiguration{}, errors.New("only --operator-image and --operator-replicas apply to the cluster-wide operator")
+	}
+	if len(o.runnerEgressCIDRs) > 0 && !o.runnerNetworkPolicy {
+		return install.OperatorConfiguration{}, errors.New("--runner-egress-cidr requires --runner-network-policy")
+	}
+	if o.serviceAccount != "" {
+		if errs := validation.IsDNS1123Subdomain(o.serviceAccount); len(errs) > 0 {
+			return install.OperatorConfiguration{}, errors.New(fmt.Sprintf("invalid --service-account %q: %s", o.serviceAccount, strings.Join(errs, ", ")))
+		}
 	}
+	env, err := parseEnvVars(o.operatorEnv)
+	if err != nil {
+		return install.OperatorConfiguration{}, err
+	}
+	labels, err := parseOperatorLabels(o.operatorLabels)
+	if err != nil {
+		return install.OperatorConfiguration{}, err
+	}
+	resources := corev1.ResourceList{}
+	if err := parseQuantity(resources, corev1.ResourceCPU, "operator-cpu", o.operatorCPU); err != nil {
+		return install.OperatorConfiguration{}, err
+	}
+	if err := parseQuantity(resources, corev1.ResourceMemory, "operator-memory", o.operatorMemory); err != nil {
+		return install.OperatorConfiguration{}, err
+	}
+	minAvailable := intstr.Parse(o.operatorPDBMinAvailable)
+	return install.OperatorConfiguration{
+		Namespace:      o.Namespace,
+		Image:          o.operatorImage,
+		Replicas:       &o.operatorReplicas,
+		Resources:      resources,
+		Env:            env,
+		Labels:         labels,
+		ServiceAccount: o.serviceAccount,
+		PodDisruptionBudget: install.PodDisruptionBudgetConfiguration{
+			Enabled:      o.operatorPDB,
+			MinAvailable: &minAvailable,
+		},
+		RunnerNetworkPolicy: install.RunnerNetworkPolicyConfiguration{
+			Enabled:     o.runnerNetworkPolicy,
+			EgressCIDRs: o.runnerEgressCIDRs,
+		},
+	}, nil
+}
 
+// parseQuantity adds the quantity given to the flag to the resources, unless empty
+func parseQuantity(resources corev1.ResourceList, name corev1.ResourceName, flag string, value string) error {
+	if value == "" {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid value %q for --%s: %v", value, flag, err))
+	}
+	resources[name] = quantity
 	return nil
 }
+
+// parseOperatorLabels returns the labels of the operator, that must be valid labels not set by the installation
+func parseOperatorLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		pair := strings.SplitN(value, "=", 2)
+		if len(pair) != 2 {
+			return nil, errors.New(fmt.Sprintf("invalid operator label %q, expected format KEY=VALUE", value))
+		}
+		if errs := validation.IsQualifiedName(pair[0]); len(errs) > 0 {
+			return nil, errors.New(fmt.Sprintf("invalid operator label %q: %s", value, strings.Join(errs, ", ")))
+		}
+		if errs := validation.IsValidLabelValue(pair[1]); len(errs) > 0 {
+			return nil, errors.New(fmt.Sprintf("invalid operator label %q: %s", value, strings.Join(errs, ", ")))
+		}
+		if pair[0] == "name" || pair[0] == "pod-template-hash" {
+			return nil, errors.New(fmt.Sprintf("invalid operator label %q: %s is set by the installation", value, pair[0]))
+		}
+		labels[pair[0]] = pair[1]
+	}
+	return labels, nil
+}
+
+func parseEnvVars(values []string) ([]corev1.EnvVar, error) {
+	vars := make([]corev1.EnvVar, 0, len(values))
+	for _, value := range values {
+		pair := strings.SplitN(value, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, errors.New(fmt.Sprintf("invalid environment variable %q, expected format KEY=VALUE", value))
+		}
+		vars = append(vars, corev1.EnvVar{
+			Name:  pair[0],
+			Value: pair[1],
+		})
+	}
+	return vars, nil
+}
@@ -0,0 +1,91 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jboss-fuse/yaks/pkg/util/gherkin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newCmdLint(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := lintCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+	cmd := cobra.Command{
+		Use:   "lint [test file or directory...]",
+		Short: "Check the Gherkin syntax of feature files",
+		Long:  `Parses the feature files locally, without contacting the cluster, and reports their syntax errors. Steps that are not in the catalog given with --steps, holding one regular expression or Cucumber expression per line, are reported as warnings.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  options.run,
+	}
+
+	cmd.Flags().StringVar(&options.steps, "steps", "", "Step catalog file, reporting the steps matching none of its patterns")
+
+	return &cmd
+}
+
+type lintCmdOptions struct {
+	*RootCmdOptions
+	steps string
+}
+
+func (o *lintCmdOptions) run(cmd *cobra.Command, args []string) error {
+	files, _, err := collectFiles(args)
+	if err != nil {
+		return err
+	}
+	var catalog gherkin.StepCatalog
+	if o.steps != "" {
+		if catalog, err = gherkin.LoadStepCatalog(o.steps); err != nil {
+			return err
+		}
+	}
+	cmd.SilenceUsage = true
+
+	failed := 0
+	for _, file := range files {
+		data, err := new(testCmdOptions).loadData(file)
+		if err != nil {
+			return err
+		}
+		if err := lintFeature(os.Stdout, file, data, catalog); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.New(fmt.Sprintf("%d of %d feature files have errors", failed, len(files)))
+	}
+	return nil
+}
+
+// lintFeature prints the issues of the feature, returning an error when it cannot be parsed
+func lintFeature(w io.Writer, file string, content string, catalog gherkin.StepCatalog) error {
+	issues := gherkin.Lint(content, catalog)
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%s:%s\n", file, issue)
+	}
+	if gherkin.HasErrors(issues) {
+		return errors.New(fmt.Sprintf("%s is not a valid feature file", file))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"hash/fnv"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/wercker/stern/stern"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+func newCmdLogs(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := logsCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "logs [test...]",
+		Short:             "Tail the logs of running tests",
+		Long:              `Follows the logs of the runners of the given tests, of all the running tests with --all, or of the runners matching --selector. Each line is prefixed with the name of its test, and the tests starting while tailing are followed until interrupted.`,
+		RunE:              options.run,
+		Annotations: map[string]string{
+			completionTestNamesAnnotation: "true",
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.all, "all", false, "Tail the logs of all the running tests of the namespace")
+	cmd.Flags().StringVarP(&options.selector, "selector", "l", "", "Tail the logs of the runner pods matching the label selector, e.g. yaks.dev/test in (a,b)")
+	completeFlagWith(cmd.Flags(), "selector", completeSelectorsFunction)
+
+	return &cmd
+}
+
+type logsCmdOptions struct {
+	*RootCmdOptions
+	all      bool
+	selector string
+}
+
+func (o *logsCmdOptions) run(cmd *cobra.Command, args []string) error {
+	selector, err := o.podSelector(args)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	funs := map[string]interface{}{
+		"test": func(pod string) string {
+			name := testNameFromPod(pod)
+			return testColor(name).SprintFunc()(name)
+		},
+	}
+	templ, err := template.New("log").Funcs(funs).Parse("{{test .PodName}} {{.Message}}")
+	if err != nil {
+		return err
+	}
+
+	// Pods added while tailing are followed, and the tail of a pod ends with its runner container
+	conf := stern.Config{
+		Namespace:      o.Namespace,
+		PodQuery:       regexp.MustCompile(".*"),
+		KubeConfig:     client.GetValidKubeConfig(o.KubeConfig),
+		ContextName:    o.KubeContext,
+		ContainerQuery: regexp.MustCompile("^" + runnerContainerName + "$"),
+		LabelSelector:  selector,
+		ContainerState: stern.ContainerState(stern.RUNNING),
+		Since:          runnerLogsSince,
+		Template:       templ,
+	}
+	return stern.Run(o.Context, &conf)
+}
+
+// runnerLogsSince is how far back the logs of the runner containers are printed from, so that the logs of a test
+// started before the tail are printed from its start
+const runnerLogsSince = 48 * time.Hour
+
+// runnerContainerName is the name of the container running the tests in the runner pods
+const runnerContainerName = "test"
+
+// podSelector selects the runner pods of the named tests, or of all the tests, restricted by the label selector
+func (o *logsCmdOptions) podSelector(names []string) (labels.Selector, error) {
+	if len(names) == 0 && !o.all && o.selector == "" {
+		return nil, errors.New("give the names of the tests, --all or --selector")
+	}
+	if len(names) > 0 && o.all {
+		return nil, errors.New("--all cannot be used with test names")
+	}
+
+	selector := labels.NewSelector()
+	if o.selector != "" {
+		var err error
+		if selector, err = labels.Parse(o.selector); err != nil {
+			return nil, errors.Wrap(err, "invalid --selector")
+		}
+	}
+	// The label of the test is only set on the runner pods
+	requirement, err := labels.NewRequirement("yaks.dev/test", selection.Exists, nil)
+	if len(names) > 0 {
+		requirement, err = labels.NewRequirement("yaks.dev/test", selection.In, names)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return selector.Add(*requirement), nil
+}
+
+// runnerPodName matches the names of the runner pods, test-<test>-<test id>, suffixed when created by a Job
+var runnerPodName = regexp.MustCompile(`^test-(.+)-[0-9a-v]{20}(-[0-9a-z]{5})?$`)
+
+// testNameFromPod returns the name of the test run by the pod
+func testNameFromPod(pod string) string {
+	if match := runnerPodName.FindStringSubmatch(pod); match != nil {
+		return match[1]
+	}
+	return pod
+}
+
+var testColors = []color.Attribute{
+	color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta, color.FgCyan,
+	color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta, color.FgHiCyan,
+}
+
+// testColor returns the color of the test, that stays the same across its runs
+func testColor(name string) *color.Color {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return color.New(testColors[hash.Sum32()%uint32(len(testColors))])
+}
@@ -0,0 +1,50 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestNameFromPod(t *testing.T) {
+	assert.Equal(t, "hello-world", testNameFromPod("test-hello-world-bm6qs2m1s2gsgo7e4h3g"))
+	assert.Equal(t, "hello", testNameFromPod("test-hello-bm6qs2m1s2gsgo7e4h3g-x7k2p"))
+	assert.Equal(t, "other-pod", testNameFromPod("other-pod"))
+}
+
+func TestLogsPodSelector(t *testing.T) {
+	options := logsCmdOptions{RootCmdOptions: &RootCmdOptions{}}
+
+	_, err := options.podSelector(nil)
+	assert.NotNil(t, err)
+
+	selector, err := options.podSelector([]string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, "yaks.dev/test in (a,b)", selector.String())
+
+	options.all = true
+	_, err = options.podSelector([]string{"a"})
+	assert.NotNil(t, err)
+
+	options.selector = "team=payments"
+	selector, err = options.podSelector(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "team=payments,yaks.dev/test", selector.String())
+}
@@ -0,0 +1,60 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+func newCmdMigrateStorage(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := migrateStorageCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "migrate-storage",
+		Short:             "Re-encode the stored tests and instances in the storage version",
+		Long:              `Touches all the Test and Instance objects of the cluster, for them to be stored again in the storage version of their custom resource definition, then drops the previous versions from the stored versions of the definitions. Run it after switching the storage version with "yaks install --crd-storage-version" (requires cluster-admin rights).`,
+		Args:              cobra.NoArgs,
+		RunE:              options.run,
+	}
+
+	return &cmd
+}
+
+type migrateStorageCmdOptions struct {
+	*RootCmdOptions
+}
+
+func (o *migrateStorageCmdOptions) run(cmd *cobra.Command, _ []string) error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	migrations, err := install.MigrateAllCRDStorage(o.Context, c)
+	for _, migration := range migrations {
+		fmt.Printf("%s: %d objects stored in version %s\n", migration.Name, migration.Objects, migration.Version)
+	}
+	return err
+}
@@ -12,7 +12,11 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/jboss-fuse/yaks/pkg/apis"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	yaksconfig "github.com/jboss-fuse/yaks/pkg/config"
 	"github.com/jboss-fuse/yaks/pkg/controller"
+	"github.com/jboss-fuse/yaks/pkg/controller/test"
+	"github.com/jboss-fuse/yaks/pkg/install"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
@@ -44,10 +48,42 @@ func printVersion() {
 	log.Info(fmt.Sprintf("Version of operator-sdk: %v", sdkVersion.Version))
 }
 
+// logEncoders are the zap encoders writing the logs in each format
+var logEncoders = map[yaksconfig.LogFormat]string{
+	yaksconfig.LogFormatText: "console",
+	yaksconfig.LogFormatJSON: "json",
+}
+
+// setLogEncoder configures the zap logger of the flags to write the logs in the given format
+func setLogEncoder(flags *pflag.FlagSet, value string) error {
+	format, err := yaksconfig.ParseLogFormat(value)
+	if err != nil {
+		return err
+	}
+	if flags.Changed("zap-encoder") {
+		return nil
+	}
+	return flags.Set("zap-encoder", logEncoders[format])
+}
+
+// leaderLockName returns the name of the leader lock of the operator. The cluster-wide operator, and the operators with
+// other labels, e.g. a canary, use their own lock so that they can run next to the operator of their namespace.
+func leaderLockName(watchNamespace string, operatorLabels map[string]string) string {
+	name := "yaks-lock"
+	if watchNamespace == "" {
+		name = "yaks-cluster-lock"
+	}
+	if suffix := yaksconfig.OperatorLabelsSuffix(operatorLabels); suffix != "" {
+		name += "-" + suffix
+	}
+	return name
+}
+
 func Run() {
 	// Add the zap logger flag set to the CLI. The flag set must
 	// be added before calling pflag.Parse().
 	pflag.CommandLine.AddFlagSet(zap.FlagSet())
+	logFormat := pflag.String("log-format", yaksconfig.GetLogFormat(), "Format of the operator logs, one of: text, json")
 
 	// Add flags registered by imported packages (e.g. glog and
 	// controller-runtime)
@@ -55,6 +91,12 @@ func Run() {
 
 	pflag.Parse()
 
+	// The log format selects the zap encoder, unless set explicitly with --zap-encoder
+	if err := setLogEncoder(pflag.CommandLine, *logFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// Use a zap logr.Logger implementation. If none of the zap
 	// flags are configured (or if the zap flag set is not being
 	// used), this defaults to a production zap logger.
@@ -82,7 +124,7 @@ func Run() {
 
 	ctx := context.TODO()
 	// Become the leader before proceeding
-	err = leader.Become(ctx, "yaks-lock")
+	err = leader.Become(ctx, leaderLockName(namespace, yaksconfig.GetOperatorLabels()))
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
@@ -107,6 +149,30 @@ func Run() {
 		os.Exit(1)
 	}
 
+	// Refuse to start against custom resource definitions older than the ones the operator relies on
+	c, err := client.FromManager(mgr)
+	if err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+	if err := install.CheckCRDVersions(ctx, c); err != nil {
+		log.Error(err, "Unsupported custom resource definitions")
+		os.Exit(1)
+	}
+
+	// Delete the runner pods left by tests deleted while the operator was not running. The client of the manager
+	// cannot be used, as its cache is not started yet.
+	if !yaksconfig.KeepOrphanedPods() {
+		uncached, err := client.NewClient()
+		if err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+		if err := test.CleanupOrphans(ctx, uncached, namespace); err != nil {
+			log.Error(err, "Cannot delete orphaned runner pods")
+		}
+	}
+
 	// Setup all Controllers
 	if err := controller.AddToManager(mgr); err != nil {
 		log.Error(err, "")
@@ -135,6 +201,12 @@ func Run() {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
+
+	// The manager stops dispatching requests when a termination signal is received,
+	// let the ones in progress complete and persist their status
+	if !test.Drain(yaksconfig.GetDrainTimeout()) {
+		os.Exit(1)
+	}
 }
 
 // serveCRMetrics gets the Operator/CustomResource GVKs and generates metrics based on those types.
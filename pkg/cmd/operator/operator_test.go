@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLogEncoder(t *testing.T) {
+	tests := []struct {
+		value   string
+		flags   []string
+		encoder string
+		valid   bool
+	}{
+		{value: "text", encoder: "console", valid: true},
+		{value: "json", encoder: "json", valid: true},
+		{value: "Json", encoder: "json", valid: true},
+		{value: "TEXT", encoder: "console", valid: true},
+		// An explicit zap encoder wins over the log format
+		{value: "json", flags: []string{"--zap-encoder", "console"}, encoder: "console", valid: true},
+		{value: "console"},
+		{value: "xml"},
+	}
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("operator", pflag.ContinueOnError)
+		flags.String("zap-encoder", "", "")
+		assert.Nil(t, flags.Parse(test.flags))
+
+		err := setLogEncoder(flags, test.value)
+		if test.valid {
+			assert.Nil(t, err, test.value)
+			assert.Equal(t, test.encoder, flags.Lookup("zap-encoder").Value.String(), test.value)
+		} else {
+			assert.NotNil(t, err, test.value)
+			assert.Empty(t, flags.Lookup("zap-encoder").Value.String(), test.value)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdPromote(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := promoteCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "promote <test>",
+		Short:             "Copy a test and the config maps it references to another namespace",
+		Long: `Recreates a test in the target namespace, together with the config maps it references. Secrets are
+expected to exist in the target namespace, unless --copy-secrets is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: options.run,
+		Annotations: map[string]string{
+			completionTestNamesAnnotation: "true",
+		},
+	}
+
+	cmd.Flags().StringVar(&options.to, "to", "", "Namespace the test is promoted to")
+	cmd.Flags().BoolVar(&options.copySecrets, "copy-secrets", false, "Copy the referenced secrets by value instead of expecting them in the target namespace")
+
+	return &cmd
+}
+
+type promoteCmdOptions struct {
+	*RootCmdOptions
+	to          string
+	copySecrets bool
+}
+
+func (o *promoteCmdOptions) run(cmd *cobra.Command, args []string) error {
+	if o.to == "" {
+		return errors.New("the target namespace must be given with --to")
+	}
+	if o.to == o.Namespace {
+		return errors.New("the target namespace must differ from the namespace of the test")
+	}
+	cmd.SilenceUsage = true
+
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+
+	test := v1alpha1.Test{}
+	if err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.Namespace, Name: args[0]}, &test); err != nil {
+		return err
+	}
+
+	if err := o.checkPermitted(c, "tests", "yaks.dev"); err != nil {
+		return err
+	}
+
+	configMaps, secrets := referencedObjects(&test)
+	for _, name := range configMaps {
+		if err := o.promoteConfigMap(c, name); err != nil {
+			return err
+		}
+	}
+	for _, name := range secrets {
+		if err := o.promoteSecret(c, name); err != nil {
+			return err
+		}
+	}
+
+	promoted := v1alpha1.Test{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.TestKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: promotedObjectMeta(test.ObjectMeta, o.to),
+		Spec:       *test.Spec.DeepCopy(),
+	}
+	rewriteNamespaceReferences(&promoted.Spec, o.Namespace)
+	return o.apply(c, &promoted, "test")
+}
+
+// checkPermitted verifies that the current user can create the given resources in the target namespace
+func (o *promoteCmdOptions) checkPermitted(c client.Client, resource string, group string) error {
+	review := authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: o.to,
+				Verb:      "create",
+				Group:     group,
+				Resource:  resource,
+			},
+		},
+	}
+	result, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(&review)
+	if err != nil {
+		return err
+	}
+	if !result.Status.Allowed {
+		return errors.New(fmt.Sprintf("not allowed to create %s in namespace %s", resource, o.to))
+	}
+	return nil
+}
+
+func (o *promoteCmdOptions) promoteConfigMap(c client.Client, name string) error {
+	configMap := corev1.ConfigMap{}
+	if err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.Namespace, Name: name}, &configMap); err != nil {
+		return errors.Wrap(err, "cannot read config map "+name)
+	}
+	promoted := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: promotedObjectMeta(configMap.ObjectMeta, o.to),
+		Data:       configMap.Data,
+		BinaryData: configMap.BinaryData,
+	}
+	return o.apply(c, &promoted, "configmap")
+}
+
+func (o *promoteCmdOptions) promoteSecret(c client.Client, name string) error {
+	if !o.copySecrets {
+		existing := corev1.Secret{}
+		err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.to, Name: name}, &existing)
+		if err != nil && k8serrors.IsNotFound(err) {
+			fmt.Printf("Warning: secret %s does not exist in namespace %s, create it or use --copy-secrets\n", name, o.to)
+			return nil
+		}
+		return err
+	}
+
+	secret := corev1.Secret{}
+	if err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.Namespace, Name: name}, &secret); err != nil {
+		return errors.Wrap(err, "cannot read secret "+name)
+	}
+	fmt.Printf("Warning: copying secret %s by value to namespace %s\n", name, o.to)
+	promoted := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: promotedObjectMeta(secret.ObjectMeta, o.to),
+		Type:       secret.Type,
+		Data:       secret.Data,
+	}
+	return o.apply(c, &promoted, "secret")
+}
+
+// apply creates the object in the target namespace, or replaces the existing one
+func (o *promoteCmdOptions) apply(c client.Client, obj runtime.Object, kind string) error {
+	metaObject := obj.(metav1.Object)
+	err := c.Create(o.Context, obj)
+	if err != nil && k8serrors.IsAlreadyExists(err) {
+		existing := obj.DeepCopyObject()
+		if err := c.Get(o.Context, k8sclient.ObjectKey{Namespace: o.to, Name: metaObject.GetName()}, existing); err != nil {
+			return err
+		}
+		metaObject.SetResourceVersion(existing.(metav1.Object).GetResourceVersion())
+		if err := c.Update(o.Context, obj); err != nil {
+			return err
+		}
+		fmt.Printf("%s \"%s\" updated in namespace %s\n", kind, metaObject.GetName(), o.to)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Printf("%s \"%s\" created in namespace %s\n", kind, metaObject.GetName(), o.to)
+	return nil
+}
+
+// promotedObjectMeta keeps the name, labels and annotations of an object, dropping the fields set by the server
+func promotedObjectMeta(meta metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:   namespace,
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+// referencedObjects returns the names of the config maps and secrets referenced by the test
+func referencedObjects(test *v1alpha1.Test) ([]string, []string) {
+	configMaps := make(map[string]bool)
+	secrets := make(map[string]bool)
+
+	runtimeSpec := test.Spec.Runtime
+	if runtimeSpec.TrustedCA != nil {
+		configMaps[runtimeSpec.TrustedCA.Name] = true
+	}
+	for _, secret := range runtimeSpec.ImagePullSecrets {
+		secrets[secret.Name] = true
+	}
+	for _, volume := range runtimeSpec.Volumes {
+		if volume.ConfigMap != nil {
+			configMaps[volume.ConfigMap.Name] = true
+		}
+		if volume.Secret != nil {
+			secrets[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					configMaps[source.ConfigMap.Name] = true
+				}
+				if source.Secret != nil {
+					secrets[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	return sortedKeys(configMaps), sortedKeys(secrets)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rewriteNamespaceReferences makes the references to the source namespace default to the namespace of the promoted test
+func rewriteNamespaceReferences(spec *v1alpha1.TestSpec, from string) {
+	if spec.Namespace == from {
+		spec.Namespace = ""
+	}
+	for i := range spec.Endpoints {
+		if service := spec.Endpoints[i].Service; service != nil && service.Namespace == from {
+			service.Namespace = ""
+		}
+	}
+}
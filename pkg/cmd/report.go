@@ -0,0 +1,288 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdReport(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := reportCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "report",
+		Short:             "Summarize the results of the completed tests",
+		Long:              `Aggregates the results of the completed tests of the namespace, optionally restricted to the recent or labeled ones.`,
+		Args:              cobra.NoArgs,
+		PreRunE:           options.validateArgs,
+		RunE:              options.run,
+	}
+
+	cmd.Flags().StringVarP(&options.output, "output", "o", "", "Output format of the report, one of json, junit or testcases (defaults to a table)")
+	cmd.Flags().DurationVar(&options.since, "since", 0, "Only include the tests completed within the given duration, e.g. 1h")
+	cmd.Flags().StringVarP(&options.selector, "selector", "l", "", "Only include the tests matching the given label selector")
+	completeFlagWith(cmd.Flags(), "selector", completeSelectorsFunction)
+	cmd.Flags().StringVar(&options.groupBy, "group-by", "", "Group the results in the table. One of: label (the directory the tests come from), label=<key>")
+	cmd.Flags().Float64Var(&options.failThreshold, "fail-threshold", 0, "Exit with an error when the percentage of failed and errored tests, among the ones not skipped, exceeds the given one, e.g. 5")
+	options.caseIDFlags.addFlags(&cmd)
+
+	return &cmd
+}
+
+type reportCmdOptions struct {
+	*RootCmdOptions
+	output   string
+	since    time.Duration
+	selector string
+	groupBy  string
+	// failThreshold is only applied when the flag is set
+	failThreshold    float64
+	hasFailThreshold bool
+	caseIDFlags
+}
+
+// caseIDFlags configure how the testcases output reads the case IDs of a test management system from the tags of the
+// scenarios
+type caseIDFlags struct {
+	caseIDTag      string
+	caseIDProperty string
+}
+
+func (f *caseIDFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.caseIDTag, "case-id-tag", report.DefaultCaseIDTag, "With -o testcases, regular expression matching the scenario tags holding a case ID, given by its first group")
+	cmd.Flags().StringVar(&f.caseIDProperty, "case-id-property", report.DefaultCaseIDProperty, "With -o testcases, JUnit property carrying the case IDs, e.g. test_id for TestRail or test_key for Xray")
+}
+
+func (f *caseIDFlags) caseIDOptions() (report.CaseIDOptions, error) {
+	tag, err := regexp.Compile(f.caseIDTag)
+	if err != nil {
+		return report.CaseIDOptions{}, errors.Wrap(err, "invalid --case-id-tag")
+	}
+	return report.CaseIDOptions{
+		Tag:      tag,
+		Property: f.caseIDProperty,
+	}, nil
+}
+
+// groupByLabel groups the results by the TestGroupLabel, or by the label given as label=<key>
+const groupByLabel = "label"
+
+func (o *reportCmdOptions) validateArgs(cmd *cobra.Command, _ []string) error {
+	if o.output != "" && o.output != outputJSON && o.output != outputJUnit && o.output != outputTestCases {
+		return errors.New(fmt.Sprintf("unsupported output format %q", o.output))
+	}
+	if _, err := o.caseIDOptions(); err != nil {
+		return err
+	}
+	if _, err := o.groupLabel(); err != nil {
+		return err
+	}
+	if o.since < 0 {
+		return errors.New(fmt.Sprintf("invalid duration %s, must be positive", o.since))
+	}
+	o.hasFailThreshold = cmd.Flags().Changed("fail-threshold")
+	if o.failThreshold < 0 || o.failThreshold > 100 {
+		return errors.New(fmt.Sprintf("invalid --fail-threshold %v, must be a percentage between 0 and 100", o.failThreshold))
+	}
+	return nil
+}
+
+func (o *reportCmdOptions) run(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+
+	options := k8sclient.ListOptions{Namespace: o.Namespace}
+	if o.selector != "" {
+		if err := options.SetLabelSelector(o.selector); err != nil {
+			return errors.Wrap(err, "invalid label selector")
+		}
+	}
+	tests := v1alpha1.TestList{}
+	if err := c.List(o.Context, &options, &tests); err != nil {
+		return err
+	}
+
+	completed := o.completedTests(tests.Items, time.Now())
+	summary := report.NewSummary()
+	for _, test := range completed {
+		summary.Add(report.NewTestResult(test, runningDuration(test)))
+	}
+
+	if err := o.printReport(summary, completed); err != nil {
+		return err
+	}
+	return o.checkFailThreshold(summary)
+}
+
+// checkFailThreshold returns an error when the failure rate of the tests exceeds the --fail-threshold, if given
+func (o *reportCmdOptions) checkFailThreshold(summary *report.Summary) error {
+	if !o.hasFailThreshold {
+		return nil
+	}
+	if rate := summary.FailureRate(); rate > o.failThreshold {
+		return errors.New(fmt.Sprintf("%.1f%% of the tests have failed, exceeding the threshold of %v%%", rate, o.failThreshold))
+	}
+	return nil
+}
+
+func (o *reportCmdOptions) printReport(summary *report.Summary, completed []*v1alpha1.Test) error {
+	switch o.output {
+	case outputJSON:
+		return summary.PrintJSON(os.Stdout)
+	case outputJUnit:
+		return summary.PrintJUnit(os.Stdout)
+	case outputTestCases:
+		options, _ := o.caseIDOptions()
+		return summary.PrintTestCases(os.Stdout, options)
+	}
+
+	key, _ := o.groupLabel()
+	if key == "" {
+		return printSummary(summary)
+	}
+	groups, order := groupResults(completed, key)
+	for i, group := range order {
+		if i > 0 {
+			fmt.Println()
+		}
+		if group == "" {
+			fmt.Printf("Ungrouped (no %s label)\n", key)
+		} else {
+			fmt.Printf("Group %s\n", group)
+		}
+		if err := printSummary(groups[group]); err != nil {
+			return err
+		}
+	}
+	if len(order) > 1 {
+		fmt.Printf("\nTotal: %d, passed: %d, failed: %d, errors: %d, skipped: %d, pass rate: %.1f%%\n",
+			summary.Total, summary.Passed, summary.Failed, summary.Errors, summary.Skipped, summary.PassRate)
+	}
+	return nil
+}
+
+// groupLabel returns the key of the label to group the results by, if any
+func (o *reportCmdOptions) groupLabel() (string, error) {
+	switch {
+	case o.groupBy == "":
+		return "", nil
+	case o.groupBy == groupByLabel:
+		return v1alpha1.TestGroupLabel, nil
+	case strings.HasPrefix(o.groupBy, groupByLabel+"=") && len(o.groupBy) > len(groupByLabel)+1:
+		return strings.TrimPrefix(o.groupBy, groupByLabel+"="), nil
+	}
+	return "", errors.New(fmt.Sprintf("unsupported grouping %q, must be label or label=<key>", o.groupBy))
+}
+
+// groupResults partitions the results of the tests by the value of the given label, sorting the groups by name
+func groupResults(tests []*v1alpha1.Test, key string) (map[string]*report.Summary, []string) {
+	groups := make(map[string]*report.Summary)
+	order := make([]string, 0)
+	for _, test := range tests {
+		group := test.Labels[key]
+		if _, ok := groups[group]; !ok {
+			groups[group] = report.NewSummary()
+			order = append(order, group)
+		}
+		groups[group].Add(report.NewTestResult(test, runningDuration(test)))
+	}
+	sort.Strings(order)
+	return groups, order
+}
+
+func printSummary(summary *report.Summary) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPHASE\tREASON\tDURATION\tMESSAGE")
+	for _, result := range summary.Tests {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Phase, result.Reason, result.Duration,
+			firstLine(result.Message))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Total: %d, passed: %d, failed: %d, errors: %d, skipped: %d, pass rate: %.1f%%\n",
+		summary.Total, summary.Passed, summary.Failed, summary.Errors, summary.Skipped, summary.PassRate)
+	return nil
+}
+
+// completedTests returns the tests having a result, completed within the since window when given, oldest first
+func (o *reportCmdOptions) completedTests(tests []v1alpha1.Test, now time.Time) []*v1alpha1.Test {
+	completed := make([]*v1alpha1.Test, 0, len(tests))
+	for i := range tests {
+		test := &tests[i]
+		switch test.Status.Phase {
+		case v1alpha1.TestPhasePassed, v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError, v1alpha1.TestPhaseSkipped:
+		default:
+			continue
+		}
+		if o.since > 0 {
+			if test.Status.Timings == nil || test.Status.Timings.Completed == nil ||
+				test.Status.Timings.Completed.Time.Before(now.Add(-o.since)) {
+				continue
+			}
+		}
+		completed = append(completed, test)
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completedTime(completed[i]).Before(completedTime(completed[j]))
+	})
+	return completed
+}
+
+func completedTime(test *v1alpha1.Test) time.Time {
+	if test.Status.Timings != nil && test.Status.Timings.Completed != nil {
+		return test.Status.Timings.Completed.Time
+	}
+	return test.CreationTimestamp.Time
+}
+
+// runningDuration returns how long the last run of the test has been running, if known
+func runningDuration(test *v1alpha1.Test) time.Duration {
+	if timings := test.Status.Timings; timings != nil && timings.Running != nil && timings.Completed != nil {
+		return timings.Completed.Sub(timings.Running.Time)
+	}
+	return 0
+}
+
+func firstLine(message string) string {
+	for i, r := range message {
+		if r == '\n' {
+			return message[:i]
+		}
+	}
+	return message
+}
@@ -0,0 +1,45 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFailThreshold(t *testing.T) {
+	summary := report.NewSummary()
+	for i := 0; i < 19; i++ {
+		summary.Add(report.TestResult{Phase: v1alpha1.TestPhasePassed})
+	}
+	summary.Add(report.TestResult{Phase: v1alpha1.TestPhaseFailed})
+
+	options := reportCmdOptions{}
+	assert.Nil(t, options.checkFailThreshold(summary))
+
+	options = reportCmdOptions{failThreshold: 5, hasFailThreshold: true}
+	assert.Nil(t, options.checkFailThreshold(summary))
+
+	options.failThreshold = 0
+	err := options.checkFailThreshold(summary)
+	assert.NotNil(t, err)
+	assert.Equal(t, "5.0% of the tests have failed, exceeding the threshold of 0%", err.Error())
+}
@@ -30,10 +30,13 @@ const yaksCommandLongDescription = `Yaks is Yet Another Kamel Subproject.
 
 // RootCmdOptions --
 type RootCmdOptions struct {
-	Context    context.Context
-	_client    client.Client
-	KubeConfig string
-	Namespace  string
+	Context     context.Context
+	_client     client.Client
+	KubeConfig  string
+	KubeContext string
+	Namespace   string
+	// projectConfig holds the defaults of the yaks-config.yaml file
+	projectConfig projectConfig
 }
 
 // NewYaksCommand --
@@ -47,12 +50,29 @@ func NewYaksCommand(ctx context.Context) (*cobra.Command, error) {
 		Long:  yaksCommandLongDescription,
 	}
 
-	cmd.PersistentFlags().StringVar(&options.KubeConfig, "config", os.Getenv("KUBECONFIG"), "Path to the config file to use for CLI requests")
+	cmd.PersistentFlags().StringVar(&options.KubeConfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file to use for CLI requests")
+	cmd.PersistentFlags().StringVar(&options.KubeConfig, "config", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file to use for CLI requests")
+	_ = cmd.PersistentFlags().MarkDeprecated("config", "use --kubeconfig instead")
+	cmd.PersistentFlags().StringVar(&options.KubeContext, "context", "", "Name of the kubeconfig context to use, instead of its current context")
 	cmd.PersistentFlags().StringVarP(&options.Namespace, "namespace", "n", "", "Namespace to use for all operations")
 
 	cmd.AddCommand(newCmdTest(&options))
 	cmd.AddCommand(newCmdInstall(&options))
 	cmd.AddCommand(newCmdOperator(&options))
+	cmd.AddCommand(newCmdStatus(&options))
+	cmd.AddCommand(newCmdPromote(&options))
+	cmd.AddCommand(newCmdReport(&options))
+	cmd.AddCommand(newCmdCancel(&options))
+	cmd.AddCommand(newCmdWait(&options))
+	cmd.AddCommand(newCmdLogs(&options))
+	cmd.AddCommand(newCmdLint(&options))
+	cmd.AddCommand(newCmdSteps(&options))
+	cmd.AddCommand(newCmdConfig(&options))
+	cmd.AddCommand(newCmdSchema(&options))
+	cmd.AddCommand(newCmdValidateCRD(&options))
+	cmd.AddCommand(newCmdMigrateStorage(&options))
+	cmd.AddCommand(newCmdVersion(&options))
+	cmd.AddCommand(newCmdCompletion(&options, &cmd))
 
 	return &cmd, nil
 }
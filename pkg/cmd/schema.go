@@ -0,0 +1,108 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// crdResources maps the kinds of the yaks custom resources to their definition name and embedded resource
+var crdResources = map[string]struct {
+	name     string
+	resource string
+}{
+	"test": {
+		name:     "tests.yaks.dev",
+		resource: "crds/yaks_v1alpha1_test_crd.yaml",
+	},
+	"instance": {
+		name:     "instances.yaks.dev",
+		resource: "crds/yaks_v1alpha1_instance_crd.yaml",
+	},
+}
+
+func newCmdSchema(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := schemaCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+	cmd := cobra.Command{
+		Use:       "schema [kind]",
+		Short:     "Print the OpenAPI schema of a Yaks custom resource",
+		Long:      `Prints the OpenAPI v3 schema of the installed custom resource definition for the given kind (test or instance), or the one embedded in the CLI if it's not installed. The schema can be used to validate resource files in editors.`,
+		ValidArgs: []string{"test", "instance"},
+		Args:      cobra.ExactArgs(1),
+		RunE:      options.run,
+	}
+
+	cmd.Flags().BoolVar(&options.embedded, "embedded", false, "Print the schema embedded in the CLI, without looking up the installed one")
+
+	return &cmd
+}
+
+type schemaCmdOptions struct {
+	*RootCmdOptions
+	embedded bool
+}
+
+func (o *schemaCmdOptions) run(_ *cobra.Command, args []string) error {
+	kind := strings.TrimSuffix(strings.ToLower(args[0]), "s")
+	crdResource, ok := crdResources[kind]
+	if !ok {
+		return errors.New(fmt.Sprintf("unknown kind %q, expected one of test, instance", args[0]))
+	}
+
+	var crd *unstructured.Unstructured
+	if !o.embedded {
+		var err error
+		crd, err = install.GetInstalledCRD(crdResource.name)
+		if err != nil && k8serrors.IsForbidden(err) {
+			fmt.Fprintln(os.Stderr, "Current user cannot read custom resource definitions, using the embedded schema")
+		} else if err != nil {
+			return err
+		}
+	}
+	if crd == nil {
+		obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[crdResource.resource])
+		if err != nil {
+			return err
+		}
+		crd = obj.(*unstructured.Unstructured)
+	}
+
+	schema, found, err := unstructured.NestedMap(crd.Object, "spec", "validation", "openAPIV3Schema")
+	if err != nil {
+		return err
+	} else if !found {
+		return errors.New(fmt.Sprintf("custom resource definition %s has no OpenAPI schema", crdResource.name))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
@@ -0,0 +1,156 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newCmdStatus(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := statusCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "status",
+		Short:             "Show the status of the Yaks installation",
+		Long:              `Reports whether custom resource definitions, cluster roles and the operator are installed and summarizes the tests in the namespace.`,
+		RunE:              options.run,
+	}
+
+	return &cmd
+}
+
+type statusCmdOptions struct {
+	*RootCmdOptions
+}
+
+func (o *statusCmdOptions) run(_ *cobra.Command, _ []string) error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+
+	complete := true
+
+	versions, err := install.InstalledCRDVersions(o.Context, c, v1alpha1.TestKind)
+	if err != nil {
+		return err
+	}
+	crdInstalled, err := install.AreAllCRDInstalled(o.Context, c)
+	if err != nil {
+		return err
+	}
+	if crdInstalled {
+		fmt.Printf("Custom resource definitions: installed (%s)\n", strings.Join(versions, ", "))
+	} else {
+		fmt.Println("Custom resource definitions: not installed")
+		complete = false
+	}
+
+	clusterRoleInstalled, err := install.IsClusterRoleInstalled(o.Context, c)
+	if err != nil {
+		return err
+	}
+	if clusterRoleInstalled {
+		fmt.Println("Cluster role: installed")
+	} else {
+		fmt.Println("Cluster role: not installed")
+		complete = false
+	}
+
+	deployment, err := install.GetOperatorDeployment(o.Context, c, o.Namespace)
+	if err != nil {
+		return err
+	}
+	if deployment == nil {
+		fmt.Printf("Operator: not installed in namespace %s\n", o.Namespace)
+		complete = false
+	} else {
+		var replicas int32 = 1
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		ready := "ready"
+		if deployment.Status.AvailableReplicas == 0 {
+			ready = "not ready"
+			complete = false
+		}
+		fmt.Printf("Operator: %s (%d/%d replicas available) in namespace %s\n", ready, deployment.Status.AvailableReplicas, replicas, o.Namespace)
+		o.warnOnVersionMismatch(c)
+	}
+
+	if crdInstalled {
+		if err := o.printTestCounts(c); err != nil {
+			return err
+		}
+	}
+
+	if !complete {
+		return errors.New(`yaks installation is incomplete, run "yaks install" to complete it`)
+	}
+	return nil
+}
+
+func (o *statusCmdOptions) printTestCounts(c client.Client) error {
+	tests := v1alpha1.TestList{}
+	if err := c.List(o.Context, &k8sclient.ListOptions{Namespace: o.Namespace}, &tests); err != nil {
+		return err
+	}
+
+	counts := make(map[v1alpha1.TestPhase]int)
+	for _, test := range tests.Items {
+		counts[test.Status.Phase]++
+	}
+
+	details := make([]string, 0, len(counts))
+	for _, phase := range testPhases {
+		if count, ok := counts[phase]; ok {
+			details = append(details, fmt.Sprintf("%s: %d", phase, count))
+		}
+	}
+	if count, ok := counts[v1alpha1.IntegrationTestPhaseNone]; ok {
+		details = append(details, fmt.Sprintf("New: %d", count))
+	}
+
+	if len(details) == 0 {
+		fmt.Println("Tests: 0")
+	} else {
+		fmt.Printf("Tests: %d (%s)\n", len(tests.Items), strings.Join(details, ", "))
+	}
+	return nil
+}
+
+var testPhases = []v1alpha1.TestPhase{
+	v1alpha1.TestPhasePending,
+	v1alpha1.TestPhaseRunning,
+	v1alpha1.TestPhasePassed,
+	v1alpha1.TestPhaseFailed,
+	v1alpha1.TestPhaseError,
+	v1alpha1.TestPhaseSkipped,
+	v1alpha1.TestPhaseDeleting,
+}
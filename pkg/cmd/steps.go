@@ -0,0 +1,325 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// stepsPodNamePrefix prefixes the generated names of the pods listing the steps, so that concurrent listings in the
+	// same namespace do not share their pod
+	stepsPodNamePrefix = "yaks-steps-"
+	// stepsMainClass lists the step definitions of the runner instead of running tests
+	stepsMainClass = "dev.yaks.testing.StepCatalog"
+	// stepLinePrefix tells apart the step definitions from the other output of the runner
+	stepLinePrefix = "STEP\t"
+	// outputCatalog prints one step expression per line, in the format of the --steps catalog of yaks lint
+	outputCatalog = "catalog"
+)
+
+func newCmdSteps(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := stepsCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		options.cacheDir = filepath.Join(dir, "yaks", "steps")
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "steps",
+		Short:             "List the Gherkin steps supported by the test runner",
+		Long:              `Runs the test runner image in the namespace to list its step definitions and their parameters. The steps are cached per runner image, so that the image is only run once.`,
+		Args:              cobra.NoArgs,
+		PreRunE:           options.validateArgs,
+		RunE:              options.run,
+	}
+
+	cmd.Flags().StringVarP(&options.output, "output", "o", "", "Output format of the steps, json or catalog, one step per line as read by --steps (defaults to a table)")
+	cmd.Flags().StringVar(&options.image, "image", "", "Runner image to list the steps of, defaults to the one of the operator of the namespace")
+	cmd.Flags().BoolVar(&options.refresh, "refresh", false, "Run the runner image again instead of using the cached steps, e.g. for a mutable tag")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 5*time.Minute, "How long to wait for the runner to list its steps")
+
+	return &cmd
+}
+
+type stepsCmdOptions struct {
+	*RootCmdOptions
+	output   string
+	image    string
+	refresh  bool
+	timeout  time.Duration
+	cacheDir string
+}
+
+// stepDefinition is a step supported by the runner
+type stepDefinition struct {
+	// Keyword of the step annotation, e.g. Given
+	Keyword string `json:"keyword"`
+	// Expression matched by the step, a regular expression or a Cucumber expression
+	Expression string `json:"expression"`
+	// Parameters are the types of the arguments of the step definition
+	Parameters []string `json:"parameters,omitempty"`
+}
+
+func (o *stepsCmdOptions) validateArgs(_ *cobra.Command, _ []string) error {
+	if o.output != "" && o.output != outputJSON && o.output != outputCatalog {
+		return errors.New(fmt.Sprintf("unsupported output format %q", o.output))
+	}
+	if o.timeout <= 0 {
+		return errors.New(fmt.Sprintf("invalid timeout %s, must be positive", o.timeout))
+	}
+	return nil
+}
+
+func (o *stepsCmdOptions) run(cmd *cobra.Command, _ []string) error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	image, pullSecret := o.image, ""
+	if image == "" {
+		deployment, err := install.GetOperatorDeployment(o.Context, c, o.Namespace)
+		if err != nil {
+			return err
+		}
+		image, pullSecret = runnerImageOf(deployment)
+	}
+
+	steps, ok := o.cachedSteps(image)
+	if !ok || o.refresh {
+		if steps, err = o.listSteps(o.Context, c, image, pullSecret); err != nil {
+			return err
+		}
+		if err := o.cacheSteps(image, steps); err != nil {
+			fmt.Fprintln(os.Stderr, "Cannot cache the steps:", err)
+		}
+	}
+	return printSteps(os.Stdout, steps, o.output)
+}
+
+// runnerImageOf returns the runner image of the tests run by the operator, and its image pull secret, defaulting to the
+// runner image of the version of the CLI when the operator is not installed
+func runnerImageOf(deployment *appsv1.Deployment) (string, string) {
+	if deployment == nil || len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return config.GetTestBaseImage(), ""
+	}
+	operator := deployment.Spec.Template.Spec.Containers[0]
+	pullSecret := ""
+	if env := envvar.Get(operator.Env, "DEFAULT_IMAGE_PULL_SECRET"); env != nil {
+		pullSecret = env.Value
+	}
+	if env := envvar.Get(operator.Env, "TEST_BASE_IMAGE"); env != nil && env.Value != "" {
+		return env.Value, pullSecret
+	}
+	// The operator image embeds the runner
+	return operator.Image, pullSecret
+}
+
+// listSteps runs the runner image in listing mode and reads the steps from its logs, the pod is deleted afterwards
+func (o *stepsCmdOptions) listSteps(ctx context.Context, c client.Client, image string, pullSecret string) (steps []stepDefinition, err error) {
+	pods := c.CoreV1().Pods(o.Namespace)
+	deadline := time.Now().Add(o.timeout)
+	created, err := pods.Create(newStepsPod(o.Namespace, image, pullSecret, o.timeout))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create the pod listing the steps")
+	}
+	name := created.Name
+	defer func() {
+		if deleteErr := pods.Delete(name, &metav1.DeleteOptions{}); deleteErr != nil && !k8serrors.IsNotFound(deleteErr) && err == nil {
+			err = errors.Wrap(deleteErr, "cannot delete the pod listing the steps")
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listing the steps of image %s\n", image)
+	for {
+		current, err := pods.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if current.Status.Phase == corev1.PodFailed {
+			return nil, errors.New(fmt.Sprintf("the runner image %s cannot list its steps, it may predate yaks steps", image))
+		} else if current.Status.Phase == corev1.PodSucceeded {
+			break
+		}
+		if diagnostic := podDiagnostic(current); diagnostic != "" {
+			return nil, errors.New("the steps cannot be listed: " + diagnostic)
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New(fmt.Sprintf("timeout while waiting for the steps of image %s", image))
+		}
+		time.Sleep(time.Second)
+	}
+
+	stream, err := pods.GetLogs(name, &corev1.PodLogOptions{}).Stream()
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return parseStepDefinitions(stream)
+}
+
+// newStepsPod returns the pod listing the steps of the image, that stops by itself after the timeout should the CLI be
+// interrupted before deleting it
+func newStepsPod(namespace string, image string, pullSecret string, timeout time.Duration) *corev1.Pod {
+	deadline := int64(timeout.Seconds())
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    namespace,
+			GenerateName: stepsPodNamePrefix,
+			Labels: map[string]string{
+				"yaks.dev/steps": "true",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+			Containers: []corev1.Container{
+				{
+					Name:    "steps",
+					Image:   image,
+					Command: []string{"/usr/local/s2i/run"},
+					Env: []corev1.EnvVar{
+						{
+							Name:  "JAVA_MAIN_CLASS",
+							Value: stepsMainClass,
+						},
+						{
+							Name:  "JAVA_LIB_DIR",
+							Value: "/deployments/dependencies/*",
+						},
+					},
+				},
+			},
+		},
+	}
+	if pullSecret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+	return &pod
+}
+
+// parseStepDefinitions reads the step definitions printed by the runner, one per line in the form
+// STEP<tab>keyword<tab>expression<tab>comma separated parameter types, skipping the duplicates and the other lines
+func parseStepDefinitions(r io.Reader) ([]stepDefinition, error) {
+	steps := make([]stepDefinition, 0)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(line, stepLinePrefix) {
+			continue
+		}
+		fields := strings.Split(strings.TrimPrefix(line, stepLinePrefix), "\t")
+		if len(fields) < 2 || seen[fields[0]+"\t"+fields[1]] {
+			continue
+		}
+		seen[fields[0]+"\t"+fields[1]] = true
+		step := stepDefinition{Keyword: fields[0], Expression: fields[1]}
+		if len(fields) > 2 && fields[2] != "" {
+			step.Parameters = strings.Split(fields[2], ",")
+		}
+		steps = append(steps, step)
+	}
+	return steps, scanner.Err()
+}
+
+func printSteps(w io.Writer, steps []stepDefinition, output string) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(steps)
+	case outputCatalog:
+		seen := make(map[string]bool)
+		for _, step := range steps {
+			if !seen[step.Expression] {
+				seen[step.Expression] = true
+				fmt.Fprintln(w, step.Expression)
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEYWORD\tSTEP\tPARAMETERS")
+		for _, step := range steps {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", step.Keyword, step.Expression, strings.Join(step.Parameters, ", "))
+		}
+		return tw.Flush()
+	}
+}
+
+// stepsCacheFile returns the file caching the steps of the image
+func (o *stepsCmdOptions) stepsCacheFile(image string) string {
+	name := strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(image)
+	return filepath.Join(o.cacheDir, name+".json")
+}
+
+func (o *stepsCmdOptions) cachedSteps(image string) ([]stepDefinition, bool) {
+	if o.cacheDir == "" {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(o.stepsCacheFile(image))
+	if err != nil {
+		return nil, false
+	}
+	steps := make([]stepDefinition, 0)
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, false
+	}
+	return steps, true
+}
+
+func (o *stepsCmdOptions) cacheSteps(image string, steps []stepDefinition) error {
+	if o.cacheDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.cacheDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(o.stepsCacheFile(image), data, 0644)
+}
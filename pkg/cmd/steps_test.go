@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const stepsLogs = `Starting the Java application using /opt/run-java/run-java.sh ...
+STEP	Given	^Yaks does BDD testing on Kubernetes$	
+STEP	When	^send GET request to (.+)$	String
+STEP	Then	^expect (\d+) rows in table "([^"]*)"$	int,String
+STEP	Given	^Yaks does BDD testing on Kubernetes$	
+`
+
+func TestParseStepDefinitions(t *testing.T) {
+	steps, err := parseStepDefinitions(strings.NewReader(stepsLogs))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []stepDefinition{
+		{Keyword: "Given", Expression: "^Yaks does BDD testing on Kubernetes$"},
+		{Keyword: "When", Expression: "^send GET request to (.+)$", Parameters: []string{"String"}},
+		{Keyword: "Then", Expression: `^expect (\d+) rows in table "([^"]*)"$`, Parameters: []string{"int", "String"}},
+	}, steps)
+
+	var out bytes.Buffer
+	assert.Nil(t, printSteps(&out, steps, outputCatalog))
+	assert.Equal(t, "^Yaks does BDD testing on Kubernetes$\n^send GET request to (.+)$\n^expect (\\d+) rows in table \"([^\"]*)\"$\n", out.String())
+}
+
+func TestStepsCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaks-steps")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	options := stepsCmdOptions{cacheDir: dir}
+	_, ok := options.cachedSteps("yaks/yaks:0.0.1")
+	assert.False(t, ok)
+
+	steps := []stepDefinition{{Keyword: "Given", Expression: "^hello$"}}
+	assert.Nil(t, options.cacheSteps("yaks/yaks:0.0.1", steps))
+	cached, ok := options.cachedSteps("yaks/yaks:0.0.1")
+	assert.True(t, ok)
+	assert.Equal(t, steps, cached)
+	_, ok = options.cachedSteps("yaks/yaks:0.0.2")
+	assert.False(t, ok)
+}
+
+func TestRunnerImageOf(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "yaks", Image: "quay.io/yaks/yaks:0.0.1"}}
+
+	image, pullSecret := runnerImageOf(deployment)
+	assert.Equal(t, "quay.io/yaks/yaks:0.0.1", image)
+	assert.Equal(t, "", pullSecret)
+
+	deployment.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "TEST_BASE_IMAGE", Value: "registry.local/yaks-runner:1.0"},
+		{Name: "DEFAULT_IMAGE_PULL_SECRET", Value: "registry"},
+	}
+	image, pullSecret = runnerImageOf(deployment)
+	assert.Equal(t, "registry.local/yaks-runner:1.0", image)
+	assert.Equal(t, "registry", pullSecret)
+}
+
+func TestStepsPod(t *testing.T) {
+	pod := newStepsPod("ns", "yaks/yaks:0.0.1", "registry", time.Minute)
+
+	// Concurrent listings get their own pod
+	assert.Equal(t, "", pod.Name)
+	assert.Equal(t, stepsPodNamePrefix, pod.GenerateName)
+	assert.Equal(t, int64(60), *pod.Spec.ActiveDeadlineSeconds)
+	assert.Equal(t, "registry", pod.Spec.ImagePullSecrets[0].Name)
+}
@@ -19,24 +19,37 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"io/ioutil"
-	"k8s.io/apimachinery/pkg/labels"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
 	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	"github.com/jboss-fuse/yaks/pkg/util/gherkin"
 	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
 	"github.com/pkg/errors"
+	"github.com/rs/xid"
 	"github.com/spf13/cobra"
 	"github.com/wercker/stern/stern"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -47,74 +60,758 @@ func newCmdTest(rootCmdOptions *RootCmdOptions) *cobra.Command {
 
 	cmd := cobra.Command{
 		PersistentPreRunE: options.preRun,
-		Use:               "test [test file to execute]",
+		Use:               "test [test files or directories to execute, <dir>/... for all their subdirectories, - for stdin]",
+		Aliases:           []string{"run"},
 		Short:             "Execute a test on Kubernetes",
 		Long:              `Deploys and execute a pod on Kubernetes for running tests.`,
 		PreRunE:           options.validateArgs,
 		RunE:              options.run,
 	}
 
+	cmd.Flags().StringVarP(&options.output, "output", "o", "", "Output format for the test result. One of: json, junit, testcases")
+	cmd.Flags().IntVar(&options.shards, "shards", 1, "Split the feature files across the given number of tests, running in parallel")
+	cmd.Flags().StringVar(&options.scenario, "scenario", "", "Run only the scenario with the given name")
+	cmd.Flags().Int32Var(&options.line, "line", 0, "Run only the scenario at the given line")
+	cmd.Flags().BoolVar(&options.keepSource, "keep-source", false, "Keep the test created for a feature read from stdin once completed")
+	cmd.Flags().BoolVar(&options.keepHistory, "keep-history", false, "Create a new test for each run, labeled with the test name, instead of replacing the previous run")
+	cmd.Flags().StringVar(&options.debug, "debug", "", "Keep the runner alive with a shell once the tests have run. One of: OnFailure (when given without value), Always")
+	cmd.Flags().Lookup("debug").NoOptDefVal = string(v1alpha1.DebugModeOnFailure)
+	cmd.Flags().DurationVar(&options.debugTimeout, "debug-timeout", 30*time.Minute, "How long the runner is kept alive with --debug")
+	cmd.Flags().BoolVar(&options.progress, "progress", false, "Print one line per completed test instead of streaming the logs, the logs of the failed tests being printed then")
+	cmd.Flags().StringVar(&options.logsDir, "logs-dir", "", "Write the logs of each test to <test>.log files of the given directory, once completed")
+	cmd.Flags().BoolVar(&options.savePodManifest, "save-pod-manifest", false, "Keep the manifest of the runner pod in a ConfigMap named in the test status")
+	cmd.Flags().StringVar(&options.rerunFailed, "rerun-failed", "", "Run again the failed and errored tests of the given JSON report, instead of test files")
+	cmd.Flags().BoolVar(&options.lint, "lint", false, "Check the Gherkin syntax of the feature files, refusing to create the tests of unparseable files")
+	cmd.Flags().StringVar(&options.steps, "steps", "", "Step catalog file used by --lint to report unknown steps")
+	cmd.Flags().BoolVar(&options.failFast, "fail-fast", false, "Cancel the remaining tests as soon as a test fails or errors")
+	cmd.Flags().StringVar(&options.instance, "instance", "", "Instance of the namespace whose config holds the defaults of the tests, e.g. their env")
+	cmd.Flags().StringVar(&options.operatorSelector, "operator-selector", "", "Label selector restricting the operators running the tests, e.g. channel=canary")
+	options.caseIDFlags.addFlags(&cmd)
+
 	return &cmd
 }
 
+const (
+	outputJSON  = "json"
+	outputJUnit = "junit"
+	// outputTestCases is a JUnit report with one test case per scenario, carrying the case IDs of a test management
+	// system read from the scenario tags
+	outputTestCases = "testcases"
+)
+
 type testCmdOptions struct {
 	*RootCmdOptions
+	output           string
+	shards           int
+	scenario         string
+	line             int32
+	keepSource       bool
+	keepHistory      bool
+	debug            string
+	debugTimeout     time.Duration
+	savePodManifest  bool
+	progress         bool
+	logsDir          string
+	rerunFailed      string
+	lint             bool
+	steps            string
+	failFast         bool
+	instance         string
+	operatorSelector string
+	caseIDFlags
+	// failedFast is the failed test the others have been cancelled after, with --fail-fast
+	failedFast *v1alpha1.Test
+	// out and errOut are the standard and error outputs of the command
+	out    io.Writer
+	errOut io.Writer
 }
 
+// stdinArg is the argument reading the feature from the standard input
+const stdinArg = "-"
+
+// recursiveSuffix selects the feature files of a directory and of all its subdirectories, grouped by directory
+const recursiveSuffix = "/..."
+
 func (o *testCmdOptions) validateArgs(_ *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return errors.New(fmt.Sprintf("accepts exactly 1 arg, received %d", len(args)))
+	if o.rerunFailed != "" {
+		if len(args) > 0 || o.shards != 1 || o.scenario != "" || o.line != 0 {
+			return errors.New("--rerun-failed runs the tests of the report as they have been run, without test files, shards or scenario")
+		}
+	} else if len(args) == 0 {
+		return errors.New("accepts at least 1 arg, received 0")
+	}
+	if o.steps != "" && !o.lint {
+		return errors.New("--steps only applies with --lint")
+	}
+	if o.output != "" && o.output != outputJSON && o.output != outputJUnit && o.output != outputTestCases {
+		return errors.New(fmt.Sprintf("unsupported output format %q", o.output))
+	}
+	if _, err := o.caseIDOptions(); err != nil {
+		return err
+	}
+	if _, err := metav1.ParseToLabelSelector(o.operatorSelector); err != nil {
+		return errors.Wrap(err, "invalid --operator-selector")
+	}
+	stdin := 0
+	for _, arg := range args {
+		if arg == stdinArg {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return errors.New("the standard input can only be read once")
+	}
+	if o.keepSource && stdin == 0 {
+		return errors.New("--keep-source only applies to a feature read from the standard input")
+	}
+	if o.debug != "" && o.debug != string(v1alpha1.DebugModeOnFailure) && o.debug != string(v1alpha1.DebugModeAlways) {
+		return errors.New(fmt.Sprintf("unsupported debug mode %q", o.debug))
+	}
+	if o.progress && o.debug != "" {
+		return errors.New("--progress cannot be used with --debug, that prints how to attach to the runner in its logs")
+	}
+	if o.shards < 1 {
+		return errors.New(fmt.Sprintf("invalid number of shards %d, must be at least 1", o.shards))
+	}
+	if o.scenario != "" || o.line != 0 {
+		if len(args) != 1 || o.shards != 1 {
+			return errors.New("a scenario can only be selected when running a single feature file")
+		}
+		if o.line < 0 {
+			return errors.New(fmt.Sprintf("invalid line %d", o.line))
+		}
 	}
 
 	return nil
 }
 
-func (o *testCmdOptions) run(_ *cobra.Command, args []string) error {
+// messages returns the writer for informational messages, that must not mix with machine-readable output
+func (o *testCmdOptions) messages() io.Writer {
+	if o.output != "" {
+		return o.errOut
+	}
+	return o.out
+}
+
+func (o *testCmdOptions) run(cmd *cobra.Command, args []string) error {
+	// Arguments are valid, a failing test must not print the usage
+	cmd.SilenceUsage = true
+	o.out = cmd.OutOrStdout()
+	o.errOut = cmd.ErrOrStderr()
+
 	c, err := o.GetCmdClient()
 	if err != nil {
 		return err
 	}
 
-	_, err = o.createTest(c, args)
-	return err
+	o.warnOnVersionMismatch(c)
+
+	results, err := o.runTests(c, args)
+	if err != nil {
+		return err
+	}
+	if o.failedFast != nil {
+		return errors.New(fmt.Sprintf("test %s %s, the remaining tests have been cancelled", o.failedFast.Name, strings.ToLower(string(o.failedFast.Status.Phase))))
+	}
+	// Skipped tests do not fail the command
+	for _, result := range results {
+		if hasFailed(result) {
+			return errors.New(fmt.Sprintf("test %s %s", result.Name, strings.ToLower(string(result.Status.Phase))))
+		}
+	}
+	return nil
+}
+
+// hasFailed tells whether the test has failed or could not be run
+func hasFailed(test *v1alpha1.Test) bool {
+	return test.Status.Phase == v1alpha1.TestPhaseFailed || test.Status.Phase == v1alpha1.TestPhaseError
+}
+
+// cancelAfterFailure cancels the test that has not completed when another one has failed with --fail-fast, deleting it
+// when it has not been initialized yet, and returns the test as reported, in the Error phase unless it has completed
+func (o *testCmdOptions) cancelAfterFailure(c client.Client, test *v1alpha1.Test) (*v1alpha1.Test, error) {
+	key, err := k8sclient.ObjectKeyFromObject(test)
+	if err != nil {
+		return nil, err
+	}
+	latest := v1alpha1.Test{}
+	if err := c.Get(o.Context, key, &latest); err != nil {
+		return nil, err
+	}
+	switch latest.Status.Phase {
+	case v1alpha1.TestPhasePending, v1alpha1.TestPhaseRunning:
+		if err := cancelTest(o.Context, c, &latest); err != nil {
+			return nil, err
+		}
+	case v1alpha1.IntegrationTestPhaseNone:
+		if err := c.Delete(o.Context, &latest); err != nil && !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+	default:
+		return &latest, nil
+	}
+	fmt.Fprintf(o.messages(), "test \"%s\" cancelled\n", latest.Name)
+	latest.Status.Phase = v1alpha1.TestPhaseError
+	latest.Status.Reason = v1alpha1.TestReasonCancelled
+	latest.Status.Message = fmt.Sprintf("Cancelled after test %s %s", o.failedFast.Name, strings.ToLower(string(o.failedFast.Status.Phase)))
+	return &latest, nil
+}
+
+// runTests creates the tests for the given sources, or the ones of the report to run again, and waits for their results
+func (o *testCmdOptions) runTests(c client.Client, args []string) ([]*v1alpha1.Test, error) {
+	var tests []*v1alpha1.Test
+	var err error
+	if o.rerunFailed != "" {
+		tests, err = o.createFailedTests(c)
+	} else {
+		tests, err = o.createTests(c, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tests) == 0 {
+		fmt.Fprintln(o.messages(), "No test to run")
+		return nil, nil
+	}
+	// Tests being debugged are kept, their pod is removed together with them
+	if readsStdin(args) && !o.keepSource && o.debug == "" {
+		defer o.deleteTests(c, tests)
+	}
+
+	waitTimeout := 10 * time.Minute
+	if o.debug != "" {
+		waitTimeout += o.debugTimeout
+	}
+
+	if o.logsDir != "" {
+		if err := os.MkdirAll(o.logsDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	results := make([]*v1alpha1.Test, len(tests))
+	durations := make([]time.Duration, len(tests))
+	waitErrs := make([]error, len(tests))
+
+	ctx, cancel := context.WithCancel(o.Context)
+	// The wait of the other tests stops on the first failure with --fail-fast
+	waitCtx, stopWaiting := context.WithCancel(o.Context)
+	defer stopWaiting()
+	var failFast sync.Once
+	var wg sync.WaitGroup
+	// Serializes the progress of the tests completing at the same time
+	var progress sync.Mutex
+	for i := range tests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if results[i] != nil && (o.progress || o.output != "" || o.logsDir != "") {
+					progress.Lock()
+					defer progress.Unlock()
+					o.reportCompletion(c, results[i], durations[i])
+				}
+			}()
+			results[i], waitErrs[i] = waitForTestPhase(waitCtx, c, tests[i], testEndPhases, nil, waitTimeout)
+			durations[i] = time.Since(start)
+			if o.failFast && results[i] != nil && hasFailed(results[i]) {
+				failFast.Do(func() {
+					o.failedFast = results[i]
+					stopWaiting()
+				})
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	names := make([]string, 0, len(tests))
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	// The logs are streamed to the standard output, that is kept clean for the machine-readable result: the logs of
+	// each test are then written to the error output once it has completed
+	if !o.progress && o.output == "" {
+		if err := o.printLogs(ctx, names); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	wg.Wait()
+	cancel()
+
+	if o.failedFast != nil {
+		for i, test := range tests {
+			if results[i] == nil {
+				results[i], waitErrs[i] = o.cancelAfterFailure(c, test)
+			}
+		}
+	}
+
+	summary := report.NewSummary()
+	for i, test := range tests {
+		if waitErrs[i] != nil {
+			return nil, waitErrs[i]
+		}
+		if results[i] == nil {
+			return nil, errors.New("no result received for test " + test.Name)
+		}
+		summary.Add(report.NewTestResult(results[i], durations[i]))
+	}
+
+	switch o.output {
+	case outputJSON:
+		return results, summary.PrintJSON(o.out)
+	case outputJUnit:
+		return results, summary.PrintJUnit(o.out)
+	case outputTestCases:
+		options, _ := o.caseIDOptions()
+		return results, summary.PrintTestCases(o.out, options)
+	}
+
+	if o.progress {
+		fmt.Fprintf(o.out, "Total: %d, passed: %d, failed: %d, errors: %d, skipped: %d\n",
+			summary.Total, summary.Passed, summary.Failed, summary.Errors, summary.Skipped)
+		return results, nil
+	}
+	for i, result := range results {
+		prefix := "Test result"
+		if len(results) > 1 {
+			prefix = fmt.Sprintf("Test %s result (%s)", result.Name, summary.Tests[i].Duration)
+		}
+		phase := string(result.Status.Phase)
+		if result.Status.Reason != "" {
+			phase += " (" + string(result.Status.Reason) + ")"
+		}
+		if result.Status.ExitCode != nil {
+			fmt.Fprintf(o.out, "%s: %s (exit code %d)\n", prefix, phase, *result.Status.ExitCode)
+		} else {
+			fmt.Fprintf(o.out, "%s: %s\n", prefix, phase)
+		}
+		if result.Status.Message != "" {
+			fmt.Fprintln(o.out, result.Status.Message)
+		}
+		if result.Status.Phase != v1alpha1.TestPhasePassed && result.Status.TraceID != "" {
+			fmt.Fprintf(o.out, "Trace ID: %s\n", result.Status.TraceID)
+		}
+	}
+	return results, nil
+}
+
+// collectSources returns the test sources for the given files, URLs or directories containing feature files, together
+// with the group of each source. Only the sources found in the subdirectories of a <dir>/... argument have a group,
+// derived from their directory
+func (o *testCmdOptions) collectSources(args []string) ([]v1alpha1.SourceSpec, []string, error) {
+	files, groups, err := collectFiles(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	var catalog gherkin.StepCatalog
+	if o.steps != "" {
+		if catalog, err = gherkin.LoadStepCatalog(o.steps); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sources := make([]v1alpha1.SourceSpec, 0, len(files))
+	for _, file := range files {
+		data, err := o.loadData(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if o.lint {
+			if err := lintFeature(o.errOut, file, data, catalog); err != nil {
+				return nil, nil, err
+			}
+		}
+		fileName := kubernetes.SanitizeFileName(file)
+		if file == stdinArg {
+			fileName = "stdin." + string(v1alpha1.LanguageGherkin)
+		}
+		sources = append(sources, v1alpha1.SourceSpec{
+			Name:     fileName,
+			Content:  data,
+			Language: v1alpha1.LanguageGherkin,
+		})
+	}
+	return sources, groups, nil
+}
+
+// collectFiles returns the feature files of the arguments, and the group of each file
+func collectFiles(args []string) ([]string, []string, error) {
+	files := make([]string, 0, len(args))
+	groups := make([]string, 0, len(args))
+	for _, arg := range args {
+		if strings.HasSuffix(arg, recursiveSuffix) {
+			matches, err := walkFeatures(strings.TrimSuffix(arg, recursiveSuffix))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, match := range matches {
+				files = append(files, match)
+				groups = append(groups, groupLabelValue(filepath.Dir(match)))
+			}
+		} else if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			matches, err := filepath.Glob(filepath.Join(arg, "*."+string(v1alpha1.LanguageGherkin)))
+			if err != nil {
+				return nil, nil, err
+			}
+			sort.Strings(matches)
+			for _, match := range matches {
+				files = append(files, match)
+				groups = append(groups, "")
+			}
+		} else {
+			files = append(files, arg)
+			groups = append(groups, "")
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, errors.New("no test file found")
+	}
+	return files, groups, nil
+}
+
+// walkFeatures returns the feature files of the directory and of all its subdirectories, in lexical order
+func walkFeatures(dir string) ([]string, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, errors.New(fmt.Sprintf("%s is not a directory", dir))
+	}
+	files := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == "."+string(v1alpha1.LanguageGherkin) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// generatedNameSuffixLength is the length of the random suffix appended by the cluster to a generated name, with
+// its dash
+const generatedNameSuffixLength = 6
+
+var disallowedLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9-_.]`)
+
+// groupLabelValue turns the directory, relative to the working directory, into a valid label value, e.g.
+// tests/kafka/consumer becomes tests.kafka.consumer. The end of the path is kept when it is too long, and a hash of
+// the directory is appended when it cannot be told from the value so that two directories never share a group
+func groupLabelValue(dir string) string {
+	if wd, err := os.Getwd(); err == nil && filepath.IsAbs(dir) {
+		if rel, err := filepath.Rel(wd, dir); err == nil {
+			dir = rel
+		}
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "." {
+		return ""
+	}
+	value := disallowedLabelValueChars.ReplaceAllString(strings.Replace(dir, "/", ".", -1), "-")
+	value = strings.TrimFunc(value, isNotAlphanumeric)
+	// Dots in the directory would be mixed up with the separators
+	if value == strings.Replace(dir, "/", ".", -1) && !strings.Contains(dir, ".") && len(value) <= validation.LabelValueMaxLength {
+		return value
+	}
+	hash := kubernetes.ShortHash(dir)
+	if max := validation.LabelValueMaxLength - len(hash) - 1; len(value) > max {
+		value = strings.TrimFunc(value[len(value)-max:], isNotAlphanumeric)
+	}
+	if value == "" {
+		return hash
+	}
+	return value + "-" + hash
+}
+
+func isNotAlphanumeric(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
 }
 
-func (o *testCmdOptions) createTest(c client.Client, sources []string) (*v1alpha1.Test, error) {
-	namespace := o.Namespace
+// groupSources partitions the sources by group, returning the groups in the order they are first seen
+func groupSources(sources []v1alpha1.SourceSpec, groups []string) (map[string][]v1alpha1.SourceSpec, []string) {
+	grouped := make(map[string][]v1alpha1.SourceSpec)
+	order := make([]string, 0)
+	for i, source := range sources {
+		if _, ok := grouped[groups[i]]; !ok {
+			order = append(order, groups[i])
+		}
+		grouped[groups[i]] = append(grouped[groups[i]], source)
+	}
+	return grouped, order
+}
+
+func readsStdin(args []string) bool {
+	for _, arg := range args {
+		if arg == stdinArg {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteTests removes the given tests, together with the resources they own
+func (o *testCmdOptions) deleteTests(c client.Client, tests []*v1alpha1.Test) {
+	for _, test := range tests {
+		if err := c.Delete(o.Context, test); err != nil && !k8serrors.IsNotFound(err) {
+			fmt.Fprintf(o.errOut, "cannot delete test \"%s\": %v\n", test.Name, err)
+			continue
+		}
+		fmt.Fprintf(o.messages(), "test \"%s\" deleted\n", test.Name)
+	}
+}
 
-	rawName := sources[0]
-	fileName := kubernetes.SanitizeFileName(rawName)
-	name := kubernetes.SanitizeName(rawName)
+var scenarioDeclaration = regexp.MustCompile(`^\s*Scenario( Outline)?:\s*(.*?)\s*$`)
 
+// checkSourceFilter verifies that the filter selects a scenario of the source
+func checkSourceFilter(source v1alpha1.SourceSpec, filter v1alpha1.SourceFilter) error {
+	lines := strings.Split(source.Content, "\n")
+	if filter.Line > int32(len(lines)) {
+		return errors.New(fmt.Sprintf("%s has only %d lines", source.Name, len(lines)))
+	}
+	if filter.Scenario == "" {
+		return nil
+	}
+	for _, line := range lines {
+		if match := scenarioDeclaration.FindStringSubmatch(line); match != nil && match[2] == filter.Scenario {
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("no scenario named %q found in %s", filter.Scenario, source.Name))
+}
+
+// shardSources partitions the sources into the given number of shards, deterministically by file name so that
+// a source always lands on the same shard
+func shardSources(sources []v1alpha1.SourceSpec, shards int) [][]v1alpha1.SourceSpec {
+	partitions := make([][]v1alpha1.SourceSpec, shards)
+	for _, source := range sources {
+		hash := fnv.New32a()
+		// Writing to a hash never fails
+		_, _ = hash.Write([]byte(source.Name))
+		shard := int(hash.Sum32() % uint32(shards))
+		partitions[shard] = append(partitions[shard], source)
+	}
+	return partitions
+}
+
+// createTests creates the tests for the given sources, one per group and shard
+func (o *testCmdOptions) createTests(c client.Client, args []string) ([]*v1alpha1.Test, error) {
+	name := kubernetes.SanitizeName(strings.TrimSuffix(args[0], recursiveSuffix))
+	if args[0] == stdinArg {
+		name = "stdin-" + xid.New().String()
+	}
 	if name == "" {
 		return nil, errors.New("unable to determine test name")
 	}
 
-	data, err := o.loadData(sources[0])
+	sources, groups, err := o.collectSources(args)
+	if err != nil {
+		return nil, err
+	}
+	if o.scenario != "" || o.line != 0 {
+		filter := v1alpha1.SourceFilter{
+			Scenario: o.scenario,
+			Line:     o.line,
+		}
+		if err := checkSourceFilter(sources[0], filter); err != nil {
+			return nil, err
+		}
+		sources[0].Filter = &filter
+	}
+
+	grouped, order := groupSources(sources, groups)
+	tests := make([]*v1alpha1.Test, 0, o.shards*len(order))
+	for _, group := range order {
+		groupName := name
+		if len(order) > 1 {
+			groupName = fmt.Sprintf("%s-%s", name, groupNameSuffix(group))
+		}
+		for i, shard := range shardSources(grouped[group], o.shards) {
+			if len(shard) == 0 {
+				continue
+			}
+			testName := groupName
+			if o.shards > 1 {
+				testName = fmt.Sprintf("%s-shard-%d", groupName, i)
+			}
+			test, err := o.createTest(c, kubernetes.TruncateName(testName, maxTestNameLength(o.keepHistory)), group, shard)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, test)
+		}
+	}
+	return tests, nil
+}
+
+// groupNameSuffix returns the part of the test name telling the group, with a hash of the group when sanitizing it
+// loses characters so that two groups never get the same name
+func groupNameSuffix(group string) string {
+	suffix := kubernetes.SanitizeLabel(strings.Replace(group, ".", "-", -1))
+	if suffix == group {
+		return suffix
+	}
+	return suffix + "-" + kubernetes.ShortHash(group)
+}
+
+// maxTestNameLength returns the maximum length of a test name, that is also set as a label value on its resources,
+// leaving room for the suffix generated by the cluster with --keep-history
+func maxTestNameLength(keepHistory bool) int {
+	if keepHistory {
+		return validation.LabelValueMaxLength - generatedNameSuffixLength
+	}
+	return validation.LabelValueMaxLength
+}
+
+// createFailedTests creates again the tests of the report that have failed or errored
+func (o *testCmdOptions) createFailedTests(c client.Client) ([]*v1alpha1.Test, error) {
+	data, err := ioutil.ReadFile(o.rerunFailed)
 	if err != nil {
 		return nil, err
 	}
+	summary := report.Summary{}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, errors.Wrap(err, "cannot read report "+o.rerunFailed)
+	}
+
+	failed, err := failedTestsOf(&summary, o.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	tests := make([]*v1alpha1.Test, 0, len(failed))
+	for _, test := range failed {
+		o.applyRuntimeOptions(test)
+		o.applyHistoryOptions(test)
+		created, err := o.applyTest(c, test)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, created)
+	}
+	return tests, nil
+}
+
+// failedTestsOf returns the tests to create in the namespace to run again the failed and errored tests of the report
+func failedTestsOf(summary *report.Summary, namespace string) ([]*v1alpha1.Test, error) {
+	tests := make([]*v1alpha1.Test, 0)
+	for _, result := range summary.Tests {
+		if result.Phase != v1alpha1.TestPhaseFailed && result.Phase != v1alpha1.TestPhaseError {
+			continue
+		}
+		if result.Source == nil {
+			return nil, errors.New(fmt.Sprintf("the report has no source for test %s, it must be written by a newer version of yaks", result.Name))
+		}
+		tests = append(tests, &v1alpha1.Test{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       v1alpha1.TestKind,
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      result.Name,
+				Labels:    result.Source.Labels,
+			},
+			Spec: *result.Source.Spec.DeepCopy(),
+		})
+	}
+	return tests, nil
+}
 
+func (o *testCmdOptions) createTest(c client.Client, name string, group string, sources []v1alpha1.SourceSpec) (*v1alpha1.Test, error) {
 	test := v1alpha1.Test{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       v1alpha1.TestKind,
 			APIVersion: v1alpha1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
+			Namespace: o.Namespace,
 			Name:      name,
 		},
 		Spec: v1alpha1.TestSpec{
-			Source: v1alpha1.SourceSpec{
-				Name:     fileName,
-				Content:  data,
-				Language: v1alpha1.LanguageGherkin,
-			},
+			Source:  sources[0],
+			Sources: sources[1:],
 		},
 	}
+	o.applyRuntimeOptions(&test)
+	if group != "" {
+		test.Labels = map[string]string{
+			v1alpha1.TestGroupLabel: group,
+		}
+	}
+	o.applyHistoryOptions(&test)
+	return o.applyTest(c, &test)
+}
+
+// applyHistoryOptions names the test uniquely with --keep-history, labeling it with its logical name so that the
+// operator keeps the history of its runs
+func (o *testCmdOptions) applyHistoryOptions(test *v1alpha1.Test) {
+	if !o.keepHistory {
+		return
+	}
+	name := test.Name
+	if logical, ok := test.Labels[v1alpha1.TestNameLabel]; ok {
+		// A run of the history being run again
+		name = logical
+	}
+	if test.Labels == nil {
+		test.Labels = make(map[string]string)
+	}
+	test.Labels[v1alpha1.TestNameLabel] = name
+	test.GenerateName = kubernetes.TruncateName(name, maxTestNameLength(true)) + "-"
+	test.Name = ""
+}
 
+// applyRuntimeOptions sets the runtime settings given on the command line, and the default env of the project config,
+// to the test
+func (o *testCmdOptions) applyRuntimeOptions(test *v1alpha1.Test) {
+	if o.debug != "" {
+		test.Spec.Runtime.Debug = &v1alpha1.DebugSpec{
+			Mode:    v1alpha1.DebugMode(o.debug),
+			Timeout: o.debugTimeout.String(),
+		}
+	}
+	if o.savePodManifest {
+		test.Spec.Runtime.SavePodManifest = true
+	}
+	if o.instance != "" {
+		test.Spec.Instance = o.instance
+	}
+	if o.operatorSelector != "" {
+		// Validated with the arguments
+		test.Spec.OperatorSelector, _ = metav1.ParseToLabelSelector(o.operatorSelector)
+	}
+	names := make([]string, 0, len(o.projectConfig.Env))
+	for name := range o.projectConfig.Env {
+		if !hasEnvVar(test.Spec.Runtime.Env, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		test.Spec.Runtime.Env = append(test.Spec.Runtime.Env, corev1.EnvVar{Name: name, Value: o.projectConfig.Env[name]})
+	}
+}
+
+// hasEnvVar tells whether the env holds a variable of the given name
+func hasEnvVar(env []corev1.EnvVar, name string) bool {
+	for _, variable := range env {
+		if variable.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTest creates the test, or replaces it and resets its status so that it is run again
+func (o *testCmdOptions) applyTest(c client.Client, test *v1alpha1.Test) (*v1alpha1.Test, error) {
 	existed := false
-	err = c.Create(o.Context, &test)
+	err := c.Create(o.Context, test)
 	if err != nil && k8serrors.IsAlreadyExists(err) {
 		existed = true
 		clone := test.DeepCopy()
@@ -128,13 +825,13 @@ func (o *testCmdOptions) createTest(c client.Client, sources []string) (*v1alpha
 			return nil, err
 		}
 		test.ResourceVersion = clone.ResourceVersion
-		err = c.Update(o.Context, &test)
+		err = c.Update(o.Context, test)
 		if err != nil {
 			return nil, err
 		}
 		// Reset status as well
 		test.Status = v1alpha1.TestStatus{}
-		err = c.Status().Update(o.Context, &test)
+		err = c.Status().Update(o.Context, test)
 	}
 
 	if err != nil {
@@ -142,39 +839,19 @@ func (o *testCmdOptions) createTest(c client.Client, sources []string) (*v1alpha
 	}
 
 	if !existed {
-		fmt.Printf("test \"%s\" created\n", name)
+		fmt.Fprintf(o.messages(), "test \"%s\" created\n", test.Name)
 	} else {
-		fmt.Printf("test \"%s\" updated\n", name)
+		fmt.Fprintf(o.messages(), "test \"%s\" updated\n", test.Name)
 	}
+	return test, nil
+}
 
-	ctx, cancel := context.WithCancel(o.Context)
-	go func() {
-		status := "Unknown"
-		err = kubernetes.WaitCondition(o.Context, c, &test, func(obj interface{}) (bool, error) {
-			if val, ok := obj.(*v1alpha1.Test); ok {
-				if val.Status.Phase == v1alpha1.TestPhaseDeleting ||
-					val.Status.Phase == v1alpha1.TestPhaseError ||
-					val.Status.Phase == v1alpha1.TestPhasePassed ||
-					val.Status.Phase == v1alpha1.TestPhaseFailed {
-					status = string(val.Status.Phase)
-					return true, nil
-				}
-			}
-			return false, nil
-		}, 10*time.Minute)
-
-		cancel()
-		fmt.Printf("Test result: %s\n", status)
-	}()
-
-	if err := o.printLogs(ctx, name); err != nil {
-		return nil, err
+func (o *testCmdOptions) printLogs(ctx context.Context, names []string) error {
+	selector, err := labels.NewRequirement("yaks.dev/test", selection.In, names)
+	if err != nil {
+		return err
 	}
 
-	return &test, nil
-}
-
-func (o *testCmdOptions) printLogs(ctx context.Context, name string) error {
 	t := "{{color .PodColor .PodName}} {{color .ContainerColor .ContainerName}} {{.Message}}"
 	funs := map[string]interface{}{
 		"color": func(color color.Color, text string) string {
@@ -188,15 +865,16 @@ func (o *testCmdOptions) printLogs(ctx context.Context, name string) error {
 
 	//tail := int64(100)
 	conf := stern.Config{
-		Namespace:  o.Namespace,
-		PodQuery:   regexp.MustCompile(".*"),
-		KubeConfig: client.GetValidKubeConfig(o.KubeConfig),
+		Namespace:   o.Namespace,
+		PodQuery:    regexp.MustCompile(".*"),
+		KubeConfig:  client.GetValidKubeConfig(o.KubeConfig),
+		ContextName: o.KubeContext,
 		//TailLines: &tail,
 		ContainerQuery: regexp.MustCompile(".*"),
-		LabelSelector:  labels.SelectorFromSet(labels.Set{"yaks.dev/test": name}),
+		LabelSelector:  labels.NewSelector().Add(*selector),
 		//LabelSelector: labels.SelectorFromSet(labels.Set{"name": "yaks"}),
 		ContainerState: stern.ContainerState(stern.RUNNING),
-		Since:          172800000000000,
+		Since:          runnerLogsSince,
 		Template:       templ,
 	}
 	if err := stern.Run(ctx, &conf); err != nil {
@@ -205,11 +883,84 @@ func (o *testCmdOptions) printLogs(ctx context.Context, name string) error {
 	return nil
 }
 
+// progressStatus is the status of a completed test in the progress output
+var progressStatus = map[v1alpha1.TestPhase]string{
+	v1alpha1.TestPhasePassed:  "PASS",
+	v1alpha1.TestPhaseFailed:  "FAIL",
+	v1alpha1.TestPhaseError:   "ERROR",
+	v1alpha1.TestPhaseSkipped: "SKIP",
+}
+
+// reportCompletion prints the progress line of the completed test, followed by its logs when it has not passed, or
+// only its logs with a machine-readable output, and writes its logs to the logs directory
+func (o *testCmdOptions) reportCompletion(c client.Client, test *v1alpha1.Test, duration time.Duration) {
+	if o.progress {
+		status, ok := progressStatus[test.Status.Phase]
+		if !ok {
+			status = strings.ToUpper(string(test.Status.Phase))
+		}
+		fmt.Fprintf(o.messages(), "--- %s: %s (%s)\n", status, test.Name, duration.Round(time.Millisecond))
+		if test.Status.Phase == v1alpha1.TestPhaseFailed || test.Status.Phase == v1alpha1.TestPhaseError {
+			if err := o.writeLogs(c, test.Name, o.messages()); err != nil {
+				fmt.Fprintf(o.errOut, "cannot get the logs of test \"%s\": %v\n", test.Name, err)
+			}
+		}
+	} else if o.output != "" {
+		if err := o.writeLogs(c, test.Name, o.messages()); err != nil {
+			fmt.Fprintf(o.errOut, "cannot get the logs of test \"%s\": %v\n", test.Name, err)
+		}
+	}
+	if o.logsDir != "" {
+		if err := o.saveLogs(c, test.Name); err != nil {
+			fmt.Fprintf(o.errOut, "cannot save the logs of test \"%s\": %v\n", test.Name, err)
+		}
+	}
+}
+
+func (o *testCmdOptions) saveLogs(c client.Client, name string) error {
+	file, err := os.Create(filepath.Join(o.logsDir, name+".log"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return o.writeLogs(c, name, file)
+}
+
+// writeLogs writes the logs of all the containers of the pods of the test
+func (o *testCmdOptions) writeLogs(c client.Client, name string, w io.Writer) error {
+	pods, err := c.CoreV1().Pods(o.Namespace).List(metav1.ListOptions{
+		LabelSelector: "yaks.dev/test=" + name,
+	})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			fmt.Fprintf(w, "==> %s %s <==\n", pod.Name, container.Name)
+			stream, err := c.CoreV1().Pods(o.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container.Name}).Stream()
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, stream)
+			stream.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (*testCmdOptions) loadData(fileName string) (string, error) {
 	var content []byte
 	var err error
 
-	if !strings.HasPrefix(fileName, "http://") && !strings.HasPrefix(fileName, "https://") {
+	if fileName == stdinArg {
+		content, err = ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+	} else if !strings.HasPrefix(fileName, "http://") && !strings.HasPrefix(fileName, "https://") {
 		content, err = ioutil.ReadFile(fileName)
 		if err != nil {
 			return "", err
@@ -0,0 +1,156 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestGroupLabelValue(t *testing.T) {
+	assert.Equal(t, "tests.kafka.consumer", groupLabelValue("tests/kafka/consumer"))
+	assert.Equal(t, "tests.kafka", groupLabelValue("./tests/kafka/"))
+	assert.Equal(t, "", groupLabelValue("."))
+	assert.Regexp(t, `^tests\.my-dir\.v1_2-[0-9a-f]{8}$`, groupLabelValue("tests/my dir/v1_2"))
+	assert.Regexp(t, `^shared-[0-9a-f]{8}$`, groupLabelValue("../shared"))
+
+	long := groupLabelValue("tests/" + strings.Repeat("a", 70) + "/end")
+	assert.True(t, len(long) <= 63)
+	assert.Regexp(t, `\.end-[0-9a-f]{8}$`, long)
+	assert.False(t, strings.HasPrefix(long, "."))
+	assert.NotEqual(t, long, groupLabelValue("other/"+strings.Repeat("a", 70)+"/end"))
+}
+
+func TestCollidingGroupsStayDistinct(t *testing.T) {
+	dirs := []string{"tests/a-b", "tests/a b", "tests/a_b", "tests/a.b", "tests/a/b", "../tests/a/b", "tests.a/b"}
+	groups := make(map[string]string)
+	names := make(map[string]string)
+	for _, dir := range dirs {
+		group := groupLabelValue(dir)
+		assert.Empty(t, validation.IsValidLabelValue(group), dir)
+		assert.NotContains(t, groups, group, dir)
+		groups[group] = dir
+
+		name := kubernetes.TruncateName("hello-"+groupNameSuffix(group), maxTestNameLength(false))
+		assert.Empty(t, validation.IsDNS1123Label(name), dir)
+		assert.NotContains(t, names, name, dir)
+		names[name] = dir
+	}
+}
+
+func TestTruncatedNamesStayDistinct(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	first := kubernetes.TruncateName(long+"-first", maxTestNameLength(false))
+	second := kubernetes.TruncateName(long+"-second", maxTestNameLength(false))
+	assert.Len(t, first, 63)
+	assert.Empty(t, validation.IsDNS1123Label(first))
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, "hello", kubernetes.TruncateName("hello", maxTestNameLength(false)))
+
+	options := testCmdOptions{keepHistory: true}
+	test := &v1alpha1.Test{ObjectMeta: metav1.ObjectMeta{Name: first}}
+	options.applyHistoryOptions(test)
+	assert.True(t, len(test.GenerateName)+5 <= 63)
+	assert.Equal(t, first, test.Labels[v1alpha1.TestNameLabel])
+}
+
+func TestGroupSources(t *testing.T) {
+	sources := []v1alpha1.SourceSpec{{Name: "a.feature"}, {Name: "b.feature"}, {Name: "c.feature"}}
+	grouped, order := groupSources(sources, []string{"tests.b", "tests.a", "tests.b"})
+
+	assert.Equal(t, []string{"tests.b", "tests.a"}, order)
+	assert.Equal(t, []v1alpha1.SourceSpec{{Name: "a.feature"}, {Name: "c.feature"}}, grouped["tests.b"])
+	assert.Equal(t, []v1alpha1.SourceSpec{{Name: "b.feature"}}, grouped["tests.a"])
+}
+
+func TestFailedTestsOf(t *testing.T) {
+	newTest := func(name string, phase v1alpha1.TestPhase) *v1alpha1.Test {
+		return &v1alpha1.Test{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ci",
+				Name:      name,
+				Labels:    map[string]string{v1alpha1.TestGroupLabel: "kafka"},
+			},
+			Spec: v1alpha1.TestSpec{
+				Source: v1alpha1.SourceSpec{
+					Name:    name + ".feature",
+					Content: "Feature: " + name,
+				},
+			},
+			Status: v1alpha1.TestStatus{
+				Phase: phase,
+			},
+		}
+	}
+	summary := report.NewSummary(
+		report.NewTestResult(newTest("passed", v1alpha1.TestPhasePassed), 0),
+		report.NewTestResult(newTest("failed", v1alpha1.TestPhaseFailed), 0),
+		report.NewTestResult(newTest("error", v1alpha1.TestPhaseError), 0),
+	)
+	var buffer bytes.Buffer
+	assert.Nil(t, summary.PrintJSON(&buffer))
+	read := report.Summary{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &read))
+
+	tests, err := failedTestsOf(&read, "retry")
+
+	assert.Nil(t, err)
+	assert.Len(t, tests, 2)
+	assert.Equal(t, "failed", tests[0].Name)
+	assert.Equal(t, "retry", tests[0].Namespace)
+	assert.Equal(t, "kafka", tests[0].Labels[v1alpha1.TestGroupLabel])
+	assert.Equal(t, "Feature: failed", tests[0].Spec.Source.Content)
+	assert.Equal(t, "error", tests[1].Name)
+
+	read.Tests[1].Source = nil
+	_, err = failedTestsOf(&read, "retry")
+	assert.NotNil(t, err)
+}
+
+func TestApplyHistoryOptions(t *testing.T) {
+	options := testCmdOptions{}
+	test := &v1alpha1.Test{ObjectMeta: metav1.ObjectMeta{Name: "hello"}}
+	options.applyHistoryOptions(test)
+	assert.Equal(t, "hello", test.Name)
+	assert.Empty(t, test.Labels)
+
+	options.keepHistory = true
+	options.applyHistoryOptions(test)
+	assert.Equal(t, "", test.Name)
+	assert.Equal(t, "hello-", test.GenerateName)
+	assert.Equal(t, "hello", test.Labels[v1alpha1.TestNameLabel])
+
+	// A run of the history run again is added to the same history
+	rerun := &v1alpha1.Test{ObjectMeta: metav1.ObjectMeta{
+		Name:   "hello-x7k2p",
+		Labels: map[string]string{v1alpha1.TestNameLabel: "hello"},
+	}}
+	options.applyHistoryOptions(rerun)
+	assert.Equal(t, "hello-", rerun.GenerateName)
+	assert.Equal(t, "hello", rerun.Labels[v1alpha1.TestNameLabel])
+}
@@ -1,16 +1,54 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/version"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// envVarPrefix prefixes the environment variables the flags can be set from, e.g. YAKS_OPERATOR_IMAGE for
+// --operator-image
+const envVarPrefix = "YAKS_"
+
+// lookupEnv reads the environment variables the flags are set from
+var lookupEnv = os.LookupEnv
+
+// ExitError -- an error making the CLI exit with the given code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
 func (command *RootCmdOptions) preRun(cmd *cobra.Command, _ []string) error {
+	if err := setFlagsFromEnv(cmd.Flags()); err != nil {
+		return err
+	}
+	config, err := loadProjectConfig(projectConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := setFlagsFromConfig(cmd.Flags(), config); err != nil {
+		return err
+	}
+	command.projectConfig = config
 	if command.Namespace == "" {
-		current, err := client.GetCurrentNamespace(command.KubeConfig)
-		if err != nil {
+		current, err := client.GetCurrentNamespace(command.KubeConfig, command.KubeContext)
+		if err != nil && isOffline(cmd) {
+			// Explaining and printing the version of the CLI do not require any cluster, nor a kubeconfig file
+			current = "default"
+		} else if err != nil {
 			return errors.Wrap(err, "cannot get current namespace")
 		}
 		err = cmd.Flag("namespace").Value.Set(current)
@@ -21,6 +59,39 @@ func (command *RootCmdOptions) preRun(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+// isOffline tells whether the command runs without connecting to the cluster, i.e. yaks install --explain, that only
+// renders resources, or yaks version --client
+func isOffline(cmd *cobra.Command) bool {
+	return isFlagTrue(cmd, "explain") || cmd.Name() == "version" && isFlagTrue(cmd, "client")
+}
+
+func isFlagTrue(cmd *cobra.Command, name string) bool {
+	flag := cmd.Flags().Lookup(name)
+	return flag != nil && flag.Value.String() == "true"
+}
+
+// envVarNameFor returns the name of the environment variable setting the given flag
+func envVarNameFor(flag string) string {
+	return envVarPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// setFlagsFromEnv sets the flags that are not given on the command line from their environment variable, if any,
+// so that flags take precedence over environment variables, that take precedence over defaults
+func setFlagsFromEnv(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == "help" {
+			return
+		}
+		if value, ok := lookupEnv(envVarNameFor(flag.Name)); ok {
+			if setErr := flags.Set(flag.Name, value); setErr != nil {
+				err = errors.Wrap(setErr, "invalid value of environment variable "+envVarNameFor(flag.Name))
+			}
+		}
+	})
+	return err
+}
+
 // GetCmdClient returns the client that can be used from command line tools
 func (command *RootCmdOptions) GetCmdClient() (client.Client, error) {
 	// Get the pre-computed client
@@ -34,5 +105,18 @@ func (command *RootCmdOptions) GetCmdClient() (client.Client, error) {
 
 // NewCmdClient returns a new client that can be used from command line tools
 func (command *RootCmdOptions) NewCmdClient() (client.Client, error) {
-	return client.NewOutOfClusterClient(command.KubeConfig)
+	return client.NewOutOfClusterClient(command.KubeConfig, command.KubeContext)
+}
+
+// warnOnVersionMismatch warns when the operator of the namespace does not run the same version as the CLI. Errors are
+// ignored, e.g. when the operator is global or cannot be read by the current user.
+func (command *RootCmdOptions) warnOnVersionMismatch(c client.Client) {
+	deployment, err := install.GetOperatorDeployment(command.Context, c, command.Namespace)
+	if err != nil || deployment == nil {
+		return
+	}
+	if operatorVersion := install.OperatorVersion(deployment); operatorVersion != "" && operatorVersion != version.Version {
+		fmt.Fprintf(os.Stderr, "Warning: the Yaks operator runs version %s while the CLI is version %s, "+
+			"run \"yaks install\" to upgrade the operator\n", operatorVersion, version.Version)
+	}
 }
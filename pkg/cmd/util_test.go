@@ -0,0 +1,141 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://localhost:8443
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: from-config
+- name: other
+  context:
+    cluster: test
+    namespace: from-other-context
+current-context: test
+`
+
+// runWithEnv runs a command with a --namespace and an --operator-image flag, the given environment and arguments,
+// and returns the resulting options
+func runWithEnv(t *testing.T, env map[string]string, args ...string) (*RootCmdOptions, string) {
+	dir, err := ioutil.TempDir("", "yaks-cmd")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	kubeConfig := filepath.Join(dir, "config")
+	assert.Nil(t, ioutil.WriteFile(kubeConfig, []byte(testKubeConfig), 0600))
+
+	defer func(lookup func(string) (string, bool)) { lookupEnv = lookup }(lookupEnv)
+	lookupEnv = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	options := RootCmdOptions{Context: context.TODO()}
+	image := ""
+	cmd := cobra.Command{
+		Use:               "yaks",
+		PersistentPreRunE: options.preRun,
+		RunE:              func(_ *cobra.Command, _ []string) error { return nil },
+	}
+	cmd.PersistentFlags().StringVar(&options.KubeConfig, "config", kubeConfig, "")
+	cmd.PersistentFlags().StringVar(&options.KubeContext, "context", "", "")
+	cmd.PersistentFlags().StringVarP(&options.Namespace, "namespace", "n", "", "")
+	cmd.Flags().StringVar(&image, "operator-image", "", "")
+	cmd.SetArgs(args)
+	assert.Nil(t, cmd.Execute())
+	return &options, image
+}
+
+func TestFlagsTakePrecedenceOverEnv(t *testing.T) {
+	env := map[string]string{
+		"YAKS_NAMESPACE":      "from-env",
+		"YAKS_OPERATOR_IMAGE": "env/yaks:latest",
+	}
+	options, image := runWithEnv(t, env, "--namespace", "from-flag", "--operator-image", "flag/yaks:latest")
+
+	assert.Equal(t, "from-flag", options.Namespace)
+	assert.Equal(t, "flag/yaks:latest", image)
+}
+
+func TestEnvTakesPrecedenceOverConfigFile(t *testing.T) {
+	env := map[string]string{
+		"YAKS_NAMESPACE":      "from-env",
+		"YAKS_OPERATOR_IMAGE": "env/yaks:latest",
+	}
+	options, image := runWithEnv(t, env)
+
+	assert.Equal(t, "from-env", options.Namespace)
+	assert.Equal(t, "env/yaks:latest", image)
+}
+
+func TestConfigFileIsUsedWithoutFlagOrEnv(t *testing.T) {
+	options, image := runWithEnv(t, map[string]string{})
+
+	assert.Equal(t, "from-config", options.Namespace)
+	assert.Equal(t, "", image)
+}
+
+func TestContextSelectsTheNamespace(t *testing.T) {
+	options, _ := runWithEnv(t, map[string]string{}, "--context", "other")
+	assert.Equal(t, "from-other-context", options.Namespace)
+
+	options, _ = runWithEnv(t, map[string]string{"YAKS_CONTEXT": "other"})
+	assert.Equal(t, "from-other-context", options.Namespace)
+}
+
+func TestExplainDoesNotRequireKubeConfig(t *testing.T) {
+	options := RootCmdOptions{Context: context.TODO()}
+	explain := false
+	cmd := cobra.Command{
+		Use:               "yaks",
+		PersistentPreRunE: options.preRun,
+		RunE:              func(_ *cobra.Command, _ []string) error { return nil },
+	}
+	cmd.PersistentFlags().StringVar(&options.KubeConfig, "config", "/nonexistent/kubeconfig", "")
+	cmd.PersistentFlags().StringVar(&options.KubeContext, "context", "", "")
+	cmd.PersistentFlags().StringVarP(&options.Namespace, "namespace", "n", "", "")
+	cmd.Flags().BoolVar(&explain, "explain", false, "")
+
+	cmd.SetArgs([]string{})
+	assert.NotNil(t, cmd.Execute())
+
+	cmd.SetArgs([]string{"--explain"})
+	assert.Nil(t, cmd.Execute())
+	assert.Equal(t, "default", options.Namespace)
+}
+
+func TestEnvVarNameFor(t *testing.T) {
+	assert.Equal(t, "YAKS_NAMESPACE", envVarNameFor("namespace"))
+	assert.Equal(t, "YAKS_OPERATOR_PDB_MIN_AVAILABLE", envVarNameFor("operator-pdb-min-available"))
+}
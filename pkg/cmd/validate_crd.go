@@ -0,0 +1,94 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newCmdValidateCRD(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := validateCRDCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "validate-crd",
+		Short:             "Check the installed custom resource definitions",
+		Long:              `Compares the installed Test and Instance custom resource definitions with the ones of the CLI, reporting manual edits and partial upgrades. Use --fix to reapply them (requires cluster-admin rights).`,
+		Args:              cobra.NoArgs,
+		RunE:              options.run,
+	}
+
+	cmd.Flags().BoolVar(&options.fix, "fix", false, "Reapply the custom resource definitions that differ from the ones of the CLI")
+
+	return &cmd
+}
+
+type validateCRDCmdOptions struct {
+	*RootCmdOptions
+	fix bool
+}
+
+func (o *validateCRDCmdOptions) run(cmd *cobra.Command, _ []string) error {
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	drifts, err := install.CheckCRDDrift(o.Context, c)
+	if err != nil {
+		return err
+	}
+	drifted := false
+	for _, drift := range drifts {
+		switch {
+		case drift.Missing:
+			fmt.Printf("%s (%s): not installed\n", drift.Name, drift.Kind)
+		case len(drift.Differences) > 0:
+			fmt.Printf("%s (%s): %d differences\n", drift.Name, drift.Kind, len(drift.Differences))
+			for _, difference := range drift.Differences {
+				fmt.Printf("  %s\n", difference)
+			}
+		default:
+			fmt.Printf("%s (%s): up to date\n", drift.Name, drift.Kind)
+		}
+		if drift.ManagedBy != "" && drift.Drifted() {
+			fmt.Printf("  managed by %s\n", drift.ManagedBy)
+		}
+		drifted = drifted || drift.Drifted()
+	}
+	if !drifted {
+		return nil
+	}
+
+	if !o.fix {
+		return errors.New(`custom resource definitions differ from the ones of the CLI, run "yaks validate-crd --fix" to reapply them`)
+	}
+	ctx := install.WithApplyObserver(o.Context, printApplyResult)
+	if err := install.FixCRDDrift(ctx, c, drifts); err != nil {
+		return err
+	}
+	fmt.Println("Custom resource definitions reapplied")
+	return nil
+}
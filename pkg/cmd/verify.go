@@ -0,0 +1,167 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	verifyTestName = "yaks-verify"
+	verifyTimeout  = 5 * time.Minute
+	verifyFeature  = `Feature: installation verification
+
+  Scenario: print slogan
+    Given Yaks does BDD testing on Kubernetes
+    Then Yaks is cool!
+`
+)
+
+// verifyInstallation runs a built-in hello world test and checks that it passes, the test is deleted afterwards
+func verifyInstallation(ctx context.Context, c client.Client, namespace string) (err error) {
+	test := v1alpha1.Test{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.TestKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      verifyTestName,
+		},
+		Spec: v1alpha1.TestSpec{
+			Source: v1alpha1.SourceSpec{
+				Name:     "verify.feature",
+				Content:  verifyFeature,
+				Language: v1alpha1.LanguageGherkin,
+			},
+		},
+	}
+
+	// Remove leftovers of a previous verification
+	if err := c.Delete(ctx, test.DeepCopy()); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if err := waitDeleted(ctx, c, &test); err != nil {
+		return err
+	}
+
+	fmt.Println("Verifying the installation with test", verifyTestName)
+	if err := c.Create(ctx, &test); err != nil {
+		return errors.Wrap(err, "cannot create the verification test")
+	}
+	defer func() {
+		if deleteErr := c.Delete(ctx, &test); deleteErr != nil && !k8serrors.IsNotFound(deleteErr) && err == nil {
+			err = errors.Wrap(deleteErr, "cannot delete the verification test")
+		}
+	}()
+
+	key := k8sclient.ObjectKey{Namespace: namespace, Name: verifyTestName}
+	deadline := time.Now().Add(verifyTimeout)
+	for time.Now().Before(deadline) {
+		if err := c.Get(ctx, key, &test); err != nil {
+			return err
+		}
+		switch test.Status.Phase {
+		case v1alpha1.TestPhasePassed:
+			fmt.Println("Installation verified successfully")
+			return nil
+		case v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError, v1alpha1.TestPhaseSkipped:
+			return errors.New(fmt.Sprintf("verification test ended in phase %s: %s", test.Status.Phase, test.Status.Message))
+		}
+
+		diagnostic, err := verifyPodDiagnostic(ctx, c, namespace)
+		if err != nil {
+			return err
+		}
+		if diagnostic != "" {
+			return errors.New("verification test cannot run: " + diagnostic)
+		}
+		time.Sleep(time.Second)
+	}
+
+	if test.Status.Phase == v1alpha1.IntegrationTestPhaseNone {
+		return errors.New(`verification test was not picked up by the operator, check that it is running with "yaks status"`)
+	}
+	return errors.New(fmt.Sprintf("timeout while waiting for the verification test, last phase was %s", test.Status.Phase))
+}
+
+// verifyPodDiagnostic returns a description of the problem preventing the verification pod from running, if any
+func verifyPodDiagnostic(ctx context.Context, c client.Client, namespace string) (string, error) {
+	pods := corev1.PodList{}
+	options := k8sclient.ListOptions{Namespace: namespace}
+	if err := options.SetLabelSelector("yaks.dev/test=" + verifyTestName); err != nil {
+		return "", err
+	}
+	if err := c.List(ctx, &options, &pods); err != nil {
+		return "", err
+	}
+
+	for i := range pods.Items {
+		if diagnostic := podDiagnostic(&pods.Items[i]); diagnostic != "" {
+			return diagnostic, nil
+		}
+	}
+	return "", nil
+}
+
+// podDiagnostic returns a description of the problem preventing the pod from running, if any
+func podDiagnostic(pod *corev1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return fmt.Sprintf("pod %s cannot be scheduled: %s", pod.Name, condition.Message)
+		}
+	}
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+			return fmt.Sprintf("image %s of pod %s cannot be pulled (%s), check the TEST_BASE_IMAGE operator setting and the image pull secrets: %s",
+				status.Image, pod.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+		case "CreateContainerConfigError":
+			return fmt.Sprintf("container %s of pod %s cannot be created: %s", status.Name, pod.Name, status.State.Waiting.Message)
+		}
+	}
+	return ""
+}
+
+func waitDeleted(ctx context.Context, c client.Client, test *v1alpha1.Test) error {
+	key := k8sclient.ObjectKey{Namespace: test.Namespace, Name: test.Name}
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		if err := c.Get(ctx, key, test.DeepCopy()); err != nil && k8serrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return errors.New("timeout while waiting for the deletion of test " + test.Name)
+}
@@ -0,0 +1,189 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/version"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newCmdVersion(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := versionCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "version",
+		Short:             "Print the versions of the CLI, the operator and the custom resource definitions",
+		Long:              `Prints the version of the CLI, the version run by the operator of the namespace and the versions served by the installed custom resource definitions, flagging the ones that do not match the CLI.`,
+		Args:              cobra.NoArgs,
+		PreRunE:           options.validateArgs,
+		RunE:              options.run,
+	}
+
+	cmd.Flags().StringVarP(&options.output, "output", "o", "", "Output format of the versions, json (defaults to text)")
+	cmd.Flags().BoolVar(&options.clientOnly, "client", false, "Only print the version of the CLI, without connecting to the cluster")
+
+	return &cmd
+}
+
+type versionCmdOptions struct {
+	*RootCmdOptions
+	output     string
+	clientOnly bool
+}
+
+// versionInfo holds the versions of the YAKS components
+type versionInfo struct {
+	Client   string               `json:"client"`
+	Operator *operatorVersionInfo `json:"operator,omitempty"`
+	// CRDs are the versions served by the installed custom resource definitions, by kind
+	CRDs       map[string][]string `json:"crds,omitempty"`
+	Mismatches []string            `json:"mismatches,omitempty"`
+}
+
+// operatorVersionInfo describes the operator deployment of the namespace
+type operatorVersionInfo struct {
+	Namespace string `json:"namespace"`
+	Installed bool   `json:"installed"`
+	// Version is read from the tag of the operator image, it is empty when the image has no version tag
+	Version string `json:"version,omitempty"`
+	Image   string `json:"image,omitempty"`
+}
+
+func (o *versionCmdOptions) validateArgs(_ *cobra.Command, _ []string) error {
+	if o.output != "" && o.output != outputJSON {
+		return errors.New(fmt.Sprintf("unsupported output format %q", o.output))
+	}
+	return nil
+}
+
+func (o *versionCmdOptions) run(cmd *cobra.Command, _ []string) error {
+	info := versionInfo{Client: version.Version}
+	if !o.clientOnly {
+		c, err := o.GetCmdClient()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		operator := operatorVersionInfo{Namespace: o.Namespace}
+		deployment, err := install.GetOperatorDeployment(o.Context, c, o.Namespace)
+		if err != nil {
+			return err
+		}
+		if deployment != nil {
+			operator.Installed = true
+			operator.Version = install.OperatorVersion(deployment)
+			if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+				operator.Image = containers[0].Image
+			}
+		}
+		info.Operator = &operator
+
+		info.CRDs = make(map[string][]string)
+		for _, kind := range []string{v1alpha1.TestKind, v1alpha1.InstanceKind} {
+			versions, err := install.InstalledCRDVersions(o.Context, c, kind)
+			if err != nil {
+				return err
+			}
+			info.CRDs[kind] = versions
+		}
+		info.Mismatches = versionMismatches(info)
+	}
+
+	if o.output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+	printVersionInfo(info)
+	return nil
+}
+
+// versionMismatches lists the components that do not match the version of the CLI
+func versionMismatches(info versionInfo) []string {
+	mismatches := make([]string, 0)
+	if info.Operator != nil && info.Operator.Installed && info.Operator.Version != "" && info.Operator.Version != info.Client {
+		mismatches = append(mismatches, fmt.Sprintf("the operator runs version %s while the CLI is version %s, run \"yaks install\" to upgrade the operator",
+			info.Operator.Version, info.Client))
+	}
+	kinds := make([]string, 0, len(info.CRDs))
+	for kind := range info.CRDs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		versions := info.CRDs[kind]
+		if len(versions) == 0 {
+			mismatches = append(mismatches, fmt.Sprintf("the custom resource definition of %s is not installed", kind))
+		} else if !containsString(versions, v1alpha1.SchemeGroupVersion.Version) {
+			mismatches = append(mismatches, fmt.Sprintf("the custom resource definition of %s serves %s, while the CLI uses version %s",
+				kind, strings.Join(versions, ", "), v1alpha1.SchemeGroupVersion.Version))
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return mismatches
+}
+
+func printVersionInfo(info versionInfo) {
+	fmt.Printf("Client: %s\n", info.Client)
+	if info.Operator != nil {
+		switch {
+		case !info.Operator.Installed:
+			fmt.Printf("Operator: not installed in namespace %s\n", info.Operator.Namespace)
+		case info.Operator.Version != "":
+			fmt.Printf("Operator: %s (namespace %s)\n", info.Operator.Version, info.Operator.Namespace)
+		default:
+			fmt.Printf("Operator: unknown, image %s (namespace %s)\n", info.Operator.Image, info.Operator.Namespace)
+		}
+	}
+	for _, kind := range []string{v1alpha1.TestKind, v1alpha1.InstanceKind} {
+		if versions, ok := info.CRDs[kind]; ok {
+			served := strings.Join(versions, ", ")
+			if served == "" {
+				served = "not installed"
+			}
+			fmt.Printf("%s CRD: %s\n", kind, served)
+		}
+	}
+	for _, mismatch := range info.Mismatches {
+		fmt.Printf("Warning: %s\n", mismatch)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionMismatches(t *testing.T) {
+	info := versionInfo{
+		Client:   "0.0.2",
+		Operator: &operatorVersionInfo{Namespace: "test", Installed: true, Version: "0.0.2"},
+		CRDs:     map[string][]string{"Test": {"v1alpha1"}, "Instance": {"v1alpha1"}},
+	}
+	assert.Nil(t, versionMismatches(info))
+
+	info.Operator.Version = "0.0.1"
+	info.CRDs["Instance"] = nil
+	info.CRDs["Test"] = []string{"v1"}
+	assert.Equal(t, []string{
+		`the operator runs version 0.0.1 while the CLI is version 0.0.2, run "yaks install" to upgrade the operator`,
+		"the custom resource definition of Instance is not installed",
+		"the custom resource definition of Test serves v1, while the CLI uses version v1alpha1",
+	}, versionMismatches(info))
+
+	// The version of an operator image without version tag is unknown
+	info.Operator.Version = ""
+	assert.Len(t, versionMismatches(info), 2)
+}
+
+func TestClientVersionDoesNotRequireKubeConfig(t *testing.T) {
+	root, err := NewYaksCommand(context.TODO())
+	assert.Nil(t, err)
+	root.SetArgs([]string{"version", "--client", "--kubeconfig", "/nonexistent/kubeconfig"})
+	assert.Nil(t, root.Execute())
+
+	root, err = NewYaksCommand(context.TODO())
+	assert.Nil(t, err)
+	root.SetArgs([]string{"version", "--kubeconfig", "/nonexistent/kubeconfig"})
+	assert.NotNil(t, root.Execute())
+}
@@ -0,0 +1,227 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// exitCodeFailurePhase is the exit code of yaks wait when the test reaches one of the failure phases
+	exitCodeFailurePhase = 1
+	// exitCodeTimeout is the exit code of yaks wait when the test reaches none of the phases in time
+	exitCodeTimeout = 2
+)
+
+// testEndPhases are the phases a test ends its run in
+var testEndPhases = []v1alpha1.TestPhase{
+	v1alpha1.TestPhasePassed,
+	v1alpha1.TestPhaseFailed,
+	v1alpha1.TestPhaseError,
+	v1alpha1.TestPhaseSkipped,
+	v1alpha1.TestPhaseDeleting,
+}
+
+func newCmdWait(rootCmdOptions *RootCmdOptions) *cobra.Command {
+	options := waitCmdOptions{
+		RootCmdOptions: rootCmdOptions,
+	}
+
+	cmd := cobra.Command{
+		PersistentPreRunE: options.preRun,
+		Use:               "wait <test>",
+		Short:             "Wait for a test to reach a phase",
+		Long:              `Blocks until the test reaches one of the phases given with --for, exiting with code 1 as soon as it reaches one of the --fail-on phases and with code 2 when the timeout expires.`,
+		Args:              cobra.ExactArgs(1),
+		PreRunE:           options.validate,
+		RunE:              options.run,
+		Annotations: map[string]string{
+			completionTestNamesAnnotation: "true",
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&options.forConditions, "for", []string{"phase=Passed"}, "Condition to wait for, in the form phase=<phase>[,<phase>...] (can be repeated)")
+	cmd.Flags().StringSliceVar(&options.failOn, "fail-on", nil, "Phases ending the wait with a non-zero exit code (default the phases among Failed and Error that are not waited for)")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 5*time.Minute, "How long to wait for the test")
+
+	return &cmd
+}
+
+type waitCmdOptions struct {
+	*RootCmdOptions
+	forConditions []string
+	failOn        []string
+	timeout       time.Duration
+}
+
+func (o *waitCmdOptions) validate(_ *cobra.Command, _ []string) error {
+	accepted, err := o.acceptedPhases()
+	if err != nil {
+		return err
+	}
+	failure, err := o.failurePhases(accepted)
+	if err != nil {
+		return err
+	}
+	for _, phase := range failure {
+		if containsPhase(accepted, phase) {
+			return errors.New(fmt.Sprintf("phase %s is both waited for and a failure phase", phase))
+		}
+	}
+	if o.timeout <= 0 {
+		return errors.New("--timeout must be positive")
+	}
+	return nil
+}
+
+func (o *waitCmdOptions) run(cmd *cobra.Command, args []string) error {
+	accepted, _ := o.acceptedPhases()
+	failure, _ := o.failurePhases(accepted)
+
+	c, err := o.GetCmdClient()
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	test := v1alpha1.Test{}
+	test.Name = args[0]
+	test.Namespace = o.Namespace
+	result, err := waitForTestPhase(o.Context, c, &test, accepted, failure, o.timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Test %s reached phase %s\n", result.Name, result.Status.Phase)
+	return nil
+}
+
+// acceptedPhases parses the --for conditions
+func (o *waitCmdOptions) acceptedPhases() ([]v1alpha1.TestPhase, error) {
+	phases := make([]v1alpha1.TestPhase, 0)
+	for _, condition := range o.forConditions {
+		if !strings.HasPrefix(condition, "phase=") {
+			return nil, errors.New(fmt.Sprintf("invalid condition %q, expected phase=<phase>[,<phase>...]", condition))
+		}
+		parsed, err := parsePhases(strings.Split(strings.TrimPrefix(condition, "phase="), ","))
+		if err != nil {
+			return nil, err
+		}
+		phases = append(phases, parsed...)
+	}
+	if len(phases) == 0 {
+		return nil, errors.New("at least one phase to wait for is required")
+	}
+	return phases, nil
+}
+
+// failurePhases parses the --fail-on phases, defaulting to Failed and Error unless they are waited for
+func (o *waitCmdOptions) failurePhases(accepted []v1alpha1.TestPhase) ([]v1alpha1.TestPhase, error) {
+	if o.failOn != nil {
+		return parsePhases(o.failOn)
+	}
+	phases := make([]v1alpha1.TestPhase, 0)
+	for _, phase := range []v1alpha1.TestPhase{v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError} {
+		if !containsPhase(accepted, phase) {
+			phases = append(phases, phase)
+		}
+	}
+	return phases, nil
+}
+
+// parsePhases parses phase names, ignoring their case
+func parsePhases(names []string) ([]v1alpha1.TestPhase, error) {
+	known := []v1alpha1.TestPhase{
+		v1alpha1.TestPhasePending,
+		v1alpha1.TestPhaseRunning,
+		v1alpha1.TestPhasePassed,
+		v1alpha1.TestPhaseFailed,
+		v1alpha1.TestPhaseError,
+		v1alpha1.TestPhaseSkipped,
+	}
+	phases := make([]v1alpha1.TestPhase, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, phase := range known {
+			if strings.EqualFold(strings.TrimSpace(name), string(phase)) {
+				phases = append(phases, phase)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New(fmt.Sprintf("unknown phase %q, expected one of Pending, Running, Passed, Failed, Error, Skipped", name))
+		}
+	}
+	return phases, nil
+}
+
+func containsPhase(phases []v1alpha1.TestPhase, phase v1alpha1.TestPhase) bool {
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForTestPhase waits until the test reaches one of the accepted phases and returns it at that point. An ExitError
+// is returned as soon as the test reaches one of the failure phases, or when the timeout expires.
+func waitForTestPhase(ctx context.Context, c client.Client, test *v1alpha1.Test, accepted []v1alpha1.TestPhase, failure []v1alpha1.TestPhase, timeout time.Duration) (*v1alpha1.Test, error) {
+	var reached *v1alpha1.Test
+	err := kubernetes.WaitCondition(ctx, c, test, func(obj interface{}) (bool, error) {
+		if val, ok := obj.(*v1alpha1.Test); ok {
+			if containsPhase(accepted, val.Status.Phase) || containsPhase(failure, val.Status.Phase) {
+				reached = val.DeepCopy()
+				return true, nil
+			}
+		}
+		return false, nil
+	}, timeout)
+	if err == kubernetes.ErrWaitTimeout {
+		return nil, &ExitError{
+			Code: exitCodeTimeout,
+			Err:  errors.New(fmt.Sprintf("test %s did not reach phase %s within %s", test.Name, joinPhases(accepted), timeout)),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if containsPhase(failure, reached.Status.Phase) {
+		return reached, &ExitError{
+			Code: exitCodeFailurePhase,
+			Err:  errors.New(fmt.Sprintf("test %s reached phase %s", test.Name, reached.Status.Phase)),
+		}
+	}
+	return reached, nil
+}
+
+func joinPhases(phases []v1alpha1.TestPhase) string {
+	names := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		names = append(names, string(phase))
+	}
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,58 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitPhases(t *testing.T) {
+	options := waitCmdOptions{forConditions: []string{"phase=Passed,skipped", "phase=Failed"}}
+	accepted, err := options.acceptedPhases()
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.TestPhase{v1alpha1.TestPhasePassed, v1alpha1.TestPhaseSkipped, v1alpha1.TestPhaseFailed}, accepted)
+
+	failure, err := options.failurePhases(accepted)
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.TestPhase{v1alpha1.TestPhaseError}, failure)
+
+	options.failOn = []string{"Error", "Running"}
+	failure, err = options.failurePhases(accepted)
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.TestPhase{v1alpha1.TestPhaseError, v1alpha1.TestPhaseRunning}, failure)
+}
+
+func TestWaitValidate(t *testing.T) {
+	options := waitCmdOptions{forConditions: []string{"phase=Passed"}, timeout: 0}
+	assert.NotNil(t, options.validate(nil, nil))
+
+	options.timeout = 1
+	assert.Nil(t, options.validate(nil, nil))
+
+	options.failOn = []string{"Passed"}
+	assert.NotNil(t, options.validate(nil, nil))
+
+	options = waitCmdOptions{forConditions: []string{"state=Passed"}, timeout: 1}
+	assert.NotNil(t, options.validate(nil, nil))
+
+	options = waitCmdOptions{forConditions: []string{"phase=Done"}, timeout: 1}
+	assert.NotNil(t, options.validate(nil, nil))
+}
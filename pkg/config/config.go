@@ -1,9 +1,18 @@
 package config
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jboss-fuse/yaks/version"
+	"github.com/pkg/errors"
 )
 
 func GetTestBaseImage() string {
@@ -15,5 +24,407 @@ func GetTestBaseImage() string {
 }
 
 func getDefaultTestBaseImage() string {
-	return "yaks/yaks:" + version.Version
+	return defaultImageRepository + ":" + version.Version
+}
+
+// defaultImageRepository is the repository of the images of the releases
+const defaultImageRepository = "yaks/yaks"
+
+// GetAllowedOperatorImages returns the images the cluster-wide operator may deploy as the operator of an instance, from
+// the comma separated ALLOWED_OPERATOR_IMAGES, the repository of the releases by default. An entry ending with / allows
+// the images of a registry or an organization, the other entries a repository, with any tag or digest, or an image.
+func GetAllowedOperatorImages() []string {
+	images := make([]string, 0)
+	for _, image := range strings.Split(os.Getenv("ALLOWED_OPERATOR_IMAGES"), ",") {
+		if image = strings.TrimSpace(image); image != "" {
+			images = append(images, image)
+		}
+	}
+	if len(images) == 0 {
+		images = append(images, defaultImageRepository)
+	}
+	return images
+}
+
+// IsAllowedOperatorImage tells whether the image matches one of the allowed operator images
+func IsAllowedOperatorImage(image string, allowed []string) bool {
+	for _, entry := range allowed {
+		switch {
+		case image == entry:
+			return true
+		case strings.HasSuffix(entry, "/") && strings.HasPrefix(image, entry):
+			return true
+		case strings.HasPrefix(image, entry+":") || strings.HasPrefix(image, entry+"@"):
+			return true
+		}
+	}
+	return false
+}
+
+// IsOperatorPaused tells whether the operator has been paused, in which case no new test pods are started
+func IsOperatorPaused() bool {
+	paused, err := strconv.ParseBool(os.Getenv("OPERATOR_PAUSED"))
+	return err == nil && paused
+}
+
+// GetTestWorkload returns the operator wide kind of workload running the tests, Pod when not set
+func GetTestWorkload() string {
+	if workload := os.Getenv("TEST_WORKLOAD"); workload != "" {
+		return workload
+	}
+	return "Pod"
+}
+
+// defaultPropagatedLabels are the label keys always copied from a test to its child resources
+var defaultPropagatedLabels = []string{"app"}
+
+// GetPropagatedLabels returns the label keys copied from a test to the resources created for it, the default
+// ones extended with the comma separated keys of PROPAGATED_LABELS
+func GetPropagatedLabels() []string {
+	keys := append([]string{}, defaultPropagatedLabels...)
+	for _, key := range strings.Split(os.Getenv("PROPAGATED_LABELS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetDefaultImagePullSecret returns the name of the secret used to pull the runner image of the tests that
+// do not define their own image pull secrets, if any
+func GetDefaultImagePullSecret() string {
+	return os.Getenv("DEFAULT_IMAGE_PULL_SECRET")
+}
+
+// GetAllowedTargetNamespaces returns the namespaces, other than their own, where the tests are allowed to create
+// resources, from the comma separated ALLOWED_TARGET_NAMESPACES. A "*" allows any namespace.
+func GetAllowedTargetNamespaces() []string {
+	namespaces := make([]string, 0)
+	for _, namespace := range strings.Split(os.Getenv("ALLOWED_TARGET_NAMESPACES"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// GetMaxConcurrentTests returns the maximum number of tests running at the same time in the watched namespaces,
+// from MAX_CONCURRENT_TESTS. Zero means no limit.
+func GetMaxConcurrentTests() int {
+	if limit, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_TESTS")); err == nil && limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// GetMaxHistoryPerTest returns how many completed tests sharing the same yaks.dev/test-name label are kept in a
+// namespace, from MAX_HISTORY_PER_TEST. Zero means no limit.
+func GetMaxHistoryPerTest() int {
+	if limit, err := strconv.Atoi(os.Getenv("MAX_HISTORY_PER_TEST")); err == nil && limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// GetRequeueInterval returns how often the tests in progress are reconciled again, in addition to the reconciliations
+// triggered by changes of their pods. A zero interval disables the periodic reconciliation.
+func GetRequeueInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("REQUEUE_INTERVAL")); err == nil && interval >= 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// ReportStore --
+type ReportStore struct {
+	// Endpoint of the S3 compatible object store, e.g. https://s3.eu-west-1.amazonaws.com
+	Endpoint string
+	Bucket   string
+	Region   string
+	// Secret of the operator namespace holding the accessKeyId and secretAccessKey of the object store
+	Secret string
+}
+
+// Enabled tells whether the reports are uploaded
+func (s ReportStore) Enabled() bool {
+	return s.Endpoint != "" && s.Bucket != ""
+}
+
+// GetReportStore returns the object store where the reports of the completed tests are uploaded, from the
+// REPORT_STORE_ENDPOINT, REPORT_STORE_BUCKET, REPORT_STORE_REGION and REPORT_STORE_SECRET settings
+func GetReportStore() ReportStore {
+	store := ReportStore{
+		Endpoint: os.Getenv("REPORT_STORE_ENDPOINT"),
+		Bucket:   os.Getenv("REPORT_STORE_BUCKET"),
+		Region:   os.Getenv("REPORT_STORE_REGION"),
+		Secret:   os.Getenv("REPORT_STORE_SECRET"),
+	}
+	if store.Region == "" {
+		store.Region = "us-east-1"
+	}
+	return store
+}
+
+// GetReportClaim returns the persistent volume claim, of the test namespaces, where the reports of the tests are kept,
+// from REPORT_CLAIM
+func GetReportClaim() string {
+	return os.Getenv("REPORT_CLAIM")
+}
+
+// GetReportPath returns the directory where the runners write their reports, copied to the report claim once the tests
+// have run, from REPORT_PATH
+func GetReportPath() string {
+	return os.Getenv("REPORT_PATH")
+}
+
+// DependencyCacheScope tells how the runs of the tests share the dependency cache
+type DependencyCacheScope string
+
+const (
+	// DependencyCacheScopeShared shares one cache between all the tests of a namespace
+	DependencyCacheScopeShared DependencyCacheScope = "Shared"
+	// DependencyCacheScopeTest gives each test a cache of its own, reused by its runs only
+	DependencyCacheScopeTest DependencyCacheScope = "Test"
+)
+
+// GetDependencyCacheClaim returns the persistent volume claim of the test namespaces where the dependencies resolved
+// by the runners are cached, from DEPENDENCY_CACHE_CLAIM. Caching is disabled when empty.
+func GetDependencyCacheClaim() string {
+	return os.Getenv("DEPENDENCY_CACHE_CLAIM")
+}
+
+// GetDependencyCacheScope returns how the dependency cache is shared, from DEPENDENCY_CACHE_SCOPE, Test by default as
+// concurrent runs cannot safely write to the same local Maven repository
+func GetDependencyCacheScope() DependencyCacheScope {
+	if strings.EqualFold(os.Getenv("DEPENDENCY_CACHE_SCOPE"), string(DependencyCacheScopeShared)) {
+		return DependencyCacheScopeShared
+	}
+	return DependencyCacheScopeTest
+}
+
+// GetDrainTimeout returns how long the operator waits for in-flight reconciliations to complete when shutting down
+func GetDrainTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(os.Getenv("DRAIN_TIMEOUT")); err == nil {
+		return timeout
+	}
+	// Leave some margin before the default termination grace period of 30 seconds expires
+	return 25 * time.Second
+}
+
+// KeepOrphanedPods tells whether the runner pods of deleted tests are kept at operator startup, from KEEP_ORPHANED_PODS,
+// e.g. to inspect them
+func KeepOrphanedPods() bool {
+	keep, err := strconv.ParseBool(os.Getenv("KEEP_ORPHANED_PODS"))
+	return err == nil && keep
+}
+
+// GetTestTTL returns how long completed tests are kept before being deleted, from TEST_TTL. Zero, the default, keeps
+// them forever.
+func GetTestTTL() time.Duration {
+	if ttl, err := ParseTTL(os.Getenv("TEST_TTL")); err == nil {
+		return ttl
+	}
+	return 0
+}
+
+// GetTestCleanupRules returns the operator wide cleanup rules of the completed tests, from TEST_CLEANUP_RULES
+func GetTestCleanupRules() string {
+	return os.Getenv("TEST_CLEANUP_RULES")
+}
+
+// ParseTTL parses a non negative duration, that can also be given in days, e.g. 7d
+func ParseTTL(value string) (time.Duration, error) {
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 0 {
+			return 0, errors.New("invalid number of days: " + value)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, errors.New("negative duration: " + value)
+	}
+	return ttl, nil
+}
+
+// GetDefaultJavaOptions returns the options passed to the JVM of the runners of the tests that do not define their
+// own, if any
+func GetDefaultJavaOptions() string {
+	return os.Getenv("DEFAULT_JAVA_OPTIONS")
+}
+
+// defaultLogShipperImage is a Fluent Bit image providing the shell the log shipper sidecar runs with
+const defaultLogShipperImage = "docker.io/fluent/fluent-bit:1.3-debug"
+
+// GetLogShipperOutput returns the Fluent Bit output and its properties the logs of the runners are shipped to, e.g.
+// "forward -p host=fluentd.logging -p port=24224", from LOG_SHIPPER_OUTPUT. Log shipping is disabled when empty.
+func GetLogShipperOutput() string {
+	return strings.TrimSpace(os.Getenv("LOG_SHIPPER_OUTPUT"))
+}
+
+// GetLogShipperImage returns the image of the log shipper sidecar, from LOG_SHIPPER_IMAGE
+func GetLogShipperImage() string {
+	if image := os.Getenv("LOG_SHIPPER_IMAGE"); image != "" {
+		return image
+	}
+	return defaultLogShipperImage
+}
+
+// GetClusterDomain returns the domain of the cluster ingress, e.g. apps.example.com, from CLUSTER_DOMAIN. When empty,
+// the domain is read from the ingress configuration of OpenShift clusters.
+func GetClusterDomain() string {
+	return os.Getenv("CLUSTER_DOMAIN")
+}
+
+// UnknownFieldsPolicy tells how the tests whose spec has fields unknown to the operator, e.g. typos, are handled
+type UnknownFieldsPolicy string
+
+const (
+	// UnknownFieldsIgnore does not check the fields of the spec
+	UnknownFieldsIgnore UnknownFieldsPolicy = "Ignore"
+	// UnknownFieldsWarn lists the unknown fields in the SpecValid condition of the tests, that are run anyway
+	UnknownFieldsWarn UnknownFieldsPolicy = "Warn"
+	// UnknownFieldsReject sets the tests in error, without running them
+	UnknownFieldsReject UnknownFieldsPolicy = "Reject"
+)
+
+// GetUnknownFieldsPolicy returns how the unknown fields of the spec of the tests are handled, from
+// UNKNOWN_FIELDS_POLICY, Warn by default
+func GetUnknownFieldsPolicy() UnknownFieldsPolicy {
+	value := os.Getenv("UNKNOWN_FIELDS_POLICY")
+	for _, policy := range []UnknownFieldsPolicy{UnknownFieldsIgnore, UnknownFieldsReject} {
+		if strings.EqualFold(value, string(policy)) {
+			return policy
+		}
+	}
+	return UnknownFieldsWarn
+}
+
+// PodInfoDir is where the labels of the operator pod are mounted through the downward API
+const PodInfoDir = "/etc/yaks/podinfo"
+
+// operatorPodLabels are the labels set on the operator pods by the installation and by Kubernetes, that are not
+// operator labels
+var operatorPodLabels = map[string]bool{
+	"name":              true,
+	"pod-template-hash": true,
+}
+
+// GetOperatorLabels returns the labels of the operator the operator selector of the tests is matched against, i.e. the
+// labels of the operator pod read through the downward API, but the ones set by the installation and by Kubernetes.
+// No label is returned when not running in a pod.
+func GetOperatorLabels() map[string]string {
+	content, err := ioutil.ReadFile(filepath.Join(PodInfoDir, "labels"))
+	if err != nil {
+		return map[string]string{}
+	}
+	return ParseOperatorLabels(string(content))
+}
+
+// ParseOperatorLabels returns the operator labels from the labels of the operator pod, written by the downward API as
+// one key="value" pair per line
+func ParseOperatorLabels(content string) map[string]string {
+	labels := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 || operatorPodLabels[parts[0]] {
+			continue
+		}
+		value, err := strconv.Unquote(parts[1])
+		if err != nil {
+			value = parts[1]
+		}
+		labels[parts[0]] = value
+	}
+	return labels
+}
+
+// OperatorLabelsSuffix returns a short stable suffix identifying the given operator labels, telling apart the
+// Deployment and the leader lock of operators running with different labels in the same namespace, or an empty
+// string without labels
+func OperatorLabelsSuffix(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	sum := sha256.Sum256([]byte(strings.Join(pairs, ",")))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// ReconcileSelectedTestsOnly tells whether the tests without an operator selector are left to the other operators,
+// from SELECTED_TESTS_ONLY. It defaults to true for an operator with the given operator labels, e.g. a canary operator,
+// so that the tests without selector are not run by both the labelled operator and the operator without labels.
+func ReconcileSelectedTestsOnly(operatorLabels map[string]string) bool {
+	if only, err := strconv.ParseBool(os.Getenv("SELECTED_TESTS_ONLY")); err == nil {
+		return only
+	}
+	return len(operatorLabels) > 0
+}
+
+// IsImagePreflightEnabled tells whether the registry is asked whether the runner image of a test exists before its
+// runner is created, from IMAGE_PREFLIGHT
+func IsImagePreflightEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("IMAGE_PREFLIGHT"))
+	return err == nil && enabled
+}
+
+// GetTrustedRegistryRealms returns the hosts of the authorization servers, other than the registries themselves, the
+// credentials of the image pull secrets are sent to when checking the runner images, from the comma separated
+// TRUSTED_REGISTRY_REALMS
+func GetTrustedRegistryRealms() []string {
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(os.Getenv("TRUSTED_REGISTRY_REALMS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetAllowedGateHosts returns the hosts, other than the services of the target namespace of the test, that the HTTP
+// and TCP readiness gates may reach, from the comma separated ALLOWED_GATE_HOSTS. A "*" allows any host.
+func GetAllowedGateHosts() []string {
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(os.Getenv("ALLOWED_GATE_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// LogFormat is the format of the logs of the operator
+type LogFormat string
+
+const (
+	// LogFormatText writes human readable log lines, e.g. for local development
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes a JSON object per log entry, e.g. for log aggregation
+	LogFormatJSON LogFormat = "json"
+)
+
+// GetLogFormat returns the format of the logs of the operator, from LOG_FORMAT, text by default
+func GetLogFormat() string {
+	if format := os.Getenv("LOG_FORMAT"); format != "" {
+		return format
+	}
+	return string(LogFormatText)
+}
+
+// ParseLogFormat returns the log format with the given name, ignoring case
+func ParseLogFormat(value string) (LogFormat, error) {
+	for _, format := range []LogFormat{LogFormatText, LogFormatJSON} {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	return "", errors.New("unsupported log format " + value + ", expected one of text, json")
 }
@@ -0,0 +1,95 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOperatorLabels(t *testing.T) {
+	labels := ParseOperatorLabels("channel=\"canary\"\nname=\"yaks-1a2b3c4d\"\npod-template-hash=\"5d8f7b\"\nteam=\"a\"\n")
+	assert.Equal(t, map[string]string{"channel": "canary", "team": "a"}, labels)
+
+	assert.Empty(t, ParseOperatorLabels(""))
+}
+
+func TestOperatorLabelsSuffix(t *testing.T) {
+	assert.Equal(t, "", OperatorLabelsSuffix(nil))
+
+	suffix := OperatorLabelsSuffix(map[string]string{"channel": "canary", "team": "a"})
+	assert.Len(t, suffix, 8)
+	assert.Equal(t, suffix, OperatorLabelsSuffix(map[string]string{"team": "a", "channel": "canary"}))
+	assert.NotEqual(t, suffix, OperatorLabelsSuffix(map[string]string{"channel": "stable", "team": "a"}))
+}
+
+func TestIsAllowedOperatorImage(t *testing.T) {
+	allowed := []string{"yaks/yaks", "quay.io/acme/", "registry.local/yaks:stable"}
+
+	assert.True(t, IsAllowedOperatorImage("yaks/yaks:0.0.1", allowed))
+	assert.True(t, IsAllowedOperatorImage("yaks/yaks@sha256:0123", allowed))
+	assert.True(t, IsAllowedOperatorImage("quay.io/acme/yaks:0.0.1", allowed))
+	assert.True(t, IsAllowedOperatorImage("registry.local/yaks:stable", allowed))
+
+	assert.False(t, IsAllowedOperatorImage("yaks/yaks-evil:0.0.1", allowed))
+	assert.False(t, IsAllowedOperatorImage("quay.io/acme-evil/yaks:0.0.1", allowed))
+	assert.False(t, IsAllowedOperatorImage("registry.local/yaks:latest", allowed))
+	assert.False(t, IsAllowedOperatorImage("docker.io/attacker/tools", allowed))
+}
+
+func TestReconcileSelectedTestsOnly(t *testing.T) {
+	defer os.Unsetenv("SELECTED_TESTS_ONLY")
+	canary := map[string]string{"channel": "canary"}
+
+	// A labelled operator leaves the tests without selector to the operator without labels by default
+	assert.True(t, ReconcileSelectedTestsOnly(canary))
+	assert.False(t, ReconcileSelectedTestsOnly(map[string]string{}))
+
+	assert.Nil(t, os.Setenv("SELECTED_TESTS_ONLY", "false"))
+	assert.False(t, ReconcileSelectedTestsOnly(canary))
+	assert.Nil(t, os.Setenv("SELECTED_TESTS_ONLY", "true"))
+	assert.True(t, ReconcileSelectedTestsOnly(map[string]string{}))
+}
+
+func TestParseLogFormat(t *testing.T) {
+	tests := []struct {
+		value  string
+		format LogFormat
+		valid  bool
+	}{
+		{value: "text", format: LogFormatText, valid: true},
+		{value: "json", format: LogFormatJSON, valid: true},
+		{value: "Text", format: LogFormatText, valid: true},
+		{value: "JSON", format: LogFormatJSON, valid: true},
+		// The zap encoder names are not log formats
+		{value: "console"},
+		{value: "xml"},
+		{value: ""},
+	}
+	for _, test := range tests {
+		format, err := ParseLogFormat(test.value)
+		if test.valid {
+			assert.Nil(t, err, test.value)
+			assert.Equal(t, test.format, format, test.value)
+		} else {
+			assert.EqualError(t, err, "unsupported log format "+test.value+", expected one of text, json", test.value)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/jboss-fuse/yaks/pkg/controller/instance"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, instance.Add)
+}
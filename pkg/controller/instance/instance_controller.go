@@ -0,0 +1,214 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/install"
+	"github.com/jboss-fuse/yaks/pkg/util/log"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Log --
+var Log = log.Log.WithName("controller").WithName("instance")
+
+// driftCheckInterval is how often the operator of an instance is reconciled again, as the resources of the other
+// namespaces are not watched
+const driftCheckInterval = time.Minute
+
+// Add creates a new Instance Controller and adds it to the Manager. Only the cluster-wide operator, watching all the
+// namespaces, deploys the operators of the instances: the operator of a namespace leaves the Instances alone.
+func Add(mgr manager.Manager) error {
+	watchNamespace, err := k8sutil.GetWatchNamespace()
+	if err != nil {
+		return err
+	}
+	if watchNamespace != "" {
+		Log.Info("Instances are left to the cluster-wide operator", "namespace", watchNamespace)
+		return nil
+	}
+
+	c, err := client.FromManager(mgr)
+	if err != nil {
+		return err
+	}
+	// The cache of the manager only holds the resources of the watched types
+	uncached, err := client.NewClient()
+	if err != nil {
+		return err
+	}
+	// Not known when running outside the cluster, in which case no instance is skipped
+	namespace, _ := k8sutil.GetOperatorNamespace()
+
+	r := &ReconcileInstance{
+		client:            c,
+		installer:         uncached,
+		operatorNamespace: namespace,
+	}
+	ctrl, err := controller.New("instance-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return ctrl.Watch(&source.Kind{Type: &v1alpha1.Instance{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileInstance{}
+
+// ReconcileInstance ensures the operator of the namespace of an Instance is deployed as specified
+type ReconcileInstance struct {
+	client client.Client
+	// installer reads and writes the operator resources directly from the API server
+	installer         client.Client
+	operatorNamespace string
+}
+
+// Reconcile installs or updates the resources of the operator of the instance, the ones that have not drifted being
+// left untouched
+func (r *ReconcileInstance) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	rlog := Log.WithValues("request-namespace", request.Namespace, "request-name", request.Name)
+	ctx := context.TODO()
+
+	var instance v1alpha1.Instance
+	if err := r.client.Get(ctx, request.NamespacedName, &instance); err != nil {
+		if k8serrors.IsNotFound(err) {
+			// The operator resources are garbage collected with the instance
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if instance.Spec.Operator == nil {
+		return reconcile.Result{}, nil
+	}
+
+	target := instance.DeepCopy()
+	if instance.Namespace == r.operatorNamespace {
+		setStatus(target, v1alpha1.InstancePhaseError, "the operator cannot manage the operator of its own namespace")
+		return reconcile.Result{}, r.updateStatus(ctx, &instance, target)
+	}
+
+	// The operator of the instance runs with the operator role, that can read the secrets of the namespace
+	if image := instance.Spec.Operator.Image; image != "" && !config.IsAllowedOperatorImage(image, config.GetAllowedOperatorImages()) {
+		message := fmt.Sprintf("image %s is not allowed, see ALLOWED_OPERATOR_IMAGES of the cluster-wide operator", image)
+		rlog.Info("Rejecting Instance operator image", "image", image)
+		setStatus(target, v1alpha1.InstancePhaseError, message)
+		setCondition(target, v1alpha1.InstanceConditionOperatorImageAllowed, corev1.ConditionFalse, "ImageNotAllowed", message)
+		return reconcile.Result{}, r.updateStatus(ctx, &instance, target)
+	}
+	setCondition(target, v1alpha1.InstanceConditionOperatorImageAllowed, corev1.ConditionTrue, "", "")
+
+	rlog.Info("Reconciling Instance operator")
+	cfg := install.OperatorConfiguration{
+		Namespace:  instance.Namespace,
+		Image:      instance.Spec.Operator.Image,
+		Replicas:   instance.Spec.Operator.Replicas,
+		Customizer: ownedBy(&instance),
+	}
+	if err := install.Operator(ctx, r.installer, cfg); err != nil {
+		rlog.Error(err, "Cannot reconcile the operator of the instance")
+		setStatus(target, v1alpha1.InstancePhaseError, err.Error())
+		if updateErr := r.updateStatus(ctx, &instance, target); updateErr != nil {
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, err
+	}
+
+	setStatus(target, v1alpha1.InstancePhaseReady, "")
+	return reconcile.Result{RequeueAfter: driftCheckInterval}, r.updateStatus(ctx, &instance, target)
+}
+
+func setStatus(instance *v1alpha1.Instance, phase v1alpha1.InstancePhase, message string) {
+	instance.Status.Phase = phase
+	instance.Status.Message = message
+}
+
+// setCondition sets the status of the given condition of the instance, the transition time is only updated when the
+// status changes
+func setCondition(instance *v1alpha1.Instance, conditionType v1alpha1.InstanceConditionType, status corev1.ConditionStatus, reason string, message string) {
+	condition := v1alpha1.InstanceCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+	for i := range instance.Status.Conditions {
+		if instance.Status.Conditions[i].Type == conditionType {
+			if instance.Status.Conditions[i].Status == status {
+				condition.LastTransitionTime = instance.Status.Conditions[i].LastTransitionTime
+			}
+			instance.Status.Conditions[i] = condition
+			return
+		}
+	}
+	instance.Status.Conditions = append(instance.Status.Conditions, condition)
+}
+
+// updateStatus updates the status of the instance when it has changed, to limit the API churn of the periodic
+// reconciliations
+func (r *ReconcileInstance) updateStatus(ctx context.Context, instance *v1alpha1.Instance, target *v1alpha1.Instance) error {
+	if equality.Semantic.DeepEqual(instance.Status, target.Status) {
+		return nil
+	}
+	err := r.client.Status().Update(ctx, target)
+	if err != nil && k8serrors.IsConflict(err) {
+		// Reconciled again with the latest version
+		return nil
+	}
+	return err
+}
+
+// ownedBy returns a customizer making the instance the owner of the resources, so that they are garbage collected
+// with it
+func ownedBy(instance *v1alpha1.Instance) install.ResourceCustomizer {
+	controller := true
+	blockOwnerDeletion := true
+	references := []metav1.OwnerReference{
+		{
+			// Type meta is not always populated on objects read from the cache
+			APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+			Kind:               v1alpha1.InstanceKind,
+			Name:               instance.Name,
+			UID:                instance.UID,
+			Controller:         &controller,
+			BlockOwnerDeletion: &blockOwnerDeletion,
+		},
+	}
+	return func(object runtime.Object) runtime.Object {
+		if metaObject, ok := object.(metav1.Object); ok {
+			metaObject.SetOwnerReferences(references)
+		}
+		return object
+	}
+}
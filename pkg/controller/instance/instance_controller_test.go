@@ -0,0 +1,75 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestOwnedBy(t *testing.T) {
+	instance := v1alpha1.Instance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "yaks", UID: types.UID("uid")},
+	}
+	deployment := &appsv1.Deployment{}
+
+	ownedBy(&instance)(deployment)
+
+	assert.Len(t, deployment.OwnerReferences, 1)
+	owner := deployment.OwnerReferences[0]
+	assert.Equal(t, v1alpha1.InstanceKind, owner.Kind)
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.String(), owner.APIVersion)
+	assert.Equal(t, "yaks", owner.Name)
+	assert.Equal(t, types.UID("uid"), owner.UID)
+	assert.True(t, *owner.Controller)
+}
+
+func TestOperatorImageNotAllowed(t *testing.T) {
+	instance := &v1alpha1.Instance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "yaks"},
+		Spec: v1alpha1.InstanceSpec{
+			Operator: &v1alpha1.InstanceOperatorSpec{Image: "docker.io/attacker/tools:latest"},
+		},
+	}
+	c := testutil.NewFakeClient(instance)
+	// Nothing is installed for a rejected image
+	r := &ReconcileInstance{client: c, operatorNamespace: "yaks-system"}
+
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "yaks"}})
+	assert.Nil(t, err)
+
+	var updated v1alpha1.Instance
+	assert.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "team-a", Name: "yaks"}, &updated))
+	assert.Equal(t, v1alpha1.InstancePhaseError, updated.Status.Phase)
+	assert.Len(t, updated.Status.Conditions, 1)
+	condition := updated.Status.Conditions[0]
+	assert.Equal(t, v1alpha1.InstanceConditionOperatorImageAllowed, condition.Type)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "ImageNotAllowed", condition.Reason)
+	assert.Contains(t, condition.Message, "docker.io/attacker/tools:latest")
+}
@@ -0,0 +1,164 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	assertionsContainerName = "assertions"
+	assertionsVolumeName    = "assertions"
+	assertionsPath          = "/var/yaks/assertions"
+)
+
+// assertionsRunScript runs the command of the test container given as arguments, then tells the assertions sidecar
+// that the artifacts can be checked
+var assertionsRunScript = recordRunnerPidScript(assertionsPath) + `"$@"
+code=$?
+touch ` + assertionsPath + `/done
+exit $code
+`
+
+// assertionsScript checks the artifacts once the test container is done, given as check, path and pattern arguments,
+// and writes the index of the assertions that are not met to its termination log. It fails without checking them when
+// the runner has been killed before telling it is done.
+var assertionsScript = waitForRunnerScript(assertionsPath) + `if [ ! -f ` + assertionsPath + `/done ]; then
+  echo "the runner has been killed before completing" > /dev/termination-log
+  exit 1
+fi
+index=0
+while [ $# -ge 3 ]; do
+  case "$1" in
+    ` + string(v1alpha1.ArtifactCheckNonEmpty) + `) test -s "$2" ;;
+    ` + string(v1alpha1.ArtifactCheckMatches) + `) test -f "$2" && grep -Eq -e "$3" "$2" ;;
+    *) test -e "$2" ;;
+  esac
+  if [ $? -ne 0 ]; then
+    echo "$index" >> /dev/termination-log
+  fi
+  index=$((index + 1))
+  shift 3
+done
+exit 0
+`
+
+// artifactAssertionsFor returns the artifact assertions of the test
+func artifactAssertionsFor(test *v1alpha1.Test) []v1alpha1.ArtifactAssertion {
+	if test.Spec.Assertions == nil {
+		return nil
+	}
+	return test.Spec.Assertions.Artifacts
+}
+
+// applyAssertions adds a sidecar checking the artifacts of the test once the test container is done. The sidecar
+// mounts the volumes of the test container, including the workspace, at the same paths and in the same working
+// directory, so that the artifacts are resolved as they are by the test, and shares the process namespace of the pod
+// to tell when the test container has been killed.
+func applyAssertions(test *v1alpha1.Test, pod *v1.Pod) {
+	artifacts := artifactAssertionsFor(test)
+	if len(artifacts) == 0 {
+		return
+	}
+
+	container := &pod.Spec.Containers[0]
+	wrapContainerCommand(container, assertionsRunScript)
+	mount := v1.VolumeMount{
+		Name:      assertionsVolumeName,
+		MountPath: assertionsPath,
+	}
+	container.VolumeMounts = append(container.VolumeMounts, mount)
+	shareProcessNamespace(pod)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: assertionsVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+
+	args := make([]string, 0, 3*len(artifacts))
+	for _, artifact := range artifacts {
+		check := artifact.Check
+		if check == "" {
+			check = v1alpha1.ArtifactCheckExists
+		}
+		args = append(args, string(check), artifact.Path, artifact.Pattern)
+	}
+	sidecar := v1.Container{
+		Name:            assertionsContainerName,
+		Image:           config.GetTestBaseImage(),
+		ImagePullPolicy: imagePullPolicyFor(test),
+		Command:         []string{"/bin/sh", "-c", assertionsScript, "assertions"},
+		Args:            args,
+		WorkingDir:      container.WorkingDir,
+		VolumeMounts:    append([]v1.VolumeMount{}, container.VolumeMounts...),
+	}
+	pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+}
+
+// failedAssertions returns the description of the artifact assertions reported as not met by the sidecar of the
+// terminated test pod
+func failedAssertions(test *v1alpha1.Test, pod *v1.Pod) []string {
+	artifacts := artifactAssertionsFor(test)
+	if len(artifacts) == 0 {
+		return nil
+	}
+	var terminated *v1.ContainerStateTerminated
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == assertionsContainerName {
+			terminated = status.State.Terminated
+		}
+	}
+	if terminated == nil {
+		return nil
+	}
+	if terminated.ExitCode != 0 {
+		reason := terminated.Reason
+		if message := strings.TrimSpace(terminated.Message); message != "" {
+			reason = message
+		}
+		return []string{"artifacts could not be checked: " + reason}
+	}
+
+	var failed []string
+	for _, line := range strings.Fields(terminated.Message) {
+		index, err := strconv.Atoi(line)
+		if err != nil || index < 0 || index >= len(artifacts) {
+			continue
+		}
+		failed = append(failed, describeAssertion(artifacts[index]))
+	}
+	return failed
+}
+
+// describeAssertion returns a readable form of the assertion, e.g. "target/report.txt matches ^PASSED"
+func describeAssertion(artifact v1alpha1.ArtifactAssertion) string {
+	switch artifact.Check {
+	case v1alpha1.ArtifactCheckNonEmpty:
+		return artifact.Path + " is not empty"
+	case v1alpha1.ArtifactCheckMatches:
+		return artifact.Path + " matches " + artifact.Pattern
+	default:
+		return artifact.Path + " exists"
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestWithAssertions() *v1alpha1.Test {
+	test := newTestForStart()
+	test.Spec.Assertions = &v1alpha1.AssertionsSpec{
+		Artifacts: []v1alpha1.ArtifactAssertion{
+			{
+				Path: "report.txt",
+			},
+			{
+				Path:    "report.txt",
+				Check:   v1alpha1.ArtifactCheckMatches,
+				Pattern: "^PASSED",
+			},
+		},
+	}
+	return test
+}
+
+func TestAssertionsSidecar(t *testing.T) {
+	action := startAction{}
+	test := newTestWithAssertions()
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+
+	assert.Len(t, pod.Spec.Containers, 2)
+	runner := pod.Spec.Containers[0]
+	sidecar := pod.Spec.Containers[1]
+	assert.Equal(t, assertionsContainerName, sidecar.Name)
+	assert.Equal(t, runner.WorkingDir, sidecar.WorkingDir)
+	assert.Equal(t, runner.VolumeMounts, sidecar.VolumeMounts)
+	assert.Equal(t, []string{"exists", "report.txt", "", "matches", "report.txt", "^PASSED"}, sidecar.Args)
+	assert.Equal(t, "run", runner.Command[3])
+	// The sidecar tells when the runner has been killed from its processes
+	assert.True(t, *pod.Spec.ShareProcessNamespace)
+}
+
+func TestFailedAssertions(t *testing.T) {
+	test := newTestWithAssertions()
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodSucceeded,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: testContainerName,
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{},
+					},
+				},
+				{
+					Name: assertionsContainerName,
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							Message: "1\n",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	evaluatePod(test, pod)
+
+	assert.Equal(t, v1alpha1.TestPhaseFailed, test.Status.Phase)
+	assert.Equal(t, v1alpha1.TestReasonAssertionFailed, test.Status.Reason)
+	assert.Equal(t, []string{"report.txt matches ^PASSED"}, test.Status.FailedAssertions)
+	assert.Equal(t, "assertions not met: report.txt matches ^PASSED", test.Status.Message)
+}
+
+func TestValidateAssertions(t *testing.T) {
+	test := newTestWithAssertions()
+	message, err := validateAssertions(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+
+	test.Spec.Assertions.Artifacts[1].Pattern = "("
+	message, err = validateAssertions(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Contains(t, message, "invalid pattern")
+
+	// Perl classes are not understood by grep -E
+	test.Spec.Assertions.Artifacts[1].Pattern = `^PASSED: \d+`
+	message, err = validateAssertions(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Contains(t, message, "POSIX extended regular expression")
+
+	test.Spec.Assertions.Artifacts[1].Pattern = "^PASSED: [0-9]+ (tests|scenarios)$"
+	message, err = validateAssertions(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+}
@@ -0,0 +1,85 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+)
+
+// asyncResultTTL is how long the result of a background check is kept when it is not collected, e.g. because its test
+// has been deleted
+const asyncResultTTL = 5 * time.Minute
+
+// asyncChecks runs the checks that would block the reconciliation, e.g. network round-trips, in the background. The
+// test is kept pending, and reconciled again after the pending poll interval, until the result is collected.
+type asyncChecks struct {
+	lock    sync.Mutex
+	results map[string]*asyncResult
+}
+
+type asyncResult struct {
+	done      bool
+	completed time.Time
+	message   string
+	err       error
+}
+
+var backgroundChecks = &asyncChecks{results: make(map[string]*asyncResult)}
+
+// asyncCheckKey identifies a check of the current run of the test
+func asyncCheckKey(test *v1alpha1.Test, check string) string {
+	return string(test.UID) + "/" + test.Status.TestID + "/" + check
+}
+
+// run returns the result of the check with the given key, starting it in the background on the first call, and
+// whether it has completed. A completed result is returned once, the check being run again on the next call.
+func (c *asyncChecks) run(key string, check func() (string, error)) (string, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	for k, result := range c.results {
+		if result.done && now.Sub(result.completed) > asyncResultTTL {
+			delete(c.results, k)
+		}
+	}
+
+	if result, ok := c.results[key]; ok {
+		if !result.done {
+			return "", false, nil
+		}
+		delete(c.results, key)
+		return result.message, true, result.err
+	}
+
+	result := &asyncResult{}
+	c.results[key] = result
+	go func() {
+		message, err := check()
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		result.message = message
+		result.err = err
+		result.completed = time.Now()
+		result.done = true
+	}()
+	return "", false, nil
+}
@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncChecks(t *testing.T) {
+	checks := &asyncChecks{results: make(map[string]*asyncResult)}
+	release := make(chan struct{})
+	check := func() (string, error) {
+		<-release
+		return "not found", nil
+	}
+
+	_, done, err := checks.run("test/image", check)
+	assert.Nil(t, err)
+	assert.False(t, done)
+
+	// Still in progress
+	_, done, _ = checks.run("test/image", check)
+	assert.False(t, done)
+
+	close(release)
+	message := ""
+	for i := 0; i < 100 && !done; i++ {
+		time.Sleep(10 * time.Millisecond)
+		message, done, err = checks.run("test/image", check)
+	}
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Equal(t, "not found", message)
+
+	// The result has been collected, the check is run again
+	_, done, _ = checks.run("test/image", check)
+	assert.False(t, done)
+}
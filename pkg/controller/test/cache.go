@@ -0,0 +1,122 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	dependencyCachePath       = "/var/yaks/cache"
+	dependencyCacheVolumeName = "dependency-cache"
+	// dependencyCacheMavenRepo is the local Maven repository, shared by Maven, JBang and the resolvers of the runner
+	dependencyCacheMavenRepo = dependencyCachePath + "/m2/repository"
+	dependencyCacheJBangDir  = dependencyCachePath + "/jbang"
+)
+
+// dependencyCacheSubPath returns the directory of the cache claim mounted into the runner of the test: a directory of
+// its own in the Test scope, shared by the runs of the same logical test, i.e. with the same yaks.dev/test-name label,
+// or the whole claim in the Shared scope
+func dependencyCacheSubPath(test *v1alpha1.Test) string {
+	if config.GetDependencyCacheScope() == config.DependencyCacheScopeShared {
+		return ""
+	}
+	if name := test.Labels[v1alpha1.TestNameLabel]; name != "" {
+		return "tests/" + name
+	}
+	return "tests/" + test.Name
+}
+
+// applyDependencyCache mounts the operator wide DEPENDENCY_CACHE_CLAIM into the test container, and points Maven,
+// JBang and the JVM of the runner to it, so that the dependencies downloaded by a run are reused by the next ones
+func applyDependencyCache(test *v1alpha1.Test, pod *v1.Pod) {
+	claim := config.GetDependencyCacheClaim()
+	if claim == "" {
+		return
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: dependencyCacheVolumeName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claim,
+			},
+		},
+	})
+
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      dependencyCacheVolumeName,
+		MountPath: dependencyCachePath,
+		SubPath:   dependencyCacheSubPath(test),
+	})
+	envvar.SetVal(&container.Env, "YAKS_DEPENDENCY_CACHE", dependencyCachePath)
+	envvar.SetVal(&container.Env, "JBANG_REPO", dependencyCacheMavenRepo)
+	envvar.SetVal(&container.Env, "JBANG_CACHE_DIR", dependencyCacheJBangDir)
+	mavenOptions := "-Dmaven.repo.local=" + dependencyCacheMavenRepo
+	if current := envvar.Get(container.Env, "MAVEN_OPTS"); current != nil && current.Value != "" {
+		mavenOptions = current.Value + " " + mavenOptions
+	}
+	envvar.SetVal(&container.Env, "MAVEN_OPTS", mavenOptions)
+	appendJavaOptions(container, "-Dmaven.repo.local="+dependencyCacheMavenRepo)
+}
+
+func validateDependencyCache(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	claim := config.GetDependencyCacheClaim()
+	if claim == "" {
+		return "", nil
+	}
+	pvc, err := sharedClaims.get(ctx, c, test.Namespace, claim, time.Now())
+	if err != nil {
+		return "", err
+	} else if pvc == nil {
+		return fmt.Sprintf("dependency cache claim %s does not exist", claim), nil
+	}
+	return "", nil
+}
+
+// checkDependencyCache warns with the DependencyCacheShared condition when the dependency cache claim is not
+// ReadWriteMany, in which case concurrent tests scheduled on other nodes cannot mount it and stay pending
+func checkDependencyCache(ctx context.Context, c client.Client, test *v1alpha1.Test) error {
+	claim := config.GetDependencyCacheClaim()
+	if claim == "" {
+		return nil
+	}
+	pvc, err := sharedClaims.get(ctx, c, test.Namespace, claim, time.Now())
+	if err != nil {
+		return err
+	} else if pvc == nil {
+		return k8serrors.NewNotFound(v1.Resource("persistentvolumeclaims"), claim)
+	}
+	if isReadWriteMany(pvc) {
+		setCondition(test, v1alpha1.TestConditionDependencyCacheShared, v1.ConditionTrue, "ReadWriteMany", "")
+		return nil
+	}
+	Log.ForTest(test).Info("Dependency cache claim is not ReadWriteMany, concurrent tests on other nodes cannot mount it", "claim", claim)
+	setCondition(test, v1alpha1.TestConditionDependencyCacheShared, v1.ConditionFalse, "NotReadWriteMany",
+		fmt.Sprintf("dependency cache claim %s is not ReadWriteMany, concurrent tests scheduled on other nodes cannot mount it", claim))
+	return nil
+}
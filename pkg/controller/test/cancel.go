@@ -0,0 +1,84 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewCancelAction creates a new cancel action
+func NewCancelAction() Action {
+	return &cancelAction{}
+}
+
+type cancelAction struct {
+	baseAction
+}
+
+// Name returns a common name of the action
+func (action *cancelAction) Name() string {
+	return "cancel"
+}
+
+// CanHandle tells whether this action can handle the test
+func (action *cancelAction) CanHandle(test *v1alpha1.Test) bool {
+	return isCancelled(test) &&
+		(test.Status.Phase == v1alpha1.TestPhasePending || test.Status.Phase == v1alpha1.TestPhaseRunning)
+}
+
+// Handle handles the test
+func (action *cancelAction) Handle(ctx context.Context, test *v1alpha1.Test) (*v1alpha1.Test, error) {
+	if test.Status.Phase == v1alpha1.TestPhaseRunning {
+		if err := deleteWorkload(action.client, test); err != nil {
+			return nil, err
+		}
+	}
+
+	action.L.Info("Test cancelled")
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonCancelled
+	test.Status.WaitingFor = ""
+	test.Status.Message = "test cancelled"
+	return test, nil
+}
+
+// deleteWorkload deletes the pod, or the Job and its pods, running the test
+func deleteWorkload(c client.Client, test *v1alpha1.Test) error {
+	name := TestPodNameFor(test)
+	var err error
+	if workloadFor(test) == v1alpha1.WorkloadTypeJob {
+		propagation := metav1.DeletePropagationBackground
+		err = c.BatchV1().Jobs(test.Namespace).Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	} else {
+		err = c.CoreV1().Pods(test.Namespace).Delete(name, &metav1.DeleteOptions{})
+	}
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// isCancelled tells whether the current run of the test has been requested to be cancelled
+func isCancelled(test *v1alpha1.Test) bool {
+	return test.Status.TestID != "" && test.Annotations[v1alpha1.TestCancelAnnotation] == test.Status.TestID
+}
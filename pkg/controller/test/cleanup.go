@@ -0,0 +1,149 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/pkg/errors"
+)
+
+// cleanupRule decides how long the completed tests matching all its conditions are kept. Rules are written as
+// <condition>[&<condition>...] -> <ttl|keep> and separated by semicolons, e.g.
+// "label:investigate -> keep; phase:Failed -> 1d; phase:Passed -> 0s".
+type cleanupRule struct {
+	conditions []cleanupCondition
+	ttl        time.Duration
+	keep       bool
+}
+
+// cleanupCondition matches the phase ("phase:Failed"), a label ("label:investigate" or "label:team=payments") or an
+// annotation ("annotation:owner" or "annotation:owner=qa") of a test. The "*" condition matches any test.
+type cleanupCondition struct {
+	kind  string
+	key   string
+	value string
+	any   bool
+}
+
+func (c cleanupCondition) matches(test *v1alpha1.Test) bool {
+	var values map[string]string
+	switch c.kind {
+	case "*":
+		return true
+	case "phase":
+		return strings.EqualFold(string(test.Status.Phase), c.key)
+	case "label":
+		values = test.Labels
+	case "annotation":
+		values = test.Annotations
+	}
+	value, ok := values[c.key]
+	return ok && (c.any || value == c.value)
+}
+
+func (r cleanupRule) matches(test *v1alpha1.Test) bool {
+	for _, condition := range r.conditions {
+		if !condition.matches(test) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCleanupRules parses a semicolon separated list of cleanup rules, evaluated in order
+func parseCleanupRules(value string) ([]cleanupRule, error) {
+	rules := make([]cleanupRule, 0)
+	for _, definition := range strings.Split(value, ";") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+		parts := strings.Split(definition, "->")
+		if len(parts) != 2 {
+			return nil, errors.New(fmt.Sprintf("invalid cleanup rule %q, expected <conditions> -> <ttl|keep>", definition))
+		}
+		rule := cleanupRule{}
+		for _, condition := range strings.Split(parts[0], "&") {
+			c, err := parseCleanupCondition(strings.TrimSpace(condition))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid cleanup rule %q", definition)
+			}
+			rule.conditions = append(rule.conditions, c)
+		}
+		action := strings.TrimSpace(parts[1])
+		if action == "keep" {
+			rule.keep = true
+		} else {
+			ttl, err := config.ParseTTL(action)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid cleanup rule %q", definition)
+			}
+			rule.ttl = ttl
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseCleanupCondition(value string) (cleanupCondition, error) {
+	if value == "*" {
+		return cleanupCondition{kind: "*"}, nil
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return cleanupCondition{}, errors.New(fmt.Sprintf("invalid condition %q", value))
+	}
+	condition := cleanupCondition{kind: parts[0]}
+	switch condition.kind {
+	case "phase":
+		condition.key = parts[1]
+	case "label", "annotation":
+		if kv := strings.SplitN(parts[1], "=", 2); len(kv) == 2 {
+			condition.key, condition.value = kv[0], kv[1]
+		} else {
+			condition.key, condition.any = parts[1], true
+		}
+	default:
+		return cleanupCondition{}, errors.New(fmt.Sprintf("unknown condition %q, expected phase, label or annotation", condition.kind))
+	}
+	return condition, nil
+}
+
+// cleanupRuleFor returns the first rule of the given rule set matching the test. The name of the source of the rules is
+// used to log invalid rule sets, that are ignored.
+func cleanupRuleFor(test *v1alpha1.Test, source string, value string) (cleanupRule, bool) {
+	if value == "" {
+		return cleanupRule{}, false
+	}
+	rules, err := parseCleanupRules(value)
+	if err != nil {
+		Log.ForTest(test).Info("Invalid cleanup rules, ignoring them", "source", source, "error", err.Error())
+		return cleanupRule{}, false
+	}
+	for _, rule := range rules {
+		if rule.matches(test) {
+			return rule, true
+		}
+	}
+	return cleanupRule{}, false
+}
@@ -0,0 +1,131 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// testCounts --
+type testCounts struct {
+	running int
+	queued  int
+	// queuedBefore is the number of tests queued before the one being counted for, that are admitted first
+	queuedBefore int
+}
+
+// countTests counts the running and queued tests of the watched namespaces that select the operator. The tests are read
+// from the cache of the manager, the ones admitted by the previous reconciliations that the cache has not seen running
+// yet being counted as running.
+func countTests(ctx context.Context, c k8sclient.Reader, test *v1alpha1.Test) (testCounts, error) {
+	tests := v1alpha1.TestList{}
+	if err := c.List(ctx, &k8sclient.ListOptions{}, &tests); err != nil {
+		return testCounts{}, err
+	}
+	return countIn(tests.Items, test, admitted.inFlight(tests.Items, time.Now())), nil
+}
+
+// admissionTimeout is how long an admitted test is counted as running while the cache of the manager has not seen it
+// running yet
+const admissionTimeout = time.Minute
+
+// admissions are the tests admitted by the operator, by UID, with the time they have been admitted at
+type admissions struct {
+	lock    sync.Mutex
+	started map[types.UID]time.Time
+}
+
+// admitted are the tests started by the operator, that is the only one starting the tests it selects
+var admitted = admissions{started: make(map[types.UID]time.Time)}
+
+func (a *admissions) add(uid types.UID, now time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.started[uid] = now
+}
+
+// inFlight returns the admitted tests that are still pending in the given tests, forgetting the other ones
+func (a *admissions) inFlight(tests []v1alpha1.Test, now time.Time) map[types.UID]bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	pending := make(map[types.UID]bool)
+	for i := range tests {
+		if tests[i].Status.Phase == v1alpha1.TestPhasePending {
+			pending[tests[i].UID] = true
+		}
+	}
+	inFlight := make(map[types.UID]bool)
+	for uid, at := range a.started {
+		if pending[uid] && now.Sub(at) < admissionTimeout {
+			inFlight[uid] = true
+		} else {
+			delete(a.started, uid)
+		}
+	}
+	return inFlight
+}
+
+func countIn(tests []v1alpha1.Test, test *v1alpha1.Test, inFlight map[types.UID]bool) testCounts {
+	operatorLabels := config.GetOperatorLabels()
+	selectedOnly := config.ReconcileSelectedTestsOnly(operatorLabels)
+	counts := testCounts{}
+	for i := range tests {
+		other := &tests[i]
+		if !selectsOperator(other, operatorLabels, selectedOnly) {
+			continue
+		}
+		if other.Status.Phase == v1alpha1.TestPhaseRunning || inFlight[other.UID] {
+			counts.running++
+		} else if isQueued(other) {
+			counts.queued++
+			if test != nil && other.UID != test.UID && queuedBefore(other, test) {
+				counts.queuedBefore++
+			}
+		}
+	}
+	return counts
+}
+
+func isQueued(test *v1alpha1.Test) bool {
+	return test.Status.Phase == v1alpha1.TestPhasePending && test.Status.Reason == v1alpha1.TestReasonConcurrencyLimit
+}
+
+// queuedBefore tells whether a test has been pending for longer than another one, so that the queue is first in,
+// first out
+func queuedBefore(test *v1alpha1.Test, other *v1alpha1.Test) bool {
+	since, otherSince := pendingSince(test), pendingSince(other)
+	if since.Equal(&otherSince) {
+		return test.Namespace+"/"+test.Name < other.Namespace+"/"+other.Name
+	}
+	return since.Before(&otherSince)
+}
+
+func pendingSince(test *v1alpha1.Test) metav1.Time {
+	if test.Status.Timings != nil && test.Status.Timings.Pending != nil {
+		return *test.Status.Timings.Pending
+	}
+	return test.CreationTimestamp
+}
@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newQueuedTest(name string, since time.Time) v1alpha1.Test {
+	pending := metav1.NewTime(since)
+	test := newTestForStart()
+	test.Name = name
+	test.UID = types.UID(name)
+	test.Status.Phase = v1alpha1.TestPhasePending
+	test.Status.Reason = v1alpha1.TestReasonConcurrencyLimit
+	test.Status.Timings = &v1alpha1.TestTimings{Pending: &pending}
+	return *test
+}
+
+func TestCountIn(t *testing.T) {
+	now := time.Now()
+	running := *newTestForStart()
+	running.Status.Phase = v1alpha1.TestPhaseRunning
+	first := newQueuedTest("first", now.Add(-2*time.Minute))
+	second := newQueuedTest("second", now.Add(-time.Minute))
+	tests := []v1alpha1.Test{running, second, first}
+
+	counts := countIn(tests, &second, nil)
+	assert.Equal(t, 1, counts.running)
+	assert.Equal(t, 2, counts.queued)
+	assert.Equal(t, 1, counts.queuedBefore)
+
+	counts = countIn(tests, &first, nil)
+	assert.Equal(t, 0, counts.queuedBefore)
+}
+
+func TestAdmittedTestsCountedAsRunning(t *testing.T) {
+	now := time.Now()
+	a := admissions{started: make(map[types.UID]time.Time)}
+	// Admitted, but still seen queued in the cache
+	admittedTest := newQueuedTest("admitted", now.Add(-2*time.Minute))
+	queued := newQueuedTest("queued", now.Add(-time.Minute))
+	tests := []v1alpha1.Test{admittedTest, queued}
+	a.add(admittedTest.UID, now)
+
+	counts := countIn(tests, &queued, a.inFlight(tests, now))
+	assert.Equal(t, 1, counts.running)
+	assert.Equal(t, 1, counts.queued)
+	assert.Equal(t, 0, counts.queuedBefore)
+
+	// Forgotten once the cache has seen it running
+	tests[0].Status.Phase = v1alpha1.TestPhaseRunning
+	assert.Empty(t, a.inFlight(tests, now))
+	assert.Empty(t, a.started)
+
+	// or after the admission timeout
+	tests[0].Status.Phase = v1alpha1.TestPhasePending
+	a.add(admittedTest.UID, now)
+	assert.Empty(t, a.inFlight(tests, now.Add(admissionTimeout)))
+}
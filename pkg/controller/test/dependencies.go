@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/fixture"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// dependencyNameFor returns the name of the dependency as reported in the test status
+func dependencyNameFor(dependency v1alpha1.DependencySpec) string {
+	return strings.ToLower(string(dependency.Kind)) + "/" + dependency.Name
+}
+
+// unreadyDependency returns the first dependency of the test that is not ready, if any, along with its failure when
+// it will not become ready. Dependencies are checked by the fixture provider registered for their kind, that reads
+// them directly from the API server, as they are not watched by the operator. They are neither created nor started by
+// the operator, so they start in no particular order: the declared order only decides which one is reported.
+func unreadyDependency(c client.Client, test *v1alpha1.Test) (string, *fixture.Failure, error) {
+	namespace := targetNamespaceFor(test)
+	for _, dependency := range test.Spec.Dependencies {
+		provider, ok := fixture.ProviderFor(dependency.Kind)
+		if !ok {
+			return "", nil, errors.New("no fixture provider for dependency kind " + string(dependency.Kind))
+		}
+		ready, err := provider.Ready(c, namespace, dependency.Name)
+		if failure, ok := err.(*fixture.Failure); ok {
+			return dependencyNameFor(dependency), failure, nil
+		} else if err != nil && k8serrors.IsNotFound(err) {
+			return dependencyNameFor(dependency), nil, nil
+		} else if err != nil {
+			return "", nil, err
+		}
+		if !ready {
+			return dependencyNameFor(dependency), nil, nil
+		}
+	}
+	return "", nil, nil
+}
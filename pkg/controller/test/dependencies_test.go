@@ -0,0 +1,68 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDependencyDeployment(name string, available int32) *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: available, AvailableReplicas: available},
+	}
+}
+
+func TestUnreadyDependencyInDeclaredOrder(t *testing.T) {
+	c := testutil.NewFakeClient()
+	// The application is ready before its database, the dependencies being started by no one in particular
+	for _, deployment := range []*appsv1.Deployment{newDependencyDeployment("postgres", 0), newDependencyDeployment("my-app", 1)} {
+		_, err := c.AppsV1().Deployments("ns").Create(deployment)
+		assert.Nil(t, err)
+	}
+	test := &v1alpha1.Test{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "hello"},
+		Spec: v1alpha1.TestSpec{
+			Dependencies: []v1alpha1.DependencySpec{
+				{Kind: v1alpha1.DependencyKindDeployment, Name: "postgres"},
+				{Kind: v1alpha1.DependencyKindDeployment, Name: "my-app"},
+				{Kind: v1alpha1.DependencyKindDeployment, Name: "missing"},
+			},
+		},
+	}
+
+	name, failure, err := unreadyDependency(c, test)
+	assert.Nil(t, err)
+	assert.Nil(t, failure)
+	assert.Equal(t, "deployment/postgres", name)
+
+	_, err = c.AppsV1().Deployments("ns").Update(newDependencyDeployment("postgres", 1))
+	assert.Nil(t, err)
+	name, _, err = unreadyDependency(c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "deployment/missing", name)
+}
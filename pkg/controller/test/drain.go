@@ -0,0 +1,83 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"sync"
+	"time"
+)
+
+// inFlightTracker keeps track of the reconcile requests being processed, so that they can be
+// completed before the operator shuts down
+type inFlightTracker struct {
+	lock     sync.Mutex
+	count    int
+	draining bool
+}
+
+var inFlight inFlightTracker
+
+// begin registers a new reconcile request, returning false if the controller is draining
+func (t *inFlightTracker) begin() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.draining {
+		return false
+	}
+	t.count++
+	return true
+}
+
+// end marks a reconcile request registered with begin as completed
+func (t *inFlightTracker) end() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.count--
+}
+
+func (t *inFlightTracker) size() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.count
+}
+
+// Drain stops the controller from accepting new reconcile requests and waits for the ones in progress
+// to complete, up to the given timeout. It returns false if the timeout expires first.
+func Drain(timeout time.Duration) bool {
+	inFlight.lock.Lock()
+	inFlight.draining = true
+	count := inFlight.count
+	inFlight.lock.Unlock()
+
+	Log.Info("Draining test controller", "in-flight", count, "timeout", timeout.String())
+
+	deadline := time.Now().Add(timeout)
+	for inFlight.size() > 0 {
+		if time.Now().After(deadline) {
+			Log.Info("Timeout expired while draining test controller", "in-flight", inFlight.size())
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	Log.Info("Test controller drained")
+	return true
+}
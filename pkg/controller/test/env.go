@@ -0,0 +1,158 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// envPlaceholder matches the ${NAME} placeholders of the runtime env values
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+const clusterDomainPlaceholder = "CLUSTER_DOMAIN"
+
+// applyEnv adds the runtime env of the test to the runner container, overriding the defaults of the instance
+func applyEnv(test *v1alpha1.Test, pod *v1.Pod) {
+	container := &pod.Spec.Containers[0]
+	for _, env := range test.Spec.Runtime.Env {
+		envvar.SetVar(&container.Env, *env.DeepCopy())
+	}
+}
+
+// testUIDAnnotation exposes the UID of the test on its runner pod, to the downward API
+const testUIDAnnotation = "yaks.dev/test-uid"
+
+// applyTestMetadata exposes the name, namespace and UID of the test to the runner container through the downward
+// API, unless the variables are already set by the runtime env of the test or the defaults of the instance
+func applyTestMetadata(test *v1alpha1.Test, pod *v1.Pod) {
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[testUIDAnnotation] = string(test.UID)
+
+	container := &pod.Spec.Containers[0]
+	for _, env := range []struct{ name, path string }{
+		{"TEST_NAME", "metadata.labels['yaks.dev/test']"},
+		{"TEST_NAMESPACE", "metadata.namespace"},
+		{"TEST_UID", "metadata.annotations['" + testUIDAnnotation + "']"},
+	} {
+		if envvar.Get(container.Env, env.name) == nil {
+			envvar.SetValFrom(&container.Env, env.name, env.path)
+		}
+	}
+}
+
+// resolveEnvTemplates replaces the placeholders of the runtime env values of the test in the runner container. The
+// cluster domain is only looked up when referenced, unknown placeholders are left intact.
+func resolveEnvTemplates(test *v1alpha1.Test, pod *v1.Pod) {
+	values := map[string]string{
+		"NAMESPACE":        test.Namespace,
+		"TARGET_NAMESPACE": targetNamespaceFor(test),
+		"TEST_NAME":        test.Name,
+	}
+	container := &pod.Spec.Containers[0]
+	for _, env := range test.Spec.Runtime.Env {
+		resolved := envvar.Get(container.Env, env.Name)
+		if resolved == nil || resolved.Value == "" {
+			continue
+		}
+		resolved.Value = envPlaceholder.ReplaceAllStringFunc(resolved.Value, func(placeholder string) string {
+			name := envPlaceholder.FindStringSubmatch(placeholder)[1]
+			if _, ok := values[name]; !ok && name == clusterDomainPlaceholder {
+				values[name] = clusterDomainFor(test)
+			}
+			if value, ok := values[name]; ok && value != "" {
+				return value
+			}
+			Log.ForTest(test).Info("Leaving unresolved placeholder in env value", "env", env.Name, "placeholder", placeholder)
+			return placeholder
+		})
+	}
+}
+
+// clusterDomainRetryInterval is how long the cluster domain is not looked up again after a failed lookup
+const clusterDomainRetryInterval = time.Minute
+
+// clusterDomainCache keeps the domain of the cluster ingress once it has been looked up, failed lookups being retried
+// after an interval
+type clusterDomainCache struct {
+	lock   sync.Mutex
+	domain string
+	failed time.Time
+}
+
+// get returns the cached domain, looking it up when unknown and the last failed lookup is old enough
+func (c *clusterDomainCache) get(lookup func() (string, error), now time.Time) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.domain != "" || !c.failed.IsZero() && now.Sub(c.failed) < clusterDomainRetryInterval {
+		return c.domain
+	}
+	domain, err := lookup()
+	if err != nil {
+		// Not an OpenShift cluster, or the operator is not allowed to read its ingress configuration
+		Log.Info("Cannot look up the cluster domain, set CLUSTER_DOMAIN on the operator", "error", err.Error())
+		c.failed = now
+		return ""
+	}
+	c.domain = domain
+	return domain
+}
+
+// clusterDomain is the domain of the cluster ingress read from the ingress configuration of OpenShift
+var clusterDomain clusterDomainCache
+
+// clusterDomainFor returns the domain of the cluster ingress, from the operator configuration or from the ingress
+// configuration of OpenShift, or an empty string when unknown
+func clusterDomainFor(test *v1alpha1.Test) string {
+	if domain := config.GetClusterDomain(); domain != "" {
+		return domain
+	}
+	return clusterDomain.get(lookupClusterDomain, time.Now())
+}
+
+// lookupClusterDomain reads the domain of the cluster ingress from the cluster-scoped ingress configuration of
+// OpenShift, that only the cluster-wide operator is allowed to read
+func lookupClusterDomain() (string, error) {
+	dynamicClient, err := sharedDynamicClient()
+	if err != nil {
+		Log.Error(err, "Cannot create the client looking up the cluster domain")
+		return "", err
+	}
+	gvr := schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "ingresses"}
+	ingress, err := dynamicClient.Resource(gvr).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	domain, _, _ := unstructured.NestedString(ingress.Object, "spec", "domain")
+	if domain == "" {
+		return "", errors.New("no domain in the ingress configuration of the cluster")
+	}
+	return domain, nil
+}
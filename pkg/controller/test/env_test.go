@@ -0,0 +1,159 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnvTemplates(t *testing.T) {
+	defer os.Unsetenv("CLUSTER_DOMAIN")
+	assert.Nil(t, os.Setenv("CLUSTER_DOMAIN", "apps.example.com"))
+
+	action := startAction{}
+	test := newTestForStart()
+	test.Spec.Runtime.Env = []v1.EnvVar{
+		{Name: "APP_URL", Value: "https://${TEST_NAME}-${NAMESPACE}.${CLUSTER_DOMAIN}/api"},
+		{Name: "UNKNOWN", Value: "${UNKNOWN} and $NAMESPACE"},
+		{Name: "PLAIN", Value: "value"},
+	}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	env := pod.Spec.Containers[0].Env
+
+	assert.Equal(t, "https://hello-ns.apps.example.com/api", envvar.Get(env, "APP_URL").Value)
+	assert.Equal(t, "${UNKNOWN} and $NAMESPACE", envvar.Get(env, "UNKNOWN").Value)
+	assert.Equal(t, "value", envvar.Get(env, "PLAIN").Value)
+	// The spec of the test is left untouched
+	assert.Equal(t, "https://${TEST_NAME}-${NAMESPACE}.${CLUSTER_DOMAIN}/api", test.Spec.Runtime.Env[0].Value)
+}
+
+func TestEnvOverridesInstanceDefaults(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	test.Spec.Runtime.Env = []v1.EnvVar{{Name: "LEVEL", Value: "debug"}}
+	instance := &v1alpha1.Instance{
+		Spec: v1alpha1.InstanceSpec{
+			Config: v1alpha1.InstanceConfig{
+				Env: []v1.EnvVar{{Name: "LEVEL", Value: "info"}},
+			},
+		},
+	}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, instance)
+
+	assert.Equal(t, "debug", envvar.Get(pod.Spec.Containers[0].Env, "LEVEL").Value)
+}
+
+func TestInstanceTimeout(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	instance := &v1alpha1.Instance{
+		Spec: v1alpha1.InstanceSpec{
+			Config: v1alpha1.InstanceConfig{
+				Timeout: "10m",
+			},
+		},
+	}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, instance)
+	assert.Equal(t, int64(600), *pod.Spec.ActiveDeadlineSeconds)
+
+	// Debug sessions are kept alive on top of the timeout
+	test.Spec.Runtime.Debug = &v1alpha1.DebugSpec{Timeout: "5m"}
+	pod = action.newTestingPod(context.TODO(), test, cm, instance)
+	assert.Equal(t, int64(900), *pod.Spec.ActiveDeadlineSeconds)
+
+	// Invalid timeouts are ignored
+	instance.Spec.Config.Timeout = "forever"
+	pod = action.newTestingPod(context.TODO(), test, cm, instance)
+	assert.Nil(t, pod.Spec.ActiveDeadlineSeconds)
+}
+
+func TestTestMetadataEnv(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	test.Spec.Runtime.Env = []v1.EnvVar{{Name: "TEST_NAME", Value: "custom"}}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+
+	env := pod.Spec.Containers[0].Env
+	assert.Equal(t, "custom", envvar.Get(env, "TEST_NAME").Value)
+	assert.Nil(t, envvar.Get(env, "TEST_NAME").ValueFrom)
+	assert.Equal(t, "metadata.namespace", envvar.Get(env, "TEST_NAMESPACE").ValueFrom.FieldRef.FieldPath)
+	assert.Equal(t, "metadata.annotations['yaks.dev/test-uid']", envvar.Get(env, "TEST_UID").ValueFrom.FieldRef.FieldPath)
+	assert.Equal(t, "a1b2c3", pod.Annotations[testUIDAnnotation])
+}
+
+func TestInstanceNameFor(t *testing.T) {
+	test := newTestForStart()
+	assert.Equal(t, "", instanceNameFor(test))
+
+	test.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "suite"},
+		{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: v1alpha1.InstanceKind, Name: "payments"},
+	}
+	assert.Equal(t, "payments", instanceNameFor(test))
+
+	test.Spec.Instance = "checkout"
+	assert.Equal(t, "checkout", instanceNameFor(test))
+}
+
+func TestClusterDomainRetriedAfterFailure(t *testing.T) {
+	cache := clusterDomainCache{}
+	lookups := 0
+	domain := ""
+	lookup := func() (string, error) {
+		lookups++
+		if domain == "" {
+			return "", errors.New("forbidden")
+		}
+		return domain, nil
+	}
+	now := time.Now()
+
+	assert.Equal(t, "", cache.get(lookup, now))
+	assert.Equal(t, 1, lookups)
+
+	// Not looked up again right away
+	domain = "apps.example.com"
+	assert.Equal(t, "", cache.get(lookup, now.Add(time.Second)))
+	assert.Equal(t, 1, lookups)
+
+	assert.Equal(t, "apps.example.com", cache.get(lookup, now.Add(clusterDomainRetryInterval)))
+	assert.Equal(t, 2, lookups)
+
+	// Kept once found
+	assert.Equal(t, "apps.example.com", cache.get(lookup, now.Add(time.Hour)))
+	assert.Equal(t, 2, lookups)
+}
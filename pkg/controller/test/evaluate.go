@@ -19,8 +19,10 @@ package test
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/report"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,23 +50,157 @@ func (action *evaluateAction) CanHandle(build *v1alpha1.Test) bool {
 
 // Handle handles the test
 func (action *evaluateAction) Handle(ctx context.Context, test *v1alpha1.Test) (*v1alpha1.Test, error) {
-	phase, err := action.getTestPodPhase(ctx, test)
+	if workloadFor(test) == v1alpha1.WorkloadTypeJob {
+		return action.evaluateJob(ctx, test)
+	}
+
+	pod, err := action.getTestPod(ctx, test)
 	if err != nil && k8serrors.IsNotFound(err) {
 		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonRunnerNotFound
+		test.Status.Message = "test pod " + TestPodNameFor(test) + " not found"
+		return test, nil
 	} else if err != nil {
 		return nil, err
 	}
 
-	if phase == v1.PodSucceeded {
-		test.Status.Phase = v1alpha1.TestPhasePassed
-	} else if phase == v1.PodFailed {
-		test.Status.Phase = v1alpha1.TestPhaseFailed
+	if setImagePullError(test, pod) {
+		if err := deleteWorkload(action.client, test); err != nil {
+			return nil, err
+		}
+		return test, nil
+	}
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return test, nil
 	}
 
+	evaluatePod(test, pod)
 	return test, nil
 }
 
-func (action *evaluateAction) getTestPodPhase(ctx context.Context, test *v1alpha1.Test) (v1.PodPhase, error) {
+// evaluatePod sets the test result from the terminated test pod
+func evaluatePod(test *v1alpha1.Test, pod *v1.Pod) {
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonRunnerError
+	if terminated := testContainerTerminatedState(pod); terminated != nil {
+		exitCode := terminated.ExitCode
+		test.Status.ExitCode = &exitCode
+		test.Status.Phase = phaseForExitCode(exitCode)
+		test.Status.Reason = reasonForTermination(terminated)
+		test.Status.Results = parseResults(test, terminated.Message)
+		if test.Status.Phase != v1alpha1.TestPhasePassed {
+			test.Status.Message = strings.TrimSpace(terminated.Message)
+			if test.Status.Message == "" {
+				test.Status.Message = terminated.Reason
+			}
+		}
+	} else if pod.Status.Phase == v1.PodSucceeded {
+		test.Status.Phase = v1alpha1.TestPhasePassed
+		test.Status.Reason = ""
+	} else if pod.Status.Reason == podReasonDeadlineExceeded {
+		test.Status.Reason = v1alpha1.TestReasonTimeout
+		test.Status.Message = pod.Status.Message
+	}
+
+	if failed := failedAssertions(test, pod); len(failed) > 0 {
+		test.Status.FailedAssertions = failed
+		if test.Status.Phase == v1alpha1.TestPhasePassed {
+			test.Status.Phase = v1alpha1.TestPhaseFailed
+			test.Status.Reason = v1alpha1.TestReasonAssertionFailed
+			test.Status.Message = "assertions not met: " + strings.Join(failed, ", ")
+		}
+	}
+}
+
+// podReasonDeadlineExceeded is the reason of the pods and Jobs stopped after their active deadline
+const podReasonDeadlineExceeded = "DeadlineExceeded"
+
+// reasonForTermination returns the reason code of a test whose runner container has terminated
+func reasonForTermination(terminated *v1.ContainerStateTerminated) v1alpha1.TestReason {
+	switch {
+	case terminated.ExitCode == exitCodePassed:
+		return ""
+	case terminated.Reason == "OOMKilled":
+		return v1alpha1.TestReasonOutOfMemory
+	case terminated.ExitCode == exitCodeFailed:
+		return v1alpha1.TestReasonScenarioFailed
+	default:
+		return v1alpha1.TestReasonRunnerError
+	}
+}
+
+// imagePullFailureReasons are the waiting reasons of the test container whose image cannot be pulled. ErrImagePull is
+// left out as it is often transient, the kubelet retries the pull and backs off when it keeps failing.
+var imagePullFailureReasons = map[string]bool{
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// setImagePullError sets the test in error when the image of the test container cannot be pulled, as the runner
+// would otherwise stay pending forever. The caller deletes the runner so that it does not start once the pull succeeds.
+func setImagePullError(test *v1alpha1.Test, pod *v1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != testContainerName || status.State.Waiting == nil {
+			continue
+		}
+		if waiting := status.State.Waiting; imagePullFailureReasons[waiting.Reason] {
+			test.Status.Phase = v1alpha1.TestPhaseError
+			test.Status.Reason = v1alpha1.TestReasonImagePullError
+			test.Status.Message = "cannot pull the image " + status.Image + ": " + waiting.Message
+			return true
+		}
+	}
+	return false
+}
+
+// parseResults extracts the scenario results from the termination message, in the result format of the test
+func parseResults(test *v1alpha1.Test, message string) []v1alpha1.ScenarioResult {
+	parser, ok := report.ResultParserFor(test.Spec.Runtime.ResultFormat)
+	if !ok {
+		return nil
+	}
+	results, err := parser.Parse(message)
+	if err != nil {
+		Log.ForTest(test).Info("Cannot parse the test results", "format", test.Spec.Runtime.ResultFormat, "error", err.Error())
+		return nil
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return results
+}
+
+// Exit codes of the test runner
+const (
+	exitCodePassed int32 = 0
+	// exitCodeFailed is returned when some test scenarios have failed
+	exitCodeFailed int32 = 1
+)
+
+// phaseForExitCode tells apart failed tests from runner errors (e.g. exceptions, killed or out of memory containers)
+func phaseForExitCode(exitCode int32) v1alpha1.TestPhase {
+	switch exitCode {
+	case exitCodePassed:
+		return v1alpha1.TestPhasePassed
+	case exitCodeFailed:
+		return v1alpha1.TestPhaseFailed
+	default:
+		return v1alpha1.TestPhaseError
+	}
+}
+
+// testContainerTerminatedState returns the terminated state of the test container, that contains its exit code and the
+// message written on termination with the test results
+func testContainerTerminatedState(pod *v1.Pod) *v1.ContainerStateTerminated {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == testContainerName && status.State.Terminated != nil {
+			return status.State.Terminated
+		}
+	}
+	return nil
+}
+
+func (action *evaluateAction) getTestPod(ctx context.Context, test *v1alpha1.Test) (*v1.Pod, error) {
 	pod := v1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Pod",
@@ -80,7 +216,7 @@ func (action *evaluateAction) getTestPodPhase(ctx context.Context, test *v1alpha
 		Name:      TestPodNameFor(test),
 	}
 	if err := action.client.Get(ctx, key, &pod); err != nil {
-		return "", err
+		return nil, err
 	}
-	return pod.Status.Phase, nil
+	return &pod, nil
 }
@@ -0,0 +1,137 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTerminatedPod(phase v1.PodPhase, terminated v1.ContainerStateTerminated) *v1.Pod {
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: testContainerName,
+					State: v1.ContainerState{
+						Terminated: &terminated,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEvaluatePodReasons(t *testing.T) {
+	cases := []struct {
+		name    string
+		pod     *v1.Pod
+		phase   v1alpha1.TestPhase
+		reason  v1alpha1.TestReason
+		message string
+	}{
+		{
+			name:  "passed",
+			pod:   newTerminatedPod(v1.PodSucceeded, v1.ContainerStateTerminated{ExitCode: 0}),
+			phase: v1alpha1.TestPhasePassed,
+		},
+		{
+			name:    "scenario failed",
+			pod:     newTerminatedPod(v1.PodFailed, v1.ContainerStateTerminated{ExitCode: 1, Message: "1 scenario failed\n"}),
+			phase:   v1alpha1.TestPhaseFailed,
+			reason:  v1alpha1.TestReasonScenarioFailed,
+			message: "1 scenario failed",
+		},
+		{
+			name:    "out of memory",
+			pod:     newTerminatedPod(v1.PodFailed, v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}),
+			phase:   v1alpha1.TestPhaseError,
+			reason:  v1alpha1.TestReasonOutOfMemory,
+			message: "OOMKilled",
+		},
+		{
+			name:    "runner error",
+			pod:     newTerminatedPod(v1.PodFailed, v1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"}),
+			phase:   v1alpha1.TestPhaseError,
+			reason:  v1alpha1.TestReasonRunnerError,
+			message: "Error",
+		},
+		{
+			name: "deadline exceeded",
+			pod: &v1.Pod{
+				Status: v1.PodStatus{
+					Phase:   v1.PodFailed,
+					Reason:  "DeadlineExceeded",
+					Message: "Pod was active on the node longer than the specified deadline",
+				},
+			},
+			phase:   v1alpha1.TestPhaseError,
+			reason:  v1alpha1.TestReasonTimeout,
+			message: "Pod was active on the node longer than the specified deadline",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test := newTestForStart()
+			evaluatePod(test, c.pod)
+			assert.Equal(t, c.phase, test.Status.Phase)
+			assert.Equal(t, c.reason, test.Status.Reason)
+			assert.Equal(t, c.message, test.Status.Message)
+		})
+	}
+}
+
+func TestSetImagePullError(t *testing.T) {
+	test := newTestForStart()
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  testContainerName,
+					Image: "yaks/yaks:missing",
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{
+							Reason:  "ImagePullBackOff",
+							Message: "Back-off pulling image",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	assert.True(t, setImagePullError(test, pod))
+	assert.Equal(t, v1alpha1.TestPhaseError, test.Status.Phase)
+	assert.Equal(t, v1alpha1.TestReasonImagePullError, test.Status.Reason)
+	assert.Equal(t, "cannot pull the image yaks/yaks:missing: Back-off pulling image", test.Status.Message)
+
+	test = newTestForStart()
+	pod.Status.ContainerStatuses[0].State.Waiting.Reason = "ErrImagePull"
+	assert.False(t, setImagePullError(test, pod))
+	assert.Equal(t, v1alpha1.IntegrationTestPhaseNone, test.Status.Phase)
+
+	pod.Status.ContainerStatuses[0].State.Waiting.Reason = "ContainerCreating"
+	assert.False(t, setImagePullError(test, pod))
+	assert.Equal(t, v1alpha1.IntegrationTestPhaseNone, test.Status.Phase)
+}
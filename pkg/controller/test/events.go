@@ -0,0 +1,81 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the events recorded on the tests for their lifecycle transitions
+const (
+	eventReasonStarted = "Started"
+	eventReasonPassed  = "Passed"
+	eventReasonFailed  = "Failed"
+	eventReasonError   = "Error"
+	eventReasonSkipped = "Skipped"
+	eventReasonRetried = "Retried"
+	eventReasonCleaned = "Cleaned"
+)
+
+// lifecycleEvent tells the type, reason and message of the event recorded when the test transitions from the given
+// phase, and false when the transition is not worth an event
+func lifecycleEvent(test *v1alpha1.Test, from v1alpha1.TestPhase) (string, string, string, bool) {
+	message := test.Status.Message
+	switch test.Status.Phase {
+	case v1alpha1.TestPhaseRunning:
+		return v1.EventTypeNormal, eventReasonStarted, "Test runner started", true
+	case v1alpha1.TestPhasePassed:
+		if message == "" {
+			message = "Test passed"
+		}
+		return v1.EventTypeNormal, eventReasonPassed, message, true
+	case v1alpha1.TestPhaseFailed:
+		if message == "" {
+			message = "Test failed"
+		}
+		return v1.EventTypeWarning, eventReasonFailed, message, true
+	case v1alpha1.TestPhaseError:
+		if message == "" {
+			message = "Test could not be run"
+		}
+		return v1.EventTypeWarning, eventReasonError, message, true
+	case v1alpha1.TestPhaseSkipped:
+		if message == "" {
+			message = "Test skipped"
+		}
+		return v1.EventTypeNormal, eventReasonSkipped, message, true
+	case v1alpha1.IntegrationTestPhaseNone:
+		switch from {
+		case v1alpha1.TestPhasePassed, v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError, v1alpha1.TestPhaseSkipped:
+			return v1.EventTypeNormal, eventReasonRetried, fmt.Sprintf("Test run again as its spec has changed, last run %s", from), true
+		case v1alpha1.TestPhasePending, v1alpha1.TestPhaseRunning:
+			// Retried on the reason of its failure
+			return v1.EventTypeWarning, eventReasonRetried, message, true
+		}
+	}
+	return "", "", "", false
+}
+
+// cleanedEventMessage is the message of the event recorded when a completed test is deleted once expired
+func cleanedEventMessage(test *v1alpha1.Test, defaults v1alpha1.InstanceConfig) string {
+	ttl, _ := ttlFor(test, defaults)
+	return fmt.Sprintf("Test deleted %s after its completion as %s", ttl, test.Status.Phase)
+}
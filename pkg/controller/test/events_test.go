@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestLifecycleEvent(t *testing.T) {
+	test := newTestForStart()
+
+	test.Status.Phase = v1alpha1.TestPhaseRunning
+	eventType, reason, _, ok := lifecycleEvent(test, v1alpha1.TestPhasePending)
+	assert.True(t, ok)
+	assert.Equal(t, v1.EventTypeNormal, eventType)
+	assert.Equal(t, eventReasonStarted, reason)
+
+	test.Status.Phase = v1alpha1.TestPhaseFailed
+	test.Status.Message = "2 scenarios failed"
+	eventType, reason, message, ok := lifecycleEvent(test, v1alpha1.TestPhaseRunning)
+	assert.True(t, ok)
+	assert.Equal(t, v1.EventTypeWarning, eventType)
+	assert.Equal(t, eventReasonFailed, reason)
+	assert.Equal(t, "2 scenarios failed", message)
+
+	test.Status.Phase = v1alpha1.TestPhasePassed
+	test.Status.Message = ""
+	eventType, reason, message, ok = lifecycleEvent(test, v1alpha1.TestPhaseRunning)
+	assert.True(t, ok)
+	assert.Equal(t, v1.EventTypeNormal, eventType)
+	assert.Equal(t, eventReasonPassed, reason)
+	assert.Equal(t, "Test passed", message)
+
+	test.Status.Phase = v1alpha1.IntegrationTestPhaseNone
+	_, reason, _, ok = lifecycleEvent(test, v1alpha1.TestPhasePassed)
+	assert.True(t, ok)
+	assert.Equal(t, eventReasonRetried, reason)
+
+	test.Status.Phase = v1alpha1.TestPhasePending
+	_, _, _, ok = lifecycleEvent(test, v1alpha1.IntegrationTestPhaseNone)
+	assert.False(t, ok)
+}
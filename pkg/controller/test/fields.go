@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// checkUnknownFields reads the spec of the test as stored by the API server, the typed test dropping the fields that
+// are unknown to the operator, and lists them in the SpecValid condition. It returns a message when the test is rejected
+// for them, according to the operator wide UNKNOWN_FIELDS_POLICY. Unknown fields are not checked when the stored spec
+// cannot be read. The dynamic client reading the stored spec is only created when the unknown fields are checked.
+func checkUnknownFields(test *v1alpha1.Test, dynamicClient func() (dynamic.Interface, error)) string {
+	policy := config.GetUnknownFieldsPolicy()
+	if policy == config.UnknownFieldsIgnore {
+		return ""
+	}
+	c, err := dynamicClient()
+	if err != nil {
+		Log.ForTest(test).Info("Cannot check the unknown fields of the spec", "error", err.Error())
+		return ""
+	}
+	tests := c.Resource(v1alpha1.SchemeGroupVersion.WithResource("tests")).Namespace(test.Namespace)
+	raw, err := tests.Get(test.Name, metav1.GetOptions{})
+	if err != nil {
+		Log.ForTest(test).Info("Cannot check the unknown fields of the spec", "error", err.Error())
+		return ""
+	}
+
+	fields := unknownFields("spec", raw.Object["spec"], reflect.TypeOf(v1alpha1.TestSpec{}))
+	if len(fields) == 0 {
+		setCondition(test, v1alpha1.TestConditionSpecValid, v1.ConditionTrue, "KnownFields", "")
+		return ""
+	}
+	message := "unknown fields in the spec: " + strings.Join(fields, ", ")
+	Log.ForTest(test).Info("Test spec has unknown fields", "fields", fields)
+	setCondition(test, v1alpha1.TestConditionSpecValid, v1.ConditionFalse, "UnknownFields", message)
+	if policy == config.UnknownFieldsReject {
+		return message
+	}
+	return ""
+}
+
+// unknownFields returns the paths of the fields of the JSON value that the given type does not decode, the values
+// whose type does not match being left to the decoding
+func unknownFields(path string, value interface{}, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types decoding themselves, e.g. quantities, are not checked
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	unknown := make([]string, 0)
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		collectJSONFields(t, fields)
+		for _, key := range sortedMapKeys(object) {
+			if field, ok := fields[key]; ok {
+				unknown = append(unknown, unknownFields(path+"."+key, object[key], field)...)
+			} else {
+				unknown = append(unknown, path+"."+key)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, unknownFields(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+		}
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedMapKeys(entries) {
+			unknown = append(unknown, unknownFields(path+"."+key, entries[key], t.Elem())...)
+		}
+	}
+	return unknown
+}
+
+// collectJSONFields collects the JSON names of the fields of the struct type, including the ones of its inlined structs
+func collectJSONFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectJSONFields(embedded, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
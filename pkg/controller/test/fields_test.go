@@ -0,0 +1,100 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestUnknownFields(t *testing.T) {
+	spec := make(map[string]interface{})
+	err := json.Unmarshal([]byte(`{
+		"source": {"name": "hello.feature", "content": "Feature: hello", "langauge": "feature"},
+		"runtime": {
+			"evn": [{"name": "A", "value": "a"}],
+			"env": [{"name": "B", "valueFrom": {"secretKeyRef": {"name": "s", "key": "k", "optinal": true}}}],
+			"volumes": [{"name": "data", "persistentVolumeClaim": {"claimName": "data"}}],
+			"podLabels": {"team": "qa"}
+		},
+		"readinessGates": [{"http": {"url": "http://example.com"}, "timeout": "1m"}],
+		"timout": "5m"
+	}`), &spec)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{
+		"spec.runtime.env[0].valueFrom.secretKeyRef.optinal",
+		"spec.runtime.evn",
+		"spec.source.langauge",
+		"spec.timout",
+	}, unknownFields("spec", spec, reflect.TypeOf(v1alpha1.TestSpec{})))
+}
+
+func TestKnownFields(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.Runtime.WorkspaceSize = "2Gi"
+	data, err := json.Marshal(test.Spec)
+	assert.Nil(t, err)
+	spec := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &spec))
+
+	assert.Empty(t, unknownFields("spec", spec, reflect.TypeOf(v1alpha1.TestSpec{})))
+}
+
+func TestUnknownFieldsRejected(t *testing.T) {
+	defer os.Unsetenv("UNKNOWN_FIELDS_POLICY")
+	assert.Nil(t, os.Setenv("UNKNOWN_FIELDS_POLICY", "Reject"))
+
+	test := newTestForStart()
+	test.Status = v1alpha1.TestStatus{}
+	// The stored test has a typo the typed test drops
+	stored := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": v1alpha1.SchemeGroupVersion.String(),
+		"kind":       "Test",
+		"metadata":   map[string]interface{}{"namespace": test.Namespace, "name": test.Name},
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"name": "hello.feature", "content": "Feature: hello"},
+			"timout": "5m",
+		},
+	}}
+	action := initializeAction{
+		dynamicClient: func() (dynamic.Interface, error) {
+			return fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), stored), nil
+		},
+	}
+
+	test, err := action.Handle(context.TODO(), test)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.TestPhaseError, test.Status.Phase)
+	assert.Equal(t, v1alpha1.TestReasonInvalidSpec, test.Status.Reason)
+	assert.Equal(t, "unknown fields in the spec: spec.timout", test.Status.Message)
+	assert.Equal(t, v1alpha1.TestConditionSpecValid, test.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionFalse, test.Status.Conditions[0].Status)
+	assert.Equal(t, "UnknownFields", test.Status.Conditions[0].Reason)
+}
@@ -0,0 +1,324 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// defaultGateTimeout is how long a readiness gate is waited for when it has no timeout
+const defaultGateTimeout = 5 * time.Minute
+
+// gateCheckTimeout bounds a single HTTP or TCP check, so that an unresponsive endpoint does not delay the other gates
+const gateCheckTimeout = 5 * time.Second
+
+// maxGatePollInterval bounds the interval between two checks of the readiness gates and dependencies of a test, that
+// grows with the time they have been waited for
+const maxGatePollInterval = time.Minute
+
+// gatePollInterval returns the interval before the readiness gates and dependencies of a test waited for the given
+// duration are checked again, doubled for every minute waited so that long waits do not keep the operator busy
+func gatePollInterval(waited time.Duration) time.Duration {
+	interval := pendingPollInterval
+	for i := time.Duration(0); i < waited/time.Minute && interval < maxGatePollInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxGatePollInterval {
+		return maxGatePollInterval
+	}
+	return interval
+}
+
+// gateNameFor returns the name of the readiness gate as reported in the test status
+func gateNameFor(gate v1alpha1.ReadinessGate) string {
+	switch {
+	case gate.HTTP != nil:
+		return "http " + gate.HTTP.URL
+	case gate.TCP != nil:
+		return "tcp " + net.JoinHostPort(gate.TCP.Host, strconv.Itoa(int(gate.TCP.Port)))
+	case gate.Condition != nil:
+		return fmt.Sprintf("condition %s of %s/%s", gate.Condition.Type, gate.Condition.Resource, gate.Condition.Name)
+	}
+	return "gate"
+}
+
+// gateTimeoutFor returns the timeout of the readiness gate, validated before the test is started
+func gateTimeoutFor(gate v1alpha1.ReadinessGate) time.Duration {
+	if timeout, err := time.ParseDuration(gate.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return defaultGateTimeout
+}
+
+// unmetGate returns the first readiness gate of the test that is not met, if any, and whether it has not been met
+// within its timeout. The gates are checked in the background, so that unresponsive endpoints do not block the
+// reconciliation: they are reported unmet until the result of the check is collected by a later reconciliation, the
+// gate waited for so far being reported meanwhile.
+func unmetGate(c client.Client, test *v1alpha1.Test, now time.Time) (string, bool, error) {
+	gates := test.Spec.ReadinessGates
+	if len(gates) == 0 {
+		return "", false, nil
+	}
+	namespace := targetNamespaceFor(test)
+	key := asyncCheckKey(test, "readiness gates")
+	check := func() (string, error) {
+		return firstUnmetGate(c, namespace, gates)
+	}
+	name, done, err := backgroundChecks.run(key, check)
+	if err != nil {
+		return "", false, err
+	} else if done && name == "" {
+		return "", false, nil
+	} else if done {
+		// Checked again right away, the result being collected by the next reconciliation
+		backgroundChecks.run(key, check)
+	} else {
+		name = gateNameFor(gates[0])
+		if gateNamed(gates, test.Status.WaitingFor) != nil {
+			name = test.Status.WaitingFor
+		}
+	}
+
+	since := gatesCheckedSince(test)
+	return name, now.Sub(since.Time) >= gateTimeoutFor(*gateNamed(gates, name)), nil
+}
+
+// gatesCheckedSince returns when the readiness gates of the test have started to be checked: its scheduled start when
+// it has been delayed, with startAfter or by the backoff of a retry, or when it has been queued otherwise
+func gatesCheckedSince(test *v1alpha1.Test) metav1.Time {
+	if test.Status.ScheduledStart != nil {
+		return *test.Status.ScheduledStart
+	}
+	return pendingSince(test)
+}
+
+// firstUnmetGate returns the name of the first readiness gate that is not met, or an empty string when all are met
+func firstUnmetGate(c client.Client, namespace string, gates []v1alpha1.ReadinessGate) (string, error) {
+	for _, gate := range gates {
+		met, err := gateMet(c, namespace, gate)
+		if err != nil {
+			return "", err
+		}
+		if !met {
+			return gateNameFor(gate), nil
+		}
+	}
+	return "", nil
+}
+
+func gateNamed(gates []v1alpha1.ReadinessGate, name string) *v1alpha1.ReadinessGate {
+	for i := range gates {
+		if gateNameFor(gates[i]) == name {
+			return &gates[i]
+		}
+	}
+	return nil
+}
+
+// isAllowedGateHost tells whether the HTTP and TCP readiness gates of a test may reach the host: a service of the
+// target namespace of the test, e.g. my-app.my-namespace.svc or my-app.my-namespace.svc.cluster.local, or a host
+// allowed by the operator, so that tests cannot make the operator reach arbitrary endpoints
+func isAllowedGateHost(host string, namespace string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, host) {
+			return true
+		}
+	}
+	labels := strings.Split(host, ".")
+	return len(labels) >= 3 && labels[0] != "" && labels[1] == namespace && labels[2] == "svc"
+}
+
+// gateMet runs the check of the readiness gate. Failed checks are not errors, the gate is checked again until
+// its timeout.
+func gateMet(c client.Client, namespace string, gate v1alpha1.ReadinessGate) (bool, error) {
+	switch {
+	case gate.HTTP != nil:
+		return httpGateMet(gate.HTTP), nil
+	case gate.TCP != nil:
+		return tcpGateMet(gate.TCP), nil
+	case gate.Condition != nil:
+		return conditionGateMet(c, namespace, gate.Condition)
+	}
+	return true, nil
+}
+
+func httpGateMet(gate *v1alpha1.HTTPGate) bool {
+	c := http.Client{
+		Timeout: gateCheckTimeout,
+		// Redirects could reach hosts the gate is not allowed to, the status of the redirect is checked instead
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := c.Get(gate.URL)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	expected := gate.Status
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+	return int32(resp.StatusCode) == expected
+}
+
+func tcpGateMet(gate *v1alpha1.TCPGate) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(gate.Host, strconv.Itoa(int(gate.Port))), gateCheckTimeout)
+	if err != nil {
+		return false
+	}
+	// The connection has been opened, closing it cannot change the outcome
+	_ = conn.Close()
+	return true
+}
+
+func conditionGateMet(c client.Client, namespace string, gate *v1alpha1.ConditionGate) (bool, error) {
+	gv, err := schema.ParseGroupVersion(gate.APIVersion)
+	if err != nil {
+		return false, err
+	}
+	namespaced, found, err := isNamespacedResource(c, gv, gate.Resource)
+	if err != nil {
+		return false, err
+	} else if !found {
+		return false, errors.New(fmt.Sprintf("resource %s is not served by %s", gate.Resource, gate.APIVersion))
+	}
+	dynamicClient, err := sharedDynamicClient()
+	if err != nil {
+		return false, err
+	}
+	var resources dynamic.ResourceInterface = dynamicClient.Resource(gv.WithResource(gate.Resource))
+	if namespaced {
+		resources = dynamicClient.Resource(gv.WithResource(gate.Resource)).Namespace(namespace)
+	}
+	obj, err := resources.Get(gate.Name, metav1.GetOptions{})
+	if err != nil && k8serrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return hasCondition(obj, gate.Type, gate.Status), nil
+}
+
+// sharedDynamic is the dynamic client reading the resources of the condition gates, the cluster configuration and the
+// stored spec of the tests, shared by all the checks
+var sharedDynamic struct {
+	once   sync.Once
+	client dynamic.Interface
+	err    error
+}
+
+func sharedDynamicClient() (dynamic.Interface, error) {
+	sharedDynamic.once.Do(func() {
+		var conf *rest.Config
+		if conf, sharedDynamic.err = client.GetConfig(); sharedDynamic.err == nil {
+			sharedDynamic.client, sharedDynamic.err = dynamic.NewForConfig(conf)
+		}
+	})
+	return sharedDynamic.client, sharedDynamic.err
+}
+
+// isNamespacedResource tells whether the resource is namespaced, and whether it is served by the group version
+func isNamespacedResource(c client.Client, gv schema.GroupVersion, resource string) (bool, bool, error) {
+	resources, err := c.Discovery().ServerResourcesForGroupVersion(gv.String())
+	if err != nil && k8serrors.IsNotFound(err) {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return r.Namespaced, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// validateGateAccess checks that the operator is allowed to read the resource of the condition gate, in the target
+// namespace of the test or cluster-wide for cluster-scoped resources, returning a message describing the problem
+func validateGateAccess(c client.Client, test *v1alpha1.Test, index int, gate *v1alpha1.ConditionGate) (string, error) {
+	gv, err := schema.ParseGroupVersion(gate.APIVersion)
+	if err != nil {
+		return fmt.Sprintf("invalid apiVersion %s of readiness gate %d: %v", gate.APIVersion, index, err), nil
+	}
+	namespaced, found, err := isNamespacedResource(c, gv, gate.Resource)
+	if err != nil {
+		return "", err
+	} else if !found {
+		return fmt.Sprintf("resource %s of readiness gate %d is not served by %s", gate.Resource, index, gate.APIVersion), nil
+	}
+	namespace, scope := "", "cluster-wide"
+	if namespaced {
+		namespace = targetNamespaceFor(test)
+		scope = "in namespace " + namespace
+	}
+	review := authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Group:     gv.Group,
+				Resource:  gate.Resource,
+			},
+		},
+	}
+	result, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(&review)
+	if err != nil {
+		return "", err
+	}
+	if !result.Status.Allowed {
+		return fmt.Sprintf("the operator is not allowed to get %s %s, required by readiness gate %d", gate.Resource, scope, index), nil
+	}
+	return "", nil
+}
+
+// hasCondition tells whether the status of the resource reports the condition with the given status, True by default
+func hasCondition(obj *unstructured.Unstructured, conditionType string, status string) bool {
+	if status == "" {
+		status = "True"
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == conditionType {
+			return condition["status"] == status
+		}
+	}
+	return false
+}
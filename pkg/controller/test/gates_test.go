@@ -0,0 +1,200 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newGatedTest(pending time.Time, gates ...v1alpha1.ReadinessGate) *v1alpha1.Test {
+	return &v1alpha1.Test{
+		Spec: v1alpha1.TestSpec{
+			ReadinessGates: gates,
+		},
+		Status: v1alpha1.TestStatus{
+			Phase: v1alpha1.TestPhasePending,
+			Timings: &v1alpha1.TestTimings{
+				Pending: &metav1.Time{Time: pending},
+			},
+		},
+	}
+}
+
+func TestHTTPGate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	assert.True(t, httpGateMet(&v1alpha1.HTTPGate{URL: server.URL}))
+	assert.False(t, httpGateMet(&v1alpha1.HTTPGate{URL: server.URL + "/missing"}))
+	assert.True(t, httpGateMet(&v1alpha1.HTTPGate{URL: server.URL + "/missing", Status: http.StatusNotFound}))
+}
+
+func TestTCPGate(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	assert.True(t, tcpGateMet(&v1alpha1.TCPGate{Host: "127.0.0.1", Port: int32(port)}))
+	assert.Nil(t, listener.Close())
+	assert.False(t, tcpGateMet(&v1alpha1.TCPGate{Host: "127.0.0.1", Port: int32(port)}))
+}
+
+func TestUnmetGateTimesOut(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.Nil(t, listener.Close())
+
+	gate := v1alpha1.ReadinessGate{
+		TCP:     &v1alpha1.TCPGate{Host: "127.0.0.1", Port: int32(port)},
+		Timeout: "1m",
+	}
+	now := time.Now()
+	test := newGatedTest(now.Add(-30*time.Second), gate)
+	test.Status.TestID = "timeout"
+
+	name, expired, err := unmetGate(nil, test, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp 127.0.0.1:"+strconv.Itoa(port), name)
+	assert.False(t, expired)
+
+	test.Status.Timings.Pending = &metav1.Time{Time: now.Add(-2 * time.Minute)}
+	_, expired, err = unmetGate(nil, test, now)
+	assert.Nil(t, err)
+	assert.True(t, expired)
+
+	// The timeout of delayed tests is counted from their scheduled start
+	test.Status.ScheduledStart = &metav1.Time{Time: now.Add(-30 * time.Second)}
+	_, expired, err = unmetGate(nil, test, now)
+	assert.Nil(t, err)
+	assert.False(t, expired)
+}
+
+func TestGatesAreCheckedInTheBackground(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	gate := v1alpha1.ReadinessGate{
+		TCP: &v1alpha1.TCPGate{Host: "127.0.0.1", Port: int32(port)},
+	}
+	test := newGatedTest(time.Now(), gate)
+	test.Status.TestID = "background"
+
+	// Reported unmet until the result of the check is collected
+	name, _, err := unmetGate(nil, test, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp 127.0.0.1:"+strconv.Itoa(port), name)
+	for i := 0; i < 100 && name != ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		name, _, err = unmetGate(nil, test, time.Now())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, "", name)
+}
+
+func TestHasCondition(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "True"},
+				map[string]interface{}{"type": "Available", "status": "False"},
+			},
+		},
+	}}
+
+	assert.True(t, hasCondition(obj, "Progressing", ""))
+	assert.False(t, hasCondition(obj, "Available", ""))
+	assert.True(t, hasCondition(obj, "Available", "False"))
+	assert.False(t, hasCondition(obj, "Ready", ""))
+	assert.False(t, hasCondition(&unstructured.Unstructured{Object: map[string]interface{}{}}, "Ready", ""))
+}
+
+func TestIsAllowedGateHost(t *testing.T) {
+	assert.True(t, isAllowedGateHost("my-app.ns.svc", "ns", nil))
+	assert.True(t, isAllowedGateHost("my-app.ns.svc.cluster.local.", "ns", nil))
+	assert.False(t, isAllowedGateHost("my-app.other.svc", "ns", nil))
+	assert.False(t, isAllowedGateHost("my-app", "ns", nil))
+	assert.False(t, isAllowedGateHost("169.254.169.254", "ns", nil))
+	assert.False(t, isAllowedGateHost("my-app.example.com", "ns", nil))
+
+	assert.True(t, isAllowedGateHost("My-App.example.com", "ns", []string{"my-app.example.com"}))
+	assert.True(t, isAllowedGateHost("169.254.169.254", "ns", []string{"*"}))
+}
+
+func TestGateHostNotAllowed(t *testing.T) {
+	test := newGatedTest(time.Now(), v1alpha1.ReadinessGate{
+		HTTP: &v1alpha1.HTTPGate{URL: "http://169.254.169.254/latest/meta-data"},
+	})
+	test.Namespace = "ns"
+	message, err := validateReadinessGates(context.TODO(), testutil.NewFakeClient(), test)
+	assert.Nil(t, err)
+	assert.Equal(t, "host 169.254.169.254 of readiness gate 0 is neither a service of namespace ns nor allowed by the operator", message)
+
+	test.Spec.ReadinessGates[0] = v1alpha1.ReadinessGate{TCP: &v1alpha1.TCPGate{Host: "db.other.svc", Port: 5432}}
+	message, err = validateReadinessGates(context.TODO(), testutil.NewFakeClient(), test)
+	assert.Nil(t, err)
+	assert.Contains(t, message, "host db.other.svc of readiness gate 0")
+
+	test.Spec.ReadinessGates[0] = v1alpha1.ReadinessGate{HTTP: &v1alpha1.HTTPGate{URL: "http://my-app.ns.svc:8080/health"}}
+	message, err = validateReadinessGates(context.TODO(), testutil.NewFakeClient(), test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+}
+
+func TestHTTPGateDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	assert.False(t, httpGateMet(&v1alpha1.HTTPGate{URL: server.URL + "/health"}))
+	assert.True(t, httpGateMet(&v1alpha1.HTTPGate{URL: server.URL + "/health", Status: http.StatusFound}))
+}
+
+func TestGatePollInterval(t *testing.T) {
+	assert.Equal(t, pendingPollInterval, gatePollInterval(0))
+	assert.Equal(t, pendingPollInterval, gatePollInterval(59*time.Second))
+	assert.Equal(t, 2*pendingPollInterval, gatePollInterval(time.Minute))
+	assert.Equal(t, 4*pendingPollInterval, gatePollInterval(2*time.Minute))
+	assert.Equal(t, maxGatePollInterval, gatePollInterval(time.Hour))
+
+	test := newGatedTest(time.Now().Add(-3 * time.Minute))
+	test.Status.WaitingFor = "tcp db:5432"
+	assert.Equal(t, 8*pendingPollInterval, requeueResultFor(test, v1alpha1.InstanceConfig{}).RequeueAfter)
+}
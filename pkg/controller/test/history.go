@@ -0,0 +1,98 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pruneHistory deletes the oldest completed tests sharing the logical name of the given one, the value of its
+// yaks.dev/test-name label, so that only the MAX_HISTORY_PER_TEST most recent results are kept
+func pruneHistory(ctx context.Context, c client.Client, recorder record.EventRecorder, test *v1alpha1.Test) {
+	limit := config.GetMaxHistoryPerTest()
+	name := test.Labels[v1alpha1.TestNameLabel]
+	if limit == 0 || name == "" {
+		return
+	}
+	tests := v1alpha1.TestList{}
+	options := &k8sclient.ListOptions{
+		Namespace:     test.Namespace,
+		LabelSelector: labels.SelectorFromSet(labels.Set{v1alpha1.TestNameLabel: name}),
+	}
+	if err := c.List(ctx, options, &tests); err != nil {
+		Log.ForTest(test).Error(err, "Cannot list the history of the test", "test-name", name)
+		return
+	}
+	for _, expired := range historyToPrune(tests.Items, test, limit) {
+		if err := c.Delete(ctx, expired); err != nil && !k8serrors.IsNotFound(err) {
+			Log.ForTest(expired).Error(err, "Cannot delete the test beyond the history limit")
+			continue
+		}
+		Log.ForTest(expired).Info("Test beyond the history limit deleted", "test-name", name, "limit", limit)
+		message := fmt.Sprintf("Test deleted as more than %d results of %s are kept", limit, name)
+		recorder.Event(expired, v1.EventTypeNormal, eventReasonCleaned, message)
+	}
+}
+
+// historyToPrune returns the completed tests beyond the limit, the oldest first, the given test standing for its
+// possibly stale version in the list
+func historyToPrune(tests []v1alpha1.Test, test *v1alpha1.Test, limit int) []*v1alpha1.Test {
+	completed := make([]*v1alpha1.Test, 0, len(tests))
+	found := false
+	for i := range tests {
+		item := &tests[i]
+		if item.UID == test.UID {
+			item = test
+			found = true
+		}
+		if isCompleted(item) && item.GetDeletionTimestamp() == nil {
+			completed = append(completed, item)
+		}
+	}
+	if !found && isCompleted(test) {
+		completed = append(completed, test)
+	}
+	if len(completed) <= limit {
+		return nil
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completedAt(completed[i]).Before(completedAt(completed[j]))
+	})
+	return completed[:len(completed)-limit]
+}
+
+// completedAt is when the test has completed, its creation time for the tests completed before the timings were
+// recorded
+func completedAt(test *v1alpha1.Test) time.Time {
+	if test.Status.Timings != nil && test.Status.Timings.Completed != nil {
+		return test.Status.Timings.Completed.Time
+	}
+	return test.CreationTimestamp.Time
+}
@@ -0,0 +1,60 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestForHistory(name string, phase v1alpha1.TestPhase, completed time.Time) v1alpha1.Test {
+	test := v1alpha1.Test{}
+	test.Name = name
+	test.UID = types.UID(name)
+	test.Status.Phase = phase
+	test.Status.Timings = &v1alpha1.TestTimings{Completed: &metav1.Time{Time: completed}}
+	return test
+}
+
+func TestHistoryToPrune(t *testing.T) {
+	now := time.Date(2019, 10, 1, 8, 0, 0, 0, time.UTC)
+	tests := []v1alpha1.Test{
+		newTestForHistory("run-3", v1alpha1.TestPhasePassed, now.Add(-1*time.Hour)),
+		newTestForHistory("run-1", v1alpha1.TestPhaseFailed, now.Add(-3*time.Hour)),
+		newTestForHistory("run-5", v1alpha1.TestPhaseRunning, now),
+		newTestForHistory("run-2", v1alpha1.TestPhasePassed, now.Add(-2*time.Hour)),
+		// Still running in the cache
+		newTestForHistory("run-4", v1alpha1.TestPhaseRunning, now),
+	}
+	completed := newTestForHistory("run-4", v1alpha1.TestPhasePassed, now)
+
+	pruned := historyToPrune(tests, &completed, 2)
+	names := make([]string, 0, len(pruned))
+	for _, test := range pruned {
+		names = append(names, test.Name)
+	}
+	assert.Equal(t, []string{"run-1", "run-2"}, names)
+
+	assert.Empty(t, historyToPrune(tests, &completed, 5))
+}
@@ -24,15 +24,20 @@ import (
 	"github.com/jboss-fuse/yaks/pkg/util/digest"
 	"github.com/jboss-fuse/yaks/version"
 	"github.com/rs/xid"
+	"k8s.io/client-go/dynamic"
 )
 
 // NewInitializeAction creates a new initialize action
 func NewInitializeAction() Action {
-	return &initializeAction{}
+	return &initializeAction{
+		dynamicClient: sharedDynamicClient,
+	}
 }
 
 type initializeAction struct {
 	baseAction
+	// dynamicClient reads the spec of the tests as stored by the API server, to check its unknown fields
+	dynamicClient func() (dynamic.Interface, error)
 }
 
 // Name returns a common name of the action
@@ -56,5 +61,22 @@ func (action *initializeAction) Handle(ctx context.Context, test *v1alpha1.Test)
 	test.Status.TestID = xid.New().String()
 	test.Status.Digest = testDigest
 	test.Status.Version = version.Version
+	test.Status.Message = ""
+	test.Status.ExitCode = nil
+	test.Status.Timings = nil
+	test.Status.Results = nil
+	test.Status.WaitingFor = ""
+	test.Status.Reason = ""
+	test.Status.Conditions = nil
+	test.Status.PodManifest = ""
+	test.Status.FailedAssertions = nil
+	test.Status.Reports = ""
+	test.Status.ScheduledStart = nil
+	test.Status.TraceID = traceIDFor(test)
+	if message := checkUnknownFields(test, action.dynamicClient); message != "" {
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonInvalidSpec
+		test.Status.Message = message
+	}
 	return test, nil
 }
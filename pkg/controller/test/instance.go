@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// lookupInstanceFor returns the instance holding the defaults of the tests in the given namespace, if any.
+// When several instances exist in the namespace, the first one by name is used.
+func lookupInstanceFor(ctx context.Context, c client.Client, namespace string) (*v1alpha1.Instance, error) {
+	list := v1alpha1.InstanceList{}
+	if err := c.List(ctx, &k8sclient.ListOptions{Namespace: namespace}, &list); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	if len(list.Items) > 1 {
+		Log.Info("Multiple instances found in namespace, using the first one", "namespace", namespace, "instance", list.Items[0].Name)
+	}
+	return &list.Items[0], nil
+}
+
+// instanceFor returns the instance holding the defaults of the test: the one referenced by its spec, else the one
+// owning the test, else the instance of its namespace, if any
+func instanceFor(ctx context.Context, c client.Client, test *v1alpha1.Test) (*v1alpha1.Instance, error) {
+	name := instanceNameFor(test)
+	if name == "" {
+		return lookupInstanceFor(ctx, c, test.Namespace)
+	}
+	instance := v1alpha1.Instance{}
+	if err := c.Get(ctx, k8sclient.ObjectKey{Namespace: test.Namespace, Name: name}, &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// instanceNameFor returns the name of the instance referenced by the spec of the test, or owning the test
+func instanceNameFor(test *v1alpha1.Test) string {
+	if test.Spec.Instance != "" {
+		return test.Spec.Instance
+	}
+	for _, owner := range test.OwnerReferences {
+		if owner.Kind == v1alpha1.InstanceKind && owner.APIVersion == v1alpha1.SchemeGroupVersion.String() {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// instanceConfigFor returns the defaults of the instance of the test, or empty defaults when the test has no instance.
+// Errors are logged as the operator wide defaults can be used instead.
+func instanceConfigFor(ctx context.Context, c client.Client, test *v1alpha1.Test) v1alpha1.InstanceConfig {
+	instance, err := instanceFor(ctx, c, test)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			Log.ForTest(test).Error(err, "Cannot get the instance of the test, using the operator wide defaults")
+		}
+		return v1alpha1.InstanceConfig{}
+	}
+	if instance == nil {
+		return v1alpha1.InstanceConfig{}
+	}
+	return instance.Spec.Config
+}
+
+func validateInstance(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	name := instanceNameFor(test)
+	if name == "" {
+		return "", nil
+	}
+	instance := v1alpha1.Instance{}
+	err := c.Get(ctx, k8sclient.ObjectKey{Namespace: test.Namespace, Name: name}, &instance)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return fmt.Sprintf("instance %s does not exist in namespace %s", name, test.Namespace), nil
+	} else if err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// isManagedByInstanceOperator tells whether the instance of the namespace has its own operator, that runs the tests
+// of the namespace
+func isManagedByInstanceOperator(ctx context.Context, c client.Client, namespace string) (bool, error) {
+	instance, err := lookupInstanceFor(ctx, c, namespace)
+	if err != nil {
+		return false, err
+	}
+	return instance != nil && instance.Spec.Operator != nil, nil
+}
+
+// applyInstanceConfig applies the instance defaults to the test pod, before any test specific setting: the runtime env
+// of the test takes precedence over the env of the instance, that takes precedence over the defaults of the runner
+func applyInstanceConfig(test *v1alpha1.Test, config v1alpha1.InstanceConfig, pod *v1.Pod) {
+	container := &pod.Spec.Containers[0]
+	if config.Image != "" {
+		container.Image = config.Image
+	}
+	for _, env := range config.Env {
+		if envvar.Get(container.Env, env.Name) == nil {
+			envvar.SetVar(&container.Env, env)
+		}
+	}
+	if deadline, ok := activeDeadlineFor(test, config); ok && pod.Spec.ActiveDeadlineSeconds == nil {
+		pod.Spec.ActiveDeadlineSeconds = &deadline
+	}
+}
+
+// activeDeadlineFor returns the active deadline, in seconds, of the runner pod of the test from the timeout of the
+// instance, extended by the debug timeout of the test so that debug sessions are not cut short
+func activeDeadlineFor(test *v1alpha1.Test, config v1alpha1.InstanceConfig) (int64, bool) {
+	if config.Timeout == "" {
+		return 0, false
+	}
+	timeout, err := time.ParseDuration(config.Timeout)
+	if err != nil || timeout <= 0 {
+		Log.ForTest(test).Info("Invalid timeout of the instance, ignoring it", "value", config.Timeout)
+		return 0, false
+	}
+	if test.Spec.Runtime.Debug != nil {
+		timeout += debugTimeoutFor(test)
+	}
+	return int64(timeout.Seconds()), true
+}
@@ -0,0 +1,180 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// workloadFor returns the kind of workload running the test, either set on the test or operator wide
+func workloadFor(test *v1alpha1.Test) v1alpha1.WorkloadType {
+	if test.Spec.Runtime.Workload != "" {
+		return test.Spec.Runtime.Workload
+	}
+	return v1alpha1.WorkloadType(config.GetTestWorkload())
+}
+
+// newTestingWorkload returns the object running the given test pod, either the bare pod or a Job wrapping it
+func newTestingWorkload(test *v1alpha1.Test, pod *v1.Pod) runtime.Object {
+	if workloadFor(test) == v1alpha1.WorkloadTypeJob {
+		return newTestingJob(test, pod)
+	}
+	return pod
+}
+
+func newTestingJob(test *v1alpha1.Test, pod *v1.Pod) *batchv1.Job {
+	// Failed tests are not retried unless requested, nor when the operator retries them on the reasons of their failures
+	backoffLimit := int32(0)
+	if test.Spec.Runtime.RetryLimit != nil && !isRetriedByOperator(test) {
+		backoffLimit = *test.Spec.Runtime.RetryLimit
+	}
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       pod.Namespace,
+			Name:            pod.Name,
+			Labels:          pod.Labels,
+			OwnerReferences: pod.OwnerReferences,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      pod.Labels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+}
+
+// evaluateJob sets the test result once the Job running the test has completed, from its last test pod
+func (action *evaluateAction) evaluateJob(ctx context.Context, test *v1alpha1.Test) (*v1alpha1.Test, error) {
+	job := batchv1.Job{}
+	key := client.ObjectKey{
+		Namespace: test.Namespace,
+		Name:      TestPodNameFor(test),
+	}
+	err := action.client.Get(ctx, key, &job)
+	if err != nil && k8serrors.IsNotFound(err) {
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonRunnerNotFound
+		test.Status.Message = "test job " + key.Name + " not found"
+		return test, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	failed := jobCondition(&job, batchv1.JobFailed)
+	if job.Status.Succeeded == 0 && failed == nil {
+		return action.checkJobImagePull(ctx, test)
+	}
+
+	pod, err := action.getLastJobPod(ctx, test)
+	if err != nil {
+		return nil, err
+	}
+	if pod != nil {
+		evaluatePod(test, pod)
+	} else if job.Status.Succeeded > 0 {
+		test.Status.Phase = v1alpha1.TestPhasePassed
+	} else {
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonRunnerError
+		if failed.Reason == podReasonDeadlineExceeded {
+			test.Status.Reason = v1alpha1.TestReasonTimeout
+		}
+		test.Status.Message = failed.Message
+	}
+	return test, nil
+}
+
+// checkJobImagePull sets the running test in error, and deletes its Job, when the image of the test container of one of
+// the Job pods cannot be pulled
+func (action *evaluateAction) checkJobImagePull(ctx context.Context, test *v1alpha1.Test) (*v1alpha1.Test, error) {
+	pods, err := action.listJobPods(ctx, test)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		if setImagePullError(test, &pods[i]) {
+			if err := deleteWorkload(action.client, test); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return test, nil
+}
+
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		condition := &job.Status.Conditions[i]
+		if condition.Type == conditionType && condition.Status == v1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// getLastJobPod returns the most recent terminated pod created by the Job for the test, if any
+func (action *evaluateAction) getLastJobPod(ctx context.Context, test *v1alpha1.Test) (*v1.Pod, error) {
+	pods, err := action.listJobPods(ctx, test)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *v1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			continue
+		}
+		if last == nil || last.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			last = pod
+		}
+	}
+	return last, nil
+}
+
+// listJobPods returns the pods created by the Job for the test
+func (action *evaluateAction) listJobPods(ctx context.Context, test *v1alpha1.Test) ([]v1.Pod, error) {
+	pods := v1.PodList{}
+	options := client.ListOptions{Namespace: test.Namespace}
+	if err := options.SetLabelSelector("yaks.dev/test-id=" + test.Status.TestID); err != nil {
+		return nil, err
+	}
+	if err := action.client.List(ctx, &options, &pods); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	logShipperContainerName = "log-shipper"
+	logShipperVolumeName    = "runner-logs"
+	logShipperPath          = "/var/yaks/logs"
+	logShipperLogFile       = logShipperPath + "/runner.log"
+)
+
+// logShipperRunScript runs the command of the test container given as arguments, copying its output to the log file
+// shipped by the sidecar, then tells the sidecar to stop once the output is flushed
+var logShipperRunScript = recordRunnerPidScript(logShipperPath) +
+	`{ "$@"; echo $? > ` + logShipperPath + `/code; } 2>&1 | tee -a ` + logShipperLogFile + `
+code=$(cat ` + logShipperPath + `/code 2> /dev/null || echo 1)
+touch ` + logShipperPath + `/done
+exit $code
+`
+
+// logShipperScript tails the log file of the runner with Fluent Bit and ships it to the configured output, until the
+// test container is done or has been killed. The shipper is stopped with SIGTERM, so that it flushes the remaining
+// records.
+var logShipperScript = `set -f
+touch ` + logShipperLogFile + `
+/fluent-bit/bin/fluent-bit -f 1 -i tail -p path=` + logShipperLogFile + ` -p read_from_head=true -p refresh_interval=1 \
+  -t "$LOG_TAG" -o $LOG_SHIPPER_OUTPUT -m '*' &
+shipper=$!
+` + waitForRunnerScript(logShipperPath) + `sleep 2
+kill -TERM $shipper 2> /dev/null
+wait $shipper
+exit 0
+`
+
+// applyLogShipping adds a sidecar shipping the output of the test container to the log collector of the operator
+// wide LOG_SHIPPER_OUTPUT, through a log file shared with the test container. The command of the test container is
+// wrapped to write the log file and to signal its completion, so that the sidecar terminates after it, the process
+// namespace of the pod being shared for the sidecar to terminate as well when the test container is killed.
+func applyLogShipping(test *v1alpha1.Test, pod *v1.Pod) {
+	output := config.GetLogShipperOutput()
+	if output == "" {
+		return
+	}
+
+	mount := v1.VolumeMount{
+		Name:      logShipperVolumeName,
+		MountPath: logShipperPath,
+	}
+	container := &pod.Spec.Containers[0]
+	wrapContainerCommand(container, logShipperRunScript)
+	container.VolumeMounts = append(container.VolumeMounts, mount)
+	shareProcessNamespace(pod)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: logShipperVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+		Name:    logShipperContainerName,
+		Image:   config.GetLogShipperImage(),
+		Command: []string{"/bin/sh", "-c", logShipperScript},
+		Env: []v1.EnvVar{
+			{
+				Name:  "LOG_SHIPPER_OUTPUT",
+				Value: output,
+			},
+			{
+				Name:  "LOG_TAG",
+				Value: "yaks." + test.Namespace + "." + test.Name,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{mount},
+	})
+}
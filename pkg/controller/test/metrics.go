@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	operatorPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "yaks_operator_paused",
+		Help: "Whether the operator is paused (1) and does not start new tests or not (0)",
+	})
+	testsRunning = prometheus.NewDesc("yaks_tests_running",
+		"Number of tests running in the watched namespaces", nil, nil)
+	testsQueued = prometheus.NewDesc("yaks_tests_queued",
+		"Number of pending tests waiting for the number of running tests to drop below MAX_CONCURRENT_TESTS", nil, nil)
+)
+
+func init() {
+	// Metrics are served by the manager on the metrics bind address
+	metrics.Registry.MustRegister(operatorPaused)
+}
+
+// testCountsCollector counts the running and queued tests from the cache of the manager when the metrics are scraped
+type testCountsCollector struct {
+	reader k8sclient.Reader
+}
+
+func (c testCountsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- testsRunning
+	ch <- testsQueued
+}
+
+func (c testCountsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := countTests(context.TODO(), c.reader, nil)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(testsRunning, err)
+		ch <- prometheus.NewInvalidMetric(testsQueued, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(testsRunning, prometheus.GaugeValue, float64(counts.running))
+	ch <- prometheus.MustNewConstMetric(testsQueued, prometheus.GaugeValue, float64(counts.queued))
+}
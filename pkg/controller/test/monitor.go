@@ -42,7 +42,8 @@ func (action *monitorAction) Name() string {
 func (action *monitorAction) CanHandle(build *v1alpha1.Test) bool {
 	return build.Status.Phase == v1alpha1.TestPhaseFailed ||
 		build.Status.Phase == v1alpha1.TestPhasePassed ||
-		build.Status.Phase == v1alpha1.TestPhaseError
+		build.Status.Phase == v1alpha1.TestPhaseError ||
+		build.Status.Phase == v1alpha1.TestPhaseSkipped
 }
 
 // Handle handles the test
@@ -56,6 +57,8 @@ func (action *monitorAction) Handle(ctx context.Context, test *v1alpha1.Test) (*
 	if expectedDigest != test.Status.Digest {
 		// Restart the test
 		test.Status.Phase = v1alpha1.IntegrationTestPhaseNone
+		test.Status.Retries = 0
+		test.Status.RetryAt = nil
 	}
 
 	return test, nil
@@ -0,0 +1,130 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runnerSelector selects the pods and jobs running tests
+const runnerSelector = "yaks.dev/app=yaks,yaks.dev/test"
+
+// CleanupOrphans deletes the runner pods and jobs of the given namespace, or of all namespaces when empty, that do
+// not belong to an existing test, e.g. because the test has been deleted while the operator was not running
+func CleanupOrphans(ctx context.Context, c client.Client, namespace string) error {
+	tests := v1alpha1.TestList{}
+	if err := c.List(ctx, &k8sclient.ListOptions{Namespace: namespace}, &tests); err != nil {
+		return err
+	}
+	live := make(map[types.NamespacedName]types.UID, len(tests.Items))
+	for _, test := range tests.Items {
+		live[types.NamespacedName{Namespace: test.Namespace, Name: test.Name}] = test.UID
+	}
+
+	options := metav1.ListOptions{LabelSelector: runnerSelector}
+	jobs, err := c.BatchV1().Jobs(namespace).List(options)
+	if err != nil {
+		return err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !isOrphan(job, live) {
+			continue
+		}
+		Log.Info("Deleting orphaned runner job", "namespace", job.Namespace, "name", job.Name)
+		propagation := metav1.DeletePropagationBackground
+		err := c.BatchV1().Jobs(job.Namespace).Delete(job.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	pods, err := c.CoreV1().Pods(namespace).List(options)
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// The pods of jobs are deleted together with their job
+		if metav1.GetControllerOf(pod) != nil && metav1.GetControllerOf(pod).Kind == "Job" {
+			continue
+		}
+		if !isOrphan(pod, live) {
+			continue
+		}
+		Log.Info("Deleting orphaned runner pod", "namespace", pod.Namespace, "name", pod.Name)
+		if err := c.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isOrphan tells whether the runner object belongs to none of the live tests, either because no test has the name
+// of its label, or because that test has been recreated since the object has been created for it
+func isOrphan(obj metav1.Object, live map[types.NamespacedName]types.UID) bool {
+	uid, ok := live[types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetLabels()["yaks.dev/test"]}]
+	if !ok {
+		return true
+	}
+	if owner := metav1.GetControllerOf(obj); owner != nil && owner.Kind == v1alpha1.TestKind {
+		return owner.UID != uid
+	}
+	return false
+}
+
+// deleteTargetNamespaceRoles deletes the role bindings of the test of the given namespace and name in the given target
+// namespaces, or in all namespaces for an empty one. They cannot be owned by the test, that lives in another namespace,
+// so they are not garbage collected with it.
+func deleteTargetNamespaceRoles(c client.Client, targetNamespaces []string, namespace string, name string) error {
+	selector := labels.SelectorFromSet(targetNamespaceRoleBindingLabels(namespace, name)).String()
+	for _, targetNamespace := range targetNamespaces {
+		bindings := c.RbacV1beta1().RoleBindings(targetNamespace)
+		list, err := bindings.List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return err
+		}
+		for _, rb := range list.Items {
+			if err := c.RbacV1beta1().RoleBindings(rb.Namespace).Delete(rb.Name, &metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allowedTargetNamespaces returns the namespaces where the tests may have been granted access, all namespaces being
+// given as an empty one
+func allowedTargetNamespaces() []string {
+	allowed := config.GetAllowedTargetNamespaces()
+	for _, namespace := range allowed {
+		if namespace == "*" {
+			return []string{""}
+		}
+	}
+	return allowed
+}
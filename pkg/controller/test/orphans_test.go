@@ -0,0 +1,100 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newRunnerPod(testName string, owner types.UID) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "yaks-" + testName,
+			Labels: map[string]string{
+				"yaks.dev/app":  "yaks",
+				"yaks.dev/test": testName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: v1alpha1.SchemeGroupVersion.String(),
+					Kind:       v1alpha1.TestKind,
+					Name:       testName,
+					UID:        owner,
+					Controller: &controller,
+				},
+			},
+		},
+	}
+}
+
+func TestIsOrphan(t *testing.T) {
+	live := map[types.NamespacedName]types.UID{
+		{Namespace: "ns", Name: "hello"}: types.UID("a1b2c3"),
+	}
+
+	assert.False(t, isOrphan(newRunnerPod("hello", "a1b2c3"), live))
+	assert.True(t, isOrphan(newRunnerPod("deleted", "d4e5f6"), live))
+	// The test has been deleted and recreated with the same name
+	assert.True(t, isOrphan(newRunnerPod("hello", "d4e5f6"), live))
+}
+
+func TestDeleteTargetNamespaceRoles(t *testing.T) {
+	c := testutil.NewFakeClient()
+	for _, name := range []string{"hello", "other"} {
+		test := &v1alpha1.Test{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+		rb := &v1beta1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "target",
+				Name:      targetNamespaceRoleBindingName(test),
+				Labels:    targetNamespaceRoleBindingLabels(test.Namespace, test.Name),
+			},
+		}
+		_, err := c.RbacV1beta1().RoleBindings("target").Create(rb)
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, deleteTargetNamespaceRoles(c, []string{"target"}, "ns", "hello"))
+
+	bindings, err := c.RbacV1beta1().RoleBindings("target").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	if assert.Len(t, bindings.Items, 1) {
+		assert.Equal(t, "yaks-viewer-ns-other", bindings.Items[0].Name)
+	}
+}
+
+func TestAllowedTargetNamespaces(t *testing.T) {
+	defer os.Unsetenv("ALLOWED_TARGET_NAMESPACES")
+
+	assert.Nil(t, os.Setenv("ALLOWED_TARGET_NAMESPACES", "staging, qa"))
+	assert.Equal(t, []string{"staging", "qa"}, allowedTargetNamespaces())
+
+	assert.Nil(t, os.Setenv("ALLOWED_TARGET_NAMESPACES", "staging,*"))
+	assert.Equal(t, []string{""}, allowedTargetNamespaces())
+}
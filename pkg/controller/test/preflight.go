@@ -0,0 +1,129 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/registry"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// imagePreflightTimeout bounds the lookup of the runner image, that delays the start of the test
+const imagePreflightTimeout = 10 * time.Second
+
+// checkRunnerImage returns a message when the registry of the runner image answers that the image does not exist, or
+// that it cannot be pulled with the image pull secrets of the pod or of its service account, so that the test fails
+// fast instead of its runner staying in ImagePullBackOff. The lookup runs in the background, the returned flag telling
+// whether it has completed. The registries that cannot be reached by the operator are not checked, nor the images
+// that may be pulled with credentials the operator does not know, e.g. the ones of the nodes.
+func (action *startAction) checkRunnerImage(ctx context.Context, test *v1alpha1.Test, pod *v1.Pod) (string, bool, error) {
+	container := pod.Spec.Containers[0]
+	if !config.IsImagePreflightEnabled() || container.ImagePullPolicy == v1.PullNever {
+		return "", true, nil
+	}
+	ref, err := registry.ParseReference(container.Image)
+	if err != nil {
+		return err.Error(), true, nil
+	}
+	credentials, err := action.registryCredentials(ctx, pod, ref.Registry)
+	if err != nil {
+		return "", false, err
+	}
+
+	logger := action.L
+	return backgroundChecks.run(asyncCheckKey(test, "image"), func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), imagePreflightTimeout)
+		defer cancel()
+		lookup := registry.Client{TrustedRealms: config.GetTrustedRegistryRealms()}
+		err := lookup.CheckManifest(ctx, ref, credentials)
+		switch errors.Cause(err) {
+		case nil:
+			return "", nil
+		case registry.ErrManifestNotFound:
+			return fmt.Sprintf("runner image %s not found in registry %s", container.Image, ref.Registry), nil
+		case registry.ErrUnauthorized:
+			if credentials == nil {
+				// The kubelet may pull the image with the credentials of the node
+				logger.Info("Cannot check the runner image without credentials", "image", container.Image)
+				return "", nil
+			}
+			return fmt.Sprintf("runner image %s cannot be pulled with the image pull secrets of the test, or does not exist",
+				container.Image), nil
+		default:
+			logger.Info("Cannot check the runner image", "image", container.Image, "error", err.Error())
+			return "", nil
+		}
+	})
+}
+
+// registryCredentials returns the credentials of the registry held by the first image pull secret of the pod, or else
+// of its service account, that has some, if any
+func (action *startAction) registryCredentials(ctx context.Context, pod *v1.Pod, host string) (*registry.Credentials, error) {
+	refs := pod.Spec.ImagePullSecrets
+	if pod.Spec.ServiceAccountName != "" {
+		sa := v1.ServiceAccount{}
+		key := k8sclient.ObjectKey{
+			Namespace: pod.Namespace,
+			Name:      pod.Spec.ServiceAccountName,
+		}
+		err := action.client.Get(ctx, key, &sa)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		refs = append(append([]v1.LocalObjectReference{}, refs...), sa.ImagePullSecrets...)
+	}
+
+	for _, ref := range refs {
+		secret := v1.Secret{}
+		key := k8sclient.ObjectKey{
+			Namespace: pod.Namespace,
+			Name:      ref.Name,
+		}
+		err := action.client.Get(ctx, key, &secret)
+		if err != nil && k8serrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		data, ok := secret.Data[v1.DockerConfigJsonKey]
+		if !ok {
+			data, ok = secret.Data[v1.DockerConfigKey]
+		}
+		if !ok {
+			continue
+		}
+		credentials, err := registry.CredentialsFor(data, host)
+		if err != nil {
+			action.L.Info("Cannot read the image pull secret", "secret", ref.Name, "error", err.Error())
+			continue
+		}
+		if credentials != nil {
+			return credentials, nil
+		}
+	}
+	return nil, nil
+}
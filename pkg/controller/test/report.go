@@ -0,0 +1,296 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/jboss-fuse/yaks/pkg/util/s3"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const reportUploadTimeout = time.Minute
+
+// storeReport uploads the JSON and JUnit reports of a completed test to the configured object store, in the
+// background. Upload failures are logged and do not affect the test result.
+func storeReport(c client.Client, test *v1alpha1.Test) {
+	store := config.GetReportStore()
+	if !store.Enabled() {
+		return
+	}
+	test = test.DeepCopy()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), reportUploadTimeout)
+		defer cancel()
+
+		prefix, err := uploadReport(ctx, c, store, test)
+		if err != nil {
+			Log.ForTest(test).Error(err, "Cannot upload the test report", "bucket", store.Bucket)
+			return
+		}
+		Log.ForTest(test).Info("Test report uploaded", "bucket", store.Bucket, "prefix", prefix)
+	}()
+}
+
+func uploadReport(ctx context.Context, c client.Client, store config.ReportStore, test *v1alpha1.Test) (string, error) {
+	credentials, err := reportStoreCredentials(c, store)
+	if err != nil {
+		return "", err
+	}
+
+	var duration time.Duration
+	completed := metav1.Now()
+	if timings := test.Status.Timings; timings != nil && timings.Completed != nil {
+		completed = *timings.Completed
+		if timings.Running != nil {
+			duration = timings.Completed.Sub(timings.Running.Time)
+		}
+	}
+	summary := report.NewSummary(report.NewTestResult(test, duration))
+
+	var jsonReport, junitReport bytes.Buffer
+	if err := summary.PrintJSON(&jsonReport); err != nil {
+		return "", err
+	}
+	if err := summary.PrintJUnit(&junitReport); err != nil {
+		return "", err
+	}
+
+	timestamp := completed.UTC().Format("20060102T150405Z")
+	prefix := test.Namespace + "/" + test.Name + "/" + timestamp
+	tags := map[string]string{
+		"namespace": test.Namespace,
+		"test":      test.Name,
+		"phase":     string(test.Status.Phase),
+		"timestamp": timestamp,
+	}
+	s3Client := s3.Client{
+		Endpoint:    store.Endpoint,
+		Bucket:      store.Bucket,
+		Region:      store.Region,
+		Credentials: credentials,
+	}
+	if err := s3Client.PutObject(ctx, prefix+"/report.json", jsonReport.Bytes(), "application/json", tags); err != nil {
+		return "", err
+	}
+	if err := s3Client.PutObject(ctx, prefix+"/junit.xml", junitReport.Bytes(), "application/xml", tags); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+// reportStoreCredentials reads the credentials of the object store from the secret of the operator namespace
+func reportStoreCredentials(c client.Client, store config.ReportStore) (s3.Credentials, error) {
+	if store.Secret == "" {
+		return s3.Credentials{}, errors.New("no credentials secret configured with REPORT_STORE_SECRET")
+	}
+	namespace, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		return s3.Credentials{}, err
+	}
+	// Read from the API server, secrets are not cached by the operator
+	secret, err := c.CoreV1().Secrets(namespace).Get(store.Secret, metav1.GetOptions{})
+	if err != nil {
+		return s3.Credentials{}, err
+	}
+	return s3.Credentials{
+		AccessKeyID:     string(secret.Data["accessKeyId"]),
+		SecretAccessKey: string(secret.Data["secretAccessKey"]),
+		SessionToken:    string(secret.Data["sessionToken"]),
+	}, nil
+}
+
+const (
+	reportClaimPath       = "/var/yaks/reports"
+	reportClaimVolumeName = "reports"
+)
+
+// reportClaimRunScript runs the command of the test container given as arguments, then copies the reports of the
+// runner to the claim, next to an index entry describing the run
+const reportClaimRunScript = `mkdir -p "$YAKS_REPORTS_DIR"
+"$@"
+code=$?
+if [ -n "$YAKS_RUNNER_REPORTS" ] && [ -d "$YAKS_RUNNER_REPORTS" ]; then
+  cp -R "$YAKS_RUNNER_REPORTS"/. "$YAKS_REPORTS_DIR"/
+fi
+printf '{"namespace":"%s","name":"%s","uid":"%s","exitCode":%d,"completed":"%s"}\n' \
+  "$TEST_NAMESPACE" "$TEST_NAME" "$TEST_UID" "$code" "$(date -u +%Y-%m-%dT%H:%M:%SZ)" > "$YAKS_REPORTS_DIR/test.json"
+exit $code
+`
+
+// reportClaimFor returns the persistent volume claim where the reports of the test are kept, if any, and the
+// directory of the runner reports copied to it
+func reportClaimFor(test *v1alpha1.Test) (string, string) {
+	claim, reports := config.GetReportClaim(), config.GetReportPath()
+	if spec := test.Spec.Reports; spec != nil {
+		if spec.ClaimName != "" {
+			claim = spec.ClaimName
+		}
+		if spec.Path != "" {
+			reports = spec.Path
+		}
+	}
+	return claim, reports
+}
+
+// reportClaimDir returns the directory of the claim holding the reports of the test, relative to the claim root
+func reportClaimDir(test *v1alpha1.Test) string {
+	return path.Join(test.Namespace, test.Name, string(test.UID))
+}
+
+// applyReportClaim mounts the report claim into the test container, exposing the directory of the test with
+// YAKS_REPORTS_DIR, and copies the runner reports to it once the test has run
+func applyReportClaim(test *v1alpha1.Test, pod *v1.Pod) {
+	claim, reports := reportClaimFor(test)
+	if claim == "" {
+		return
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: reportClaimVolumeName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claim,
+			},
+		},
+	})
+
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      reportClaimVolumeName,
+		MountPath: reportClaimPath,
+	})
+	envvar.SetVal(&container.Env, "YAKS_REPORTS_DIR", path.Join(reportClaimPath, reportClaimDir(test)))
+	if reports != "" {
+		envvar.SetVal(&container.Env, "YAKS_RUNNER_REPORTS", reports)
+	}
+	wrapContainerCommand(container, reportClaimRunScript)
+}
+
+// validateReportClaim checks that the report claim of the test exists and is not lost. Pending claims are accepted,
+// as the claims of storage classes binding their volumes when they are first used, i.e. WaitForFirstConsumer, stay
+// pending until the runner pod is scheduled.
+func validateReportClaim(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	claim, _ := reportClaimFor(test)
+	if claim == "" {
+		return "", nil
+	}
+	pvc, err := sharedClaims.get(ctx, c, test.Namespace, claim, time.Now())
+	if err != nil {
+		return "", err
+	} else if pvc == nil {
+		return fmt.Sprintf("report claim %s does not exist", claim), nil
+	}
+	if pvc.Status.Phase == v1.ClaimLost {
+		return fmt.Sprintf("report claim %s has lost its volume", claim), nil
+	}
+	return "", nil
+}
+
+// claimCheckInterval is how long the claims shared by the tests of a namespace, i.e. the report and the dependency
+// cache claims, are trusted once read
+const claimCheckInterval = time.Minute
+
+// claimCache holds the shared claims read recently, by namespace and name
+type claimCache struct {
+	lock   sync.Mutex
+	claims map[k8sclient.ObjectKey]cachedClaim
+}
+
+type cachedClaim struct {
+	pvc    *v1.PersistentVolumeClaim
+	readAt time.Time
+}
+
+var sharedClaims = claimCache{claims: make(map[k8sclient.ObjectKey]cachedClaim)}
+
+// get returns the given claim, read again when it has been read for longer than the check interval, or nil when it
+// does not exist
+func (cache *claimCache) get(ctx context.Context, c client.Client, namespace string, name string, now time.Time) (*v1.PersistentVolumeClaim, error) {
+	key := k8sclient.ObjectKey{Namespace: namespace, Name: name}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if cached, ok := cache.claims[key]; ok && now.Sub(cached.readAt) < claimCheckInterval {
+		return cached.pvc, nil
+	}
+	pvc := v1.PersistentVolumeClaim{}
+	err := c.Get(ctx, key, &pvc)
+	if err != nil && k8serrors.IsNotFound(err) {
+		// Not cached, so that the claim is used as soon as it is created
+		delete(cache.claims, key)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cache.claims[key] = cachedClaim{pvc: &pvc, readAt: now}
+	return &pvc, nil
+}
+
+// isReadWriteMany tells whether the claim can be mounted by the pods of several nodes, from the access modes requested by
+// the claim while it is pending
+func isReadWriteMany(pvc *v1.PersistentVolumeClaim) bool {
+	modes := pvc.Status.AccessModes
+	if pvc.Status.Phase != v1.ClaimBound {
+		modes = pvc.Spec.AccessModes
+	}
+	for _, mode := range modes {
+		if mode == v1.ReadWriteMany {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReportClaim records where the reports of the test are kept, and warns with the ReportStorageShared condition
+// when the claim is not ReadWriteMany, in which case concurrent tests scheduled on other nodes cannot mount it and
+// stay pending
+func checkReportClaim(ctx context.Context, c client.Client, test *v1alpha1.Test) error {
+	claim, _ := reportClaimFor(test)
+	if claim == "" {
+		return nil
+	}
+	pvc, err := sharedClaims.get(ctx, c, test.Namespace, claim, time.Now())
+	if err != nil {
+		return err
+	} else if pvc == nil {
+		return k8serrors.NewNotFound(v1.Resource("persistentvolumeclaims"), claim)
+	}
+	test.Status.Reports = claim + ":" + reportClaimDir(test)
+	if isReadWriteMany(pvc) {
+		setCondition(test, v1alpha1.TestConditionReportStorageShared, v1.ConditionTrue, "ReadWriteMany", "")
+		return nil
+	}
+	Log.ForTest(test).Info("Report claim is not ReadWriteMany, concurrent tests on other nodes cannot mount it", "claim", claim)
+	setCondition(test, v1alpha1.TestConditionReportStorageShared, v1.ConditionFalse, "NotReadWriteMany",
+		fmt.Sprintf("report claim %s is not ReadWriteMany, concurrent tests scheduled on other nodes cannot mount it", claim))
+	return nil
+}
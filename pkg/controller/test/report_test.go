@@ -0,0 +1,68 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReportClaim(t *testing.T) {
+	defer func() {
+		sharedClaims = claimCache{claims: make(map[k8sclient.ObjectKey]cachedClaim)}
+	}()
+	// Bound when the runner pod is scheduled, e.g. with the WaitForFirstConsumer binding mode
+	pending := newClaim("reports", v1.ClaimPending)
+	pending.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}
+	c := testutil.NewFakeClient(pending)
+	test := newTestForStart()
+	test.Spec.Reports = &v1alpha1.ReportsSpec{ClaimName: "reports"}
+
+	message, err := validateReportClaim(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+	assert.Nil(t, checkReportClaim(context.TODO(), c, test))
+	assert.Equal(t, v1.ConditionTrue, test.Status.Conditions[0].Status)
+
+	// The claim is read once for all the tests of the namespace
+	assert.Nil(t, c.Delete(context.TODO(), pending))
+	message, err = validateReportClaim(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+
+	// until it is read again
+	pvc, err := sharedClaims.get(context.TODO(), c, "ns", "reports", time.Now().Add(claimCheckInterval))
+	assert.Nil(t, err)
+	assert.Nil(t, pvc)
+	message, err = validateReportClaim(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "report claim reports does not exist", message)
+
+	assert.Nil(t, c.Create(context.TODO(), newClaim("reports", v1.ClaimLost)))
+	message, err = validateReportClaim(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "report claim reports has lost its volume", message)
+}
@@ -0,0 +1,50 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+)
+
+// unmetRequirement returns the first API group or group version required by the test that is not available
+// in the cluster, if any
+func unmetRequirement(c client.Client, test *v1alpha1.Test) (string, error) {
+	if len(test.Spec.Requires) == 0 {
+		return "", nil
+	}
+
+	groups, err := c.Discovery().ServerGroups()
+	if err != nil {
+		return "", err
+	}
+	available := make(map[string]bool)
+	for _, group := range groups.Groups {
+		available[group.Name] = true
+		for _, version := range group.Versions {
+			available[version.GroupVersion] = true
+		}
+	}
+
+	for _, requirement := range test.Spec.Requires {
+		if !available[requirement] {
+			return requirement, nil
+		}
+	}
+	return "", nil
+}
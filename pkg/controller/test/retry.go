@@ -0,0 +1,140 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultRetryLimit is how many times a test is retried on the reasons of its retryOnFailure when it has no retry limit
+const defaultRetryLimit = int32(1)
+
+// Backoff of the retries of the tests, doubled on each retry
+const (
+	retryBackoff    = 10 * time.Second
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// retryableReasons are the reasons of the failures a test can be retried on, in the order they are listed. The invalid
+// and cancelled tests would fail the same way when run again.
+var retryableReasons = []v1alpha1.TestReason{
+	v1alpha1.TestReasonScenarioFailed,
+	v1alpha1.TestReasonAssertionFailed,
+	v1alpha1.TestReasonFixtureFailed,
+	v1alpha1.TestReasonReadinessGateTimeout,
+	v1alpha1.TestReasonTargetNamespaceUnavailable,
+	v1alpha1.TestReasonSecretConflict,
+	v1alpha1.TestReasonRBACDenied,
+	v1alpha1.TestReasonQuotaExceeded,
+	v1alpha1.TestReasonImagePullError,
+	v1alpha1.TestReasonTimeout,
+	v1alpha1.TestReasonOutOfMemory,
+	v1alpha1.TestReasonRunnerError,
+	v1alpha1.TestReasonRunnerNotFound,
+}
+
+// isRetriedByOperator tells whether the failures of the test are retried by the operator, on the reasons of its
+// retryOnFailure, instead of by the Job running it
+func isRetriedByOperator(test *v1alpha1.Test) bool {
+	return len(test.Spec.Runtime.RetryOnFailure) > 0
+}
+
+// retryLimitFor returns how many times the test is retried by the operator
+func retryLimitFor(test *v1alpha1.Test) int32 {
+	if test.Spec.Runtime.RetryLimit != nil {
+		return *test.Spec.Runtime.RetryLimit
+	}
+	return defaultRetryLimit
+}
+
+// canRetryOnFailure tells whether the test has just failed, coming from the given phase, with one of the reasons of its
+// retryOnFailure and it has retries left
+func canRetryOnFailure(test *v1alpha1.Test, from v1alpha1.TestPhase) bool {
+	if from != v1alpha1.TestPhasePending && from != v1alpha1.TestPhaseRunning {
+		return false
+	}
+	if test.Status.Phase != v1alpha1.TestPhaseFailed && test.Status.Phase != v1alpha1.TestPhaseError {
+		return false
+	}
+	return isRetriedOn(test, test.Status.Reason) && test.Status.Retries < retryLimitFor(test)
+}
+
+// retryOnFailure runs the test again, after a backoff, when it can be retried on the reason of its failure. It returns
+// whether the test is run again. The runner of the failed run must be deleted by the caller.
+func retryOnFailure(test *v1alpha1.Test, from v1alpha1.TestPhase, now time.Time) bool {
+	if !canRetryOnFailure(test, from) {
+		return false
+	}
+
+	test.Status.Retries++
+	test.Status.RetryAt = &metav1.Time{Time: now.Add(retryBackoffFor(test.Status.Retries))}
+	test.Status.Message = fmt.Sprintf("Test run again after failing with reason %s, retry %d of %d", test.Status.Reason,
+		test.Status.Retries, retryLimitFor(test))
+	test.Status.Phase = v1alpha1.IntegrationTestPhaseNone
+	return true
+}
+
+// retryBackoffFor returns how long the given retry of a test is delayed
+func retryBackoffFor(retry int32) time.Duration {
+	backoff := retryBackoff
+	for i := int32(1); i < retry && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+func isRetriedOn(test *v1alpha1.Test, reason v1alpha1.TestReason) bool {
+	for _, r := range test.Spec.Runtime.RetryOnFailure {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRetryOnFailure(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for _, reason := range test.Spec.Runtime.RetryOnFailure {
+		if !isRetryable(reason) {
+			names := make([]string, 0, len(retryableReasons))
+			for _, r := range retryableReasons {
+				names = append(names, string(r))
+			}
+			return fmt.Sprintf("cannot retry on failure reason %q, expected one of %s", reason, strings.Join(names, ", ")), nil
+		}
+	}
+	return "", nil
+}
+
+func isRetryable(reason v1alpha1.TestReason) bool {
+	for _, r := range retryableReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRetryOnFailure(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonImagePullError, v1alpha1.TestReasonTimeout}
+
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonTimeout
+	assert.True(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+	assert.Equal(t, v1alpha1.IntegrationTestPhaseNone, test.Status.Phase)
+	assert.Equal(t, int32(1), test.Status.Retries)
+	assert.Equal(t, "Test run again after failing with reason Timeout, retry 1 of 1", test.Status.Message)
+
+	// No retry left
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonImagePullError
+	assert.False(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+	assert.Equal(t, v1alpha1.TestPhaseError, test.Status.Phase)
+
+	limit := int32(3)
+	test.Spec.Runtime.RetryLimit = &limit
+	assert.True(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+	assert.Equal(t, int32(2), test.Status.Retries)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	now := time.Date(2019, 10, 1, 8, 0, 0, 0, time.UTC)
+	limit := int32(2)
+	test := newTestForStart()
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonTimeout}
+	test.Spec.Runtime.RetryLimit = &limit
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonTimeout
+
+	assert.True(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, now))
+	assert.Equal(t, now.Add(10*time.Second), test.Status.RetryAt.Time)
+
+	// The next run starts once the backoff has elapsed
+	start, err := scheduledStart(test)
+	assert.Nil(t, err)
+	assert.Equal(t, test.Status.RetryAt.Time, start.Time)
+
+	test.Status.Phase = v1alpha1.TestPhaseError
+	assert.True(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, now))
+	assert.Equal(t, now.Add(20*time.Second), test.Status.RetryAt.Time)
+
+	assert.Equal(t, maxRetryBackoff, retryBackoffFor(10))
+}
+
+func TestRetryOnFailureIgnoresOtherReasons(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonImagePullError}
+
+	test.Status.Phase = v1alpha1.TestPhaseFailed
+	test.Status.Reason = v1alpha1.TestReasonAssertionFailed
+	assert.False(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+	assert.Equal(t, v1alpha1.TestPhaseFailed, test.Status.Phase)
+	assert.Equal(t, int32(0), test.Status.Retries)
+
+	// Only the runs that have just failed are retried
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonImagePullError
+	assert.False(t, retryOnFailure(test, v1alpha1.TestPhaseError, time.Now()))
+
+	// Nothing is retried without retryOnFailure
+	test.Spec.Runtime.RetryOnFailure = nil
+	assert.False(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+}
+
+func TestRetriedTestEvent(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonOutOfMemory}
+	test.Status.Phase = v1alpha1.TestPhaseError
+	test.Status.Reason = v1alpha1.TestReasonOutOfMemory
+	assert.True(t, retryOnFailure(test, v1alpha1.TestPhaseRunning, time.Now()))
+
+	eventType, reason, message, ok := lifecycleEvent(test, v1alpha1.TestPhaseRunning)
+	assert.True(t, ok)
+	assert.Equal(t, v1.EventTypeWarning, eventType)
+	assert.Equal(t, eventReasonRetried, reason)
+	assert.Equal(t, "Test run again after failing with reason OutOfMemory, retry 1 of 1", message)
+}
+
+func TestValidateRetryOnFailure(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonTimeout, v1alpha1.TestReasonRunnerError}
+	message, err := validateRetryOnFailure(context.Background(), nil, test)
+	assert.Nil(t, err)
+	assert.Empty(t, message)
+
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonCancelled}
+	message, err = validateRetryOnFailure(context.Background(), nil, test)
+	assert.Nil(t, err)
+	assert.Contains(t, message, `cannot retry on failure reason "Cancelled"`)
+}
+
+func TestRetryLimitWithRetryOnFailure(t *testing.T) {
+	limit := int32(2)
+	test := newTestForStart()
+	test.Spec.Runtime.Workload = v1alpha1.WorkloadTypePod
+	test.Spec.Runtime.RetryLimit = &limit
+
+	message, err := validateWorkload(context.Background(), nil, test)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, message)
+
+	// The operator retries the tests of any workload
+	test.Spec.Runtime.RetryOnFailure = []v1alpha1.TestReason{v1alpha1.TestReasonTimeout}
+	message, err = validateWorkload(context.Background(), nil, test)
+	assert.Nil(t, err)
+	assert.Empty(t, message)
+
+	// The Job does not retry on its own
+	test.Spec.Runtime.Workload = v1alpha1.WorkloadTypeJob
+	job := newTestingJob(test, &v1.Pod{})
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+
+	test.Spec.Runtime.RetryOnFailure = nil
+	job = newTestingJob(test, &v1.Pod{})
+	assert.Equal(t, int32(2), *job.Spec.BackoffLimit)
+}
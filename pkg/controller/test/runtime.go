@@ -0,0 +1,536 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// podCustomizer applies the runtime settings of a test to its test pod
+type podCustomizer func(test *v1alpha1.Test, pod *v1.Pod)
+
+var podCustomizers = []podCustomizer{
+	applyEnv,
+	applyTestMetadata,
+	applyTraceID,
+	applyJavaOptions,
+	applyCommand,
+	applyWorkspace,
+	applyReportClaim,
+	applyDependencyCache,
+	applyTrustedCA,
+	applyClusterAccess,
+	applyTargetNamespace,
+	applyEndpoints,
+	applyEphemeralSecrets,
+	applySourceFilter,
+	applyExperimentalAnnotations,
+	applyPodMetadata,
+	applyDebug,
+	applyTrafficCapture,
+	applyAssertions,
+	applyLogShipping,
+}
+
+const (
+	trustedCAPath        = "/etc/yaks/ca"
+	trustStorePath       = "/etc/yaks/truststore"
+	trustStorePassword   = "changeit"
+	trustedCAVolumeName  = "trusted-ca"
+	trustStoreVolumeName = "truststore"
+)
+
+// importTrustedCAScript copies the default Java trust store and imports every certificate found in the
+// trusted CA bundles, splitting bundles containing multiple certificates
+const importTrustedCAScript = `set -e
+truststore=` + trustStorePath + `/cacerts
+cp "$(find -L "${JAVA_HOME:-/usr/lib/jvm}" -name cacerts | head -n 1)" "$truststore"
+chmod u+w "$truststore"
+mkdir -p /tmp/ca
+for file in ` + trustedCAPath + `/*; do
+  awk -v prefix="/tmp/ca/$(basename "$file")-" '/-----BEGIN CERTIFICATE-----/{n++} n>0{print > (prefix n ".pem")}' "$file"
+done
+for cert in /tmp/ca/*.pem; do
+  keytool -importcert -noprompt -alias "yaks-$(basename "$cert" .pem)" -file "$cert" -keystore "$truststore" -storepass ` + trustStorePassword + `
+done
+`
+
+// applyTrustedCA mounts the trusted CA bundles into the test container, exposing them to OpenSSL based
+// tools, and imports them into a Java trust store prepared by an init container
+func applyTrustedCA(test *v1alpha1.Test, pod *v1.Pod) {
+	if test.Spec.Runtime.TrustedCA == nil {
+		return
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		v1.Volume{
+			Name: trustedCAVolumeName,
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: *test.Spec.Runtime.TrustedCA,
+				},
+			},
+		},
+		v1.Volume{
+			Name: trustStoreVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+
+	mounts := []v1.VolumeMount{
+		{
+			Name:      trustedCAVolumeName,
+			MountPath: trustedCAPath,
+			ReadOnly:  true,
+		},
+		{
+			Name:      trustStoreVolumeName,
+			MountPath: trustStorePath,
+		},
+	}
+
+	container := &pod.Spec.Containers[0]
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{
+		Name:            "import-trusted-ca",
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", importTrustedCAScript},
+		VolumeMounts:    mounts,
+	})
+
+	container.VolumeMounts = append(container.VolumeMounts, mounts...)
+	envvar.SetVal(&container.Env, "SSL_CERT_DIR", trustedCAPath)
+	appendJavaOptions(container, "-Djavax.net.ssl.trustStore="+trustStorePath+"/cacerts -Djavax.net.ssl.trustStorePassword="+trustStorePassword)
+}
+
+// appendCucumberOptions adds the given options to the ones passed to Cucumber by the runner
+func appendCucumberOptions(container *v1.Container, options string) {
+	if current := envvar.Get(container.Env, "CUCUMBER_OPTIONS"); current != nil && current.Value != "" {
+		options = current.Value + " " + options
+	}
+	envvar.SetVal(&container.Env, "CUCUMBER_OPTIONS", options)
+}
+
+// applyJavaOptions passes the Java options of the test, or the operator wide default ones, to the JVM of the runner,
+// after the ones of the runtime env
+func applyJavaOptions(test *v1alpha1.Test, pod *v1.Pod) {
+	options := test.Spec.Runtime.JavaOptions
+	if options == "" {
+		options = config.GetDefaultJavaOptions()
+	}
+	if options = strings.TrimSpace(options); options != "" {
+		appendJavaOptions(&pod.Spec.Containers[0], options)
+	}
+}
+
+// appendJavaOptions adds the given options to the ones passed to the JVM by the runner
+func appendJavaOptions(container *v1.Container, options string) {
+	if current := envvar.Get(container.Env, "JAVA_OPTIONS"); current != nil && current.Value != "" {
+		options = current.Value + " " + options
+	}
+	envvar.SetVal(&container.Env, "JAVA_OPTIONS", options)
+}
+
+var nonEnvNameChars = regexp.MustCompile("[^A-Z0-9_]")
+
+// applyEndpoints exposes the test endpoints to the runner as YAKS_ENDPOINT_<NAME> environment variables
+func applyEndpoints(test *v1alpha1.Test, pod *v1.Pod) {
+	container := &pod.Spec.Containers[0]
+	for _, endpoint := range test.Spec.Endpoints {
+		name := "YAKS_ENDPOINT_" + nonEnvNameChars.ReplaceAllString(strings.ToUpper(endpoint.Name), "_")
+		envvar.SetVal(&container.Env, name, endpointURLFor(test, endpoint))
+	}
+}
+
+// endpointURLFor returns the URL of the endpoint, resolving service references to their cluster DNS name
+func endpointURLFor(test *v1alpha1.Test, endpoint v1alpha1.EndpointSpec) string {
+	if endpoint.Service == nil {
+		return endpoint.URL
+	}
+	host := fmt.Sprintf("%s.%s.svc", endpoint.Service.Name, serviceNamespaceFor(test, endpoint.Service))
+	if endpoint.Service.Port > 0 {
+		return fmt.Sprintf("%s:%d", host, endpoint.Service.Port)
+	}
+	return host
+}
+
+func serviceNamespaceFor(test *v1alpha1.Test, service *v1alpha1.ServiceReference) string {
+	if service.Namespace != "" {
+		return service.Namespace
+	}
+	return test.Namespace
+}
+
+// experimentalAnnotationPrefix marks the test annotations toggling experimental runner behavior
+const experimentalAnnotationPrefix = "yaks.dev/experimental-"
+
+// experimentalAnnotations maps the supported experimental annotations to the function applying them to the test container
+var experimentalAnnotations = map[string]func(container *v1.Container, value string){
+	experimentalAnnotationPrefix + "cucumber-options": appendCucumberOptions,
+	experimentalAnnotationPrefix + "java-options":     appendJavaOptions,
+}
+
+// applyExperimentalAnnotations translates the recognized experimental annotations of the test into runner settings
+func applyExperimentalAnnotations(test *v1alpha1.Test, pod *v1.Pod) {
+	keys := make([]string, 0)
+	for key := range test.Annotations {
+		if strings.HasPrefix(key, experimentalAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	container := &pod.Spec.Containers[0]
+	for _, key := range keys {
+		if apply, ok := experimentalAnnotations[key]; ok {
+			apply(container, test.Annotations[key])
+		} else {
+			Log.ForTest(test).Debug("Ignoring unknown experimental annotation", "annotation", key)
+		}
+	}
+}
+
+// applyCommand overrides the entrypoint and arguments of the test container, when set on the test
+func applyCommand(test *v1alpha1.Test, pod *v1.Pod) {
+	container := &pod.Spec.Containers[0]
+	if len(test.Spec.Runtime.Command) > 0 {
+		container.Command = test.Spec.Runtime.Command
+	}
+	if len(test.Spec.Runtime.Args) > 0 {
+		container.Args = test.Spec.Runtime.Args
+	}
+}
+
+const (
+	workspacePath        = "/var/yaks/workspace"
+	workspaceVolumeName  = "workspace"
+	defaultWorkspaceSize = "1Gi"
+)
+
+// applyWorkspace mounts a size limited emptyDir workspace into the test container, used as its working directory
+// unless set on the test or on the container, so that runaway tests are evicted before they put the node under disk pressure
+func applyWorkspace(test *v1alpha1.Test, pod *v1.Pod) {
+	size := test.Spec.Runtime.WorkspaceSize
+	if size == "" {
+		size = defaultWorkspaceSize
+	}
+	// Invalid sizes are reported by the validation of the test
+	limit, err := resource.ParseQuantity(size)
+	if err != nil {
+		return
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: workspaceVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{
+				SizeLimit: &limit,
+			},
+		},
+	})
+
+	container := &pod.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      workspaceVolumeName,
+		MountPath: workspacePath,
+	})
+	if test.Spec.Runtime.WorkingDir != "" {
+		container.WorkingDir = test.Spec.Runtime.WorkingDir
+	} else if container.WorkingDir == "" {
+		container.WorkingDir = workspacePath
+	}
+	envvar.SetVal(&container.Env, "YAKS_WORKSPACE", workspacePath)
+}
+
+const (
+	kubeConfigPath       = "/etc/yaks/kube"
+	kubeConfigVolumeName = "kubeconfig"
+	serviceAccountPath   = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// kubeConfigTemplate configures clients to use the token of the runner service account, mounted by Kubernetes
+const kubeConfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: in-cluster
+  cluster:
+    server: https://kubernetes.default.svc
+    certificate-authority: ` + serviceAccountPath + `/ca.crt
+users:
+- name: runner
+  user:
+    tokenFile: ` + serviceAccountPath + `/token
+contexts:
+- name: runner
+  context:
+    cluster: in-cluster
+    user: runner
+    namespace: %s
+current-context: runner
+`
+
+// applyClusterAccess mounts the runner service account token and a kubeconfig file using it into the test container,
+// so that the tests can talk to the Kubernetes API
+func applyClusterAccess(test *v1alpha1.Test, pod *v1.Pod) {
+	if !test.Spec.Runtime.ClusterAccess {
+		return
+	}
+
+	automount := true
+	pod.Spec.AutomountServiceAccountToken = &automount
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: kubeConfigVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+	mount := v1.VolumeMount{
+		Name:      kubeConfigVolumeName,
+		MountPath: kubeConfigPath,
+	}
+
+	container := &pod.Spec.Containers[0]
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{
+		Name:            "write-kubeconfig",
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         []string{"/bin/sh", "-c", `printf '%s' "$KUBECONFIG_CONTENT" > ` + kubeConfigPath + "/config"},
+		Env: []v1.EnvVar{
+			{
+				Name:  "KUBECONFIG_CONTENT",
+				Value: fmt.Sprintf(kubeConfigTemplate, targetNamespaceFor(test)),
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{mount},
+	})
+
+	container.VolumeMounts = append(container.VolumeMounts, mount)
+	envvar.SetVal(&container.Env, "KUBECONFIG", kubeConfigPath+"/config")
+	envvar.SetValFrom(&container.Env, "NAMESPACE", "metadata.namespace")
+}
+
+// applyTargetNamespace passes the namespace where the test creates its resources to the runner
+func applyTargetNamespace(test *v1alpha1.Test, pod *v1.Pod) {
+	if test.Spec.Namespace == "" {
+		return
+	}
+	envvar.SetVal(&pod.Spec.Containers[0].Env, "NAMESPACE", test.Spec.Namespace)
+}
+
+// applySourceFilter restricts the scenarios run by Cucumber to the ones selected by the filter of the test source
+func applySourceFilter(test *v1alpha1.Test, pod *v1.Pod) {
+	filter := test.Spec.Source.Filter
+	if filter == nil {
+		return
+	}
+
+	container := &pod.Spec.Containers[0]
+	if filter.Scenario != "" {
+		// Single quotes delimit the option value, they are matched as any character
+		name := strings.Replace(regexp.QuoteMeta(filter.Scenario), "'", ".", -1)
+		appendCucumberOptions(container, fmt.Sprintf("--name '^%s$'", name))
+	}
+	if filter.Line > 0 {
+		// Feature paths given in the options replace the ones of the runner
+		appendCucumberOptions(container, fmt.Sprintf("/etc/yaks/test/%s:%d", test.Spec.Source.Name, filter.Line))
+	}
+}
+
+// applyPodMetadata adds the custom labels and annotations of the test to the pod, the ones set by the operator are kept
+func applyPodMetadata(test *v1alpha1.Test, pod *v1.Pod) {
+	pod.Labels = mergeMissing(pod.Labels, test.Spec.Runtime.PodLabels)
+	pod.Annotations = mergeMissing(pod.Annotations, test.Spec.Runtime.PodAnnotations)
+}
+
+func mergeMissing(target map[string]string, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return target
+	}
+	if target == nil {
+		target = make(map[string]string, len(values))
+	}
+	for key, value := range values {
+		if _, ok := target[key]; !ok {
+			target[key] = value
+		}
+	}
+	return target
+}
+
+const (
+	trafficCaptureContainerName  = "traffic-capture"
+	trafficCaptureVolumeName     = "traffic-capture"
+	trafficCapturePath           = "/var/yaks/capture"
+	trafficCaptureOutputPath     = "/var/yaks/capture-output"
+	defaultTrafficCaptureFilter  = "tcp"
+	trafficCaptureDoneMarkerFile = trafficCapturePath + "/done"
+)
+
+// trafficCaptureRunScript runs the command of the test container given as arguments, then tells the capture sidecar
+// to stop, so that it does not keep the pod running
+const trafficCaptureRunScript = `"$@"
+code=$?
+touch ` + trafficCaptureDoneMarkerFile + `
+exit $code
+`
+
+// trafficCaptureScript captures the traffic of the pod until the test container is done, and writes the capture
+// to the log when it is not kept on a volume
+const trafficCaptureScript = `set -f
+tcpdump -i any -p -U -w "$CAPTURE_FILE" $CAPTURE_FILTER &
+capture=$!
+while [ ! -f ` + trafficCaptureDoneMarkerFile + ` ] && kill -0 $capture 2> /dev/null; do
+  sleep 1
+done
+kill -INT $capture 2> /dev/null
+wait $capture
+if [ "$CAPTURE_TO_LOG" = "true" ] && [ -f "$CAPTURE_FILE" ]; then
+  base64 "$CAPTURE_FILE"
+fi
+exit 0
+`
+
+// applyTrafficCapture adds a sidecar capturing the network traffic of the pod, that is shared by all its containers.
+// The command of the test container is wrapped to signal its completion to the sidecar through a shared volume.
+func applyTrafficCapture(test *v1alpha1.Test, pod *v1.Pod) {
+	capture := test.Spec.Runtime.TrafficCapture
+	if capture == nil {
+		return
+	}
+
+	container := &pod.Spec.Containers[0]
+	wrapContainerCommand(container, trafficCaptureRunScript)
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      trafficCaptureVolumeName,
+		MountPath: trafficCapturePath,
+	})
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name: trafficCaptureVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+
+	// The runner base image of the operator version ships tcpdump
+	image := capture.Image
+	if image == "" {
+		image = config.GetTestBaseImage()
+	}
+	filter := capture.Filter
+	if filter == "" {
+		filter = defaultTrafficCaptureFilter
+	}
+	sidecar := v1.Container{
+		Name:            trafficCaptureContainerName,
+		Image:           image,
+		ImagePullPolicy: imagePullPolicyFor(test),
+		Command:         []string{"/bin/sh", "-c", trafficCaptureScript},
+		Env: []v1.EnvVar{
+			{
+				Name:  "CAPTURE_FILTER",
+				Value: filter,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      trafficCaptureVolumeName,
+				MountPath: trafficCapturePath,
+			},
+		},
+		SecurityContext: &v1.SecurityContext{
+			Capabilities: &v1.Capabilities{
+				// Capturing without promiscuous mode only requires raw sockets
+				Add: []v1.Capability{"NET_RAW"},
+			},
+		},
+	}
+	if capture.Volume != "" {
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, v1.VolumeMount{
+			Name:      capture.Volume,
+			MountPath: trafficCaptureOutputPath,
+		})
+		envvar.SetVal(&sidecar.Env, "CAPTURE_FILE", fmt.Sprintf("%s/%s-%s.pcap", trafficCaptureOutputPath, test.Name, test.Status.TestID))
+	} else {
+		envvar.SetVal(&sidecar.Env, "CAPTURE_FILE", trafficCapturePath+"/traffic.pcap")
+		envvar.SetVal(&sidecar.Env, "CAPTURE_TO_LOG", "true")
+	}
+	pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+}
+
+const (
+	defaultDebugTimeout = 30 * time.Minute
+	maxDebugTimeout     = 24 * time.Hour
+	debugDoneMarkerFile = "/tmp/yaks-debug-done"
+)
+
+// debugScript runs the command of the test container given as arguments, then keeps the container alive depending
+// on the debug mode and the exit code, printing how to exec into it
+const debugScript = `"$@"
+code=$?
+if [ "$code" -ne 0 ] || [ "$YAKS_DEBUG_MODE" = "` + string(v1alpha1.DebugModeAlways) + `" ]; then
+  echo "Tests exited with code $code, the runner is kept alive for $YAKS_DEBUG_TIMEOUT seconds, attach to it with:"
+  echo "  kubectl exec -it -n $YAKS_NAMESPACE $HOSTNAME -c ` + testContainerName + ` -- /bin/sh"
+  echo "and end the session with: touch ` + debugDoneMarkerFile + `"
+  end=$(( $(date +%s) + YAKS_DEBUG_TIMEOUT ))
+  while [ ! -f ` + debugDoneMarkerFile + ` ] && [ "$(date +%s)" -lt "$end" ]; do
+    sleep 5
+  done
+fi
+exit $code
+`
+
+// debugTimeoutFor returns how long the runner container of the test is kept alive in debug mode
+func debugTimeoutFor(test *v1alpha1.Test) time.Duration {
+	if debug := test.Spec.Runtime.Debug; debug != nil && debug.Timeout != "" {
+		if timeout, err := time.ParseDuration(debug.Timeout); err == nil {
+			return timeout
+		}
+	}
+	return defaultDebugTimeout
+}
+
+// applyDebug wraps the command of the test container to keep it alive once the tests have run, the test result is
+// reported when the debug session ends
+func applyDebug(test *v1alpha1.Test, pod *v1.Pod) {
+	debug := test.Spec.Runtime.Debug
+	if debug == nil {
+		return
+	}
+	mode := debug.Mode
+	if mode == "" {
+		mode = v1alpha1.DebugModeOnFailure
+	}
+
+	container := &pod.Spec.Containers[0]
+	wrapContainerCommand(container, debugScript)
+	envvar.SetVal(&container.Env, "YAKS_DEBUG_MODE", string(mode))
+	envvar.SetVal(&container.Env, "YAKS_DEBUG_TIMEOUT", strconv.Itoa(int(debugTimeoutFor(test).Seconds())))
+	envvar.SetValFrom(&container.Env, "YAKS_NAMESPACE", "metadata.namespace")
+}
@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newPodForWorkspace(workingDir string) *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: testContainerName, WorkingDir: workingDir}},
+		},
+	}
+}
+
+func TestApplyWorkspace(t *testing.T) {
+	test := &v1alpha1.Test{}
+	pod := newPodForWorkspace("")
+	applyWorkspace(test, pod)
+
+	assert.Len(t, pod.Spec.Volumes, 1)
+	assert.Equal(t, workspaceVolumeName, pod.Spec.Volumes[0].Name)
+	assert.Equal(t, resource.MustParse(defaultWorkspaceSize), *pod.Spec.Volumes[0].EmptyDir.SizeLimit)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, []v1.VolumeMount{{Name: workspaceVolumeName, MountPath: workspacePath}}, container.VolumeMounts)
+	assert.Equal(t, workspacePath, container.WorkingDir)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "YAKS_WORKSPACE", Value: workspacePath})
+}
+
+func TestApplyWorkspaceSize(t *testing.T) {
+	test := &v1alpha1.Test{}
+	test.Spec.Runtime.WorkspaceSize = "200Mi"
+	pod := newPodForWorkspace("")
+	applyWorkspace(test, pod)
+	assert.Equal(t, resource.MustParse("200Mi"), *pod.Spec.Volumes[0].EmptyDir.SizeLimit)
+
+	// Reported by the validation of the test
+	test.Spec.Runtime.WorkspaceSize = "lots"
+	pod = newPodForWorkspace("")
+	applyWorkspace(test, pod)
+	assert.Empty(t, pod.Spec.Volumes)
+	assert.Empty(t, pod.Spec.Containers[0].WorkingDir)
+}
+
+func TestApplyWorkspaceWorkingDir(t *testing.T) {
+	// The working directory already set on the container is kept
+	pod := newPodForWorkspace("/deployments")
+	applyWorkspace(&v1alpha1.Test{}, pod)
+	assert.Equal(t, "/deployments", pod.Spec.Containers[0].WorkingDir)
+
+	// The one of the test wins
+	test := &v1alpha1.Test{}
+	test.Spec.Runtime.WorkingDir = "/var/yaks/workspace/tests"
+	pod = newPodForWorkspace("/deployments")
+	applyWorkspace(test, pod)
+	assert.Equal(t, "/var/yaks/workspace/tests", pod.Spec.Containers[0].WorkingDir)
+}
@@ -0,0 +1,60 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scheduledStart returns when the test must be started according to its startAfter, either a timestamp or a duration
+// from the time the test is pending, or to the backoff of its retry, or nil when the start is not delayed
+func scheduledStart(test *v1alpha1.Test) (*metav1.Time, error) {
+	start, err := startAfter(test)
+	if err != nil {
+		return nil, err
+	}
+	if retryAt := test.Status.RetryAt; retryAt != nil && test.Status.Retries > 0 && (start == nil || start.Before(retryAt)) {
+		start = retryAt.DeepCopy()
+	}
+	return start, nil
+}
+
+func startAfter(test *v1alpha1.Test) (*metav1.Time, error) {
+	value := test.Spec.StartAfter
+	if value == "" {
+		return nil, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return &metav1.Time{Time: timestamp}, nil
+	}
+	delay, err := config.ParseTTL(value)
+	if err != nil {
+		return nil, err
+	}
+	since := pendingSince(test)
+	return &metav1.Time{Time: since.Add(delay)}, nil
+}
+
+// isScheduled tells whether the test is pending until its scheduled start
+func isScheduled(test *v1alpha1.Test) bool {
+	return test.Status.Phase == v1alpha1.TestPhasePending && test.Status.Reason == v1alpha1.TestReasonScheduled
+}
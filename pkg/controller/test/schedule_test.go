@@ -0,0 +1,65 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScheduledStart(t *testing.T) {
+	pending := time.Date(2019, 10, 1, 8, 0, 0, 0, time.UTC)
+	test := newTestForStart()
+	test.Status.Timings = &v1alpha1.TestTimings{Pending: &metav1.Time{Time: pending}}
+
+	start, err := scheduledStart(test)
+	assert.Nil(t, err)
+	assert.Nil(t, start)
+
+	test.Spec.StartAfter = "10m"
+	start, err = scheduledStart(test)
+	assert.Nil(t, err)
+	assert.Equal(t, pending.Add(10*time.Minute), start.Time)
+
+	test.Spec.StartAfter = "2019-10-02T09:30:00Z"
+	start, err = scheduledStart(test)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, 10, 2, 9, 30, 0, 0, time.UTC), start.UTC())
+
+	test.Spec.StartAfter = "tomorrow"
+	_, err = scheduledStart(test)
+	assert.NotNil(t, err)
+}
+
+func TestScheduledTestsRequeuedAtTheirStart(t *testing.T) {
+	test := newTestForStart()
+	test.Status.Phase = v1alpha1.TestPhasePending
+	test.Status.Reason = v1alpha1.TestReasonScheduled
+	test.Status.ScheduledStart = &metav1.Time{Time: time.Now().Add(time.Hour)}
+
+	result := requeueResultFor(test, v1alpha1.InstanceConfig{})
+	assert.True(t, result.RequeueAfter > 59*time.Minute)
+
+	test.Status.ScheduledStart = &metav1.Time{Time: time.Now().Add(-time.Second)}
+	assert.True(t, requeueResultFor(test, v1alpha1.InstanceConfig{}).Requeue)
+}
@@ -0,0 +1,208 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// randomPlaceholder is replaced with a random string in the templates of the ephemeral secret keys
+	randomPlaceholder = "RANDOM"
+	// defaultRandomLength is the length of the random strings of the ephemeral secret keys
+	defaultRandomLength = 16
+	maxRandomLength     = 256
+	randomChars         = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// secretPlaceholder matches the ${RANDOM} and ${<key>} placeholders of the templates of the ephemeral secret keys
+var secretPlaceholder = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// ephemeralSecretEnvVar returns the environment variable exposing the key of the ephemeral secret to the runner
+func ephemeralSecretEnvVar(secret string, key string) string {
+	return "YAKS_SECRET_" + nonEnvNameChars.ReplaceAllString(strings.ToUpper(secret), "_") + "_" +
+		nonEnvNameChars.ReplaceAllString(strings.ToUpper(key), "_")
+}
+
+// randomString returns a random alphanumeric string of the given length
+func randomString(length int) (string, error) {
+	value := make([]byte, 0, length)
+	buffer := make([]byte, length)
+	for len(value) < length {
+		if _, err := rand.Read(buffer); err != nil {
+			return "", err
+		}
+		for _, b := range buffer {
+			// Bytes above the largest multiple of the number of chars are dropped, for all the chars to be equally likely
+			if int(b) < 256-256%len(randomChars) && len(value) < length {
+				value = append(value, randomChars[int(b)%len(randomChars)])
+			}
+		}
+	}
+	return string(value), nil
+}
+
+// generateSecretData generates the values of the keys of the ephemeral secret, in order
+func generateSecretData(secret v1alpha1.EphemeralSecretSpec) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(secret.Keys))
+	for _, key := range secret.Keys {
+		template := key.Template
+		if template == "" {
+			template = "${" + randomPlaceholder + "}"
+		}
+		length := defaultRandomLength
+		if key.Length > 0 {
+			length = int(key.Length)
+		}
+		var err error
+		value := secretPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+			name := secretPlaceholder.FindStringSubmatch(placeholder)[1]
+			if name != randomPlaceholder {
+				return string(data[name])
+			}
+			random, e := randomString(length)
+			if e != nil {
+				err = e
+			}
+			return random
+		})
+		if err != nil {
+			return nil, err
+		}
+		data[key.Name] = []byte(value)
+	}
+	return data, nil
+}
+
+// ensureEphemeralSecrets generates the ephemeral secrets of the test that do not exist yet, before its dependencies are
+// checked, so that the fixtures can reference them. The secrets are kept for all the runs of the test, that share
+// their values, and garbage collected with it. A secret left by a deleted test of the same name is generated again, while
+// a secret that has not been generated for the test is not replaced, the returned message telling so.
+func ensureEphemeralSecrets(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	secrets := c.CoreV1().Secrets(test.Namespace)
+	for _, spec := range test.Spec.EphemeralSecrets {
+		existing, err := secrets.Get(spec.Name, metav1.GetOptions{})
+		if err == nil {
+			owner := metav1.GetControllerOf(existing)
+			if owner == nil || owner.Kind != v1alpha1.TestKind || owner.Name != test.Name {
+				return fmt.Sprintf("secret %s already exists and has not been generated for the test", spec.Name), nil
+			}
+			if owner.UID == test.UID {
+				continue
+			}
+			if err := secrets.Delete(spec.Name, &metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return "", err
+			}
+		} else if !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+
+		data, err := generateSecretData(spec)
+		if err != nil {
+			return "", err
+		}
+		labels := TestLabelsFor(test)
+		// The secret outlives the current run
+		delete(labels, "yaks.dev/test-id")
+		secret := v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       test.Namespace,
+				Name:            spec.Name,
+				Labels:          labels,
+				OwnerReferences: TestOwnerReferencesFor(test),
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: data,
+		}
+		if _, err := secrets.Create(&secret); err != nil {
+			return "", err
+		}
+		Log.ForTest(test).Info("Ephemeral secret generated", "secret", spec.Name)
+	}
+	return "", nil
+}
+
+// applyEphemeralSecrets exposes the keys of the ephemeral secrets to the runner as YAKS_SECRET_<SECRET>_<KEY>
+// environment variables, that reference the secrets so that their values are not part of the runner pod
+func applyEphemeralSecrets(test *v1alpha1.Test, pod *v1.Pod) {
+	container := &pod.Spec.Containers[0]
+	for _, secret := range test.Spec.EphemeralSecrets {
+		for _, key := range secret.Keys {
+			container.Env = append(container.Env, v1.EnvVar{
+				Name: ephemeralSecretEnvVar(secret.Name, key.Name),
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: secret.Name},
+						Key:                  key.Name,
+					},
+				},
+			})
+		}
+	}
+}
+
+func validateEphemeralSecrets(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if len(test.Spec.EphemeralSecrets) > 0 && targetNamespaceFor(test) != test.Namespace {
+		return fmt.Sprintf("ephemeral secrets are generated in the namespace of the test, they cannot be shared with the fixtures of target namespace %s",
+			targetNamespaceFor(test)), nil
+	}
+	names := make(map[string]bool)
+	for _, secret := range test.Spec.EphemeralSecrets {
+		if errs := validation.IsDNS1123Subdomain(secret.Name); len(errs) > 0 {
+			return fmt.Sprintf("invalid ephemeral secret name %q: %s", secret.Name, strings.Join(errs, ", ")), nil
+		}
+		if names[secret.Name] {
+			return fmt.Sprintf("ephemeral secret %s is declared more than once", secret.Name), nil
+		}
+		names[secret.Name] = true
+
+		keys := make(map[string]bool)
+		for _, key := range secret.Keys {
+			if errs := validation.IsConfigMapKey(key.Name); len(errs) > 0 {
+				return fmt.Sprintf("invalid key %q of ephemeral secret %s: %s", key.Name, secret.Name, strings.Join(errs, ", ")), nil
+			}
+			if keys[key.Name] {
+				return fmt.Sprintf("key %s of ephemeral secret %s is declared more than once", key.Name, secret.Name), nil
+			}
+			if key.Length < 0 || key.Length > maxRandomLength {
+				return fmt.Sprintf("invalid length %d of key %s of ephemeral secret %s, expected at most %d", key.Length, key.Name, secret.Name, maxRandomLength), nil
+			}
+			for _, match := range secretPlaceholder.FindAllStringSubmatch(key.Template, -1) {
+				if match[1] != randomPlaceholder && !keys[match[1]] {
+					return fmt.Sprintf("template of key %s of ephemeral secret %s references %s, that is not a previous key of the secret", key.Name, secret.Name, match[0]), nil
+				}
+			}
+			keys[key.Name] = true
+		}
+		if len(keys) == 0 {
+			return fmt.Sprintf("ephemeral secret %s has no keys", secret.Name), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,88 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateSecretData(t *testing.T) {
+	secret := v1alpha1.EphemeralSecretSpec{
+		Name: "db",
+		Keys: []v1alpha1.EphemeralSecretKey{
+			{Name: "password", Length: 24},
+			{Name: "url", Template: "postgresql://test:${password}@db:5432/test"},
+			{Name: "token", Template: "t-${RANDOM}"},
+		},
+	}
+	data, err := generateSecretData(secret)
+	assert.Nil(t, err)
+	assert.Regexp(t, regexp.MustCompile("^[A-Za-z0-9]{24}$"), string(data["password"]))
+	assert.Equal(t, "postgresql://test:"+string(data["password"])+"@db:5432/test", string(data["url"]))
+	assert.Regexp(t, regexp.MustCompile("^t-[A-Za-z0-9]{16}$"), string(data["token"]))
+
+	again, err := generateSecretData(secret)
+	assert.Nil(t, err)
+	assert.NotEqual(t, data["password"], again["password"])
+}
+
+func TestApplyEphemeralSecrets(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.EphemeralSecrets = []v1alpha1.EphemeralSecretSpec{
+		{Name: "db-credentials", Keys: []v1alpha1.EphemeralSecretKey{{Name: "password"}}},
+	}
+	pod := v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: testContainerName}}}}
+	applyEphemeralSecrets(test, &pod)
+
+	env := pod.Spec.Containers[0].Env
+	assert.Len(t, env, 1)
+	assert.Equal(t, "YAKS_SECRET_DB_CREDENTIALS_PASSWORD", env[0].Name)
+	assert.Empty(t, env[0].Value)
+	assert.Equal(t, "db-credentials", env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", env[0].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestValidateEphemeralSecrets(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.EphemeralSecrets = []v1alpha1.EphemeralSecretSpec{
+		{Name: "db", Keys: []v1alpha1.EphemeralSecretKey{
+			{Name: "password"},
+			{Name: "url", Template: "${user}:${password}"},
+		}},
+	}
+	message, err := validateEphemeralSecrets(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "template of key url of ephemeral secret db references ${user}, that is not a previous key of the secret", message)
+
+	test.Spec.EphemeralSecrets[0].Keys[1].Template = "${RANDOM}:${password}"
+	message, err = validateEphemeralSecrets(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Empty(t, message)
+
+	test.Spec.Namespace = "fixtures"
+	message, err = validateEphemeralSecrets(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, message)
+}
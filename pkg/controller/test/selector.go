@@ -0,0 +1,52 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// selectsOperator tells whether the test is run by the operator with the given labels. The tests without operator
+// selector are run by any operator, unless it only runs the tests that select it. An invalid selector selects any
+// operator, for the test to be set in error by the validation.
+func selectsOperator(test *v1alpha1.Test, operatorLabels map[string]string, selectedOnly bool) bool {
+	if test.Spec.OperatorSelector == nil {
+		return !selectedOnly
+	}
+	selector, err := metav1.LabelSelectorAsSelector(test.Spec.OperatorSelector)
+	if err != nil {
+		return true
+	}
+	return selector.Matches(labels.Set(operatorLabels))
+}
+
+func validateOperatorSelector(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if test.Spec.OperatorSelector == nil {
+		return "", nil
+	}
+	if _, err := metav1.LabelSelectorAsSelector(test.Spec.OperatorSelector); err != nil {
+		return fmt.Sprintf("invalid operator selector: %v", err), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectsOperator(t *testing.T) {
+	canary := map[string]string{"channel": "canary"}
+	stable := map[string]string{"channel": "stable"}
+
+	test := newTestForStart()
+	assert.True(t, selectsOperator(test, stable, false))
+	assert.False(t, selectsOperator(test, canary, true))
+
+	test.Spec.OperatorSelector = &metav1.LabelSelector{MatchLabels: canary}
+	assert.True(t, selectsOperator(test, canary, true))
+	assert.False(t, selectsOperator(test, stable, false))
+	assert.False(t, selectsOperator(test, nil, false))
+
+	test.Spec.OperatorSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "channel", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"canary"}},
+		},
+	}
+	assert.True(t, selectsOperator(test, stable, false))
+	assert.False(t, selectsOperator(test, canary, false))
+
+	test.Spec.OperatorSelector = &metav1.LabelSelector{}
+	assert.True(t, selectsOperator(test, canary, true))
+}
+
+func TestValidateOperatorSelector(t *testing.T) {
+	test := newTestForStart()
+	test.Spec.OperatorSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "channel", Operator: "Unknown"},
+		},
+	}
+
+	message, err := validateOperatorSelector(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Contains(t, message, "invalid operator selector")
+	// Left to the validation of any operator
+	assert.True(t, selectsOperator(test, nil, true))
+
+	test.Spec.OperatorSelector.MatchExpressions[0].Operator = metav1.LabelSelectorOpExists
+	message, err = validateOperatorSelector(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+}
@@ -0,0 +1,68 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// wrapContainerCommand replaces the command of the container with the given shell script, that runs the previous
+// command and arguments of the container, given to the script as its arguments, with "$@". The customizers wrapping
+// the command run in the order of podCustomizers, so that the script of the last one runs the scripts of the
+// previous ones.
+func wrapContainerCommand(container *v1.Container, script string) {
+	container.Args = append(append([]string{}, container.Command...), container.Args...)
+	container.Command = []string{"/bin/sh", "-c", script, "run"}
+}
+
+// runnerStartTimeout is how long, in seconds, a sidecar waits for the test container to record its process id. The
+// test container is started before the sidecars, so that it is only missed when the runner is killed right away.
+const runnerStartTimeout = 30
+
+// recordRunnerPidScript returns the first line of a script wrapping the command of the test container, that records
+// its process id in the given directory shared with a sidecar
+func recordRunnerPidScript(dir string) string {
+	return `echo $$ > ` + dir + `/pid
+`
+}
+
+// waitForRunnerScript returns the part of a sidecar script waiting, until it is done, for the test container wrapped
+// by the script of recordRunnerPidScript, in the given shared directory. The test container tells it is done with the
+// done marker file, that is missing when the runner has been killed, e.g. OOMKilled, the process namespace of the pod
+// being shared to tell that it is not running anymore.
+func waitForRunnerScript(dir string) string {
+	return `waited=0
+while [ ! -f ` + dir + `/done ]; do
+  if [ -f ` + dir + `/pid ]; then
+    [ -d "/proc/$(cat ` + dir + `/pid)" ] || break
+  elif [ $waited -ge ` + strconv.Itoa(runnerStartTimeout) + ` ]; then
+    break
+  fi
+  waited=$((waited + 1))
+  sleep 1
+done
+`
+}
+
+// shareProcessNamespace lets the sidecars of the pod see the processes of the test container
+func shareProcessNamespace(pod *v1.Pod) {
+	share := true
+	pod.Spec.ShareProcessNamespace = &share
+}
@@ -0,0 +1,116 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// waitForRunner runs the script of the sidecars waiting for the runner recorded in the given directory, returning
+// whether the runner has been seen done or killed
+func waitForRunner(t *testing.T, dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	script := waitForRunnerScript(dir) + `if [ -f ` + dir + `/done ]; then echo done; else echo killed; fi`
+	output, err := exec.CommandContext(ctx, "/bin/sh", "-c", script).Output()
+	assert.Nil(t, err)
+	return string(output)
+}
+
+func TestWaitForKilledRunner(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the processes are read from /proc")
+	}
+	dir, err := ioutil.TempDir("", "yaks-runner")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The runner has been killed before writing the done marker
+	killed := exec.Command("/bin/sh", "-c", "kill -KILL $$")
+	assert.NotNil(t, killed.Run())
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "pid"), []byte(strconv.Itoa(killed.Process.Pid)), 0644))
+
+	assert.Equal(t, "killed\n", waitForRunner(t, dir))
+}
+
+func TestWaitForCompletedRunner(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the processes are read from /proc")
+	}
+	dir, err := ioutil.TempDir("", "yaks-runner")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The runner is still running, until it writes the done marker
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "pid"), []byte(strconv.Itoa(os.Getpid())), 0644))
+	go func() {
+		time.Sleep(2 * time.Second)
+		_ = ioutil.WriteFile(filepath.Join(dir, "done"), nil, 0644)
+	}()
+
+	assert.Equal(t, "done\n", waitForRunner(t, dir))
+}
+
+func TestWrapContainerCommand(t *testing.T) {
+	container := v1.Container{Command: []string{"echo"}, Args: []string{"hello", "world"}}
+	wrapContainerCommand(&container, `echo inner; "$@"`)
+	wrapContainerCommand(&container, `echo outer; "$@"`)
+
+	assert.Equal(t, []string{"/bin/sh", "-c", `echo outer; "$@"`, "run"}, container.Command)
+	output, err := exec.Command(container.Command[0], append(container.Command[1:], container.Args...)...).Output()
+	assert.Nil(t, err)
+	assert.Equal(t, "outer\ninner\nhello world\n", string(output))
+}
+
+func TestStackedWrappers(t *testing.T) {
+	defer os.Unsetenv("LOG_SHIPPER_OUTPUT")
+	assert.Nil(t, os.Setenv("LOG_SHIPPER_OUTPUT", "forward -p host=fluentd.logging"))
+
+	action := startAction{}
+	test := newTestWithAssertions()
+	test.Spec.Reports = &v1alpha1.ReportsSpec{ClaimName: "reports"}
+	test.Spec.Runtime.Debug = &v1alpha1.DebugSpec{}
+	test.Spec.Runtime.TrafficCapture = &v1alpha1.TrafficCaptureSpec{}
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	container := pod.Spec.Containers[0]
+
+	// The log shipper sees the output of the other wrappers, and the reports are copied before the debug session
+	scripts := []string{logShipperRunScript, assertionsRunScript, trafficCaptureRunScript, debugScript, reportClaimRunScript}
+	chain := append(append([]string{}, container.Command...), container.Args...)
+	if !assert.True(t, len(chain) > 4*len(scripts)) {
+		return
+	}
+	for i, script := range scripts {
+		assert.Equal(t, []string{"/bin/sh", "-c", script, "run"}, chain[4*i:4*i+4])
+	}
+	assert.Equal(t, "/usr/local/s2i/run", chain[4*len(scripts)])
+}
@@ -19,17 +19,24 @@ package test
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
 	"github.com/jboss-fuse/yaks/pkg/config"
 	"github.com/jboss-fuse/yaks/pkg/install"
 	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/rbac/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 // NewStartAction creates a new start action
@@ -53,65 +60,287 @@ func (action *startAction) CanHandle(build *v1alpha1.Test) bool {
 
 // Handle handles the test
 func (action *startAction) Handle(ctx context.Context, test *v1alpha1.Test) (*v1alpha1.Test, error) {
+	if config.IsOperatorPaused() {
+		// Tests are reconciled again when the operator restarts unpaused
+		action.L.Info("Operator is paused, test start postponed")
+		return nil, nil
+	}
+	if instanceConfigFor(ctx, action.client, test).Paused {
+		// Tests are reconciled again when the instance is resumed
+		action.L.Info("Instance is paused, test start postponed")
+		return nil, nil
+	}
+
 	// Create the viewer service account
 	if err := action.ensureServiceAccountRoles(ctx, test.Namespace); err != nil {
 		return nil, err
 	}
 
+	if requirement, err := unmetRequirement(action.client, test); err != nil {
+		return nil, err
+	} else if requirement != "" {
+		action.L.Info("Test skipped", "requirement", requirement)
+		test.Status.Phase = v1alpha1.TestPhaseSkipped
+		test.Status.Reason = v1alpha1.TestReasonRequirementNotMet
+		test.Status.Message = "required API " + requirement + " is not available in the cluster"
+		return test, nil
+	}
+
+	if message, err := validate(ctx, action.client, test); err != nil {
+		return nil, err
+	} else if message != "" {
+		action.L.Info("Test cannot be started", "message", message)
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonInvalidSpec
+		test.Status.Message = message
+		return test, nil
+	}
+
+	if start, err := scheduledStart(test); err != nil {
+		return nil, err
+	} else if start != nil && time.Now().Before(start.Time) {
+		if isScheduled(test) {
+			// Reconciled again at the scheduled start
+			return nil, nil
+		}
+		action.L.Info("Test scheduled", "start", start.Time)
+		test.Status.ScheduledStart = start
+		test.Status.Reason = v1alpha1.TestReasonScheduled
+		test.Status.Message = "scheduled to start at " + start.UTC().Format(time.RFC3339)
+		return test, nil
+	} else if start != nil {
+		test.Status.ScheduledStart = start
+	}
+	if isScheduled(test) {
+		test.Status.Reason = ""
+		test.Status.Message = ""
+	}
+
+	if err := checkReportClaim(ctx, action.client, test); err != nil {
+		return nil, err
+	}
+	if err := checkDependencyCache(ctx, action.client, test); err != nil {
+		return nil, err
+	}
+
+	if message, err := action.ensureTargetNamespaceRoles(ctx, test); err != nil {
+		return nil, err
+	} else if message != "" {
+		action.L.Info("Test cannot be started", "message", message)
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonTargetNamespaceUnavailable
+		test.Status.Message = message
+		return test, nil
+	}
+
+	if message, err := ensureEphemeralSecrets(ctx, action.client, test); err != nil {
+		return nil, err
+	} else if message != "" {
+		action.L.Info("Test cannot be started", "message", message)
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.Reason = v1alpha1.TestReasonSecretConflict
+		test.Status.Message = message
+		return test, nil
+	}
+
+	if dependency, failure, err := unreadyDependency(action.client, test); err != nil {
+		return nil, err
+	} else if failure != nil {
+		// Reported as an error, not a failure, as the test itself has not been run
+		action.L.Info("Dependency failed", "dependency", dependency, "reason", failure.Reason)
+		setCondition(test, v1alpha1.TestConditionFixtureReady, v1.ConditionFalse, failure.Reason, dependency+": "+failure.Message)
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.WaitingFor = ""
+		test.Status.Reason = v1alpha1.TestReasonFixtureFailed
+		test.Status.Message = "dependency " + dependency + " failed: " + failure.Error()
+		return test, nil
+	} else if dependency != "" {
+		if test.Status.WaitingFor == dependency {
+			// Polled again after the dependency poll interval
+			return nil, nil
+		}
+		action.L.Info("Waiting for dependency", "dependency", dependency)
+		setCondition(test, v1alpha1.TestConditionFixtureReady, v1.ConditionFalse, "NotReady", dependency+" is not ready")
+		test.Status.WaitingFor = dependency
+		test.Status.Reason = ""
+		test.Status.Message = "waiting for " + dependency + " to be ready"
+		return test, nil
+	}
+	if len(test.Spec.Dependencies) > 0 {
+		setCondition(test, v1alpha1.TestConditionFixtureReady, v1.ConditionTrue, "Ready", "")
+	}
+
+	if gate, expired, err := unmetGate(action.client, test, time.Now()); err != nil {
+		return nil, err
+	} else if expired {
+		action.L.Info("Readiness gate not met", "gate", gate)
+		setCondition(test, v1alpha1.TestConditionReadinessGatesReady, v1.ConditionFalse, "Timeout", gate+" has not been met within its timeout")
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.WaitingFor = ""
+		test.Status.Reason = v1alpha1.TestReasonReadinessGateTimeout
+		test.Status.Message = "readiness gate " + gate + " has not been met within its timeout"
+		return test, nil
+	} else if gate != "" {
+		if test.Status.WaitingFor == gate {
+			// Polled again after the pending poll interval
+			return nil, nil
+		}
+		action.L.Info("Waiting for readiness gate", "gate", gate)
+		setCondition(test, v1alpha1.TestConditionReadinessGatesReady, v1.ConditionFalse, "NotMet", gate+" is not met")
+		test.Status.WaitingFor = gate
+		test.Status.Reason = ""
+		test.Status.Message = "waiting for " + gate
+		return test, nil
+	}
+	if len(test.Spec.ReadinessGates) > 0 {
+		setCondition(test, v1alpha1.TestConditionReadinessGatesReady, v1.ConditionTrue, "Met", "")
+	}
+	if test.Status.WaitingFor != "" && !strings.HasPrefix(test.Status.WaitingFor, waitingForRunnerImage) {
+		test.Status.WaitingFor = ""
+		test.Status.Message = ""
+	}
+
+	if limit := config.GetMaxConcurrentTests(); limit > 0 {
+		counts, err := countTests(ctx, action.client, test)
+		if err != nil {
+			return nil, err
+		}
+		if counts.running+counts.queuedBefore >= limit {
+			if isQueued(test) {
+				// Polled again after the pending poll interval
+				return nil, nil
+			}
+			action.L.Info("Test queued", "running", counts.running, "limit", limit)
+			test.Status.Reason = v1alpha1.TestReasonConcurrencyLimit
+			test.Status.Message = fmt.Sprintf("waiting for one of the %d concurrent test slots", limit)
+			return test, nil
+		}
+	}
+	if isQueued(test) {
+		test.Status.Reason = ""
+		test.Status.Message = ""
+	}
+
+	instance, err := instanceFor(ctx, action.client, test)
+	if err != nil {
+		return nil, err
+	}
+
 	cm := action.newTestingConfigMap(ctx, test)
-	pod := action.newTestingPod(ctx, test, cm)
-	resources := []runtime.Object{cm, pod}
-	if err := kubernetes.ReplaceResources(ctx, action.client, resources); err != nil {
+	pod := action.newTestingPod(ctx, test, cm, instance)
+	if message, done, err := action.checkRunnerImage(ctx, test, pod); err != nil {
+		return nil, err
+	} else if !done {
+		waitingFor := waitingForRunnerImage + pod.Spec.Containers[0].Image
+		if test.Status.WaitingFor == waitingFor {
+			// Polled again after the pending poll interval
+			return nil, nil
+		}
+		test.Status.WaitingFor = waitingFor
+		test.Status.Message = "checking that the runner image can be pulled"
+		return test, nil
+	} else if message != "" {
+		action.L.Info("Test cannot be started", "message", message)
+		test.Status.Phase = v1alpha1.TestPhaseError
+		test.Status.WaitingFor = ""
+		test.Status.Reason = v1alpha1.TestReasonImagePullError
+		test.Status.Message = message
+		return test, nil
+	}
+	if test.Status.WaitingFor != "" {
+		test.Status.WaitingFor = ""
+		test.Status.Message = ""
+	}
+	if err := action.createRunner(ctx, test, cm, pod); err != nil && setCreationForbidden(test, err) {
+		action.L.Info("Test cannot be started", "error", err.Error())
+		return test, nil
+	} else if err != nil {
 		return nil, err
 	}
 
+	if config.GetMaxConcurrentTests() > 0 {
+		admitted.add(test.UID, time.Now())
+	}
 	test.Status.Phase = v1alpha1.TestPhaseRunning
 	return test, nil
 }
 
-func (action *startAction) newTestingPod(ctx context.Context, test *v1alpha1.Test, cm *v1.ConfigMap) *v1.Pod {
-	controller := true
-	blockOwnerDeletion := true
+// createRunner creates the resources running the test, all owned by the test so that they are garbage collected with
+// it, i.e. the ConfigMap of the sources, the Pod or Job workload and the saved manifest if any
+func (action *startAction) createRunner(ctx context.Context, test *v1alpha1.Test, cm *v1.ConfigMap, pod *v1.Pod) error {
+	resources := []runtime.Object{cm, newTestingWorkload(test, pod)}
+	if test.Spec.Runtime.SavePodManifest {
+		manifest, err := newPodManifestConfigMap(test, pod)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, manifest)
+		test.Status.PodManifest = manifest.Name
+	}
+	return kubernetes.ReplaceResources(ctx, action.client, resources)
+}
+
+// waitingForRunnerImage prefixes the image of the runner while the registry is asked whether it exists
+const waitingForRunnerImage = "runner image "
+
+// rbacDenialPattern matches the messages of the API server denying a request because of the role of the user, e.g.
+// 'User "system:serviceaccount:ns:yaks" cannot create resource "pods" in API group "" in the namespace "ns"', as
+// opposed to the requests forbidden by a resource quota or an admission controller
+var rbacDenialPattern = regexp.MustCompile(`cannot create (resource )?"?[a-z]+`)
+
+// setCreationForbidden sets the test in error when the creation of the runner resources is forbidden, as requeuing the
+// test would fail the same way, telling whether the operator lacks the permissions to create them, the resource quota
+// of the namespace is exceeded or an admission controller has rejected them
+func setCreationForbidden(test *v1alpha1.Test, err error) bool {
+	cause := errors.Cause(err)
+	if !k8serrors.IsForbidden(cause) {
+		return false
+	}
+	test.Status.Phase = v1alpha1.TestPhaseError
+	switch message := cause.Error(); {
+	case rbacDenialPattern.MatchString(message):
+		test.Status.Reason = v1alpha1.TestReasonRBACDenied
+		test.Status.Message = fmt.Sprintf("operator is not allowed to create the test runner in namespace %s, "+
+			"run \"yaks install -n %s\" to grant it the missing permissions: %s", test.Namespace, test.Namespace, message)
+	case strings.Contains(message, "exceeded quota") || strings.Contains(message, "failed quota"):
+		test.Status.Reason = v1alpha1.TestReasonQuotaExceeded
+		test.Status.Message = fmt.Sprintf("test runner exceeds the resource quota of namespace %s: %s", test.Namespace, message)
+	default:
+		test.Status.Reason = v1alpha1.TestReasonAdmissionDenied
+		test.Status.Message = fmt.Sprintf("test runner rejected by an admission controller of namespace %s: %s", test.Namespace, message)
+	}
+	return true
+}
+
+func (action *startAction) newTestingPod(ctx context.Context, test *v1alpha1.Test, cm *v1.ConfigMap, instance *v1alpha1.Instance) *v1.Pod {
 	pod := v1.Pod{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Pod",
 			APIVersion: v1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: test.Namespace,
-			Name:      TestPodNameFor(test),
-			Labels: map[string]string{
-				"yaks.dev/app":     "yaks",
-				"yaks.dev/test":    test.Name,
-				"yaks.dev/test-id": test.Status.TestID,
-			},
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion:         test.APIVersion,
-					Kind:               test.Kind,
-					Name:               test.Name,
-					UID:                test.UID,
-					Controller:         &controller,
-					BlockOwnerDeletion: &blockOwnerDeletion,
-				},
-			},
+			Namespace:       test.Namespace,
+			Name:            TestPodNameFor(test),
+			Labels:          TestLabelsFor(test),
+			OwnerReferences: TestOwnerReferencesFor(test),
 		},
 		Spec: v1.PodSpec{
 			ServiceAccountName: "yaks-viewer",
+			ImagePullSecrets:   imagePullSecretsFor(test),
 			Containers: []v1.Container{
 				{
-					Name:            "test",
-					Image:           config.GetTestBaseImage(),
-					Command:         []string{"/usr/local/s2i/run"},
+					Name:                     testContainerName,
+					Image:                    config.GetTestBaseImage(),
+					Command:                  []string{"/usr/local/s2i/run"},
 					TerminationMessagePolicy: "FallbackToLogsOnError",
-					TerminationMessagePath: "/dev/termination-log",
-					ImagePullPolicy: v1.PullIfNotPresent,
-					VolumeMounts: []v1.VolumeMount{
+					TerminationMessagePath:   "/dev/termination-log",
+					ImagePullPolicy:          imagePullPolicyFor(test),
+					VolumeMounts: append([]v1.VolumeMount{
 						{
 							Name:      "tests",
 							MountPath: "/etc/yaks/test",
 						},
-					},
+					}, test.Spec.Runtime.VolumeMounts...),
 					Env: []v1.EnvVar{
 						{
 							Name:  "JAVA_MAIN_CLASS",
@@ -125,7 +354,7 @@ func (action *startAction) newTestingPod(ctx context.Context, test *v1alpha1.Tes
 				},
 			},
 			RestartPolicy: v1.RestartPolicyNever,
-			Volumes: []v1.Volume{
+			Volumes: append([]v1.Volume{
 				{
 					Name: "tests",
 					VolumeSource: v1.VolumeSource{
@@ -136,19 +365,50 @@ func (action *startAction) newTestingPod(ctx context.Context, test *v1alpha1.Tes
 						},
 					},
 				},
-			},
+			}, test.Spec.Runtime.Volumes...),
 		},
 	}
 
+	if instance != nil {
+		applyInstanceConfig(test, instance.Spec.Config, &pod)
+	}
+
+	for _, customizer := range podCustomizers {
+		customizer(test, &pod)
+	}
+	resolveEnvTemplates(test, &pod)
+
 	return &pod
 }
 
-func (action *startAction) newTestingConfigMap(ctx context.Context, test *v1alpha1.Test) *v1.ConfigMap {
-	controller := true
-	blockOwnerDeletion := true
+func imagePullPolicyFor(test *v1alpha1.Test) v1.PullPolicy {
+	if test.Spec.Runtime.ImagePullPolicy != "" {
+		return test.Spec.Runtime.ImagePullPolicy
+	}
+	return v1.PullIfNotPresent
+}
+
+// imagePullSecretsFor returns the image pull secrets of the test, or the operator wide default one
+func imagePullSecretsFor(test *v1alpha1.Test) []v1.LocalObjectReference {
+	if len(test.Spec.Runtime.ImagePullSecrets) > 0 {
+		return test.Spec.Runtime.ImagePullSecrets
+	}
+	if secret := config.GetDefaultImagePullSecret(); secret != "" {
+		return []v1.LocalObjectReference{
+			{
+				Name: secret,
+			},
+		}
+	}
+	return nil
+}
 
+func (action *startAction) newTestingConfigMap(ctx context.Context, test *v1alpha1.Test) *v1.ConfigMap {
 	sources := make(map[string]string)
 	sources[test.Spec.Source.Name] = test.Spec.Source.Content
+	for _, source := range test.Spec.Sources {
+		sources[source.Name] = source.Content
+	}
 
 	cm := v1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
@@ -156,29 +416,44 @@ func (action *startAction) newTestingConfigMap(ctx context.Context, test *v1alph
 			APIVersion: v1.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: test.Namespace,
-			Name:      TestResourceNameFor(test),
-			Labels: map[string]string{
-				"yaks.dev/app":     "yaks",
-				"yaks.dev/test":    test.Name,
-				"yaks.dev/test-id": test.Status.TestID,
-			},
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion:         test.APIVersion,
-					Kind:               test.Kind,
-					Name:               test.Name,
-					UID:                test.UID,
-					Controller:         &controller,
-					BlockOwnerDeletion: &blockOwnerDeletion,
-				},
-			},
+			Namespace:       test.Namespace,
+			Name:            TestResourceNameFor(test),
+			Labels:          TestLabelsFor(test),
+			OwnerReferences: TestOwnerReferencesFor(test),
 		},
 		Data: sources,
 	}
 	return &cm
 }
 
+// podManifestKey is the key of the runner pod manifest in its ConfigMap
+const podManifestKey = "pod.yaml"
+
+// newPodManifestConfigMap returns the ConfigMap holding the manifest of the runner pod, once all the defaults and
+// customizations of the operator have been applied
+func newPodManifestConfigMap(test *v1alpha1.Test, pod *v1.Pod) (*v1.ConfigMap, error) {
+	manifest, err := yaml.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	cm := v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: v1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       test.Namespace,
+			Name:            TestResourceNameFor(test) + "-pod",
+			Labels:          TestLabelsFor(test),
+			OwnerReferences: TestOwnerReferencesFor(test),
+		},
+		Data: map[string]string{
+			podManifestKey: string(manifest),
+		},
+	}
+	return &cm, nil
+}
+
 func (action *startAction) ensureServiceAccountRoles(ctx context.Context, namespace string) error {
 	rb := v1beta1.RoleBinding{}
 	rbKey := client.ObjectKey{
@@ -193,3 +468,35 @@ func (action *startAction) ensureServiceAccountRoles(ctx context.Context, namesp
 	}
 	return err
 }
+
+// targetNamespaceRoleBindingName returns the name of the role binding granting the runner of the test access to its
+// target namespace, one per test so that it can be deleted once the test has completed
+func targetNamespaceRoleBindingName(test *v1alpha1.Test) string {
+	return kubernetes.TruncateName(fmt.Sprintf("yaks-viewer-%s-%s", test.Namespace, test.Name), validation.DNS1123SubdomainMaxLength)
+}
+
+// targetNamespaceRoleBindingLabels returns the labels selecting the role binding of the test in its target namespace
+func targetNamespaceRoleBindingLabels(namespace string, name string) map[string]string {
+	return map[string]string{
+		"yaks.dev/app":            "yaks",
+		"yaks.dev/test":           name,
+		"yaks.dev/test-namespace": namespace,
+	}
+}
+
+// ensureTargetNamespaceRoles grants the runner service account access to the target namespace of the test, returning
+// a message when the namespace does not exist or the operator is not allowed to grant access to it
+func (action *startAction) ensureTargetNamespaceRoles(ctx context.Context, test *v1alpha1.Test) (string, error) {
+	namespace := targetNamespaceFor(test)
+	if namespace == test.Namespace {
+		return "", nil
+	}
+
+	err := install.ViewerRolesForNamespace(ctx, action.client, namespace, test.Namespace, targetNamespaceRoleBindingName(test), targetNamespaceRoleBindingLabels(test.Namespace, test.Name))
+	if err != nil && k8serrors.IsNotFound(err) {
+		return fmt.Sprintf("target namespace %s does not exist", namespace), nil
+	} else if err != nil && k8serrors.IsForbidden(err) {
+		return fmt.Sprintf("operator is not allowed to grant access to target namespace %s", namespace), nil
+	}
+	return "", err
+}
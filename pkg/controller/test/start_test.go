@@ -0,0 +1,343 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestForStart() *v1alpha1.Test {
+	return &v1alpha1.Test{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "hello",
+			UID:       types.UID("a1b2c3"),
+		},
+		Spec: v1alpha1.TestSpec{
+			Source: v1alpha1.SourceSpec{
+				Name:     "hello.feature",
+				Content:  "Feature: hello",
+				Language: v1alpha1.LanguageGherkin,
+			},
+		},
+		Status: v1alpha1.TestStatus{
+			TestID: "xyz",
+		},
+	}
+}
+
+func assertOwnedByTest(t *testing.T, test *v1alpha1.Test, obj metav1.Object) {
+	refs := obj.GetOwnerReferences()
+	assert.Len(t, refs, 1)
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.String(), refs[0].APIVersion)
+	assert.Equal(t, v1alpha1.TestKind, refs[0].Kind)
+	assert.Equal(t, test.Name, refs[0].Name)
+	assert.Equal(t, test.UID, refs[0].UID)
+	assert.True(t, *refs[0].Controller)
+	assert.True(t, *refs[0].BlockOwnerDeletion)
+}
+
+func TestChildResourcesOwnedByTest(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+
+	assertOwnedByTest(t, test, cm)
+	assertOwnedByTest(t, test, pod)
+}
+
+func TestJobWorkload(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	retries := int32(2)
+	test.Spec.Runtime.Workload = v1alpha1.WorkloadTypeJob
+	test.Spec.Runtime.RetryLimit = &retries
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	job, ok := newTestingWorkload(test, pod).(*batchv1.Job)
+
+	assert.True(t, ok)
+	assertOwnedByTest(t, test, job)
+	assert.Equal(t, int32(2), *job.Spec.BackoffLimit)
+	assert.Equal(t, test.Name, job.Spec.Template.Labels["yaks.dev/test"])
+	assert.Equal(t, pod.Spec.Containers, job.Spec.Template.Spec.Containers)
+}
+
+func TestCreatedRunnerOwnedByTest(t *testing.T) {
+	for _, workload := range []v1alpha1.WorkloadType{v1alpha1.WorkloadTypePod, v1alpha1.WorkloadTypeJob} {
+		c := testutil.NewFakeClient()
+		action := startAction{baseAction{client: c}}
+		test := newTestForStart()
+		test.Spec.Runtime.Workload = workload
+		test.Spec.Runtime.SavePodManifest = true
+
+		cm := action.newTestingConfigMap(context.TODO(), test)
+		pod := action.newTestingPod(context.TODO(), test, cm, nil)
+		assert.Nil(t, action.createRunner(context.TODO(), test, cm, pod))
+
+		created := []runtime.Object{&v1.ConfigMap{}, &v1.ConfigMap{}}
+		keys := []k8sclient.ObjectKey{
+			{Namespace: test.Namespace, Name: cm.Name},
+			{Namespace: test.Namespace, Name: test.Status.PodManifest},
+		}
+		if workload == v1alpha1.WorkloadTypeJob {
+			created = append(created, &batchv1.Job{})
+		} else {
+			created = append(created, &v1.Pod{})
+		}
+		keys = append(keys, k8sclient.ObjectKey{Namespace: test.Namespace, Name: pod.Name})
+		for i, obj := range created {
+			assert.Nil(t, c.Get(context.TODO(), keys[i], obj))
+			assertOwnedByTest(t, test, obj.(metav1.Object))
+		}
+	}
+}
+
+func TestStartPostponedWhileInstancePaused(t *testing.T) {
+	instance := &v1alpha1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "yaks",
+		},
+		Spec: v1alpha1.InstanceSpec{
+			Config: v1alpha1.InstanceConfig{
+				Paused: true,
+			},
+		},
+	}
+	pending := newTestForStart()
+	pending.Status.Phase = v1alpha1.TestPhasePending
+	running := newTestForStart()
+	running.Name = "running"
+	running.Status.Phase = v1alpha1.TestPhaseRunning
+	c := testutil.NewFakeClient(instance, pending, running)
+	action := startAction{baseAction{client: c, L: Log}}
+
+	target, err := action.Handle(context.TODO(), pending.DeepCopy())
+	assert.Nil(t, err)
+	assert.Nil(t, target)
+
+	// Resuming the instance reconciles its pending tests again
+	requests := pendingTestRequests(c, "ns")
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "hello", requests[0].Name)
+}
+
+func TestTrafficCaptureSidecar(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	test.Spec.Runtime.TrafficCapture = &v1alpha1.TrafficCaptureSpec{}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+
+	sidecar := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+	assert.Equal(t, trafficCaptureContainerName, sidecar.Name)
+	// Pinned to the version of the operator, with the least capabilities
+	assert.Equal(t, config.GetTestBaseImage(), sidecar.Image)
+	assert.Equal(t, []v1.Capability{"NET_RAW"}, sidecar.SecurityContext.Capabilities.Add)
+}
+
+func TestPodManifestConfigMap(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	manifest, err := newPodManifestConfigMap(test, pod)
+	assert.Nil(t, err)
+
+	assertOwnedByTest(t, test, manifest)
+	assert.Equal(t, "test-hello-pod", manifest.Name)
+	assert.Contains(t, manifest.Data[podManifestKey], "kind: Pod")
+	assert.Contains(t, manifest.Data[podManifestKey], "image: "+pod.Spec.Containers[0].Image)
+}
+
+func TestCreationForbidden(t *testing.T) {
+	test := newTestForStart()
+	forbidden := k8serrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "",
+		errors.New(`User "system:serviceaccount:ns:yaks" cannot create resource "pods" in API group "" in the namespace "ns"`))
+
+	assert.False(t, setCreationForbidden(test, errors.Wrap(errors.New("boom"), "could not create or replace pod")))
+	assert.Equal(t, v1alpha1.TestPhase(""), test.Status.Phase)
+
+	assert.True(t, setCreationForbidden(test, errors.Wrap(forbidden, "could not create or replace pod")))
+	assert.Equal(t, v1alpha1.TestPhaseError, test.Status.Phase)
+	assert.Equal(t, v1alpha1.TestReasonRBACDenied, test.Status.Reason)
+	assert.Contains(t, test.Status.Message, forbidden.Error())
+	assert.Contains(t, test.Status.Message, "yaks install -n ns")
+
+	quota := k8serrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "test-hello",
+		errors.New("exceeded quota: compute, requested: limits.memory=1Gi, used: limits.memory=4Gi, limited: limits.memory=4Gi"))
+	assert.True(t, setCreationForbidden(test, quota))
+	assert.Equal(t, v1alpha1.TestReasonQuotaExceeded, test.Status.Reason)
+	assert.Contains(t, test.Status.Message, "exceeded quota")
+
+	admission := k8serrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "test-hello",
+		errors.New("unable to validate against any pod security policy"))
+	assert.True(t, setCreationForbidden(test, admission))
+	assert.Equal(t, v1alpha1.TestReasonAdmissionDenied, test.Status.Reason)
+}
+
+func TestJavaOptions(t *testing.T) {
+	defer os.Unsetenv("DEFAULT_JAVA_OPTIONS")
+	assert.Nil(t, os.Setenv("DEFAULT_JAVA_OPTIONS", "-Xmx512m"))
+
+	action := startAction{}
+	test := newTestForStart()
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Equal(t, "-Xmx512m", envvar.Get(pod.Spec.Containers[0].Env, "JAVA_OPTIONS").Value)
+
+	test.Spec.Runtime.JavaOptions = "-Xmx1g"
+	test.Spec.Runtime.Env = []v1.EnvVar{{Name: "JAVA_OPTIONS", Value: "-Dfoo=bar"}}
+	pod = action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Equal(t, "-Dfoo=bar -Xmx1g", envvar.Get(pod.Spec.Containers[0].Env, "JAVA_OPTIONS").Value)
+}
+
+func TestReportClaim(t *testing.T) {
+	defer os.Unsetenv("REPORT_CLAIM")
+	assert.Nil(t, os.Setenv("REPORT_CLAIM", "reports"))
+
+	action := startAction{}
+	test := newTestForStart()
+	test.Spec.Reports = &v1alpha1.ReportsSpec{Path: "target/cucumber"}
+
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	container := pod.Spec.Containers[0]
+
+	assert.Equal(t, []string{"/bin/sh", "-c", reportClaimRunScript, "run"}, container.Command)
+	assert.Equal(t, "/usr/local/s2i/run", container.Args[0])
+	assert.Equal(t, "/var/yaks/reports/ns/hello/a1b2c3", envvar.Get(container.Env, "YAKS_REPORTS_DIR").Value)
+	assert.Equal(t, "target/cucumber", envvar.Get(container.Env, "YAKS_RUNNER_REPORTS").Value)
+	assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: reportClaimVolumeName, MountPath: reportClaimPath})
+
+	claims := make([]string, 0)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	assert.Equal(t, []string{"reports"}, claims)
+
+	// The claim of the test overrides the operator wide one
+	test.Spec.Reports.ClaimName = "team-reports"
+	claim, _ := reportClaimFor(test)
+	assert.Equal(t, "team-reports", claim)
+}
+
+func TestLogShipping(t *testing.T) {
+	action := startAction{}
+	test := newTestForStart()
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Len(t, pod.Spec.Containers, 1)
+
+	defer os.Unsetenv("LOG_SHIPPER_OUTPUT")
+	assert.Nil(t, os.Setenv("LOG_SHIPPER_OUTPUT", "forward -p host=fluentd.logging"))
+
+	pod = action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Len(t, pod.Spec.Containers, 2)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, []string{"/bin/sh", "-c", logShipperRunScript, "run"}, container.Command)
+	assert.Equal(t, "/usr/local/s2i/run", container.Args[0])
+
+	sidecar := pod.Spec.Containers[1]
+	assert.Equal(t, logShipperContainerName, sidecar.Name)
+	assert.Equal(t, "docker.io/fluent/fluent-bit:1.3-debug", sidecar.Image)
+	assert.Equal(t, "forward -p host=fluentd.logging", envvar.Get(sidecar.Env, "LOG_SHIPPER_OUTPUT").Value)
+	assert.Equal(t, container.VolumeMounts[len(container.VolumeMounts)-1], sidecar.VolumeMounts[0])
+	// The sidecar tells when the runner has been killed from its processes
+	assert.True(t, *pod.Spec.ShareProcessNamespace)
+}
+
+func TestDependencyCache(t *testing.T) {
+	defer os.Unsetenv("DEPENDENCY_CACHE_CLAIM")
+	defer os.Unsetenv("DEPENDENCY_CACHE_SCOPE")
+	assert.Nil(t, os.Setenv("DEPENDENCY_CACHE_CLAIM", "maven-cache"))
+
+	action := startAction{}
+	test := newTestForStart()
+	cm := action.newTestingConfigMap(context.TODO(), test)
+	pod := action.newTestingPod(context.TODO(), test, cm, nil)
+	container := pod.Spec.Containers[0]
+
+	assert.Contains(t, container.VolumeMounts, v1.VolumeMount{
+		Name:      dependencyCacheVolumeName,
+		MountPath: dependencyCachePath,
+		SubPath:   "tests/hello",
+	})
+	assert.Equal(t, "-Dmaven.repo.local=/var/yaks/cache/m2/repository", envvar.Get(container.Env, "MAVEN_OPTS").Value)
+	assert.Contains(t, envvar.Get(container.Env, "JAVA_OPTIONS").Value, "-Dmaven.repo.local=/var/yaks/cache/m2/repository")
+
+	// The runs of a logical test share its cache
+	test.Name = "hello-x7k2p"
+	test.Labels = map[string]string{v1alpha1.TestNameLabel: "hello"}
+	pod = action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Contains(t, pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      dependencyCacheVolumeName,
+		MountPath: dependencyCachePath,
+		SubPath:   "tests/hello",
+	})
+
+	assert.Nil(t, os.Setenv("DEPENDENCY_CACHE_SCOPE", "shared"))
+	pod = action.newTestingPod(context.TODO(), test, cm, nil)
+	assert.Contains(t, pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{Name: dependencyCacheVolumeName, MountPath: dependencyCachePath})
+}
+
+func TestDependencyCacheNotShared(t *testing.T) {
+	defer os.Unsetenv("DEPENDENCY_CACHE_CLAIM")
+	defer func() {
+		sharedClaims = claimCache{claims: make(map[k8sclient.ObjectKey]cachedClaim)}
+	}()
+	assert.Nil(t, os.Setenv("DEPENDENCY_CACHE_CLAIM", "maven-cache"))
+	claim := newClaim("maven-cache", v1.ClaimBound)
+	claim.Status.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+	c := testutil.NewFakeClient(claim)
+	test := newTestForStart()
+
+	assert.Nil(t, checkDependencyCache(context.TODO(), c, test))
+	assert.Len(t, test.Status.Conditions, 1)
+	condition := test.Status.Conditions[0]
+	assert.Equal(t, v1alpha1.TestConditionDependencyCacheShared, condition.Type)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, "NotReadWriteMany", condition.Reason)
+}
@@ -2,23 +2,33 @@ package test
 
 import (
 	"context"
+	"reflect"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
 	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
 	"github.com/jboss-fuse/yaks/pkg/util/log"
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 )
 
 /**
@@ -33,14 +43,32 @@ func Add(mgr manager.Manager) error {
 	if err != nil {
 		return err
 	}
+
+	if config.IsOperatorPaused() {
+		Log.Info("Operator is paused: running tests are monitored but no new test pods are started")
+		operatorPaused.Set(1)
+	} else {
+		operatorPaused.Set(0)
+	}
+	if err := metrics.Registry.Register(testCountsCollector{reader: mgr.GetClient()}); err != nil {
+		return err
+	}
+
 	return add(mgr, newReconciler(mgr, c))
 }
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager, c client.Client) reconcile.Reconciler {
+	// Not known when running outside the cluster
+	namespace, _ := k8sutil.GetOperatorNamespace()
+	operatorLabels := config.GetOperatorLabels()
 	return &ReconcileIntegrationTest{
-		client: c,
-		scheme: mgr.GetScheme(),
+		client:            c,
+		scheme:            mgr.GetScheme(),
+		recorder:          mgr.GetRecorder("yaks-operator"),
+		operatorNamespace: namespace,
+		operatorLabels:    operatorLabels,
+		selectedTestsOnly: config.ReconcileSelectedTestsOnly(operatorLabels),
 	}
 }
 
@@ -61,7 +89,11 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 			// or except when the integration phase changes as it's used to transition from one phase
 			// to another
 			return oldTest.Generation != newTest.Generation ||
-				oldTest.Status.Phase != newTest.Status.Phase
+				oldTest.Status.Phase != newTest.Status.Phase ||
+				oldTest.Annotations[v1alpha1.TestCancelAnnotation] != newTest.Annotations[v1alpha1.TestCancelAnnotation] ||
+				oldTest.Annotations[v1alpha1.TestTTLAnnotation] != newTest.Annotations[v1alpha1.TestTTLAnnotation] ||
+				oldTest.Annotations[v1alpha1.TestCleanupRulesAnnotation] != newTest.Annotations[v1alpha1.TestCleanupRulesAnnotation] ||
+				isCompleted(newTest) && !reflect.DeepEqual(oldTest.Labels, newTest.Labels)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			// Evaluates to false if the object has been confirmed deleted
@@ -95,9 +127,75 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for related Jobs changing, as their status is updated after the one of their pods
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			job := a.Object.(*batchv1.Job)
+			var requests []reconcile.Request
+
+			if testName, ok := job.Labels["yaks.dev/test"]; ok {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: job.Namespace,
+						Name:      testName,
+					},
+				})
+			}
+
+			return requests
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for instances being resumed, so that the tests they have postponed start right away
+	err = c.Watch(&source.Kind{Type: &v1alpha1.Instance{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			return pendingTestRequests(mgr.GetClient(), a.Meta.GetNamespace())
+		}),
+	}, predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.(*v1alpha1.Instance).Spec.Config.Paused && !e.ObjectNew.(*v1alpha1.Instance).Spec.Config.Paused
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return e.Object.(*v1alpha1.Instance).Spec.Config.Paused
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// pendingTestRequests returns the requests reconciling the pending tests of the namespace
+func pendingTestRequests(c k8sclient.Client, namespace string) []reconcile.Request {
+	list := v1alpha1.TestList{}
+	if err := c.List(context.TODO(), &k8sclient.ListOptions{Namespace: namespace}, &list); err != nil {
+		Log.Error(err, "Cannot list the tests of the resumed instance", "namespace", namespace)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, test := range list.Items {
+		if test.Status.Phase == v1alpha1.TestPhasePending {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: test.Namespace,
+					Name:      test.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
+
 var _ reconcile.Reconciler = &ReconcileIntegrationTest{}
 
 // ReconcileIntegrationTest reconciles a IntegrationTest object
@@ -106,6 +204,14 @@ type ReconcileIntegrationTest struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// recorder records the lifecycle events of the tests
+	recorder record.EventRecorder
+	// operatorNamespace is the namespace the operator runs in
+	operatorNamespace string
+	// operatorLabels are matched against the operator selector of the tests
+	operatorLabels map[string]string
+	// selectedTestsOnly leaves the tests without operator selector to the other operators
+	selectedTestsOnly bool
 }
 
 // Reconcile reads that state of the cluster for a Integration object and makes changes based on the state read
@@ -115,6 +221,14 @@ type ReconcileIntegrationTest struct {
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileIntegrationTest) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	rlog := Log.WithValues("request-namespace", request.Namespace, "request-name", request.Name)
+
+	if !inFlight.begin() {
+		// The operator is shutting down, the request is handled again by the next leader
+		rlog.Info("Operator is draining, ignoring request")
+		return reconcile.Result{}, nil
+	}
+	defer inFlight.end()
+
 	rlog.Info("Reconciling Test")
 
 	ctx := context.TODO()
@@ -126,16 +240,44 @@ func (r *ReconcileIntegrationTest) Reconcile(request reconcile.Request) (reconci
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// The role bindings in the target namespaces are not owned, and deleted here when left by a running test.
 			// Return and don't requeue
+			namespaces := allowedTargetNamespaces()
+			if err := deleteTargetNamespaceRoles(r.client, namespaces, request.Namespace, request.Name); err != nil {
+				rlog.Error(err, "Cannot delete the role bindings of the deleted test in its target namespace")
+			}
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
+	if !selectsOperator(&instance, r.operatorLabels, r.selectedTestsOnly) {
+		// Left to the operators matching the operator selector of the test
+		return reconcile.Result{}, nil
+	}
+
+	if instance.Namespace != r.operatorNamespace {
+		if managed, err := isManagedByInstanceOperator(ctx, r.client, instance.Namespace); err != nil {
+			return reconcile.Result{}, err
+		} else if managed {
+			// Left to the operator deployed for the instance of the namespace
+			return reconcile.Result{}, nil
+		}
+	}
+
+	defaults := instanceConfigFor(ctx, r.client, &instance)
+
 	// Delete phase
 	if instance.GetDeletionTimestamp() != nil {
 		instance.Status.Phase = v1alpha1.TestPhaseDeleting
+	} else if remaining, ok := expiresIn(&instance, defaults, time.Now()); ok && remaining <= 0 {
+		rlog.Info("Test expired, deleting it")
+		if err := r.client.Delete(ctx, &instance); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		r.recorder.Event(&instance, v1.EventTypeNormal, eventReasonCleaned, cleanedEventMessage(&instance, defaults))
+		return reconcile.Result{}, nil
 	}
 
 	target := instance.DeepCopy()
@@ -143,6 +285,7 @@ func (r *ReconcileIntegrationTest) Reconcile(request reconcile.Request) (reconci
 
 	actions := []Action{
 		NewInitializeAction(),
+		NewCancelAction(),
 		NewStartAction(),
 		NewEvaluateAction(),
 		NewMonitorAction(),
@@ -155,22 +298,59 @@ func (r *ReconcileIntegrationTest) Reconcile(request reconcile.Request) (reconci
 		if a.CanHandle(target) {
 			targetLog.Infof("Invoking action %s", a.Name())
 
+			// Actions may change the target in place
+			phase := target.Status.Phase
 			newTarget, err := a.Handle(ctx, target)
 			if err != nil {
 				return reconcile.Result{}, err
 			}
 
 			if newTarget != nil {
-				if r, err := r.update(ctx, targetLog, newTarget); err != nil {
-					return r, err
+				target = newTarget
+				var failed *v1alpha1.Test
+				if canRetryOnFailure(newTarget, phase) {
+					// The runner of the failed run is deleted so that it does not run along the next one
+					if err := deleteWorkload(r.client, newTarget); err != nil {
+						return reconcile.Result{}, err
+					}
+					failed = newTarget.DeepCopy()
+					recordPhaseTransition(failed, metav1.Now())
+					retryOnFailure(newTarget, phase, time.Now())
+				}
+				if newTarget.Status.Phase != phase {
+					recordPhaseTransition(newTarget, metav1.Now())
+				}
+
+				if result, err := r.update(ctx, targetLog, newTarget); err != nil || result.Requeue {
+					return result, err
+				}
+
+				if failed != nil {
+					// The failed run is kept in the history of the test
+					if eventType, reason, message, ok := lifecycleEvent(failed, phase); ok {
+						r.recorder.Event(failed, eventType, reason, message)
+					}
+					storeReport(r.client, failed)
 				}
 
-				if newTarget.Status.Phase != target.Status.Phase {
+				if newTarget.Status.Phase != phase {
 					targetLog.Info(
 						"state transition",
-						"phase-from", target.Status.Phase,
+						"phase-from", phase,
 						"phase-to", newTarget.Status.Phase,
 					)
+					if eventType, reason, message, ok := lifecycleEvent(newTarget, phase); ok {
+						r.recorder.Event(newTarget, eventType, reason, message)
+					}
+					if isCompleted(newTarget) {
+						if namespace := targetNamespaceFor(newTarget); namespace != newTarget.Namespace {
+							if err := deleteTargetNamespaceRoles(r.client, []string{namespace}, newTarget.Namespace, newTarget.Name); err != nil {
+								targetLog.Error(err, "Cannot delete the role binding of the target namespace")
+							}
+						}
+						storeReport(r.client, newTarget)
+						pruneHistory(ctx, r.client, r.recorder, newTarget)
+					}
 				}
 			}
 
@@ -180,7 +360,75 @@ func (r *ReconcileIntegrationTest) Reconcile(request reconcile.Request) (reconci
 		}
 	}
 
-	return reconcile.Result{}, nil
+	return requeueResultFor(target, defaults), nil
+}
+
+// pendingPollInterval is how often a pending test waiting for its dependencies, readiness gates or a concurrency slot
+// is checked again
+const pendingPollInterval = 5 * time.Second
+
+// requeueResultFor reconciles the tests in progress periodically, as a safety net in case an event of their
+// pods has been missed, given the defaults of the instance of the test
+func requeueResultFor(test *v1alpha1.Test, defaults v1alpha1.InstanceConfig) reconcile.Result {
+	if isScheduled(test) && test.Status.ScheduledStart != nil {
+		// Started once the scheduled time has come
+		if delay := time.Until(test.Status.ScheduledStart.Time); delay > 0 {
+			return reconcile.Result{RequeueAfter: delay}
+		}
+		return reconcile.Result{Requeue: true}
+	}
+	if test.Status.Phase == v1alpha1.TestPhasePending && test.Status.WaitingFor != "" {
+		// Dependencies and readiness gates are not watched
+		since := gatesCheckedSince(test)
+		return reconcile.Result{RequeueAfter: gatePollInterval(time.Since(since.Time))}
+	}
+	if test.Status.Phase == v1alpha1.TestPhasePending && isQueued(test) {
+		// Slots are freed by other tests
+		return reconcile.Result{RequeueAfter: pendingPollInterval}
+	}
+	if remaining, ok := expiresIn(test, defaults, time.Now()); ok {
+		// Deleted once expired
+		return reconcile.Result{RequeueAfter: remaining}
+	}
+	interval := config.GetRequeueInterval()
+	if interval == 0 {
+		return reconcile.Result{}
+	}
+	switch test.Status.Phase {
+	case v1alpha1.TestPhasePending, v1alpha1.TestPhaseRunning:
+		return reconcile.Result{RequeueAfter: interval}
+	}
+	return reconcile.Result{}
+}
+
+// isCompleted tells whether the test has a result
+func isCompleted(test *v1alpha1.Test) bool {
+	switch test.Status.Phase {
+	case v1alpha1.TestPhasePassed, v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError, v1alpha1.TestPhaseSkipped:
+		return true
+	}
+	return false
+}
+
+// recordPhaseTransition records when the test has entered its current phase
+func recordPhaseTransition(test *v1alpha1.Test, now metav1.Time) {
+	switch test.Status.Phase {
+	case v1alpha1.TestPhasePending:
+		// A new run of the test starts
+		test.Status.Timings = &v1alpha1.TestTimings{
+			Pending: &now,
+		}
+	case v1alpha1.TestPhaseRunning:
+		if test.Status.Timings == nil {
+			test.Status.Timings = &v1alpha1.TestTimings{}
+		}
+		test.Status.Timings.Running = &now
+	case v1alpha1.TestPhasePassed, v1alpha1.TestPhaseFailed, v1alpha1.TestPhaseError, v1alpha1.TestPhaseSkipped:
+		if test.Status.Timings == nil {
+			test.Status.Timings = &v1alpha1.TestTimings{}
+		}
+		test.Status.Timings.Completed = &now
+	}
 }
 
 // Update --
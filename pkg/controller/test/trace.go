@@ -0,0 +1,83 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/rs/xid"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// traceIDEnvVar exposes the trace ID of the run to the runner, that propagates it to the requests of the tests
+	traceIDEnvVar = "YAKS_TRACE_ID"
+	// traceIDAnnotation exposes the trace ID of the run on the runner pod
+	traceIDAnnotation = "yaks.dev/trace-id"
+)
+
+// traceIDPattern matches the W3C trace context trace IDs
+var traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// traceIDFor returns the trace ID of a new run of the test, from its spec or its runtime env, or a random one
+func traceIDFor(test *v1alpha1.Test) string {
+	if test.Spec.TraceID != "" {
+		return test.Spec.TraceID
+	}
+	if env := envvar.Get(test.Spec.Runtime.Env, traceIDEnvVar); env != nil && env.Value != "" {
+		return env.Value
+	}
+	return newTraceID()
+}
+
+// newTraceID generates a random trace ID, falling back to the bytes of a unique ID should the random source fail
+func newTraceID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		copy(id, xid.New().Bytes())
+	}
+	return hex.EncodeToString(id)
+}
+
+// applyTraceID exposes the trace ID of the run to the runner container, that already accounts for the runtime env of
+// the test
+func applyTraceID(test *v1alpha1.Test, pod *v1.Pod) {
+	if test.Status.TraceID == "" {
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[traceIDAnnotation] = test.Status.TraceID
+
+	envvar.SetVal(&pod.Spec.Containers[0].Env, traceIDEnvVar, test.Status.TraceID)
+}
+
+func validateTraceID(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if test.Spec.TraceID != "" && !traceIDPattern.MatchString(test.Spec.TraceID) {
+		return fmt.Sprintf("invalid traceId %s, expected 32 lowercase hex characters", test.Spec.TraceID), nil
+	}
+	return "", nil
+}
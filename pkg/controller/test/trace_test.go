@@ -0,0 +1,49 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestTraceIDFor(t *testing.T) {
+	test := newTestForStart()
+	generated := traceIDFor(test)
+	assert.Regexp(t, traceIDPattern, generated)
+	assert.NotEqual(t, generated, traceIDFor(test))
+
+	test.Spec.Runtime.Env = []v1.EnvVar{{Name: traceIDEnvVar, Value: "0af7651916cd43dd8448eb211c80319c"}}
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", traceIDFor(test))
+
+	test.Spec.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceIDFor(test))
+
+	message, err := validateTraceID(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.Empty(t, message)
+
+	test.Spec.TraceID = "4BF92F3577B34DA6"
+	message, err = validateTraceID(context.TODO(), nil, test)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, message)
+}
@@ -0,0 +1,71 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+)
+
+// ttlFor returns how long the test is kept once completed, and false when it is kept forever. In order of precedence:
+// the TestTTLAnnotation, the first matching cleanup rule of the TestCleanupRulesAnnotation, the first matching cleanup
+// rule then the TTL of the defaults of the instance of the test, the first matching rule of the operator wide
+// TEST_CLEANUP_RULES, and finally the operator wide TEST_TTL. A zero TTL keeps the test forever, except in cleanup
+// rules where "keep" is used for that and zero deletes the test as soon as it completes.
+func ttlFor(test *v1alpha1.Test, defaults v1alpha1.InstanceConfig) (time.Duration, bool) {
+	if value, ok := test.Annotations[v1alpha1.TestTTLAnnotation]; ok {
+		ttl, err := config.ParseTTL(value)
+		if err == nil {
+			return ttl, ttl != 0
+		}
+		Log.ForTest(test).Info("Invalid TTL annotation, using the cleanup rules or the default TTL", "value", value, "error", err.Error())
+	}
+	if rule, ok := cleanupRuleFor(test, v1alpha1.TestCleanupRulesAnnotation, test.Annotations[v1alpha1.TestCleanupRulesAnnotation]); ok {
+		return rule.ttl, !rule.keep
+	}
+	if rule, ok := cleanupRuleFor(test, "instance", defaults.CleanupRules); ok {
+		return rule.ttl, !rule.keep
+	}
+	if defaults.TTL != "" {
+		ttl, err := config.ParseTTL(defaults.TTL)
+		if err == nil {
+			return ttl, ttl != 0
+		}
+		Log.ForTest(test).Info("Invalid TTL of the instance, using the operator wide defaults", "value", defaults.TTL, "error", err.Error())
+	}
+	if rule, ok := cleanupRuleFor(test, "TEST_CLEANUP_RULES", config.GetTestCleanupRules()); ok {
+		return rule.ttl, !rule.keep
+	}
+	ttl := config.GetTestTTL()
+	return ttl, ttl != 0
+}
+
+// expiresIn returns how long the completed test is kept from now on, and false when the test is not completed or is
+// not deleted after completion
+func expiresIn(test *v1alpha1.Test, defaults v1alpha1.InstanceConfig, now time.Time) (time.Duration, bool) {
+	if !isCompleted(test) || test.Status.Timings == nil || test.Status.Timings.Completed == nil {
+		return 0, false
+	}
+	ttl, ok := ttlFor(test, defaults)
+	if !ok {
+		return 0, false
+	}
+	return test.Status.Timings.Completed.Add(ttl).Sub(now), true
+}
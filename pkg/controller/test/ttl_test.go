@@ -0,0 +1,176 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCompletedTest(completed time.Time, ttl string) *v1alpha1.Test {
+	test := v1alpha1.Test{
+		Status: v1alpha1.TestStatus{
+			Phase: v1alpha1.TestPhasePassed,
+			Timings: &v1alpha1.TestTimings{
+				Completed: &metav1.Time{Time: completed},
+			},
+		},
+	}
+	if ttl != "" {
+		test.Annotations = map[string]string{v1alpha1.TestTTLAnnotation: ttl}
+	}
+	return &test
+}
+
+func TestTTLAnnotationOverridesOperatorTTL(t *testing.T) {
+	defer os.Unsetenv("TEST_TTL")
+	assert.Nil(t, os.Setenv("TEST_TTL", "1h"))
+	now := time.Now()
+
+	remaining, ok := expiresIn(newCompletedTest(now, ""), v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, remaining)
+
+	remaining, ok = expiresIn(newCompletedTest(now, "7d"), v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*24*time.Hour, remaining)
+
+	// Invalid annotations fall back to the operator wide TTL
+	remaining, ok = expiresIn(newCompletedTest(now, "-1h"), v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, remaining)
+
+	// A zero TTL keeps the test forever
+	_, ok = expiresIn(newCompletedTest(now, "0s"), v1alpha1.InstanceConfig{}, now)
+	assert.False(t, ok)
+}
+
+func TestTestsAreKeptWithoutTTL(t *testing.T) {
+	now := time.Now()
+
+	_, ok := expiresIn(newCompletedTest(now.Add(-24*time.Hour), ""), v1alpha1.InstanceConfig{}, now)
+	assert.False(t, ok)
+
+	remaining, ok := expiresIn(newCompletedTest(now.Add(-2*time.Hour), "1h"), v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.True(t, remaining < 0)
+}
+
+func TestCleanupRules(t *testing.T) {
+	defer os.Unsetenv("TEST_TTL")
+	defer os.Unsetenv("TEST_CLEANUP_RULES")
+	assert.Nil(t, os.Setenv("TEST_TTL", "1h"))
+	assert.Nil(t, os.Setenv("TEST_CLEANUP_RULES", "label:investigate -> keep; phase:Failed -> 1d; phase:Passed -> 0s"))
+	now := time.Now()
+
+	passed := newCompletedTest(now, "")
+	remaining, ok := expiresIn(passed, v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), remaining)
+
+	failed := newCompletedTest(now, "")
+	failed.Status.Phase = v1alpha1.TestPhaseFailed
+	remaining, ok = expiresIn(failed, v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 24*time.Hour, remaining)
+
+	investigated := newCompletedTest(now, "")
+	investigated.Labels = map[string]string{"investigate": "true"}
+	_, ok = expiresIn(investigated, v1alpha1.InstanceConfig{}, now)
+	assert.False(t, ok)
+
+	// Tests matching no rule use the operator wide TTL
+	errored := newCompletedTest(now, "")
+	errored.Status.Phase = v1alpha1.TestPhaseError
+	remaining, ok = expiresIn(errored, v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, remaining)
+
+	// The TTL annotation takes precedence over any rule
+	remaining, ok = expiresIn(newCompletedTest(now, "2h"), v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Hour, remaining)
+
+	// The rules of the test are evaluated before the operator wide ones
+	passed.Annotations = map[string]string{v1alpha1.TestCleanupRulesAnnotation: "phase:Passed & label:team=qa -> 3h; * -> keep"}
+	_, ok = expiresIn(passed, v1alpha1.InstanceConfig{}, now)
+	assert.False(t, ok)
+	passed.Labels = map[string]string{"team": "qa"}
+	remaining, ok = expiresIn(passed, v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Hour, remaining)
+
+	// Invalid rules of the test are ignored
+	passed.Annotations[v1alpha1.TestCleanupRulesAnnotation] = "status:Passed -> 3h"
+	remaining, ok = expiresIn(passed, v1alpha1.InstanceConfig{}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), remaining)
+}
+
+func TestParseCleanupRules(t *testing.T) {
+	rules, err := parseCleanupRules("annotation:owner=qa & phase:failed -> 7d;;")
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, 7*24*time.Hour, rules[0].ttl)
+	assert.Len(t, rules[0].conditions, 2)
+
+	for _, invalid := range []string{"phase:Failed", "phase: -> 1h", "phase:Failed -> soon", "name:foo -> keep"} {
+		_, err = parseCleanupRules(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestInstanceCleanupDefaults(t *testing.T) {
+	defer os.Unsetenv("TEST_TTL")
+	defer os.Unsetenv("TEST_CLEANUP_RULES")
+	assert.Nil(t, os.Setenv("TEST_TTL", "1h"))
+	assert.Nil(t, os.Setenv("TEST_CLEANUP_RULES", "phase:Failed -> 1d"))
+	now := time.Now()
+	defaults := v1alpha1.InstanceConfig{
+		TTL:          "2h",
+		CleanupRules: "label:investigate -> keep",
+	}
+
+	// The TTL of the instance overrides the operator wide rules and TTL
+	failed := newCompletedTest(now, "")
+	failed.Status.Phase = v1alpha1.TestPhaseFailed
+	remaining, ok := expiresIn(failed, defaults, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Hour, remaining)
+
+	// The rules of the instance are evaluated before its TTL
+	failed.Labels = map[string]string{"investigate": "true"}
+	_, ok = expiresIn(failed, defaults, now)
+	assert.False(t, ok)
+
+	// The annotations of the test take precedence over the instance
+	remaining, ok = expiresIn(newCompletedTest(now, "3h"), defaults, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Hour, remaining)
+
+	// An invalid TTL of the instance falls back to the operator wide defaults
+	remaining, ok = expiresIn(failed, v1alpha1.InstanceConfig{TTL: "soon"}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 24*time.Hour, remaining)
+}
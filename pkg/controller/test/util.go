@@ -21,8 +21,14 @@ import (
 	"fmt"
 
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// testContainerName is the name of the container running the tests in the test pod
+const testContainerName = "test"
+
 // TestPodNameFor returns the name to use for the testing pod
 func TestPodNameFor(test *v1alpha1.Test) string {
 	return fmt.Sprintf("test-%s-%s", test.Name, test.Status.TestID)
@@ -32,3 +38,66 @@ func TestPodNameFor(test *v1alpha1.Test) string {
 func TestResourceNameFor(test *v1alpha1.Test) string {
 	return fmt.Sprintf("test-%s", test.Name)
 }
+
+// TestOwnerReferencesFor returns the owner references to set on all the resources created for the test,
+// so that they are garbage collected when the test is deleted
+func TestOwnerReferencesFor(test *v1alpha1.Test) []metav1.OwnerReference {
+	controller := true
+	blockOwnerDeletion := true
+	return []metav1.OwnerReference{
+		{
+			// Type meta is not always populated on objects read from the cache
+			APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+			Kind:               v1alpha1.TestKind,
+			Name:               test.Name,
+			UID:                test.UID,
+			Controller:         &controller,
+			BlockOwnerDeletion: &blockOwnerDeletion,
+		},
+	}
+}
+
+// TestLabelsFor returns the labels to set on all the resources created for the test, including the ones
+// propagated from the test itself
+func TestLabelsFor(test *v1alpha1.Test) map[string]string {
+	labels := make(map[string]string)
+	for _, key := range config.GetPropagatedLabels() {
+		if value, ok := test.Labels[key]; ok {
+			labels[key] = value
+		}
+	}
+	labels["yaks.dev/app"] = "yaks"
+	labels["yaks.dev/test"] = test.Name
+	labels["yaks.dev/test-id"] = test.Status.TestID
+	return labels
+}
+
+// targetNamespaceFor returns the namespace where the test creates its resources
+func targetNamespaceFor(test *v1alpha1.Test) string {
+	if test.Spec.Namespace != "" {
+		return test.Spec.Namespace
+	}
+	return test.Namespace
+}
+
+// setCondition sets the status of the given condition of the test, the transition time is only updated when the
+// status changes
+func setCondition(test *v1alpha1.Test, conditionType v1alpha1.TestConditionType, status v1.ConditionStatus, reason string, message string) {
+	condition := v1alpha1.TestCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+	for i := range test.Status.Conditions {
+		if test.Status.Conditions[i].Type == conditionType {
+			if test.Status.Conditions[i].Status == status {
+				condition.LastTransitionTime = test.Status.Conditions[i].LastTransitionTime
+			}
+			test.Status.Conditions[i] = condition
+			return
+		}
+	}
+	test.Status.Conditions = append(test.Status.Conditions, condition)
+}
@@ -0,0 +1,419 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/fixture"
+	"github.com/jboss-fuse/yaks/pkg/report"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// validator checks a test before it is started, returning a message describing the problem found, if any
+type validator func(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error)
+
+var validators = []validator{
+	validateImagePullPolicy,
+	validateImagePullSecrets,
+	validateVolumes,
+	validateTrustedCA,
+	validateEndpoints,
+	validateWorkload,
+	validateResultFormat,
+	validateCommand,
+	validateTargetNamespace,
+	validateDependencies,
+	validateReadinessGates,
+	validatePodMetadata,
+	validateEnv,
+	validateTrafficCapture,
+	validateWorkspace,
+	validateDebug,
+	validateAssertions,
+	validateReportClaim,
+	validateStartAfter,
+	validateTraceID,
+	validateDependencyCache,
+	validateInstance,
+	validateEphemeralSecrets,
+	validateOperatorSelector,
+	validateRetryOnFailure,
+}
+
+// validate runs all validators on the test, returning the message of the first one that fails
+func validate(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	for _, v := range validators {
+		if message, err := v(ctx, c, test); err != nil || message != "" {
+			return message, err
+		}
+	}
+	return "", nil
+}
+
+func validateImagePullPolicy(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	switch test.Spec.Runtime.ImagePullPolicy {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+		return "", nil
+	default:
+		return fmt.Sprintf("unsupported image pull policy %q, must be one of %s, %s, %s",
+			test.Spec.Runtime.ImagePullPolicy, v1.PullAlways, v1.PullIfNotPresent, v1.PullNever), nil
+	}
+}
+
+// validateVolumes checks that all persistent volume claims referenced by the test exist and are not lost, returning a
+// message describing the first problem found. Pending claims are accepted, as the claims of storage classes binding
+// their volumes when they are first used, i.e. WaitForFirstConsumer, stay pending until the runner pod is scheduled.
+func validateVolumes(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	for _, volume := range test.Spec.Runtime.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		pvc := v1.PersistentVolumeClaim{}
+		key := k8sclient.ObjectKey{
+			Namespace: test.Namespace,
+			Name:      volume.PersistentVolumeClaim.ClaimName,
+		}
+		err := c.Get(ctx, key, &pvc)
+		if err != nil && k8serrors.IsNotFound(err) {
+			return fmt.Sprintf("persistent volume claim %s referenced by volume %s does not exist", key.Name, volume.Name), nil
+		} else if err != nil {
+			return "", err
+		}
+		if pvc.Status.Phase == v1.ClaimLost {
+			return fmt.Sprintf("persistent volume claim %s referenced by volume %s has lost its volume", key.Name, volume.Name), nil
+		}
+	}
+	return "", nil
+}
+
+func validateTrustedCA(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	if test.Spec.Runtime.TrustedCA == nil {
+		return "", nil
+	}
+
+	cm := v1.ConfigMap{}
+	key := k8sclient.ObjectKey{
+		Namespace: test.Namespace,
+		Name:      test.Spec.Runtime.TrustedCA.Name,
+	}
+	err := c.Get(ctx, key, &cm)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return fmt.Sprintf("trusted CA config map %s does not exist", key.Name), nil
+	} else if err != nil {
+		return "", err
+	}
+	for _, data := range cm.Data {
+		if strings.Contains(data, "-----BEGIN CERTIFICATE-----") {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("trusted CA config map %s does not contain any PEM encoded certificate", key.Name), nil
+}
+
+func validateEndpoints(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	if len(test.Spec.Endpoints) == 0 {
+		return "", nil
+	}
+	reason, message, err := invalidEndpoint(ctx, c, test)
+	if err != nil {
+		return "", err
+	} else if message != "" {
+		setCondition(test, v1alpha1.TestConditionEndpointsValid, v1.ConditionFalse, reason, message)
+		return message, nil
+	}
+	setCondition(test, v1alpha1.TestConditionEndpointsValid, v1.ConditionTrue, "Valid", "")
+	return "", nil
+}
+
+// invalidEndpoint returns the reason and the message of the first invalid endpoint of the test, if any
+func invalidEndpoint(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, string, error) {
+	for _, endpoint := range test.Spec.Endpoints {
+		if endpoint.Name == "" {
+			return "InvalidName", "endpoint name must not be empty", nil
+		}
+		if (endpoint.URL == "") == (endpoint.Service == nil) {
+			return "InvalidEndpoint", fmt.Sprintf("endpoint %s must define either an url or a service", endpoint.Name), nil
+		}
+		if endpoint.Service == nil {
+			if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "InvalidURL", fmt.Sprintf("invalid URL %q of endpoint %s, must be an absolute http or https URL", endpoint.URL, endpoint.Name), nil
+			}
+			continue
+		}
+
+		service := v1.Service{}
+		key := k8sclient.ObjectKey{
+			Namespace: serviceNamespaceFor(test, endpoint.Service),
+			Name:      endpoint.Service.Name,
+		}
+		err := c.Get(ctx, key, &service)
+		if err != nil && k8serrors.IsNotFound(err) {
+			return "ServiceNotFound", fmt.Sprintf("service %s/%s referenced by endpoint %s does not exist", key.Namespace, key.Name, endpoint.Name), nil
+		} else if err != nil && k8serrors.IsForbidden(err) {
+			return "ServiceNotAccessible", fmt.Sprintf("service %s/%s referenced by endpoint %s is not accessible by the operator", key.Namespace, key.Name, endpoint.Name), nil
+		} else if err != nil {
+			return "", "", err
+		}
+	}
+	return "", "", nil
+}
+
+func validateWorkload(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	workload := workloadFor(test)
+	if workload != v1alpha1.WorkloadTypePod && workload != v1alpha1.WorkloadTypeJob {
+		return fmt.Sprintf("unsupported workload %s, expected one of %s, %s", workload, v1alpha1.WorkloadTypePod, v1alpha1.WorkloadTypeJob), nil
+	}
+	if limit := test.Spec.Runtime.RetryLimit; limit != nil && *limit > 0 && workload != v1alpha1.WorkloadTypeJob && !isRetriedByOperator(test) {
+		return fmt.Sprintf("retry limit is only supported by the %s workload, or with retryOnFailure", v1alpha1.WorkloadTypeJob), nil
+	}
+	return "", nil
+}
+
+func validateResultFormat(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if _, ok := report.ResultParserFor(test.Spec.Runtime.ResultFormat); !ok {
+		return fmt.Sprintf("unsupported result format %s, expected one of %s, %s, %s", test.Spec.Runtime.ResultFormat,
+			v1alpha1.ResultFormatCitrus, v1alpha1.ResultFormatTAP, v1alpha1.ResultFormatJSON), nil
+	}
+	return "", nil
+}
+
+func validateCommand(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for _, command := range test.Spec.Runtime.Command {
+		if strings.TrimSpace(command) == "" {
+			return "runtime command must not contain empty values", nil
+		}
+	}
+	for _, arg := range test.Spec.Runtime.Args {
+		if strings.TrimSpace(arg) == "" {
+			return "runtime args must not contain empty values", nil
+		}
+	}
+	return "", nil
+}
+
+func validateImagePullSecrets(ctx context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	for _, ref := range imagePullSecretsFor(test) {
+		secret := v1.Secret{}
+		key := k8sclient.ObjectKey{
+			Namespace: test.Namespace,
+			Name:      ref.Name,
+		}
+		err := c.Get(ctx, key, &secret)
+		if err != nil && k8serrors.IsNotFound(err) {
+			return fmt.Sprintf("image pull secret %s does not exist in namespace %s", key.Name, key.Namespace), nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func validateTargetNamespace(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	namespace := targetNamespaceFor(test)
+	if namespace == test.Namespace {
+		return "", nil
+	}
+	for _, allowed := range config.GetAllowedTargetNamespaces() {
+		if allowed == "*" || allowed == namespace {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("target namespace %s is not allowed by the operator", namespace), nil
+}
+
+func validateDependencies(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for _, dependency := range test.Spec.Dependencies {
+		if _, ok := fixture.ProviderFor(dependency.Kind); !ok {
+			return fmt.Sprintf("unsupported dependency kind %s, expected one of %s", dependency.Kind, strings.Join(fixture.Kinds(), ", ")), nil
+		}
+		if dependency.Name == "" {
+			return fmt.Sprintf("%s dependency has no name", dependency.Kind), nil
+		}
+	}
+	return "", nil
+}
+
+func validateReadinessGates(_ context.Context, c client.Client, test *v1alpha1.Test) (string, error) {
+	for i, gate := range test.Spec.ReadinessGates {
+		checks := 0
+		if gate.HTTP != nil {
+			checks++
+			if u, err := url.Parse(gate.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Sprintf("invalid URL %q of readiness gate %d, must be an absolute http or https URL", gate.HTTP.URL, i), nil
+			} else if !isAllowedGateHost(u.Hostname(), targetNamespaceFor(test), config.GetAllowedGateHosts()) {
+				return disallowedGateHostMessage(u.Hostname(), i, test), nil
+			}
+			if gate.HTTP.Status != 0 && (gate.HTTP.Status < 100 || gate.HTTP.Status > 599) {
+				return fmt.Sprintf("invalid HTTP status %d of readiness gate %d", gate.HTTP.Status, i), nil
+			}
+		}
+		if gate.TCP != nil {
+			checks++
+			if gate.TCP.Host == "" || gate.TCP.Port < 1 || gate.TCP.Port > 65535 {
+				return fmt.Sprintf("readiness gate %d must have a host and a port between 1 and 65535", i), nil
+			} else if !isAllowedGateHost(gate.TCP.Host, targetNamespaceFor(test), config.GetAllowedGateHosts()) {
+				return disallowedGateHostMessage(gate.TCP.Host, i, test), nil
+			}
+		}
+		if gate.Condition != nil {
+			checks++
+			if gate.Condition.APIVersion == "" || gate.Condition.Resource == "" || gate.Condition.Name == "" || gate.Condition.Type == "" {
+				return fmt.Sprintf("readiness gate %d must have the apiVersion, resource, name and type of the condition", i), nil
+			}
+			if message, err := validateGateAccess(c, test, i, gate.Condition); err != nil || message != "" {
+				return message, err
+			}
+		}
+		if checks != 1 {
+			return fmt.Sprintf("readiness gate %d must have exactly one of http, tcp or condition", i), nil
+		}
+		if gate.Timeout != "" {
+			if timeout, err := time.ParseDuration(gate.Timeout); err != nil || timeout <= 0 {
+				return fmt.Sprintf("invalid timeout %s of readiness gate %d, must be a positive duration", gate.Timeout, i), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func disallowedGateHostMessage(host string, index int, test *v1alpha1.Test) string {
+	return fmt.Sprintf("host %s of readiness gate %d is neither a service of namespace %s nor allowed by the operator",
+		host, index, targetNamespaceFor(test))
+}
+
+func validatePodMetadata(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for key, value := range test.Spec.Runtime.PodLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Sprintf("invalid pod label key %s: %s", key, strings.Join(errs, ", ")), nil
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Sprintf("invalid value of pod label %s: %s", key, strings.Join(errs, ", ")), nil
+		}
+	}
+	for key := range test.Spec.Runtime.PodAnnotations {
+		if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+			return fmt.Sprintf("invalid pod annotation key %s: %s", key, strings.Join(errs, ", ")), nil
+		}
+	}
+	return "", nil
+}
+
+func validateEnv(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for _, env := range test.Spec.Runtime.Env {
+		if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+			return fmt.Sprintf("invalid env variable name %q: %s", env.Name, strings.Join(errs, ", ")), nil
+		}
+	}
+	return "", nil
+}
+
+func validateTrafficCapture(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	capture := test.Spec.Runtime.TrafficCapture
+	if capture == nil || capture.Volume == "" {
+		return "", nil
+	}
+	for _, volume := range test.Spec.Runtime.Volumes {
+		if volume.Name == capture.Volume {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("traffic capture volume %s is not one of the runtime volumes", capture.Volume), nil
+}
+
+func validateWorkspace(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if size := test.Spec.Runtime.WorkspaceSize; size != "" {
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			return fmt.Sprintf("invalid workspace size %s: %v", size, err), nil
+		}
+		if quantity.Sign() <= 0 {
+			return fmt.Sprintf("invalid workspace size %s: must be positive", size), nil
+		}
+	}
+	if dir := test.Spec.Runtime.WorkingDir; dir != "" && !path.IsAbs(dir) {
+		return fmt.Sprintf("working directory %s is not an absolute path", dir), nil
+	}
+	return "", nil
+}
+
+func validateDebug(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	debug := test.Spec.Runtime.Debug
+	if debug == nil {
+		return "", nil
+	}
+	switch debug.Mode {
+	case "", v1alpha1.DebugModeOnFailure, v1alpha1.DebugModeAlways:
+	default:
+		return fmt.Sprintf("unsupported debug mode %s", debug.Mode), nil
+	}
+	if debug.Timeout != "" {
+		timeout, err := time.ParseDuration(debug.Timeout)
+		if err != nil {
+			return fmt.Sprintf("invalid debug timeout %s: %v", debug.Timeout, err), nil
+		}
+		if timeout <= 0 || timeout > maxDebugTimeout {
+			return fmt.Sprintf("invalid debug timeout %s: must be positive and at most %s", debug.Timeout, maxDebugTimeout), nil
+		}
+	}
+	return "", nil
+}
+
+func validateAssertions(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	for _, artifact := range artifactAssertionsFor(test) {
+		if artifact.Path == "" {
+			return "artifact assertion without path", nil
+		}
+		switch artifact.Check {
+		case "", v1alpha1.ArtifactCheckExists, v1alpha1.ArtifactCheckNonEmpty:
+		case v1alpha1.ArtifactCheckMatches:
+			if artifact.Pattern == "" {
+				return fmt.Sprintf("artifact assertion on %s requires a pattern", artifact.Path), nil
+			}
+			// The sidecar matches the pattern with grep -E, so only the POSIX extended syntax is accepted, e.g. not \d
+			if _, err := regexp.CompilePOSIX(artifact.Pattern); err != nil {
+				return fmt.Sprintf("invalid pattern of the artifact assertion on %s, "+
+					"a POSIX extended regular expression is expected: %v", artifact.Path, err), nil
+			}
+		default:
+			return fmt.Sprintf("unsupported artifact check %s", artifact.Check), nil
+		}
+	}
+	return "", nil
+}
+
+func validateStartAfter(_ context.Context, _ client.Client, test *v1alpha1.Test) (string, error) {
+	if _, err := scheduledStart(test); err != nil {
+		return fmt.Sprintf("invalid startAfter %s, expected a duration or an RFC 3339 timestamp: %v", test.Spec.StartAfter, err), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newClaim(name string, phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func TestValidateVolumes(t *testing.T) {
+	c := testutil.NewFakeClient(
+		newClaim("bound", v1.ClaimBound),
+		// Bound when the runner pod is scheduled, e.g. with the WaitForFirstConsumer binding mode
+		newClaim("pending", v1.ClaimPending),
+		newClaim("lost", v1.ClaimLost),
+	)
+	test := newTestForStart()
+	for _, claim := range []string{"bound", "pending"} {
+		test.Spec.Runtime.Volumes = []v1.Volume{
+			{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+				},
+			},
+		}
+		message, err := validateVolumes(context.TODO(), c, test)
+		assert.Nil(t, err)
+		assert.Equal(t, "", message, claim)
+	}
+
+	test.Spec.Runtime.Volumes[0].PersistentVolumeClaim.ClaimName = "lost"
+	message, err := validateVolumes(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "persistent volume claim lost referenced by volume data has lost its volume", message)
+
+	test.Spec.Runtime.Volumes[0].PersistentVolumeClaim.ClaimName = "missing"
+	message, err = validateVolumes(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "persistent volume claim missing referenced by volume data does not exist", message)
+}
+
+func TestValidateEndpointURL(t *testing.T) {
+	c := testutil.NewFakeClient()
+	for _, invalid := range []string{"ftp://files.example.com", "file:///etc/passwd", "example.com/api", "http://", "://"} {
+		test := newTestForStart()
+		test.Spec.Endpoints = []v1alpha1.EndpointSpec{{Name: "api", URL: invalid}}
+		message, err := validateEndpoints(context.TODO(), c, test)
+		assert.Nil(t, err)
+		assert.Contains(t, message, "must be an absolute http or https URL", invalid)
+		if assert.Len(t, test.Status.Conditions, 1) {
+			condition := test.Status.Conditions[0]
+			assert.Equal(t, v1alpha1.TestConditionEndpointsValid, condition.Type)
+			assert.Equal(t, v1.ConditionFalse, condition.Status)
+			assert.Equal(t, "InvalidURL", condition.Reason)
+			assert.Equal(t, message, condition.Message)
+		}
+	}
+
+	test := newTestForStart()
+	test.Spec.Endpoints = []v1alpha1.EndpointSpec{{Name: "api", URL: "https://api.example.com/v1"}}
+	message, err := validateEndpoints(context.TODO(), c, test)
+	assert.Nil(t, err)
+	assert.Equal(t, "", message)
+	if assert.Len(t, test.Status.Conditions, 1) {
+		assert.Equal(t, v1.ConditionTrue, test.Status.Conditions[0].Status)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixture
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Built-in providers, for the workloads and services of the namespace
+func init() {
+	Register(v1alpha1.DependencyKindDeployment, ProviderFunc(isDeploymentReady))
+	Register(v1alpha1.DependencyKindStatefulSet, ProviderFunc(isStatefulSetReady))
+	Register(v1alpha1.DependencyKindPod, ProviderFunc(isPodReady))
+	Register(v1alpha1.DependencyKindJob, ProviderFunc(isJobReady))
+	Register(v1alpha1.DependencyKindService, ProviderFunc(isServiceReady))
+}
+
+// podFailureReasons are the waiting reasons of containers that do not recover by themselves
+var podFailureReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
+
+// imagePullGracePeriod is how long a pod is given to pull its images before a pull back-off is a failure, as the
+// image may be pushed, or the registry may recover, meanwhile
+const imagePullGracePeriod = 5 * time.Minute
+
+// podFailure returns the failure of the pod when it will not become ready without intervention. An image pull error
+// only fails right away when the image does not exist, and a pull back-off once the grace period has passed.
+func podFailure(pod *v1.Pod, now time.Time) *Failure {
+	if pod.Status.Phase == v1.PodFailed {
+		return &Failure{Reason: "PodFailed", Message: pod.Status.Message}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+		switch {
+		case podFailureReasons[waiting.Reason],
+			waiting.Reason == "ErrImagePull" && isImageNotFound(waiting.Message),
+			waiting.Reason == "ImagePullBackOff" && pod.Status.StartTime != nil && now.Sub(pod.Status.StartTime.Time) > imagePullGracePeriod:
+			return &Failure{Reason: waiting.Reason, Message: waiting.Message}
+		}
+	}
+	return nil
+}
+
+// isImageNotFound tells whether the pull error message, as reported by the container runtime, is about an image that
+// does not exist rather than a registry that cannot be reached
+func isImageNotFound(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "not found") || strings.Contains(message, "manifest unknown") ||
+		strings.Contains(message, "does not exist")
+}
+
+func isDeploymentReady(c client.Client, namespace string, name string) (bool, error) {
+	deployment, err := c.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == v1.ConditionFalse {
+			return false, &Failure{Reason: condition.Reason, Message: condition.Message}
+		}
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas >= replicas &&
+		deployment.Status.AvailableReplicas >= replicas, nil
+}
+
+func isStatefulSetReady(c client.Client, namespace string, name string) (bool, error) {
+	statefulSet, err := c.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.ReadyReplicas >= replicas, nil
+}
+
+func isPodReady(c client.Client, namespace string, name string) (bool, error) {
+	pod, err := c.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if failure := podFailure(pod, time.Now()); failure != nil {
+		return false, failure
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+func isJobReady(c client.Client, namespace string, name string) (bool, error) {
+	job, err := c.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			return false, &Failure{Reason: condition.Reason, Message: condition.Message}
+		}
+	}
+	return job.Status.Succeeded > 0, nil
+}
+
+func isServiceReady(c client.Client, namespace string, name string) (bool, error) {
+	endpoints, err := c.CoreV1().Endpoints(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
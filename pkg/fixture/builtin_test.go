@@ -0,0 +1,73 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newWaitingPod(reason string, message string, started time.Time) *v1.Pod {
+	start := metav1.NewTime(started)
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			Phase:     v1.PodPending,
+			StartTime: &start,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: "kafka",
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{Reason: reason, Message: message},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPodFailure(t *testing.T) {
+	now := time.Now()
+
+	// Transient pull errors are retried by the kubelet
+	assert.Nil(t, podFailure(newWaitingPod("ErrImagePull", "dial tcp: i/o timeout", now), now))
+	assert.Nil(t, podFailure(newWaitingPod("ImagePullBackOff", "Back-off pulling image", now.Add(-time.Minute)), now))
+	assert.Nil(t, podFailure(newWaitingPod("ContainerCreating", "", now.Add(-time.Hour)), now))
+
+	failure := podFailure(newWaitingPod("ImagePullBackOff", "Back-off pulling image", now.Add(-10*time.Minute)), now)
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, "ImagePullBackOff", failure.Reason)
+	}
+	failure = podFailure(newWaitingPod("ErrImagePull", "manifest for kafka:0.0 not found: manifest unknown", now), now)
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, "ErrImagePull", failure.Reason)
+	}
+	assert.NotNil(t, podFailure(newWaitingPod("CrashLoopBackOff", "", now), now))
+
+	failed := newWaitingPod("", "", now)
+	failed.Status.Phase = v1.PodFailed
+	failed.Status.ContainerStatuses = nil
+	failure = podFailure(failed, now)
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, "PodFailed", failure.Reason)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixture
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+)
+
+// Provider checks the readiness of one kind of fixture, i.e. a resource that tests depend on
+type Provider interface {
+	// Ready tells whether the named fixture of the given namespace is ready. It returns a *Failure error when the
+	// fixture will not become ready without intervention, and a not found error when it does not exist yet.
+	Ready(c client.Client, namespace string, name string) (bool, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(c client.Client, namespace string, name string) (bool, error)
+
+// Ready calls the function
+func (f ProviderFunc) Ready(c client.Client, namespace string, name string) (bool, error) {
+	return f(c, namespace, name)
+}
+
+// Failure --
+type Failure struct {
+	// Reason is the machine readable cause of the failure, e.g. CrashLoopBackOff
+	Reason  string
+	Message string
+}
+
+func (f *Failure) Error() string {
+	if f.Message == "" {
+		return f.Reason
+	}
+	return f.Reason + ": " + f.Message
+}
+
+var providers = make(map[v1alpha1.DependencyKind]Provider)
+
+// Register makes a provider available for the dependencies of the given kind. It is meant to be called from the init
+// function of the package defining the provider, and panics if a provider is already registered for the kind.
+func Register(kind v1alpha1.DependencyKind, provider Provider) {
+	if provider == nil {
+		panic("fixture: nil provider for kind " + string(kind))
+	}
+	if _, ok := providers[kind]; ok {
+		panic(fmt.Sprintf("fixture: provider already registered for kind %s", kind))
+	}
+	providers[kind] = provider
+}
+
+// ProviderFor returns the provider registered for the given kind, if any
+func ProviderFor(kind v1alpha1.DependencyKind) (Provider, bool) {
+	provider, ok := providers[kind]
+	return provider, ok
+}
+
+// Kinds returns the sorted kinds that have a registered provider
+func Kinds() []string {
+	kinds := make([]string, 0, len(providers))
+	for kind := range providers {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+	return kinds
+}
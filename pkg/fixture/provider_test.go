@@ -0,0 +1,53 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixture
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinProviders(t *testing.T) {
+	assert.Equal(t, []string{"Deployment", "Job", "Pod", "Service", "StatefulSet"}, Kinds())
+
+	_, ok := ProviderFor(v1alpha1.DependencyKindDeployment)
+	assert.True(t, ok)
+	_, ok = ProviderFor("Kafka")
+	assert.False(t, ok)
+}
+
+func TestRegisterProvider(t *testing.T) {
+	kind := v1alpha1.DependencyKind("Custom")
+	defer delete(providers, kind)
+
+	Register(kind, ProviderFunc(func(_ client.Client, _ string, name string) (bool, error) {
+		return name == "ready", nil
+	}))
+	provider, ok := ProviderFor(kind)
+	assert.True(t, ok)
+	ready, err := provider.Ready(nil, "ns", "ready")
+	assert.Nil(t, err)
+	assert.True(t, ready)
+
+	assert.Panics(t, func() {
+		Register(kind, ProviderFunc(isPodReady))
+	})
+}
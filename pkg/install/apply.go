@@ -0,0 +1,176 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes/customclient"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultFieldManager is the field manager of the resources applied server-side by the CLI
+const DefaultFieldManager = "yaks-cli"
+
+// applyPatchType is the content type of server-side apply requests, not defined by the client library
+const applyPatchType = types.PatchType("application/apply-patch+yaml")
+
+// ServerSideApply --
+type ServerSideApply struct {
+	// FieldManager owning the applied fields in the managedFields of the resources
+	FieldManager string
+	// Force takes over the fields owned by other managers instead of failing with a conflict
+	Force bool
+}
+
+type serverSideApplyKey struct{}
+
+// WithServerSideApply returns a context applying the resources installed with it server-side, the resources being
+// created or updated as before on clusters that do not support server-side apply
+func WithServerSideApply(ctx context.Context, apply ServerSideApply) context.Context {
+	if apply.FieldManager == "" {
+		apply.FieldManager = DefaultFieldManager
+	}
+	return context.WithValue(ctx, serverSideApplyKey{}, apply)
+}
+
+func serverSideApplyFrom(ctx context.Context) (ServerSideApply, bool) {
+	apply, ok := ctx.Value(serverSideApplyKey{}).(ServerSideApply)
+	return apply, ok
+}
+
+// applyServerSide applies the object with a server-side apply patch. It returns false when the cluster does not
+// support server-side apply, for the object to be installed the client-side way.
+func applyServerSide(ctx context.Context, c client.Client, obj runtime.Object, apply ServerSideApply) (bool, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	resource, namespaced, err := resourceFor(c, gvk)
+	if err != nil {
+		return false, err
+	}
+
+	live, err := newObjectLike(c, obj)
+	if err != nil {
+		return false, err
+	}
+	key, err := k8sclient.ObjectKeyFromObject(obj)
+	if err != nil {
+		return false, err
+	}
+	exists := true
+	if err := c.Get(ctx, key, live); err != nil && k8serrors.IsNotFound(err) {
+		exists = false
+	} else if err != nil {
+		return false, err
+	} else if isNeverUpdated(obj) {
+		notifyApplyObserver(ctx, obj, ApplyResultSkipped)
+		return true, nil
+	}
+
+	data, err := applyContent(obj)
+	if err != nil {
+		return false, err
+	}
+	restClient, err := customclient.GetClientFor(c, gvk.Group, gvk.Version)
+	if err != nil {
+		return false, err
+	}
+	request := restClient.
+		Patch(applyPatchType).
+		Resource(resource).
+		Name(key.Name).
+		Param("fieldManager", apply.FieldManager).
+		Body(data)
+	if namespaced {
+		request = request.Namespace(key.Namespace)
+	}
+	if apply.Force {
+		request = request.Param("force", "true")
+	}
+	raw, err := request.Do().Raw()
+	if err != nil && k8serrors.IsUnsupportedMediaType(err) {
+		return false, nil
+	} else if err != nil && k8serrors.IsConflict(err) && !apply.Force {
+		return false, errors.New(fmt.Sprintf("%v: the fields are managed by another tool, use --force-conflicts to take them over", err))
+	} else if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		notifyApplyObserver(ctx, obj, ApplyResultCreated)
+		return true, nil
+	}
+	applied := unstructured.Unstructured{}
+	if err := applied.UnmarshalJSON(raw); err != nil {
+		return false, err
+	}
+	liveMeta, ok := live.(metav1.Object)
+	if ok && liveMeta.GetResourceVersion() == applied.GetResourceVersion() {
+		notifyApplyObserver(ctx, obj, ApplyResultUnchanged)
+	} else {
+		notifyApplyObserver(ctx, obj, ApplyResultUpdated)
+	}
+	return true, nil
+}
+
+// applyContent returns the object to apply without the fields that are managed by the server
+func applyContent(obj runtime.Object) ([]byte, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+		delete(metadata, "resourceVersion")
+		delete(metadata, "managedFields")
+	}
+	// JSON is a subset of YAML, accepted by apply patches
+	return json.Marshal(content)
+}
+
+// resourceFor returns the plural name of the resource of the kind, and whether it is namespaced
+func resourceFor(c client.Client, gvk schema.GroupVersionKind) (string, bool, error) {
+	resources, err := c.Discovery().ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		return "", false, err
+	}
+	for _, resource := range resources.APIResources {
+		// Subresources, e.g. deployments/status, share the kind of their resource
+		if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+			return resource.Name, resource.Namespaced, nil
+		}
+	}
+	return "", false, errors.New("no resource found for kind " + gvk.String())
+}
@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApplyContentIgnoresServerFields(t *testing.T) {
+	deployment, err := BuildOperatorDeployment(OperatorConfiguration{})
+	assert.Nil(t, err)
+	deployment.ResourceVersion = "12345"
+	deployment.Status.Replicas = 1
+
+	data, err := applyContent(deployment)
+	assert.Nil(t, err)
+
+	content := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &content))
+	assert.NotContains(t, content, "status")
+	assert.Equal(t, "Deployment", content["kind"])
+	metadata := content["metadata"].(map[string]interface{})
+	assert.Equal(t, "yaks", metadata["name"])
+	assert.NotContains(t, metadata, "resourceVersion")
+	assert.NotContains(t, metadata, "creationTimestamp")
+}
+
+func TestServerSideApplyDefaultFieldManager(t *testing.T) {
+	_, ok := serverSideApplyFrom(context.TODO())
+	assert.False(t, ok)
+
+	apply, ok := serverSideApplyFrom(WithServerSideApply(context.TODO(), ServerSideApply{Force: true}))
+	assert.True(t, ok)
+	assert.Equal(t, DefaultFieldManager, apply.FieldManager)
+	assert.True(t, apply.Force)
+}
+
+func TestCRDApplyContent(t *testing.T) {
+	obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[embeddedCRDs[0].Resource])
+	assert.Nil(t, err)
+	crd := obj.(*unstructured.Unstructured)
+	setManagedBy(crd)
+
+	// CRDs are only applied server-side when requested, like the other resources
+	applied, err := applyCRD(context.TODO(), nil, crd)
+	assert.Nil(t, err)
+	assert.False(t, applied)
+
+	data, err := applyContent(crd)
+	assert.Nil(t, err)
+	content := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &content))
+	assert.Equal(t, "CustomResourceDefinition", content["kind"])
+	metadata := content["metadata"].(map[string]interface{})
+	assert.Equal(t, managedByCLI, metadata["annotations"].(map[string]interface{})[ManagedByAnnotation])
+}
@@ -19,59 +19,151 @@ package install
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
 	"github.com/jboss-fuse/yaks/pkg/client"
 	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
 	"github.com/jboss-fuse/yaks/pkg/util/kubernetes/customclient"
 
-	"k8s.io/apimachinery/pkg/util/yaml"
-
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// InstallMode tells whether the installation manages the cluster-wide resources
+type InstallMode string
+
+const (
+	// InstallModeGlobal installs the cluster-wide resources, i.e. the custom resource definitions and cluster roles,
+	// which requires cluster-admin rights
+	InstallModeGlobal InstallMode = "Global"
+	// InstallModeNamespaced only installs namespaced resources, relying on the custom resource definitions being
+	// installed beforehand by a cluster admin
+	InstallModeNamespaced InstallMode = "Namespaced"
+)
+
+// ParseInstallMode returns the install mode with the given name, ignoring case
+func ParseInstallMode(value string) (InstallMode, error) {
+	for _, mode := range []InstallMode{InstallModeGlobal, InstallModeNamespaced} {
+		if strings.EqualFold(value, string(mode)) {
+			return mode, nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("unsupported install mode %q, expected one of %s, %s", value, InstallModeGlobal, InstallModeNamespaced))
+}
+
 // SetupClusterwideResources --
 func SetupClusterwideResources(ctx context.Context, clientProvider client.Provider) error {
-	return SetupClusterwideResourcesOrCollect(ctx, clientProvider, nil)
+	_, err := SetupClusterwideResourcesOrCollect(ctx, clientProvider, nil)
+	return err
 }
 
-// SetupClusterwideResourcesOrCollect --
-func SetupClusterwideResourcesOrCollect(ctx context.Context, clientProvider client.Provider, collection *kubernetes.Collection) error {
+// SetupClusterwideResourcesOrCollect installs, or adds to the collection, the cluster-wide resources. The summary of
+// what has been installed is returned even when the installation fails, it is empty when collecting.
+func SetupClusterwideResourcesOrCollect(ctx context.Context, clientProvider client.Provider, collection *kubernetes.Collection) (*ClusterSetupSummary, error) {
+	summary := &ClusterSetupSummary{}
+	ctx = summary.observe(ctx)
+
 	// Get a client to install the CRD
 	c, err := clientProvider.Get()
 	if err != nil {
-		return err
+		return summary, summary.fail(err)
 	}
 
 	// Install CRD for Test
 	if err := installCRD(ctx, c, "Test", "crds/yaks_v1alpha1_test_crd.yaml", collection); err != nil {
-		return err
+		return summary, summary.fail(err)
 	}
 
-	// Installing ClusterRole
-	clusterRoleInstalled, err := IsClusterRoleInstalled(ctx, c)
-	if err != nil {
-		return err
+	// Install CRD for Instance
+	if err := installCRD(ctx, c, "Instance", "crds/yaks_v1alpha1_instance_crd.yaml", collection); err != nil {
+		return summary, summary.fail(err)
 	}
-	if !clusterRoleInstalled || collection != nil {
+
+	// Installing ClusterRole, a collection always gets it
+	clusterRoleInstalled := false
+	if collection == nil {
+		if clusterRoleInstalled, err = IsClusterRoleInstalled(ctx, c); err != nil {
+			return summary, summary.fail(err)
+		}
+	}
+	if !clusterRoleInstalled {
 		err := installClusterRole(ctx, c, collection)
 		if err != nil {
-			return err
+			return summary, summary.fail(err)
 		}
+	} else {
+		summary.ClusterRoles.add(ApplyResultSkipped)
+	}
+
+	// Link the CLI from the web console on OpenShift
+	if err := ResourceOrCollect(ctx, c, "", collection, IdentityResourceCustomizer, "cli_download.yaml"); err != nil {
+		return summary, summary.fail(err)
 	}
 
 	// Wait for all CRDs to be installed before proceeding
-	if err := WaitForAllCRDInstallation(ctx, clientProvider, 25*time.Second); err != nil {
-		return err
+	if collection == nil {
+		if err := WaitForAllCRDInstallation(ctx, clientProvider, 25*time.Second); err != nil {
+			return summary, summary.fail(err)
+		}
 	}
 
-	return nil
+	return summary, nil
+}
+
+// SetupClusterwideResourcesForMode installs, or adds to the collection, the cluster-wide resources in Global mode. In
+// Namespaced mode, nothing is installed nor collected, and the custom resource definitions must already be installed.
+func SetupClusterwideResourcesForMode(ctx context.Context, clientProvider client.Provider, mode InstallMode, collection *kubernetes.Collection) (*ClusterSetupSummary, error) {
+	if mode != InstallModeNamespaced {
+		return SetupClusterwideResourcesOrCollect(ctx, clientProvider, collection)
+	}
+
+	summary := &ClusterSetupSummary{}
+	if collection != nil {
+		return summary, nil
+	}
+	c, err := clientProvider.Get()
+	if err != nil {
+		return summary, summary.fail(err)
+	}
+	if err := CheckNamespacedPrerequisites(ctx, c); err != nil {
+		return summary, summary.fail(err)
+	}
+	summary.CRDs.add(ApplyResultSkipped)
+	summary.CRDs.add(ApplyResultSkipped)
+	summary.ClusterRoles.add(ApplyResultSkipped)
+	return summary, nil
+}
+
+// CheckNamespacedPrerequisites verifies that the custom resource definitions a namespaced installation relies on are
+// installed, telling that a cluster admin has to install them otherwise
+func CheckNamespacedPrerequisites(ctx context.Context, c client.Client) error {
+	missing := make([]string, 0)
+	for _, kind := range []string{v1alpha1.TestKind, v1alpha1.InstanceKind} {
+		installed, err := IsCRDInstalled(ctx, c, v1alpha1.SchemeGroupVersion, kind)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			missing = append(missing, kind)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New(fmt.Sprintf("custom resource definitions of %s are not installed: a namespaced installation "+
+			"does not install them, a cluster admin must run \"yaks install --cluster-setup\" first", strings.Join(missing, ", ")))
+	}
+	return CheckCRDVersions(ctx, c)
 }
 
 // WaitForAllCRDInstallation waits until all CRDs are installed
@@ -99,16 +191,123 @@ func WaitForAllCRDInstallation(ctx context.Context, clientProvider client.Provid
 
 // AreAllCRDInstalled check if all the required CRDs are installed
 func AreAllCRDInstalled(ctx context.Context, c client.Client) (bool, error) {
-	return IsCRDInstalled(ctx, c, "Test")
+	if ok, err := IsCRDInstalled(ctx, c, v1alpha1.SchemeGroupVersion, v1alpha1.TestKind); err != nil {
+		return ok, err
+	} else if !ok {
+		return false, nil
+	}
+	return IsCRDInstalled(ctx, c, v1alpha1.SchemeGroupVersion, v1alpha1.InstanceKind)
+}
+
+// InstalledCRDVersions returns the served versions of the yaks group that provide the given CRD kind
+func InstalledCRDVersions(ctx context.Context, c client.Client, kind string) ([]string, error) {
+	groups, err := c.Discovery().ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0)
+	for _, group := range groups.Groups {
+		if group.Name != v1alpha1.SchemeGroupVersion.Group {
+			continue
+		}
+		for _, version := range group.Versions {
+			installed, err := IsCRDInstalled(ctx, c, schema.GroupVersion{Group: group.Name, Version: version.Version}, kind)
+			if err != nil {
+				return nil, err
+			}
+			if installed {
+				versions = append(versions, version.Version)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// CRDRevisionAnnotation holds the revision of the schema of the custom resource definitions, that is increased
+// whenever fields are added to or removed from their schema
+const CRDRevisionAnnotation = "yaks.dev/crd-revision"
+
+// MinimumCRDRevisions are the oldest revisions of the custom resource definitions supported by the operator, by kind.
+// Definitions installed before the revisions were introduced have none and are considered at revision 0.
+var MinimumCRDRevisions = map[string]int{
+	v1alpha1.TestKind:     1,
+	v1alpha1.InstanceKind: 1,
+}
+
+// CheckCRDVersions verifies that the installed custom resource definitions serve the version of the operator, and are at
+// least at the minimum revision it supports. The revisions are not checked when the definitions cannot be read, e.g.
+// by a namespaced operator, that cannot read cluster-scoped resources.
+func CheckCRDVersions(ctx context.Context, c client.Client) error {
+	kinds := make([]string, 0, len(MinimumCRDRevisions))
+	for kind := range MinimumCRDRevisions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		expected := v1alpha1.SchemeGroupVersion.Version
+		versions, err := InstalledCRDVersions(ctx, c, kind)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return errors.New(fmt.Sprintf("custom resource definition of %s is not installed, expected version %s: "+
+				"run \"yaks install --cluster-setup\"", kind, expected))
+		}
+		served := false
+		for _, version := range versions {
+			served = served || version == expected
+		}
+		if !served {
+			return errors.New(fmt.Sprintf("custom resource definition of %s serves version %s, expected version %s: "+
+				"run \"yaks install --cluster-setup\" to upgrade it", kind, strings.Join(versions, ", "), expected))
+		}
+
+		minimum := MinimumCRDRevisions[kind]
+		revision, ok, err := InstalledCRDRevision(crdFor(kind).Name)
+		if err != nil {
+			return err
+		}
+		if ok && revision < minimum {
+			return errors.New(fmt.Sprintf("custom resource definition of %s is at revision %d, expected revision %d or newer: "+
+				"run \"yaks install --cluster-setup\" to upgrade it", kind, revision, minimum))
+		}
+	}
+	return nil
+}
+
+// InstalledCRDRevision returns the revision of the installed custom resource definition with the given name. It
+// returns false when the definition cannot be read, i.e. when it is not installed or access to it is denied.
+func InstalledCRDRevision(name string) (int, bool, error) {
+	crd, err := GetInstalledCRD(name)
+	if err != nil && k8serrors.IsForbidden(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	} else if crd == nil {
+		return 0, false, nil
+	}
+	return crdRevision(crd), true, nil
+}
+
+// crdRevision returns the revision of the custom resource definition, 0 when it has none or it is invalid
+func crdRevision(crd *unstructured.Unstructured) int {
+	revision, err := strconv.Atoi(crd.GetAnnotations()[CRDRevisionAnnotation])
+	if err != nil {
+		return 0
+	}
+	return revision
 }
 
-// IsCRDInstalled check if the given CRD kind is installed
-func IsCRDInstalled(ctx context.Context, c client.Client, kind string) (bool, error) {
-	lst, err := c.Discovery().ServerResourcesForGroupVersion("yaks.dev/v1alpha1")
+// IsCRDInstalled check if the given CRD kind is served by the given group version
+func IsCRDInstalled(ctx context.Context, c client.Client, groupVersion schema.GroupVersion, kind string) (bool, error) {
+	lst, err := c.Discovery().ServerResourcesForGroupVersion(groupVersion.String())
 	if err != nil && k8serrors.IsNotFound(err) {
 		return false, nil
 	} else if err != nil {
 		return false, err
+	} else if lst == nil {
+		return false, nil
 	}
 	for _, res := range lst.APIResources {
 		if res.Kind == kind {
@@ -118,27 +317,63 @@ func IsCRDInstalled(ctx context.Context, c client.Client, kind string) (bool, er
 	return false, nil
 }
 
+// GetInstalledCRD returns the custom resource definition with the given name, or nil if it is not installed
+func GetInstalledCRD(name string) (*unstructured.Unstructured, error) {
+	crds, err := customclient.GetDynamicClientFor("apiextensions.k8s.io", "v1beta1", "customresourcedefinitions", "")
+	if err != nil {
+		return nil, err
+	}
+	crd, err := crds.Get(name, metav1.GetOptions{})
+	if err != nil && k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return crd, err
+}
+
 func installCRD(ctx context.Context, c client.Client, kind string, resourceName string, collection *kubernetes.Collection) error {
 	crd := []byte(deploy.Resources[resourceName])
+	unstr, err := kubernetes.LoadRawResourceFromYaml(string(crd))
+	if err != nil {
+		return err
+	}
+	if err := customizeCRDStorage(ctx, unstr.(*unstructured.Unstructured)); err != nil {
+		return err
+	}
 	if collection != nil {
-		unstr, err := kubernetes.LoadRawResourceFromYaml(string(crd))
-		if err != nil {
-			return err
-		}
 		collection.Add(unstr)
 		return nil
 	}
 
 	// Installing Integration CRD
-	installed, err := IsCRDInstalled(ctx, c, kind)
+	installed, err := IsCRDInstalled(ctx, c, v1alpha1.SchemeGroupVersion, kind)
 	if err != nil {
 		return err
 	}
 	if installed {
+		// Definitions older than the embedded ones are upgraded
+		if revision, ok, err := InstalledCRDRevision(crdFor(kind).Name); err != nil {
+			return err
+		} else if ok && revision < crdRevision(unstr.(*unstructured.Unstructured)) {
+			return updateCRD(ctx, c, crdFor(kind))
+		}
+		// Switching the storage version of an installed definition requires updating it
+		if version, ok := crdStorageVersionFrom(ctx); ok {
+			switched, err := isStorageVersionSwitched(crdFor(kind).Name, version)
+			if err != nil {
+				return err
+			} else if switched {
+				return updateCRD(ctx, c, crdFor(kind))
+			}
+		}
+		notifyApplyObserver(ctx, unstr, ApplyResultUnchanged)
 		return nil
 	}
 
-	crdJSON, err := yaml.ToJSON(crd)
+	setManagedBy(unstr)
+	if applied, err := applyCRD(ctx, c, unstr.(*unstructured.Unstructured)); err != nil || applied {
+		return err
+	}
+	crdJSON, err := json.Marshal(unstr)
 	if err != nil {
 		return err
 	}
@@ -157,6 +392,7 @@ func installCRD(ctx context.Context, c client.Client, kind string, resourceName
 		return result.Error()
 	}
 
+	notifyApplyObserver(ctx, unstr, ApplyResultCreated)
 	return nil
 }
 
@@ -194,5 +430,10 @@ func installClusterRole(ctx context.Context, c client.Client, collection *kubern
 		collection.Add(obj)
 		return nil
 	}
-	return c.Create(ctx, obj)
+	setManagedBy(obj)
+	if err := c.Create(ctx, obj); err != nil {
+		return err
+	}
+	notifyApplyObserver(ctx, obj, ApplyResultCreated)
+	return nil
 }
@@ -0,0 +1,106 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientscheme "k8s.io/client-go/kubernetes/scheme"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterOperatorName is the name of the Deployment, service account, cluster role and cluster role binding of the
+// cluster-wide operator
+const ClusterOperatorName = "yaks-cluster-operator"
+
+// ClusterOperatorOrCollect installs, or adds to the collection, the cluster-wide operator in the namespace of the
+// configuration. It watches all the namespaces and deploys the operators of the ones having an Instance.
+func ClusterOperatorOrCollect(ctx context.Context, c client.Client, cfg OperatorConfiguration, collection *kubernetes.Collection) error {
+	customizer := clusterRoleBindingCustomizer(cfg.Namespace, cfg.customizer())
+	names := []string{"cluster_operator_service_account.yaml", "cluster_operator_role.yaml", "cluster_operator_role_binding.yaml"}
+	if err := ResourcesOrCollect(ctx, c, cfg.Namespace, collection, customizer, names...); err != nil {
+		return err
+	}
+
+	deployment, err := BuildClusterOperatorDeployment(cfg)
+	if err != nil {
+		return err
+	}
+	return RuntimeObjectOrCollect(ctx, c, cfg.Namespace, collection, customizer(deployment))
+}
+
+// clusterRoleBindingCustomizer binds the cluster role of the cluster-wide operator to its service account in the given
+// namespace
+func clusterRoleBindingCustomizer(namespace string, customizer ResourceCustomizer) ResourceCustomizer {
+	return func(object runtime.Object) runtime.Object {
+		if binding, ok := object.(*rbacv1.ClusterRoleBinding); ok && binding.Name == ClusterOperatorName {
+			for i := range binding.Subjects {
+				if binding.Subjects[i].Kind == rbacv1.ServiceAccountKind {
+					binding.Subjects[i].Namespace = namespace
+				}
+			}
+		}
+		return customizer(object)
+	}
+}
+
+// BuildClusterOperatorDeployment returns the Deployment of the cluster-wide operator, with the image and replicas of
+// the configuration applied
+func BuildClusterOperatorDeployment(cfg OperatorConfiguration) (*appsv1.Deployment, error) {
+	obj, err := kubernetes.LoadResourceFromYaml(clientscheme.Scheme, deploy.Resources["cluster_operator.yaml"])
+	if err != nil {
+		return nil, err
+	}
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil, errors.New("cluster operator resource is not a deployment")
+	}
+
+	if cfg.Replicas != nil {
+		replicas := *cfg.Replicas
+		deployment.Spec.Replicas = &replicas
+	}
+	if cfg.Image != "" {
+		for i := range deployment.Spec.Template.Spec.Containers {
+			deployment.Spec.Template.Spec.Containers[i].Image = cfg.Image
+		}
+	}
+	return deployment, nil
+}
+
+// IsClusterOperatorInstalled tells whether the cluster-wide operator has been granted its cluster role, which is
+// required for the operators of the Instances to be deployed
+func IsClusterOperatorInstalled(ctx context.Context, c client.Client) (bool, error) {
+	binding := rbacv1.ClusterRoleBinding{}
+	err := c.Get(ctx, k8sclient.ObjectKey{Name: ClusterOperatorName}, &binding)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
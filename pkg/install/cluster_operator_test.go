@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestClusterOperatorResources(t *testing.T) {
+	c, err := client.NewOfflineClient()
+	assert.Nil(t, err)
+	replicas := int32(2)
+	cfg := OperatorConfiguration{Namespace: "yaks-system", Image: "my-registry/yaks:1.0.0", Replicas: &replicas}
+
+	collection := kubernetes.NewCollection()
+	assert.Nil(t, ClusterOperatorOrCollect(context.Background(), c, cfg, collection))
+	assert.Equal(t, 4, collection.Size())
+
+	var binding *rbacv1.ClusterRoleBinding
+	var role *rbacv1.ClusterRole
+	var deployment *appsv1.Deployment
+	collection.Visit(func(obj runtime.Object) {
+		switch o := obj.(type) {
+		case *rbacv1.ClusterRoleBinding:
+			binding = o
+		case *rbacv1.ClusterRole:
+			role = o
+		case *appsv1.Deployment:
+			deployment = o
+		}
+	})
+
+	assert.NotNil(t, role)
+	assert.Equal(t, ClusterOperatorName, role.Name)
+	assert.Equal(t, ClusterOperatorName, binding.RoleRef.Name)
+	assert.Equal(t, ClusterOperatorName, binding.Subjects[0].Name)
+	assert.Equal(t, "yaks-system", binding.Subjects[0].Namespace)
+
+	assert.Equal(t, ClusterOperatorName, deployment.Name)
+	assert.Equal(t, int32(2), *deployment.Spec.Replicas)
+	assert.Equal(t, ClusterOperatorName, deployment.Spec.Template.Spec.ServiceAccountName)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "my-registry/yaks:1.0.0", container.Image)
+	// Watching all the namespaces
+	assert.Equal(t, "", envvar.Get(container.Env, "WATCH_NAMESPACE").Value)
+	assert.Nil(t, envvar.Get(container.Env, "WATCH_NAMESPACE").ValueFrom)
+}
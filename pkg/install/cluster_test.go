@@ -0,0 +1,75 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInstallMode(t *testing.T) {
+	mode, err := ParseInstallMode("namespaced")
+	assert.Nil(t, err)
+	assert.Equal(t, InstallModeNamespaced, mode)
+
+	mode, err = ParseInstallMode("Global")
+	assert.Nil(t, err)
+	assert.Equal(t, InstallModeGlobal, mode)
+
+	_, err = ParseInstallMode("cluster")
+	assert.NotNil(t, err)
+}
+
+func TestNamespacedModeCollectsNoClusterwideResource(t *testing.T) {
+	provider := client.Provider{Get: func() (client.Client, error) {
+		return nil, errors.New("no cluster")
+	}}
+	collection := kubernetes.NewCollection()
+
+	summary, err := SetupClusterwideResourcesForMode(context.Background(), provider, InstallModeNamespaced, collection)
+	assert.Nil(t, err)
+	assert.Empty(t, summary.Errors)
+	assert.Equal(t, 0, collection.Size())
+
+	// Without collection, the custom resource definitions are checked with the cluster
+	summary, err = SetupClusterwideResourcesForMode(context.Background(), provider, InstallModeNamespaced, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"no cluster"}, summary.Errors)
+}
+
+func TestGlobalModeCollectsOffline(t *testing.T) {
+	provider := client.Provider{Get: client.NewOfflineClient}
+
+	collection := kubernetes.NewCollection()
+	ctx := WithClusterType(context.Background(), ClusterTypeKubernetes)
+	_, err := SetupClusterwideResourcesForMode(ctx, provider, InstallModeGlobal, collection)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, collection.Size())
+
+	// The link to the CLI only applies to OpenShift
+	collection = kubernetes.NewCollection()
+	ctx = WithClusterType(context.Background(), ClusterTypeOpenShift)
+	_, err = SetupClusterwideResourcesForMode(ctx, provider, InstallModeGlobal, collection)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, collection.Size())
+}
@@ -58,12 +58,24 @@ func Resource(ctx context.Context, c client.Client, namespace string, customizer
 	return ResourceOrCollect(ctx, c, namespace, nil, customizer, name)
 }
 
-// ResourceOrCollect --
+// ResourceOrCollect installs, or adds to the collection, the named resource if it applies to the type of the cluster
 func ResourceOrCollect(ctx context.Context, c client.Client, namespace string, collection *kubernetes.Collection, customizer ResourceCustomizer, name string) error {
-	obj, err := kubernetes.LoadResourceFromYaml(c.GetScheme(), deploy.Resources[name])
+	if applicable, err := isApplicableTo(ctx, c, name); err != nil {
+		return err
+	} else if !applicable {
+		return nil
+	}
+
+	obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[name])
 	if err != nil {
 		return err
 	}
+	// Kinds unknown to the scheme, e.g. OpenShift specific ones, are kept unstructured
+	if c.GetScheme().Recognizes(obj.GetObjectKind().GroupVersionKind()) {
+		if obj, err = kubernetes.LoadResourceFromYaml(c.GetScheme(), deploy.Resources[name]); err != nil {
+			return err
+		}
+	}
 
 	return RuntimeObjectOrCollect(ctx, c, namespace, collection, customizer(obj))
 }
@@ -85,20 +97,49 @@ func RuntimeObjectOrCollect(ctx context.Context, c client.Client, namespace stri
 		metaObject.SetNamespace(namespace)
 	}
 
-	err := c.Create(ctx, obj)
-	if err != nil && errors.IsAlreadyExists(err) {
-		// Don't recreate Service object
-		if obj.GetObjectKind().GroupVersionKind().Kind == "Service" {
-			return nil
-		}
-		// Don't recreate tests, etc
-		if obj.GetObjectKind().GroupVersionKind().Kind == v1alpha1.TestKind {
-			return nil
-		}
-		if obj.GetObjectKind().GroupVersionKind().Kind == "PersistentVolumeClaim" {
-			return nil
+	if apply, ok := serverSideApplyFrom(ctx); ok {
+		if applied, err := applyServerSide(ctx, c, obj, apply); err != nil || applied {
+			return err
 		}
-		return c.Update(ctx, obj)
 	}
-	return err
+
+	err := c.Create(ctx, obj)
+	if err == nil {
+		notifyApplyObserver(ctx, obj, ApplyResultCreated)
+		return nil
+	} else if !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	if isNeverUpdated(obj) {
+		notifyApplyObserver(ctx, obj, ApplyResultSkipped)
+		return nil
+	}
+
+	// Only apply the resources that have changed, to limit the API churn on repeated installs
+	upToDate, err := isUpToDate(ctx, c, obj)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		notifyApplyObserver(ctx, obj, ApplyResultUnchanged)
+		return nil
+	}
+	if err := c.Update(ctx, obj); err != nil {
+		return err
+	}
+	notifyApplyObserver(ctx, obj, ApplyResultUpdated)
+	return nil
+}
+
+// isNeverUpdated tells whether the existing object is left as is on repeated installs
+func isNeverUpdated(obj runtime.Object) bool {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	// Don't recreate Service object
+	return kind == "Service" ||
+		// Don't recreate tests, etc
+		kind == v1alpha1.TestKind ||
+		kind == "PersistentVolumeClaim" ||
+		// The spec of a PodDisruptionBudget is immutable
+		kind == "PodDisruptionBudget"
 }
@@ -0,0 +1,276 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes/customclient"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// embeddedCRD --
+type embeddedCRD struct {
+	Kind     string
+	Name     string
+	Resource string
+}
+
+var embeddedCRDs = []embeddedCRD{
+	{Kind: v1alpha1.TestKind, Name: "tests.yaks.dev", Resource: "crds/yaks_v1alpha1_test_crd.yaml"},
+	{Kind: v1alpha1.InstanceKind, Name: "instances.yaks.dev", Resource: "crds/yaks_v1alpha1_instance_crd.yaml"},
+}
+
+// CRDDrift describes how an installed custom resource definition differs from the one embedded in the CLI
+type CRDDrift struct {
+	Kind string
+	Name string
+	// Missing is set when the definition is not installed, or does not serve its kind
+	Missing bool
+	// Differences are the paths of the fields of the spec that differ, e.g. spec.validation.openAPIV3Schema
+	Differences []string
+	// ManagedBy is the installer managing the definition, when it is not the CLI
+	ManagedBy string
+}
+
+// Drifted tells whether the installed definition has to be reapplied
+func (d CRDDrift) Drifted() bool {
+	return d.Missing || len(d.Differences) > 0
+}
+
+// CheckCRDDrift compares the installed custom resource definitions with the embedded ones. The versions and the
+// validation schema must be the same, while the other fields of the spec may have been defaulted by the server.
+func CheckCRDDrift(ctx context.Context, c client.Client) ([]CRDDrift, error) {
+	drifts := make([]CRDDrift, 0, len(embeddedCRDs))
+	for _, crd := range embeddedCRDs {
+		drift := CRDDrift{Kind: crd.Kind, Name: crd.Name}
+		installed, err := IsCRDInstalled(ctx, c, v1alpha1.SchemeGroupVersion, crd.Kind)
+		if err != nil {
+			return nil, err
+		}
+		var live *unstructured.Unstructured
+		if installed {
+			if live, err = GetInstalledCRD(crd.Name); err != nil {
+				return nil, err
+			}
+		}
+		if live == nil {
+			drift.Missing = true
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		embedded, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[crd.Resource])
+		if err != nil {
+			return nil, err
+		}
+		if err := customizeCRDStorage(ctx, embedded.(*unstructured.Unstructured)); err != nil {
+			return nil, err
+		}
+		desiredSpec, err := normalized(embedded.(*unstructured.Unstructured).Object["spec"])
+		if err != nil {
+			return nil, err
+		}
+		liveSpec, err := normalized(live.Object["spec"])
+		if err != nil {
+			return nil, err
+		}
+		desired := desiredSpec.(map[string]interface{})
+		actual, _ := liveSpec.(map[string]interface{})
+		for _, key := range sortedKeys(desired) {
+			exact := key == "version" || key == "versions" || key == "validation"
+			drift.Differences = append(drift.Differences, specDifferences("spec."+key, desired[key], actual[key], exact)...)
+		}
+		if manager, ok := live.GetAnnotations()[ManagedByAnnotation]; ok && manager != managedByCLI {
+			drift.ManagedBy = manager
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// FixCRDDrift reapplies the embedded definitions that have drifted, the definitions managed by another installer
+// being left as they are
+func FixCRDDrift(ctx context.Context, c client.Client, drifts []CRDDrift) error {
+	for _, drift := range drifts {
+		if !drift.Drifted() {
+			continue
+		}
+		if drift.ManagedBy != "" {
+			return errors.New(fmt.Sprintf("custom resource definition %s is managed by %s, it must be fixed by that installer", drift.Name, drift.ManagedBy))
+		}
+		crd := crdFor(drift.Kind)
+		if drift.Missing {
+			if err := installCRD(ctx, c, crd.Kind, crd.Resource, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := updateCRD(ctx, c, crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func crdFor(kind string) embeddedCRD {
+	for _, crd := range embeddedCRDs {
+		if crd.Kind == kind {
+			return crd
+		}
+	}
+	return embeddedCRD{}
+}
+
+// updateCRD replaces the spec of the installed definition with the embedded one
+func updateCRD(ctx context.Context, c client.Client, crd embeddedCRD) error {
+	live, err := GetInstalledCRD(crd.Name)
+	if err != nil {
+		return err
+	} else if live == nil {
+		return errors.New("custom resource definition " + crd.Name + " is not installed")
+	}
+	obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[crd.Resource])
+	if err != nil {
+		return err
+	}
+	desired := obj.(*unstructured.Unstructured)
+	if err := customizeCRDStorage(ctx, desired); err != nil {
+		return err
+	}
+	setManagedBy(desired)
+	// The labels and annotations set by other tools are kept by server-side apply
+	if applied, err := applyCRD(ctx, c, desired); err != nil || applied {
+		return err
+	}
+	desired.SetResourceVersion(live.GetResourceVersion())
+	desired.SetLabels(mergeStrings(live.GetLabels(), desired.GetLabels()))
+	desired.SetAnnotations(mergeStrings(live.GetAnnotations(), desired.GetAnnotations()))
+
+	crdJSON, err := json.Marshal(desired)
+	if err != nil {
+		return err
+	}
+	restClient, err := customclient.GetClientFor(c, "apiextensions.k8s.io", "v1beta1")
+	if err != nil {
+		return err
+	}
+	result := restClient.
+		Put().
+		Body(crdJSON).
+		Resource("customresourcedefinitions").
+		Name(crd.Name).
+		Do()
+	if result.Error() != nil {
+		return result.Error()
+	}
+
+	notifyApplyObserver(ctx, desired, ApplyResultUpdated)
+	return nil
+}
+
+// applyCRD applies the custom resource definition server-side when the context asks for it, like the other installed
+// resources. It returns false when the definition is to be created or updated the client-side way.
+func applyCRD(ctx context.Context, c client.Client, crd *unstructured.Unstructured) (bool, error) {
+	apply, ok := serverSideApplyFrom(ctx)
+	if !ok {
+		return false, nil
+	}
+	return applyServerSide(ctx, c, crd, apply)
+}
+
+func mergeStrings(from map[string]string, to map[string]string) map[string]string {
+	merged := make(map[string]string, len(from)+len(to))
+	for key, value := range from {
+		merged[key] = value
+	}
+	for key, value := range to {
+		merged[key] = value
+	}
+	return merged
+}
+
+// normalized returns the JSON content of the value, so that numbers compare equal however they have been decoded
+func normalized(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var content interface{}
+	err = json.Unmarshal(data, &content)
+	return content, err
+}
+
+// specDifferences returns the paths where live differs from desired. In exact mode, fields only set in live are
+// differences too, otherwise they are considered defaulted by the server.
+func specDifferences(path string, desired interface{}, live interface{}, exact bool) []string {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		differences := make([]string, 0)
+		for _, key := range sortedKeys(d) {
+			differences = append(differences, specDifferences(path+"."+key, d[key], l[key], exact)...)
+		}
+		if exact {
+			for _, key := range sortedKeys(l) {
+				if _, ok := d[key]; !ok {
+					differences = append(differences, path+"."+key)
+				}
+			}
+		}
+		return differences
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(d) != len(l) {
+			return []string{path}
+		}
+		differences := make([]string, 0)
+		for i := range d {
+			differences = append(differences, specDifferences(path+"["+strconv.Itoa(i)+"]", d[i], l[i], exact)...)
+		}
+		return differences
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
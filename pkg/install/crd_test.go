@@ -0,0 +1,73 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSpecDifferences(t *testing.T) {
+	desired := map[string]interface{}{
+		"names": map[string]interface{}{
+			"kind": "Test",
+		},
+		"validation": map[string]interface{}{
+			"type": "object",
+		},
+	}
+	live := map[string]interface{}{
+		"names": map[string]interface{}{
+			"kind":     "Test",
+			"listKind": "TestList",
+		},
+		"validation": map[string]interface{}{
+			"type":  "object",
+			"extra": true,
+		},
+	}
+
+	assert.Empty(t, specDifferences("spec.names", desired["names"], live["names"], false))
+	assert.Equal(t, []string{"spec.validation.extra"}, specDifferences("spec.validation", desired["validation"], live["validation"], true))
+	assert.Equal(t, []string{"spec.names"}, specDifferences("spec.names", desired["names"], nil, false))
+	assert.Equal(t, []string{"spec.versions"}, specDifferences("spec.versions", []interface{}{"v1alpha1"}, []interface{}{}, true))
+}
+
+func TestNormalizedNumbers(t *testing.T) {
+	desired, err := normalized(map[string]interface{}{"maximum": int64(65535)})
+	assert.Nil(t, err)
+	live, err := normalized(map[string]interface{}{"maximum": float64(65535)})
+	assert.Nil(t, err)
+
+	assert.Empty(t, specDifferences("spec", desired, live, true))
+}
+
+func TestEmbeddedCRDRevisions(t *testing.T) {
+	for _, crd := range embeddedCRDs {
+		obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[crd.Resource])
+		assert.Nil(t, err)
+		assert.True(t, crdRevision(obj.(*unstructured.Unstructured)) >= MinimumCRDRevisions[crd.Kind], crd.Name)
+	}
+
+	// Definitions installed without revision are at revision 0
+	assert.Equal(t, 0, crdRevision(&unstructured.Unstructured{}))
+}
@@ -0,0 +1,165 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApplyResult describes what happened to a resource during installation
+type ApplyResult string
+
+const (
+	// ApplyResultCreated --
+	ApplyResultCreated ApplyResult = "created"
+	// ApplyResultUpdated --
+	ApplyResultUpdated ApplyResult = "updated"
+	// ApplyResultUnchanged --
+	ApplyResultUnchanged ApplyResult = "unchanged"
+	// ApplyResultSkipped is reported for existing resources that are never updated
+	ApplyResultSkipped ApplyResult = "skipped"
+)
+
+// ApplyObserver is notified of the result of applying each resource
+type ApplyObserver func(obj runtime.Object, result ApplyResult)
+
+type applyObserverKey struct{}
+
+// WithApplyObserver returns a context that notifies the given observer of the resources applied with it
+func WithApplyObserver(ctx context.Context, observer ApplyObserver) context.Context {
+	return context.WithValue(ctx, applyObserverKey{}, observer)
+}
+
+func notifyApplyObserver(ctx context.Context, obj runtime.Object, result ApplyResult) {
+	if observer, ok := ctx.Value(applyObserverKey{}).(ApplyObserver); ok {
+		observer(obj, result)
+	}
+}
+
+// isUpToDate checks if the live version of the object already matches the desired one, ignoring
+// server-managed fields such as the status, the resource version or fields defaulted by the server
+func isUpToDate(ctx context.Context, c client.Client, desired runtime.Object) (bool, error) {
+	live, err := newObjectLike(c, desired)
+	if err != nil {
+		return false, err
+	}
+	key, err := k8sclient.ObjectKeyFromObject(desired)
+	if err != nil {
+		return false, err
+	}
+	if err := c.Get(ctx, key, live); err != nil {
+		return false, err
+	}
+
+	desiredContent, err := comparableContent(desired)
+	if err != nil {
+		return false, err
+	}
+	liveContent, err := comparableContent(live)
+	if err != nil {
+		return false, err
+	}
+	return isSubset(desiredContent, liveContent), nil
+}
+
+func newObjectLike(c client.Client, obj runtime.Object) (runtime.Object, error) {
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		live := unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+		return &live, nil
+	}
+	return c.GetScheme().New(obj.GetObjectKind().GroupVersionKind())
+}
+
+// comparableContent returns the content of the object without the status and the server-managed metadata
+func comparableContent(obj runtime.Object) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	delete(content, "status")
+	delete(content, "metadata")
+	if metaObject, ok := obj.(metav1.Object); ok {
+		metadata := make(map[string]interface{})
+		if labels := metaObject.GetLabels(); len(labels) > 0 {
+			metadata["labels"] = labels
+		}
+		if annotations := metaObject.GetAnnotations(); len(annotations) > 0 {
+			metadata["annotations"] = annotations
+		}
+		content["metadata"] = metadata
+	}
+	return content, nil
+}
+
+// isSubset checks that all the fields set in desired have the same value in live
+func isSubset(desired interface{}, live interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return len(d) == 0 && live == nil
+		}
+		for key, value := range d {
+			if !isSubset(value, l[key]) {
+				return false
+			}
+		}
+		return true
+	case map[string]string:
+		l, ok := live.(map[string]string)
+		if !ok {
+			return false
+		}
+		for key, value := range d {
+			if l[key] != value {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(d) != len(l) {
+			return len(d) == 0 && live == nil
+		}
+		for i := range d {
+			if !isSubset(d[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return true
+	default:
+		return reflect.DeepEqual(desired, live)
+	}
+}
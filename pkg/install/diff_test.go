@@ -0,0 +1,48 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparableContentIgnoresServerFields(t *testing.T) {
+	desired, err := BuildOperatorDeployment(OperatorConfiguration{})
+	assert.Nil(t, err)
+
+	live := desired.DeepCopy()
+	live.ResourceVersion = "12345"
+	live.UID = "a-uid"
+	live.Status.Replicas = 1
+	// Defaulted by the server
+	live.Spec.RevisionHistoryLimit = new(int32)
+	live.Spec.Template.Spec.SchedulerName = "default-scheduler"
+
+	desiredContent, err := comparableContent(desired)
+	assert.Nil(t, err)
+	liveContent, err := comparableContent(live)
+	assert.Nil(t, err)
+	assert.True(t, isSubset(desiredContent, liveContent))
+
+	live.Spec.Template.Spec.Containers[0].Image = "my-registry/yaks:latest"
+	liveContent, err = comparableContent(live)
+	assert.Nil(t, err)
+	assert.False(t, isSubset(desiredContent, liveContent))
+}
@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// WriteExplanation writes a human readable summary of the collected resources, grouped by kind, followed by the
+// settings of the operator deployment if one has been collected
+func WriteExplanation(w io.Writer, namespace string, collection *kubernetes.Collection) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	clusterScoped, namespaced := explainedKinds(collection)
+	if len(clusterScoped.kinds) > 0 {
+		fmt.Fprintln(tw, "Cluster-wide resources:")
+		clusterScoped.write(tw)
+	}
+	if len(namespaced.kinds) > 0 {
+		fmt.Fprintf(tw, "Resources of namespace %s:\n", namespace)
+		namespaced.write(tw)
+	}
+
+	collection.VisitDeployment(func(deployment *appsv1.Deployment) {
+		fmt.Fprintf(tw, "Operator deployment %s:\n", deployment.Name)
+		if deployment.Spec.Replicas != nil {
+			fmt.Fprintf(tw, "  Replicas\t%d\n", *deployment.Spec.Replicas)
+		}
+		if account := deployment.Spec.Template.Spec.ServiceAccountName; account != "" {
+			fmt.Fprintf(tw, "  Service account\t%s\n", account)
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			fmt.Fprintf(tw, "  Image\t%s\n", container.Image)
+			if cpu, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+				fmt.Fprintf(tw, "  CPU\t%s\n", cpu.String())
+			}
+			if memory, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+				fmt.Fprintf(tw, "  Memory\t%s\n", memory.String())
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom == nil {
+					fmt.Fprintf(tw, "  Environment\t%s=%s\n", env.Name, env.Value)
+				}
+			}
+		}
+	})
+	return tw.Flush()
+}
+
+// kindNames are the names of the resources of each kind, the kinds being kept in the order they have been collected
+type kindNames struct {
+	kinds []string
+	names map[string][]string
+}
+
+func (k *kindNames) add(kind string, name string) {
+	if _, ok := k.names[kind]; !ok {
+		k.kinds = append(k.kinds, kind)
+	}
+	k.names[kind] = append(k.names[kind], name)
+}
+
+func (k *kindNames) write(w io.Writer) {
+	for _, kind := range k.kinds {
+		fmt.Fprintf(w, "  %s\t%s\n", kind, strings.Join(k.names[kind], ", "))
+	}
+}
+
+// explainedKinds returns the names of the cluster-wide and of the namespaced resources of the collection
+func explainedKinds(collection *kubernetes.Collection) (*kindNames, *kindNames) {
+	clusterScoped := &kindNames{names: make(map[string][]string)}
+	namespaced := &kindNames{names: make(map[string][]string)}
+	collection.Visit(func(obj runtime.Object) {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		name := ""
+		if metaObject, ok := obj.(metav1.Object); ok {
+			name = metaObject.GetName()
+		}
+		if clusterScopedKinds[kind] {
+			clusterScoped.add(kind, name)
+		} else {
+			namespaced.add(kind, name)
+		}
+	})
+	return clusterScoped, namespaced
+}
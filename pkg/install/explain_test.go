@@ -0,0 +1,71 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestWriteExplanation(t *testing.T) {
+	c, err := client.NewOfflineClient()
+	assert.Nil(t, err)
+
+	replicas := int32(2)
+	collection := kubernetes.NewCollection()
+	ctx := WithClusterType(context.Background(), ClusterTypeKubernetes)
+	_, err = SetupClusterwideResourcesOrCollect(ctx, client.Provider{Get: client.NewOfflineClient}, collection)
+	assert.Nil(t, err)
+	err = OperatorOrCollect(ctx, c, OperatorConfiguration{
+		Namespace: "test",
+		Image:     "my-registry/yaks:latest",
+		Replicas:  &replicas,
+		Resources: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Env: []corev1.EnvVar{{Name: "MY_ENV", Value: "MyValue"}},
+	}, collection)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	assert.Nil(t, WriteExplanation(&out, "test", collection))
+
+	explanation := out.String()
+	assert.Regexp(t, `Cluster-wide resources:\n  CustomResourceDefinition +tests\.yaks\.dev, instances\.yaks\.dev\n  ClusterRole +yaks:edit\n`, explanation)
+	assert.Contains(t, explanation, "Resources of namespace test:\n")
+	assert.Regexp(t, `  Deployment +yaks\n`, explanation)
+	assert.Regexp(t, `  Replicas +2\n`, explanation)
+	assert.Regexp(t, `  Image +my-registry/yaks:latest\n`, explanation)
+	assert.Regexp(t, `  Memory +256Mi\n`, explanation)
+	assert.Regexp(t, `  Environment +MY_ENV=MyValue\n`, explanation)
+	assert.NotContains(t, explanation, "CPU")
+}
+
+func TestWriteExplanationOfEmptyCollection(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, WriteExplanation(&out, "test", kubernetes.NewCollection()))
+	assert.Empty(t, out.String())
+}
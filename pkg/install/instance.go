@@ -0,0 +1,88 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InstanceName is the name of the Instance created by the installation
+const InstanceName = "yaks"
+
+// OperatorInstance asks the cluster-wide operator to deploy the operator of the namespace, through an Instance
+func OperatorInstance(ctx context.Context, c client.Client, cfg OperatorConfiguration) error {
+	return OperatorInstanceOrCollect(ctx, c, cfg, nil)
+}
+
+// OperatorInstanceOrCollect creates the Instance managing the operator of the namespace, or adds it to the collection.
+// The operator specification of an existing Instance is replaced, its test defaults being kept.
+func OperatorInstanceOrCollect(ctx context.Context, c client.Client, cfg OperatorConfiguration, collection *kubernetes.Collection) error {
+	operator := &v1alpha1.InstanceOperatorSpec{
+		Image:    cfg.Image,
+		Replicas: cfg.Replicas,
+	}
+	instance := v1alpha1.Instance{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.InstanceKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cfg.Namespace,
+			Name:      InstanceName,
+		},
+		Spec: v1alpha1.InstanceSpec{
+			Operator: operator,
+		},
+	}
+	if collection != nil {
+		collection.Add(&instance)
+		return nil
+	}
+
+	existing := instance.DeepCopy()
+	err := c.Get(ctx, k8sclient.ObjectKey{Namespace: cfg.Namespace, Name: InstanceName}, existing)
+	if err != nil && k8serrors.IsNotFound(err) {
+		if err := c.Create(ctx, &instance); err != nil {
+			return err
+		}
+		notifyApplyObserver(ctx, &instance, ApplyResultCreated)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(existing.Spec.Operator, operator) {
+		notifyApplyObserver(ctx, existing, ApplyResultUnchanged)
+		return nil
+	}
+	existing.Spec.Operator = operator
+	if err := c.Update(ctx, existing); err != nil {
+		return err
+	}
+	notifyApplyObserver(ctx, existing, ApplyResultUpdated)
+	return nil
+}
@@ -19,13 +19,88 @@ package install
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jboss-fuse/yaks/deploy"
 	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/config"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
 	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientscheme "k8s.io/client-go/kubernetes/scheme"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// OperatorDeploymentName is the name of the operator Deployment
+const OperatorDeploymentName = "yaks"
+
+// OperatorServiceAccountName is the name of the service account created for the operator, and of its role binding
+const OperatorServiceAccountName = "yaks"
+
+// RunnerNetworkPolicyName is the name of the NetworkPolicy applied to the runner pods
+const RunnerNetworkPolicyName = "yaks-runner"
+
 // OperatorConfiguration --
 type OperatorConfiguration struct {
 	Namespace string
+	Image     string
+	Replicas  *int32
+	// Resources are both requested and limited for the operator container
+	Resources           corev1.ResourceList
+	Env                 []corev1.EnvVar
+	PodDisruptionBudget PodDisruptionBudgetConfiguration
+	RunnerNetworkPolicy RunnerNetworkPolicyConfiguration
+	// ServiceAccount is an existing service account of the namespace the operator runs with, bound to the operator role,
+	// instead of the service account created by the installation
+	ServiceAccount string
+	// Labels of the operator pods, matched against the operator selector of the tests. An operator with labels gets its
+	// own Deployment, so that it can run next to the operator of the namespace, e.g. as a canary.
+	Labels map[string]string
+	// Customizer is applied to all the operator resources, defaults to the IdentityResourceCustomizer
+	Customizer ResourceCustomizer
+}
+
+// DeploymentName returns the name of the operator Deployment of the configuration
+func (cfg OperatorConfiguration) DeploymentName() string {
+	if suffix := config.OperatorLabelsSuffix(cfg.Labels); suffix != "" {
+		return OperatorDeploymentName + "-" + suffix
+	}
+	return OperatorDeploymentName
+}
+
+func (cfg OperatorConfiguration) customizer() ResourceCustomizer {
+	if cfg.Customizer != nil {
+		return cfg.Customizer
+	}
+	return IdentityResourceCustomizer
+}
+
+// PodDisruptionBudgetConfiguration --
+type PodDisruptionBudgetConfiguration struct {
+	Enabled      bool
+	MinAvailable *intstr.IntOrString
+}
+
+// RunnerNetworkPolicyConfiguration --
+type RunnerNetworkPolicyConfiguration struct {
+	Enabled bool
+	// EgressCIDRs restrict the egress of the runner pods to the given IP blocks, in addition to the pods of the
+	// namespace and DNS, all the egress being allowed when empty
+	EgressCIDRs []string
 }
 
 // Operator installs the operator resources in the given namespace
@@ -35,10 +110,323 @@ func Operator(ctx context.Context, c client.Client, cfg OperatorConfiguration) e
 
 // OperatorOrCollect installs the operator resources or adds them to the collector if present
 func OperatorOrCollect(ctx context.Context, c client.Client, cfg OperatorConfiguration, collection *kubernetes.Collection) error {
-	return ResourcesOrCollect(ctx, c, cfg.Namespace, collection, IdentityResourceCustomizer,
-		"service_account.yaml",
-		"role.yaml",
-		"role_binding.yaml",
-		"operator.yaml",
-	)
+	customizer := cfg.customizer()
+	names := []string{"role.yaml", "role_binding.yaml"}
+	if cfg.ServiceAccount == "" {
+		names = append([]string{"service_account.yaml"}, names...)
+	} else if collection == nil {
+		if err := checkServiceAccount(ctx, c, cfg.Namespace, cfg.ServiceAccount); err != nil {
+			return err
+		}
+	}
+	if err := ResourcesOrCollect(ctx, c, cfg.Namespace, collection, cfg.roleBindingCustomizer(customizer), names...); err != nil {
+		return err
+	}
+
+	deployment, err := BuildOperatorDeployment(cfg)
+	if err != nil {
+		return err
+	}
+	if err := RuntimeObjectOrCollect(ctx, c, cfg.Namespace, collection, customizer(deployment)); err != nil {
+		return err
+	}
+
+	// A disruption budget only makes sense when more than one replica can take over the leadership
+	if cfg.PodDisruptionBudget.Enabled && deployment.Spec.Replicas != nil && *deployment.Spec.Replicas > 1 {
+		if err := RuntimeObjectOrCollect(ctx, c, cfg.Namespace, collection, customizer(BuildOperatorPodDisruptionBudget(cfg, deployment))); err != nil {
+			return err
+		}
+	}
+
+	if cfg.RunnerNetworkPolicy.Enabled {
+		policy, err := BuildRunnerNetworkPolicy(cfg)
+		if err != nil {
+			return err
+		}
+		return RuntimeObjectOrCollect(ctx, c, cfg.Namespace, collection, customizer(policy))
+	}
+	return nil
+}
+
+// roleBindingCustomizer binds the operator role to the existing service account of the configuration, if any
+func (cfg OperatorConfiguration) roleBindingCustomizer(customizer ResourceCustomizer) ResourceCustomizer {
+	if cfg.ServiceAccount == "" {
+		return customizer
+	}
+	return func(object runtime.Object) runtime.Object {
+		if binding, ok := object.(*rbacv1.RoleBinding); ok && binding.Name == OperatorServiceAccountName {
+			for i := range binding.Subjects {
+				if binding.Subjects[i].Kind == rbacv1.ServiceAccountKind {
+					binding.Subjects[i].Name = cfg.ServiceAccount
+				}
+			}
+		}
+		return customizer(object)
+	}
+}
+
+// checkServiceAccount verifies that the existing service account the operator runs with has been created
+func checkServiceAccount(ctx context.Context, c client.Client, namespace string, name string) error {
+	sa := corev1.ServiceAccount{}
+	err := c.Get(ctx, k8sclient.ObjectKey{Namespace: namespace, Name: name}, &sa)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return errors.New(fmt.Sprintf("service account %s does not exist in namespace %s, it must be created before installing the operator", name, namespace))
+	}
+	return err
+}
+
+// BuildOperatorDeployment returns the operator Deployment with the overrides from the configuration applied,
+// so that it can be inspected or modified before being installed
+func BuildOperatorDeployment(cfg OperatorConfiguration) (*appsv1.Deployment, error) {
+	obj, err := kubernetes.LoadResourceFromYaml(clientscheme.Scheme, deploy.Resources["operator.yaml"])
+	if err != nil {
+		return nil, err
+	}
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil, errors.New("operator resource is not a deployment")
+	}
+
+	if cfg.Replicas != nil {
+		replicas := *cfg.Replicas
+		deployment.Spec.Replicas = &replicas
+	}
+	if cfg.ServiceAccount != "" {
+		deployment.Spec.Template.Spec.ServiceAccountName = cfg.ServiceAccount
+	}
+	if len(cfg.Labels) > 0 {
+		// The pods of the Deployment must not be selected by the Deployment of the operator without labels
+		name := cfg.DeploymentName()
+		deployment.Name = name
+		deployment.Spec.Selector.MatchLabels["name"] = name
+		deployment.Spec.Template.Labels["name"] = name
+		for key, value := range cfg.Labels {
+			deployment.Spec.Template.Labels[key] = value
+		}
+	}
+
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		if cfg.Image != "" {
+			container.Image = cfg.Image
+		}
+		for _, env := range cfg.Env {
+			envvar.SetVar(&container.Env, env)
+		}
+		for name, quantity := range cfg.Resources {
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = corev1.ResourceList{}
+			}
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = corev1.ResourceList{}
+			}
+			container.Resources.Requests[name] = quantity
+			container.Resources.Limits[name] = quantity
+		}
+	}
+
+	return deployment, nil
+}
+
+// GetOperatorDeployment returns the operator Deployment installed in the given namespace, or nil if not present
+func GetOperatorDeployment(ctx context.Context, c client.Client, namespace string) (*appsv1.Deployment, error) {
+	return getDeployment(ctx, c, namespace, OperatorDeploymentName)
+}
+
+func getDeployment(ctx context.Context, c client.Client, namespace string, name string) (*appsv1.Deployment, error) {
+	deployment := appsv1.Deployment{}
+	key := k8sclient.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+	err := c.Get(ctx, key, &deployment)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+var versionTag = regexp.MustCompile(`^v?([0-9]+\.[0-9]+\.[0-9]+(?:[-+.][0-9A-Za-z.-]+)?)$`)
+
+// OperatorVersion returns the version of the operator, taken from the tag of its image, or an empty string when the
+// image is not tagged with a version (e.g. latest or a digest)
+func OperatorVersion(deployment *appsv1.Deployment) string {
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) == 0 || strings.Contains(containers[0].Image, "@") {
+		return ""
+	}
+	image := containers[0].Image
+	// The tag follows the last colon, unless it belongs to the registry host and port
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	if match := versionTag.FindStringSubmatch(image[i+1:]); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// IsOperatorReady check if the operator Deployment in the given namespace has been rolled out and has available replicas
+func IsOperatorReady(ctx context.Context, c client.Client, namespace string) (bool, error) {
+	return isDeploymentReady(ctx, c, namespace, OperatorDeploymentName)
+}
+
+func isDeploymentReady(ctx context.Context, c client.Client, namespace string, name string) (bool, error) {
+	deployment, err := getDeployment(ctx, c, namespace, name)
+	if err != nil || deployment == nil {
+		return false, err
+	}
+	return isRolledOut(deployment), nil
+}
+
+// isRolledOut tells whether the latest spec of the Deployment has been observed and all its replicas updated, so that
+// the available replicas are not the ones of a previous version, e.g. right after an upgrade
+func isRolledOut(deployment *appsv1.Deployment) bool {
+	status := deployment.Status
+	if status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return status.UpdatedReplicas >= replicas && status.UpdatedReplicas == status.Replicas && status.AvailableReplicas > 0
+}
+
+// WaitForOperatorReady waits until the operator Deployment of the configuration has been rolled out and has available
+// replicas
+func WaitForOperatorReady(ctx context.Context, c client.Client, cfg OperatorConfiguration, timeout time.Duration) error {
+	return waitForDeploymentReady(ctx, c, cfg.Namespace, cfg.DeploymentName(), timeout)
+}
+
+// WaitForClusterOperatorReady waits until the Deployment of the cluster-wide operator in the given namespace has been
+// rolled out and has available replicas
+func WaitForClusterOperatorReady(ctx context.Context, c client.Client, namespace string, timeout time.Duration) error {
+	return waitForDeploymentReady(ctx, c, namespace, ClusterOperatorName, timeout)
+}
+
+func waitForDeploymentReady(ctx context.Context, c client.Client, namespace string, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ready, err := isDeploymentReady(ctx, c, namespace, name)
+		if err != nil {
+			return err
+		} else if ready {
+			return nil
+		}
+		// Check after 2 seconds if not expired
+		if time.Now().After(deadline) {
+			return errors.New("operator is not ready after " + timeout.String() + notReadyCause(ctx, c, namespace, name))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// notReadyCause describes why the operator Deployment is not available, when it reports it
+func notReadyCause(ctx context.Context, c client.Client, namespace string, name string) string {
+	deployment, err := getDeployment(ctx, c, namespace, name)
+	if err != nil {
+		return ""
+	} else if deployment == nil {
+		return ": deployment " + name + " not found"
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable && condition.Message != "" {
+			return ": " + condition.Message
+		}
+	}
+	return ""
+}
+
+// BuildOperatorPodDisruptionBudget returns a PodDisruptionBudget selecting the pods of the given operator Deployment
+func BuildOperatorPodDisruptionBudget(cfg OperatorConfiguration, deployment *appsv1.Deployment) *policyv1beta1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(1)
+	if cfg.PodDisruptionBudget.MinAvailable != nil {
+		minAvailable = *cfg.PodDisruptionBudget.MinAvailable
+	}
+
+	return &policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: policyv1beta1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   deployment.Name,
+			Labels: deployment.Labels,
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     deployment.Spec.Selector.DeepCopy(),
+		},
+	}
+}
+
+// BuildRunnerNetworkPolicy returns the NetworkPolicy allowing the egress of the runner pods, selected by the label
+// set on them by the operator, so that they can reach their dependencies on clusters denying the traffic by default
+func BuildRunnerNetworkPolicy(cfg OperatorConfiguration) (*networkingv1.NetworkPolicy, error) {
+	// An empty rule allows all the egress
+	egress := []networkingv1.NetworkPolicyEgressRule{{}}
+	if len(cfg.RunnerNetworkPolicy.EgressCIDRs) > 0 {
+		udp := corev1.ProtocolUDP
+		tcp := corev1.ProtocolTCP
+		dns := intstr.FromInt(53)
+		blocks := make([]networkingv1.NetworkPolicyPeer, 0, len(cfg.RunnerNetworkPolicy.EgressCIDRs))
+		for _, cidr := range cfg.RunnerNetworkPolicy.EgressCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, errors.New("invalid runner egress CIDR " + cidr + ": " + err.Error())
+			}
+			blocks = append(blocks, networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+			})
+		}
+		egress = []networkingv1.NetworkPolicyEgressRule{
+			{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &udp, Port: &dns},
+					{Protocol: &tcp, Port: &dns},
+				},
+			},
+			{
+				To: []networkingv1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{}},
+				},
+			},
+			{
+				To: blocks,
+			},
+		}
+	}
+
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: RunnerNetworkPolicyName,
+			Labels: map[string]string{
+				"app": "yaks",
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			// The label of the test is only set on the runner pods
+			PodSelector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "yaks.dev/test",
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}, nil
 }
@@ -0,0 +1,190 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/util/envvar"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	clientscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestBuildOperatorDeploymentDefaults(t *testing.T) {
+	deployment, err := BuildOperatorDeployment(OperatorConfiguration{})
+
+	assert.Nil(t, err)
+	assert.NotNil(t, deployment)
+	assert.Equal(t, "yaks", deployment.Name)
+	assert.Equal(t, int32(1), *deployment.Spec.Replicas)
+	assert.Len(t, deployment.Spec.Template.Spec.Containers, 1)
+	assert.Equal(t, "yaks/yaks:0.0.1", deployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestBuildOperatorDeploymentOverrides(t *testing.T) {
+	replicas := int32(3)
+	deployment, err := BuildOperatorDeployment(OperatorConfiguration{
+		Image:    "my-registry/yaks:latest",
+		Replicas: &replicas,
+		Resources: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "OPERATOR_NAME",
+				Value: "my-yaks",
+			},
+			{
+				Name:  "MY_ENV",
+				Value: "MyValue",
+			},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "my-registry/yaks:latest", container.Image)
+	assert.Equal(t, "my-yaks", envvar.Get(container.Env, "OPERATOR_NAME").Value)
+	assert.Equal(t, "MyValue", envvar.Get(container.Env, "MY_ENV").Value)
+	assert.NotNil(t, envvar.Get(container.Env, "WATCH_NAMESPACE").ValueFrom)
+	assert.Equal(t, "500m", container.Resources.Requests.Cpu().String())
+	assert.Equal(t, "500m", container.Resources.Limits.Cpu().String())
+	assert.Equal(t, "256Mi", container.Resources.Requests.Memory().String())
+	assert.Equal(t, "256Mi", container.Resources.Limits.Memory().String())
+}
+
+func TestIsRolledOut(t *testing.T) {
+	deployment, err := BuildOperatorDeployment(OperatorConfiguration{})
+	assert.Nil(t, err)
+	deployment.Generation = 2
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  1,
+	}
+	assert.True(t, isRolledOut(deployment))
+
+	// The new spec has not been seen by the deployment controller yet
+	deployment.Generation = 3
+	assert.False(t, isRolledOut(deployment))
+
+	// The pod of the previous version is still running
+	deployment.Status.ObservedGeneration = 3
+	deployment.Status.Replicas = 2
+	assert.False(t, isRolledOut(deployment))
+
+	deployment.Status.Replicas = 1
+	deployment.Status.UpdatedReplicas = 0
+	deployment.Status.AvailableReplicas = 1
+	assert.False(t, isRolledOut(deployment))
+
+	deployment.Status.UpdatedReplicas = 1
+	deployment.Status.AvailableReplicas = 0
+	assert.False(t, isRolledOut(deployment))
+}
+
+func TestOperatorServiceAccount(t *testing.T) {
+	cfg := OperatorConfiguration{ServiceAccount: "workload-identity"}
+	deployment, err := BuildOperatorDeployment(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "workload-identity", deployment.Spec.Template.Spec.ServiceAccountName)
+
+	obj, err := kubernetes.LoadResourceFromYaml(clientscheme.Scheme, deploy.Resources["role_binding.yaml"])
+	assert.Nil(t, err)
+	binding := cfg.roleBindingCustomizer(IdentityResourceCustomizer)(obj).(*rbacv1.RoleBinding)
+	assert.Equal(t, "workload-identity", binding.Subjects[0].Name)
+	assert.Equal(t, "yaks", binding.RoleRef.Name)
+
+	deployment, err = BuildOperatorDeployment(OperatorConfiguration{})
+	assert.Nil(t, err)
+	assert.Equal(t, "yaks", deployment.Spec.Template.Spec.ServiceAccountName)
+}
+
+func TestOperatorLabels(t *testing.T) {
+	cfg := OperatorConfiguration{Labels: map[string]string{"channel": "canary"}}
+	deployment, err := BuildOperatorDeployment(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, cfg.DeploymentName(), deployment.Name)
+	assert.NotEqual(t, OperatorDeploymentName, deployment.Name)
+	assert.Equal(t, deployment.Name, deployment.Spec.Selector.MatchLabels["name"])
+	assert.Equal(t, deployment.Name, deployment.Spec.Template.Labels["name"])
+	assert.Equal(t, "canary", deployment.Spec.Template.Labels["channel"])
+
+	// The operator without labels keeps the default Deployment
+	assert.Equal(t, OperatorDeploymentName, OperatorConfiguration{}.DeploymentName())
+}
+
+func TestOperatorVersion(t *testing.T) {
+	versions := map[string]string{
+		"yaks/yaks:0.0.1":                   "0.0.1",
+		"my-registry:5000/yaks/yaks:v1.2.0": "1.2.0",
+		"yaks/yaks:0.1.0-SNAPSHOT":          "0.1.0-SNAPSHOT",
+		"my-registry/yaks:latest":           "",
+		"my-registry:5000/yaks":             "",
+		"yaks/yaks@sha256:0123456789abcdef": "",
+	}
+	for image, version := range versions {
+		deployment, err := BuildOperatorDeployment(OperatorConfiguration{Image: image})
+		assert.Nil(t, err)
+		assert.Equal(t, version, OperatorVersion(deployment), image)
+	}
+}
+
+func TestBuildRunnerNetworkPolicy(t *testing.T) {
+	policy, err := BuildRunnerNetworkPolicy(OperatorConfiguration{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, RunnerNetworkPolicyName, policy.Name)
+	assert.Equal(t, "yaks.dev/test", policy.Spec.PodSelector.MatchExpressions[0].Key)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, policy.Spec.PolicyTypes)
+	assert.Equal(t, []networkingv1.NetworkPolicyEgressRule{{}}, policy.Spec.Egress)
+
+	policy, err = BuildRunnerNetworkPolicy(OperatorConfiguration{
+		RunnerNetworkPolicy: RunnerNetworkPolicyConfiguration{
+			Enabled:     true,
+			EgressCIDRs: []string{"10.0.0.0/8"},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, policy.Spec.Egress, 3)
+	assert.Len(t, policy.Spec.Egress[0].Ports, 2)
+	assert.NotNil(t, policy.Spec.Egress[1].To[0].PodSelector)
+	assert.Equal(t, "10.0.0.0/8", policy.Spec.Egress[2].To[0].IPBlock.CIDR)
+
+	_, err = BuildRunnerNetworkPolicy(OperatorConfiguration{
+		RunnerNetworkPolicy: RunnerNetworkPolicyConfiguration{
+			Enabled:     true,
+			EgressCIDRs: []string{"10.0.0.0"},
+		},
+	})
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,130 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterType --
+type ClusterType string
+
+const (
+	// ClusterTypeKubernetes --
+	ClusterTypeKubernetes ClusterType = "Kubernetes"
+	// ClusterTypeOpenShift --
+	ClusterTypeOpenShift ClusterType = "OpenShift"
+)
+
+// openShiftGroup is the API group only served by OpenShift clusters
+const openShiftGroup = "route.openshift.io"
+
+// resourceClusterTypes tags the embedded resources that only apply to one type of cluster, the other ones apply to
+// any cluster
+var resourceClusterTypes = map[string]ClusterType{
+	"cli_download.yaml": ClusterTypeOpenShift,
+}
+
+// resourceKinds tells the kinds of the embedded resources that are not served by all the clusters of their type, e.g.
+// OpenShift 3.11 has routes but no console CLI downloads
+var resourceKinds = map[string]schema.GroupVersionKind{
+	"cli_download.yaml": {Group: "console.openshift.io", Version: "v1", Kind: "ConsoleCLIDownload"},
+}
+
+// ParseClusterType returns the cluster type with the given name, ignoring case
+func ParseClusterType(value string) (ClusterType, error) {
+	for _, clusterType := range []ClusterType{ClusterTypeKubernetes, ClusterTypeOpenShift} {
+		if strings.EqualFold(value, string(clusterType)) {
+			return clusterType, nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("unsupported cluster type %q, expected one of %s, %s", value, ClusterTypeKubernetes, ClusterTypeOpenShift))
+}
+
+// DetectClusterType tells whether the client is connected to an OpenShift or a plain Kubernetes cluster
+func DetectClusterType(c client.Client) (ClusterType, error) {
+	groups, err := c.Discovery().ServerGroups()
+	if err != nil {
+		return "", err
+	}
+	return clusterTypeFor(groups), nil
+}
+
+func clusterTypeFor(groups *metav1.APIGroupList) ClusterType {
+	for _, group := range groups.Groups {
+		if group.Name == openShiftGroup {
+			return ClusterTypeOpenShift
+		}
+	}
+	return ClusterTypeKubernetes
+}
+
+// IsApplicable tells whether the named embedded resource applies to the given type of cluster
+func IsApplicable(name string, clusterType ClusterType) bool {
+	target, ok := resourceClusterTypes[name]
+	return !ok || target == clusterType
+}
+
+type clusterTypeKey struct{}
+
+// WithClusterType returns a context installing the resources for the given type of cluster, instead of the type
+// detected from the cluster the client is connected to
+func WithClusterType(ctx context.Context, clusterType ClusterType) context.Context {
+	return context.WithValue(ctx, clusterTypeKey{}, clusterType)
+}
+
+func clusterTypeFrom(ctx context.Context) (ClusterType, bool) {
+	clusterType, ok := ctx.Value(clusterTypeKey{}).(ClusterType)
+	return clusterType, ok
+}
+
+// isApplicableTo tells whether the named embedded resource applies to the cluster the client is connected to. The
+// cluster type is only detected for the resources restricted to one type of cluster, and when not set on the context.
+// The resources of a kind the detected cluster does not serve are skipped.
+func isApplicableTo(ctx context.Context, c client.Client, name string) (bool, error) {
+	if _, ok := resourceClusterTypes[name]; !ok {
+		return true, nil
+	}
+	if clusterType, ok := clusterTypeFrom(ctx); ok {
+		return IsApplicable(name, clusterType), nil
+	}
+	clusterType, err := DetectClusterType(c)
+	if err != nil {
+		return false, err
+	}
+	if !IsApplicable(name, clusterType) {
+		return false, nil
+	}
+	return isServed(ctx, c, name)
+}
+
+// isServed tells whether the cluster serves the kind of the named embedded resource
+func isServed(ctx context.Context, c client.Client, name string) (bool, error) {
+	gvk, ok := resourceKinds[name]
+	if !ok {
+		return true, nil
+	}
+	return IsCRDInstalled(ctx, c, gvk.GroupVersion(), gvk.Kind)
+}
@@ -0,0 +1,94 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"testing"
+
+	testutil "github.com/jboss-fuse/yaks/pkg/util/test"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+)
+
+func TestClusterTypeFor(t *testing.T) {
+	kubernetes := metav1.APIGroupList{
+		Groups: []metav1.APIGroup{{Name: "apps"}, {Name: "batch"}},
+	}
+	openShift := metav1.APIGroupList{
+		Groups: []metav1.APIGroup{{Name: "apps"}, {Name: "route.openshift.io"}},
+	}
+
+	assert.Equal(t, ClusterTypeKubernetes, clusterTypeFor(&kubernetes))
+	assert.Equal(t, ClusterTypeOpenShift, clusterTypeFor(&openShift))
+}
+
+func TestIsApplicable(t *testing.T) {
+	assert.True(t, IsApplicable("operator.yaml", ClusterTypeKubernetes))
+	assert.True(t, IsApplicable("operator.yaml", ClusterTypeOpenShift))
+	assert.False(t, IsApplicable("cli_download.yaml", ClusterTypeKubernetes))
+	assert.True(t, IsApplicable("cli_download.yaml", ClusterTypeOpenShift))
+}
+
+func TestParseClusterType(t *testing.T) {
+	clusterType, err := ParseClusterType("openshift")
+	assert.Nil(t, err)
+	assert.Equal(t, ClusterTypeOpenShift, clusterType)
+
+	_, err = ParseClusterType("minikube")
+	assert.NotNil(t, err)
+}
+
+func TestIsApplicableToClusterTypeOfContext(t *testing.T) {
+	ctx := WithClusterType(context.Background(), ClusterTypeOpenShift)
+
+	// No client is needed to detect the type of the cluster
+	applicable, err := isApplicableTo(ctx, nil, "cli_download.yaml")
+	assert.Nil(t, err)
+	assert.True(t, applicable)
+
+	applicable, err = isApplicableTo(WithClusterType(ctx, ClusterTypeKubernetes), nil, "cli_download.yaml")
+	assert.Nil(t, err)
+	assert.False(t, applicable)
+}
+
+func TestCLIDownloadSkippedWhenNotServed(t *testing.T) {
+	routes := &metav1.APIResourceList{
+		GroupVersion: "route.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "routes", Kind: "Route", Namespaced: true}},
+	}
+	downloads := &metav1.APIResourceList{
+		GroupVersion: "console.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "consoleclidownloads", Kind: "ConsoleCLIDownload"}},
+	}
+
+	// OpenShift 3.11 has routes but no console CLI downloads
+	c := testutil.NewFakeClient()
+	c.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{routes}
+	applicable, err := isApplicableTo(context.Background(), c, "cli_download.yaml")
+	assert.Nil(t, err)
+	assert.False(t, applicable)
+
+	c = testutil.NewFakeClient()
+	c.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{routes, downloads}
+	applicable, err = isApplicableTo(context.Background(), c, "cli_download.yaml")
+	assert.Nil(t, err)
+	assert.True(t, applicable)
+}
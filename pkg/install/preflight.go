@@ -0,0 +1,86 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManagedByAnnotation identifies the installer managing a cluster-wide resource
+const ManagedByAnnotation = "yaks.dev/managed-by"
+
+// managedByCLI is the value of the ManagedByAnnotation set on the resources installed by the yaks CLI
+const managedByCLI = "yaks-cli"
+
+// setManagedBy marks the object as managed by the yaks CLI
+func setManagedBy(obj runtime.Object) {
+	if metaObject, ok := obj.(metav1.Object); ok {
+		annotations := metaObject.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[ManagedByAnnotation] = managedByCLI
+		metaObject.SetAnnotations(annotations)
+	}
+}
+
+// ClusterwideConflicts returns a description of the existing Test custom resource definition and yaks:edit cluster
+// role when they are managed by another installer (e.g. an operator lifecycle manager), with which a CLI installation
+// could conflict
+func ClusterwideConflicts(ctx context.Context, c client.Client) ([]string, error) {
+	conflicts := make([]string, 0)
+
+	crd, err := GetInstalledCRD("tests.yaks.dev")
+	if err != nil {
+		return nil, err
+	} else if crd != nil {
+		if conflict := managedByConflict("custom resource definition", crd); conflict != "" {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	clusterRole := rbacv1.ClusterRole{}
+	err = c.Get(ctx, k8sclient.ObjectKey{Name: "yaks:edit"}, &clusterRole)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil {
+		if conflict := managedByConflict("cluster role", &clusterRole); conflict != "" {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	return conflicts, nil
+}
+
+func managedByConflict(kind string, obj metav1.Object) string {
+	manager, ok := obj.GetAnnotations()[ManagedByAnnotation]
+	if !ok || manager == managedByCLI {
+		// Resources installed by previous versions of the CLI are not annotated
+		return ""
+	}
+	return fmt.Sprintf("%s %s is managed by %s", kind, obj.GetName(), manager)
+}
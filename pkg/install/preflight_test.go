@@ -0,0 +1,58 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/stretchr/testify/assert"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestManagedByConflict(t *testing.T) {
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "yaks:edit"}}
+	// Installed by a previous version of the CLI
+	assert.Equal(t, "", managedByConflict("cluster role", role))
+
+	setManagedBy(role)
+	assert.Equal(t, "", managedByConflict("cluster role", role))
+
+	role.Annotations[ManagedByAnnotation] = "olm"
+	assert.Equal(t, "cluster role yaks:edit is managed by olm", managedByConflict("cluster role", role))
+}
+
+func TestCollectedResourcesNotManagedByCLI(t *testing.T) {
+	collection := kubernetes.NewCollection()
+	ctx := WithClusterType(context.Background(), ClusterTypeKubernetes)
+	_, err := SetupClusterwideResourcesOrCollect(ctx, client.Provider{Get: client.NewOfflineClient}, collection)
+	assert.Nil(t, err)
+
+	// The saved manifests are applied by another installer, e.g. a GitOps tool, that manages them from then on
+	names := make([]string, 0)
+	collection.VisitMetaObject(func(obj metav1.Object) {
+		names = append(names, obj.GetName())
+		assert.NotContains(t, obj.GetAnnotations(), ManagedByAnnotation, obj.GetName())
+	})
+	assert.Contains(t, names, "tests.yaks.dev")
+	assert.Contains(t, names, "yaks:edit")
+}
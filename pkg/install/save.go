@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// clusterScopedDirectory is the sub directory of the split output holding the cluster-wide resources
+const clusterScopedDirectory = "cluster"
+
+// clusterScopedKinds are the kinds of the cluster-wide resources collected by the installation
+var clusterScopedKinds = map[string]bool{
+	"CustomResourceDefinition": true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"ConsoleCLIDownload":       true,
+	"Namespace":                true,
+}
+
+// WriteBundle writes the collected resources as a single multi-document YAML stream
+func WriteBundle(w io.Writer, collection *kubernetes.Collection) error {
+	for i, obj := range collection.Items() {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSplit writes each collected resource to its own file of the given directory, the cluster-wide resources
+// being written to a separate sub directory
+func WriteSplit(dir string, collection *kubernetes.Collection) error {
+	written := make(map[string]bool)
+	for _, obj := range collection.Items() {
+		path := filepath.Join(dir, SplitFileNameFor(obj))
+		if written[path] {
+			return errors.New("duplicate resource " + path)
+		}
+		written[path] = true
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SplitFileNameFor returns the path of the file holding the resource, relative to the split output directory, in the
+// form kind-name.yaml. Kinds contain no dash, so that the file names of different resources never collide.
+func SplitFileNameFor(obj runtime.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	name := ""
+	if metaObject, ok := obj.(metav1.Object); ok {
+		name = metaObject.GetName()
+	}
+	fileName := strings.ToLower(kind) + "-" + name + ".yaml"
+	if clusterScopedKinds[kind] {
+		return filepath.Join(clusterScopedDirectory, fileName)
+	}
+	return fileName
+}
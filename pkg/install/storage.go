@@ -0,0 +1,214 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/client"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes/customclient"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type crdStorageVersionKey struct{}
+
+// WithCRDStorageVersion returns a context installing the custom resource definitions with the given version marked as
+// the storage version, instead of the one marked in the embedded definitions
+func WithCRDStorageVersion(ctx context.Context, version string) context.Context {
+	if version == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, crdStorageVersionKey{}, version)
+}
+
+func crdStorageVersionFrom(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(crdStorageVersionKey{}).(string)
+	return version, ok
+}
+
+// SetCRDStorageVersion marks the given version as the only storage version of the custom resource definition. The
+// version must be served by the definition.
+func SetCRDStorageVersion(crd *unstructured.Unstructured, version string) error {
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return err
+	}
+	found := false
+	names := make([]string, 0, len(versions))
+	for i := range versions {
+		v, ok := versions[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := v["name"].(string)
+		names = append(names, name)
+		if name != version {
+			v["storage"] = false
+			continue
+		}
+		if served, _ := v["served"].(bool); !served {
+			return errors.New(fmt.Sprintf("version %s of custom resource definition %s is not served, it cannot be the storage version", version, crd.GetName()))
+		}
+		v["storage"] = true
+		found = true
+	}
+	if !found {
+		return errors.New(fmt.Sprintf("custom resource definition %s has no version %s, expected one of %s", crd.GetName(), version, strings.Join(names, ", ")))
+	}
+	return unstructured.SetNestedSlice(crd.Object, versions, "spec", "versions")
+}
+
+// CRDStorageVersion returns the version marked as the storage version of the custom resource definition, failing when
+// none or several versions are marked
+func CRDStorageVersion(crd *unstructured.Unstructured) (string, error) {
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return "", err
+	}
+	storage := make([]string, 0, 1)
+	for _, version := range versions {
+		v, ok := version.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if marked, _ := v["storage"].(bool); marked {
+			name, _ := v["name"].(string)
+			storage = append(storage, name)
+		}
+	}
+	switch len(storage) {
+	case 0:
+		return "", errors.New(fmt.Sprintf("custom resource definition %s has no storage version", crd.GetName()))
+	case 1:
+		return storage[0], nil
+	default:
+		return "", errors.New(fmt.Sprintf("custom resource definition %s marks several versions as storage: %s", crd.GetName(), strings.Join(storage, ", ")))
+	}
+}
+
+// customizeCRDStorage applies the storage version of the context to the definition to install, and checks that a
+// single version is marked as storage
+func customizeCRDStorage(ctx context.Context, crd *unstructured.Unstructured) error {
+	if version, ok := crdStorageVersionFrom(ctx); ok {
+		if err := SetCRDStorageVersion(crd, version); err != nil {
+			return err
+		}
+	}
+	_, err := CRDStorageVersion(crd)
+	return err
+}
+
+// isStorageVersionSwitched tells whether the installed definition stores its objects in another version than the given one
+func isStorageVersionSwitched(name string, version string) (bool, error) {
+	live, err := GetInstalledCRD(name)
+	if err != nil || live == nil {
+		return false, err
+	}
+	current, err := CRDStorageVersion(live)
+	if err != nil {
+		return true, nil
+	}
+	return current != version, nil
+}
+
+// CRDStorageMigration describes the re-encoding of the stored objects of a custom resource definition
+type CRDStorageMigration struct {
+	Name string
+	// Version is the storage version the objects have been re-encoded in
+	Version string
+	// Objects is the number of objects that have been touched
+	Objects int
+}
+
+// MigrateAllCRDStorage re-encodes the stored objects of all the custom resource definitions of the CLI
+func MigrateAllCRDStorage(ctx context.Context, c client.Client) ([]CRDStorageMigration, error) {
+	migrations := make([]CRDStorageMigration, 0, len(embeddedCRDs))
+	for _, crd := range embeddedCRDs {
+		migration, err := MigrateCRDStorage(ctx, c, crd.Name)
+		if err != nil {
+			return migrations, err
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// MigrateCRDStorage re-encodes the stored objects of the custom resource definition in its storage version, by
+// updating each of them unchanged, then records the storage version as the only stored version of the definition
+func MigrateCRDStorage(ctx context.Context, c client.Client, name string) (CRDStorageMigration, error) {
+	migration := CRDStorageMigration{Name: name}
+	crd, err := GetInstalledCRD(name)
+	if err != nil {
+		return migration, err
+	} else if crd == nil {
+		return migration, errors.New("custom resource definition " + name + " is not installed")
+	}
+	if migration.Version, err = CRDStorageVersion(crd); err != nil {
+		return migration, err
+	}
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+
+	objects, err := customclient.GetDynamicClientFor(group, migration.Version, plural, "")
+	if err != nil {
+		return migration, err
+	}
+	list, err := objects.List(metav1.ListOptions{})
+	if err != nil {
+		return migration, err
+	}
+	for i := range list.Items {
+		obj := list.Items[i]
+		namespaced, err := customclient.GetDynamicClientFor(group, migration.Version, plural, obj.GetNamespace())
+		if err != nil {
+			return migration, err
+		}
+		// A conflict means the object has been written since it has been listed, hence re-encoded already
+		if _, err := namespaced.Update(&obj, metav1.UpdateOptions{}); err != nil && !k8serrors.IsConflict(err) && !k8serrors.IsNotFound(err) {
+			return migration, err
+		}
+		migration.Objects++
+	}
+
+	if err := unstructured.SetNestedStringSlice(crd.Object, []string{migration.Version}, "status", "storedVersions"); err != nil {
+		return migration, err
+	}
+	crdJSON, err := json.Marshal(crd)
+	if err != nil {
+		return migration, err
+	}
+	restClient, err := customclient.GetClientFor(c, "apiextensions.k8s.io", "v1beta1")
+	if err != nil {
+		return migration, err
+	}
+	result := restClient.
+		Put().
+		Body(crdJSON).
+		Resource("customresourcedefinitions").
+		Name(name).
+		SubResource("status").
+		Do()
+	return migration, result.Error()
+}
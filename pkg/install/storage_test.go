@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/deploy"
+	"github.com/jboss-fuse/yaks/pkg/util/kubernetes"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func twoVersionsCRD() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "tests.yaks.dev"},
+		"spec": map[string]interface{}{
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": true},
+				map[string]interface{}{"name": "v1", "served": true, "storage": false},
+				map[string]interface{}{"name": "v2alpha1", "served": false, "storage": false},
+			},
+		},
+	}}
+}
+
+func TestSetCRDStorageVersion(t *testing.T) {
+	crd := twoVersionsCRD()
+	assert.Nil(t, SetCRDStorageVersion(crd, "v1"))
+	version, err := CRDStorageVersion(crd)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", version)
+
+	assert.EqualError(t, SetCRDStorageVersion(crd, "v2alpha1"), "version v2alpha1 of custom resource definition tests.yaks.dev is not served, it cannot be the storage version")
+	assert.EqualError(t, SetCRDStorageVersion(crd, "v3"), "custom resource definition tests.yaks.dev has no version v3, expected one of v1alpha1, v1, v2alpha1")
+}
+
+func TestSeveralStorageVersionsAreRejected(t *testing.T) {
+	crd := twoVersionsCRD()
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	versions[1].(map[string]interface{})["storage"] = true
+	assert.Nil(t, unstructured.SetNestedSlice(crd.Object, versions, "spec", "versions"))
+
+	assert.EqualError(t, customizeCRDStorage(context.TODO(), crd), "custom resource definition tests.yaks.dev marks several versions as storage: v1alpha1, v1")
+	// Setting the storage version of the context unmarks the other ones
+	assert.Nil(t, customizeCRDStorage(WithCRDStorageVersion(context.TODO(), "v1"), crd))
+}
+
+func TestEmbeddedCRDStorageVersions(t *testing.T) {
+	for _, crd := range embeddedCRDs {
+		obj, err := kubernetes.LoadRawResourceFromYaml(deploy.Resources[crd.Resource])
+		assert.Nil(t, err)
+		version, err := CRDStorageVersion(obj.(*unstructured.Unstructured))
+		assert.Nil(t, err)
+		assert.Equal(t, "v1alpha1", version)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterSetupSummary counts what happened to the cluster-wide resources during their installation
+type ClusterSetupSummary struct {
+	CRDs         ApplyCounts `json:"crds"`
+	ClusterRoles ApplyCounts `json:"clusterRoles"`
+	Others       ApplyCounts `json:"others"`
+	// Errors that stopped the installation
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ApplyCounts --
+type ApplyCounts struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+func (c *ApplyCounts) add(result ApplyResult) {
+	switch result {
+	case ApplyResultCreated:
+		c.Created++
+	case ApplyResultUpdated:
+		c.Updated++
+	case ApplyResultUnchanged:
+		c.Unchanged++
+	case ApplyResultSkipped:
+		c.Skipped++
+	}
+}
+
+func (c ApplyCounts) String() string {
+	return fmt.Sprintf("%d created, %d updated, %d unchanged, %d skipped", c.Created, c.Updated, c.Unchanged, c.Skipped)
+}
+
+// observe returns a context recording the resources applied with it in the summary, that still notifies the observer
+// of the given context
+func (s *ClusterSetupSummary) observe(ctx context.Context) context.Context {
+	previous, _ := ctx.Value(applyObserverKey{}).(ApplyObserver)
+	return WithApplyObserver(ctx, func(obj runtime.Object, result ApplyResult) {
+		s.record(obj, result)
+		if previous != nil {
+			previous(obj, result)
+		}
+	})
+}
+
+func (s *ClusterSetupSummary) record(obj runtime.Object, result ApplyResult) {
+	switch obj.GetObjectKind().GroupVersionKind().Kind {
+	case "CustomResourceDefinition":
+		s.CRDs.add(result)
+	case "ClusterRole":
+		s.ClusterRoles.add(result)
+	default:
+		s.Others.add(result)
+	}
+}
+
+// fail records the error stopping the installation and returns it
+func (s *ClusterSetupSummary) fail(err error) error {
+	s.Errors = append(s.Errors, err.Error())
+	return err
+}
+
+func (s *ClusterSetupSummary) String() string {
+	lines := []string{
+		"CRDs: " + s.CRDs.String(),
+		"Cluster roles: " + s.ClusterRoles.String(),
+		"Other resources: " + s.Others.String(),
+		fmt.Sprintf("Errors: %d", len(s.Errors)),
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestClusterSetupSummary(t *testing.T) {
+	observed := 0
+	ctx := WithApplyObserver(context.Background(), func(obj runtime.Object, result ApplyResult) {
+		observed++
+	})
+	summary := &ClusterSetupSummary{}
+	ctx = summary.observe(ctx)
+
+	crd := &unstructured.Unstructured{}
+	crd.SetKind("CustomResourceDefinition")
+	clusterRole := &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{Kind: "ClusterRole", APIVersion: rbacv1.SchemeGroupVersion.String()},
+	}
+	notifyApplyObserver(ctx, crd, ApplyResultCreated)
+	notifyApplyObserver(ctx, crd, ApplyResultUnchanged)
+	notifyApplyObserver(ctx, clusterRole, ApplyResultUpdated)
+	assert.NotNil(t, summary.fail(errors.New("forbidden")))
+
+	// The observer of the context is still notified
+	assert.Equal(t, 3, observed)
+	assert.Equal(t, ApplyCounts{Created: 1, Unchanged: 1}, summary.CRDs)
+	assert.Equal(t, ApplyCounts{Updated: 1}, summary.ClusterRoles)
+	assert.Equal(t, ApplyCounts{}, summary.Others)
+	assert.Equal(t, []string{"forbidden"}, summary.Errors)
+	assert.Equal(t, "CRDs: 1 created, 0 updated, 1 unchanged, 0 skipped\n"+
+		"Cluster roles: 0 created, 1 updated, 0 unchanged, 0 skipped\n"+
+		"Other resources: 0 created, 0 updated, 0 unchanged, 0 skipped\n"+
+		"Errors: 1", summary.String())
+}
@@ -21,6 +21,9 @@ import (
 	"context"
 
 	"github.com/jboss-fuse/yaks/pkg/client"
+
+	"k8s.io/api/rbac/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // ViewerServiceAccountRoles installs the viewer service account and related roles in the given namespace
@@ -31,3 +34,26 @@ func ViewerServiceAccountRoles(ctx context.Context, c client.Client, namespace s
 		"viewer_role_binding.yaml",
 	)
 }
+
+// ViewerRolesForNamespace grants the viewer service account of the given namespace the viewer role in another namespace,
+// through the role binding of the given name and labels
+func ViewerRolesForNamespace(ctx context.Context, c client.Client, namespace string, serviceAccountNamespace string, bindingName string, labels map[string]string) error {
+	if err := Resource(ctx, c, namespace, IdentityResourceCustomizer, "viewer_role.yaml"); err != nil {
+		return err
+	}
+	return Resource(ctx, c, namespace, func(object runtime.Object) runtime.Object {
+		if rb, ok := object.(*v1beta1.RoleBinding); ok {
+			rb.Name = bindingName
+			if rb.Labels == nil {
+				rb.Labels = make(map[string]string)
+			}
+			for key, value := range labels {
+				rb.Labels[key] = value
+			}
+			for i := range rb.Subjects {
+				rb.Subjects[i].Namespace = serviceAccountNamespace
+			}
+		}
+		return object
+	}, "viewer_role_binding.yaml")
+}
@@ -0,0 +1,141 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+)
+
+// junitTestSuite is the root element of a JUnit XML report
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       float64          `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Error      *junitMessage    `xml:"error,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+}
+
+// traceIDProperty is the property of the test cases holding the trace ID of the test
+const traceIDProperty = "trace_id"
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	// Type holds the reason code of the test, so that CI servers can tell apart the causes of the failures
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// PrintJUnit writes the summary as a JUnit XML report, as understood by most CI servers
+func (s *Summary) PrintJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      "yaks",
+		Tests:     s.Total,
+		Failures:  s.Failed,
+		Errors:    s.Errors,
+		Skipped:   s.Skipped,
+		TestCases: make([]junitTestCase, 0, len(s.Tests)),
+	}
+	for _, result := range s.Tests {
+		testCase := newJUnitTestCase(result)
+		suite.Time += testCase.Time
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return writeJUnit(w, suite)
+}
+
+// newJUnitTestCase returns the test case reporting the result of the test as a whole
+func newJUnitTestCase(result TestResult) junitTestCase {
+	testCase := junitTestCase{
+		Name:      result.Name,
+		ClassName: classNameOf(result),
+		Time:      seconds(result.Duration),
+	}
+	if result.TraceID != "" {
+		testCase.Properties = &junitProperties{
+			Properties: []junitProperty{{Name: traceIDProperty, Value: result.TraceID}},
+		}
+	}
+	message := &junitMessage{
+		Message: result.Message,
+		Type:    string(result.Reason),
+		Content: result.Message,
+	}
+	switch result.Phase {
+	case v1alpha1.TestPhasePassed:
+	case v1alpha1.TestPhaseFailed:
+		testCase.Failure = message
+	case v1alpha1.TestPhaseSkipped:
+		testCase.Skipped = message
+	default:
+		testCase.Error = message
+	}
+	return testCase
+}
+
+func classNameOf(result TestResult) string {
+	if result.Group != "" {
+		return "yaks." + result.Group
+	}
+	return "yaks"
+}
+
+func writeJUnit(w io.Writer, suite junitTestSuite) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(duration string) float64 {
+	if d, err := time.ParseDuration(duration); err == nil {
+		return d.Seconds()
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// ResultParser extracts the scenario results from the termination message written by a runner
+type ResultParser interface {
+	Parse(message string) ([]v1alpha1.ScenarioResult, error)
+}
+
+var resultParsers = map[v1alpha1.ResultFormat]ResultParser{
+	v1alpha1.ResultFormatCitrus: citrusResultParser{},
+	v1alpha1.ResultFormatTAP:    tapResultParser{},
+	v1alpha1.ResultFormatJSON:   jsonResultParser{},
+}
+
+// ResultParserFor returns the parser of the given result format, the Citrus one when no format is given
+func ResultParserFor(format v1alpha1.ResultFormat) (ResultParser, bool) {
+	if format == "" {
+		format = v1alpha1.ResultFormatCitrus
+	}
+	parser, ok := resultParsers[format]
+	return parser, ok
+}
+
+// citrusResultParser parses the summary written by the runner TestReporter, one "<name> SUCCESS|SKIPPED|FAILED" line per
+// scenario, failures being followed by their cause that may span several lines
+type citrusResultParser struct{}
+
+var (
+	citrusResultLine  = regexp.MustCompile(`^(.+) (SUCCESS|SKIPPED|UNKNOWN STATE)$`)
+	citrusFailureLine = regexp.MustCompile(`^(.+) FAILED - Caused by: (.*)$`)
+)
+
+func (citrusResultParser) Parse(message string) ([]v1alpha1.ScenarioResult, error) {
+	results := make([]v1alpha1.ScenarioResult, 0)
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if match := citrusFailureLine.FindStringSubmatch(line); match != nil {
+			results = append(results, v1alpha1.ScenarioResult{
+				Name:    match[1],
+				Status:  v1alpha1.ScenarioStatusFailed,
+				Message: match[2],
+			})
+		} else if match := citrusResultLine.FindStringSubmatch(line); match != nil {
+			status := v1alpha1.ScenarioStatusPassed
+			if match[2] != "SUCCESS" {
+				status = v1alpha1.ScenarioStatusSkipped
+			}
+			results = append(results, v1alpha1.ScenarioResult{
+				Name:   match[1],
+				Status: status,
+			})
+		} else if last := len(results) - 1; last >= 0 && results[last].Status == v1alpha1.ScenarioStatusFailed && line != "" {
+			results[last].Message += "\n" + line
+		}
+	}
+	return results, scanner.Err()
+}
+
+// tapResultParser parses Test Anything Protocol reports
+type tapResultParser struct{}
+
+var tapTestLine = regexp.MustCompile(`^(not )?ok\b\s*\d*\s*(?:- )?([^#]*?)\s*(?:#\s*(\w+)\s*(.*))?$`)
+
+func (tapResultParser) Parse(message string) ([]v1alpha1.ScenarioResult, error) {
+	results := make([]v1alpha1.ScenarioResult, 0)
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		match := tapTestLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			// Plan, diagnostics and YAML blocks
+			continue
+		}
+		result := v1alpha1.ScenarioResult{
+			Name:   match[2],
+			Status: v1alpha1.ScenarioStatusPassed,
+		}
+		switch directive := strings.ToUpper(match[3]); {
+		case directive == "SKIP" || directive == "TODO":
+			result.Status = v1alpha1.ScenarioStatusSkipped
+			result.Message = match[4]
+		case match[1] != "":
+			result.Status = v1alpha1.ScenarioStatusFailed
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
+
+// jsonResultParser parses a JSON array of scenario results
+type jsonResultParser struct{}
+
+func (jsonResultParser) Parse(message string) ([]v1alpha1.ScenarioResult, error) {
+	results := make([]v1alpha1.ScenarioResult, 0)
+	if err := json.Unmarshal([]byte(message), &results); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON results")
+	}
+	for _, result := range results {
+		switch result.Status {
+		case v1alpha1.ScenarioStatusPassed, v1alpha1.ScenarioStatusFailed, v1alpha1.ScenarioStatusSkipped:
+		default:
+			return nil, errors.New(fmt.Sprintf("invalid status %q of scenario %q", result.Status, result.Name))
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,81 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCitrusResultParser(t *testing.T) {
+	parser, ok := ResultParserFor("")
+	assert.True(t, ok)
+
+	results, err := parser.Parse("print slogan SUCCESS\n" +
+		"call api FAILED - Caused by: com.consol.citrus.exceptions.ValidationException: Values not equal\n" +
+		"expected 200\n" +
+		"\n" +
+		"wait for pod SKIPPED")
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.ScenarioResult{
+		{Name: "print slogan", Status: v1alpha1.ScenarioStatusPassed},
+		{Name: "call api", Status: v1alpha1.ScenarioStatusFailed, Message: "com.consol.citrus.exceptions.ValidationException: Values not equal\nexpected 200"},
+		{Name: "wait for pod", Status: v1alpha1.ScenarioStatusSkipped},
+	}, results)
+}
+
+func TestTAPResultParser(t *testing.T) {
+	parser, ok := ResultParserFor(v1alpha1.ResultFormatTAP)
+	assert.True(t, ok)
+
+	results, err := parser.Parse("TAP version 13\n" +
+		"1..3\n" +
+		"ok 1 - print slogan\n" +
+		"not ok 2 - call api\n" +
+		"  ---\n" +
+		"  message: Values not equal\n" +
+		"  ...\n" +
+		"ok 3 - wait for pod # SKIP no cluster access\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.ScenarioResult{
+		{Name: "print slogan", Status: v1alpha1.ScenarioStatusPassed},
+		{Name: "call api", Status: v1alpha1.ScenarioStatusFailed},
+		{Name: "wait for pod", Status: v1alpha1.ScenarioStatusSkipped, Message: "no cluster access"},
+	}, results)
+}
+
+func TestJSONResultParser(t *testing.T) {
+	parser, ok := ResultParserFor(v1alpha1.ResultFormatJSON)
+	assert.True(t, ok)
+
+	results, err := parser.Parse(`[{"name": "print slogan", "status": "Passed"}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha1.ScenarioResult{
+		{Name: "print slogan", Status: v1alpha1.ScenarioStatusPassed},
+	}, results)
+
+	_, err = parser.Parse(`[{"name": "print slogan", "status": "Done"}]`)
+	assert.NotNil(t, err)
+}
+
+func TestUnknownResultFormat(t *testing.T) {
+	_, ok := ResultParserFor("xml")
+	assert.False(t, ok)
+}
@@ -0,0 +1,160 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Summary aggregates the results of a set of tests
+type Summary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Errors  int `json:"errors"`
+	Skipped int `json:"skipped"`
+	// PassRate is the percentage of the tests that have passed among the ones that have not been skipped, 100 when
+	// all of them have been skipped
+	PassRate float64      `json:"passRate"`
+	Tests    []TestResult `json:"tests"`
+}
+
+// TestResult is the outcome of a single test
+type TestResult struct {
+	Name     string             `json:"name"`
+	Group    string             `json:"group,omitempty"`
+	Phase    v1alpha1.TestPhase `json:"phase"`
+	Duration string             `json:"duration,omitempty"`
+	Message  string             `json:"message,omitempty"`
+	ExitCode *int32             `json:"exitCode,omitempty"`
+	Timings  *Timings           `json:"timings,omitempty"`
+	// Reason is the machine readable code explaining the phase of the test
+	Reason v1alpha1.TestReason `json:"reason,omitempty"`
+	// Retries is the number of times the test has been run again on the reason of its failure
+	Retries int32 `json:"retries,omitempty"`
+	// TraceID correlates the test with the traces of the systems under test
+	TraceID string `json:"traceId,omitempty"`
+	// Scenarios reported by the runner
+	Scenarios []v1alpha1.ScenarioResult `json:"scenarios,omitempty"`
+	// Source of the test, so that it can be run again from the report
+	Source *TestSource `json:"source,omitempty"`
+}
+
+// TestSource is what has been run by a test
+type TestSource struct {
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// Spec of the test, including the content of its sources
+	Spec v1alpha1.TestSpec `json:"spec"`
+}
+
+// Timings splits the duration of a test between the time spent waiting for it to start and the time spent running it
+type Timings struct {
+	Queued  string `json:"queued,omitempty"`
+	Running string `json:"running,omitempty"`
+}
+
+// NewTestResult creates the result for the given test, that took the given duration to complete
+func NewTestResult(test *v1alpha1.Test, duration time.Duration) TestResult {
+	result := TestResult{
+		Name:      test.Name,
+		Group:     test.Labels[v1alpha1.TestGroupLabel],
+		Phase:     test.Status.Phase,
+		Message:   test.Status.Message,
+		Reason:    test.Status.Reason,
+		Retries:   test.Status.Retries,
+		ExitCode:  test.Status.ExitCode,
+		TraceID:   test.Status.TraceID,
+		Scenarios: test.Status.Results,
+		Source: &TestSource{
+			Namespace: test.Namespace,
+			Labels:    test.Labels,
+			Spec:      *test.Spec.DeepCopy(),
+		},
+	}
+	if duration > 0 {
+		result.Duration = duration.Round(time.Millisecond).String()
+	}
+	if timings := test.Status.Timings; timings != nil {
+		result.Timings = &Timings{
+			Queued:  between(timings.Pending, timings.Running),
+			Running: between(timings.Running, timings.Completed),
+		}
+	}
+	return result
+}
+
+func between(from *metav1.Time, to *metav1.Time) string {
+	if from == nil || to == nil {
+		return ""
+	}
+	return to.Sub(from.Time).Round(time.Millisecond).String()
+}
+
+// NewSummary creates a summary of the given results
+func NewSummary(results ...TestResult) *Summary {
+	summary := Summary{
+		PassRate: 100,
+		Tests:    make([]TestResult, 0, len(results)),
+	}
+	for _, result := range results {
+		summary.Add(result)
+	}
+	return &summary
+}
+
+// Add adds a test result to the summary
+func (s *Summary) Add(result TestResult) {
+	s.Tests = append(s.Tests, result)
+	s.Total++
+
+	switch result.Phase {
+	case v1alpha1.TestPhasePassed:
+		s.Passed++
+	case v1alpha1.TestPhaseFailed:
+		s.Failed++
+	case v1alpha1.TestPhaseSkipped:
+		s.Skipped++
+	default:
+		s.Errors++
+	}
+	if run := s.Total - s.Skipped; run > 0 {
+		s.PassRate = math.Round(10000*float64(s.Passed)/float64(run)) / 100
+	}
+}
+
+// FailureRate returns the percentage of the tests that have failed or errored among the ones that have not been skipped
+func (s *Summary) FailureRate() float64 {
+	if run := s.Total - s.Skipped; run > 0 {
+		return 100 * float64(s.Failed+s.Errors) / float64(run)
+	}
+	return 0
+}
+
+// PrintJSON writes the summary in JSON format
+func (s *Summary) PrintJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
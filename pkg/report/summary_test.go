@@ -0,0 +1,44 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassRate(t *testing.T) {
+	summary := NewSummary()
+	assert.Equal(t, 100.0, summary.PassRate)
+	assert.Equal(t, 0.0, summary.FailureRate())
+
+	summary.Add(TestResult{Name: "skipped", Phase: v1alpha1.TestPhaseSkipped})
+	assert.Equal(t, 100.0, summary.PassRate)
+
+	summary.Add(TestResult{Name: "passed", Phase: v1alpha1.TestPhasePassed})
+	summary.Add(TestResult{Name: "other", Phase: v1alpha1.TestPhasePassed})
+	summary.Add(TestResult{Name: "failed", Phase: v1alpha1.TestPhaseFailed})
+	assert.Equal(t, 66.67, summary.PassRate)
+	assert.InDelta(t, 33.33, summary.FailureRate(), 0.01)
+
+	summary.Add(TestResult{Name: "error", Phase: v1alpha1.TestPhaseError})
+	assert.Equal(t, 50.0, summary.PassRate)
+	assert.Equal(t, 50.0, summary.FailureRate())
+}
@@ -0,0 +1,142 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/util/gherkin"
+)
+
+const (
+	// DefaultCaseIDTag matches the tags like @TC-1234
+	DefaultCaseIDTag = `^@(TC-\d+)$`
+	// DefaultCaseIDProperty is the property name TestRail reads the case IDs from, Xray uses test_key
+	DefaultCaseIDProperty = "test_id"
+)
+
+// CaseIDOptions tells how the case IDs of a test management system are read from the tags of the scenarios
+type CaseIDOptions struct {
+	// Tag matches the tags holding a case ID, the ID being its first group, or the tag without @ when it has none
+	Tag *regexp.Regexp
+	// Property is the name of the JUnit property carrying the case IDs
+	Property string
+}
+
+// PrintTestCases writes the summary as a JUnit XML report with one test case per scenario, the case IDs tagged on
+// the scenarios being set as properties, as imported by test management systems like TestRail or Xray. The tests
+// without scenario results, e.g. those that could not be started, are reported as a whole.
+func (s *Summary) PrintTestCases(w io.Writer, options CaseIDOptions) error {
+	if options.Tag == nil {
+		options.Tag = regexp.MustCompile(DefaultCaseIDTag)
+	}
+	if options.Property == "" {
+		options.Property = DefaultCaseIDProperty
+	}
+	suite := junitTestSuite{
+		Name:      "yaks",
+		TestCases: make([]junitTestCase, 0, len(s.Tests)),
+	}
+	for _, result := range s.Tests {
+		if len(result.Scenarios) == 0 {
+			suite.TestCases = append(suite.TestCases, newJUnitTestCase(result))
+			continue
+		}
+		tags := scenarioTagsOf(result)
+		for _, scenario := range result.Scenarios {
+			suite.TestCases = append(suite.TestCases, newScenarioTestCase(result, scenario, tags[scenario.Name], options))
+		}
+	}
+	for _, testCase := range suite.TestCases {
+		suite.Tests++
+		suite.Time += testCase.Time
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	return writeJUnit(w, suite)
+}
+
+func newScenarioTestCase(result TestResult, scenario v1alpha1.ScenarioResult, tags []string, options CaseIDOptions) junitTestCase {
+	testCase := junitTestCase{
+		Name:      scenario.Name,
+		ClassName: classNameOf(result) + "." + result.Name,
+	}
+	properties := make([]junitProperty, 0)
+	for _, id := range caseIDs(tags, options.Tag) {
+		properties = append(properties, junitProperty{Name: options.Property, Value: id})
+	}
+	if result.TraceID != "" {
+		properties = append(properties, junitProperty{Name: traceIDProperty, Value: result.TraceID})
+	}
+	if len(properties) > 0 {
+		testCase.Properties = &junitProperties{Properties: properties}
+	}
+	message := &junitMessage{
+		Message: scenario.Message,
+		Content: scenario.Message,
+	}
+	switch scenario.Status {
+	case v1alpha1.ScenarioStatusFailed:
+		testCase.Failure = message
+	case v1alpha1.ScenarioStatusSkipped:
+		testCase.Skipped = message
+	}
+	return testCase
+}
+
+// caseIDs returns the case IDs of the tags matching the pattern
+func caseIDs(tags []string, pattern *regexp.Regexp) []string {
+	ids := make([]string, 0)
+	for _, tag := range tags {
+		match := pattern.FindStringSubmatch(tag)
+		switch {
+		case match == nil:
+		case len(match) > 1:
+			ids = append(ids, match[1])
+		default:
+			ids = append(ids, strings.TrimPrefix(tag, "@"))
+		}
+	}
+	return ids
+}
+
+// scenarioTagsOf returns the tags of the scenarios of the sources of the test, by scenario name
+func scenarioTagsOf(result TestResult) map[string][]string {
+	tags := make(map[string][]string)
+	if result.Source == nil {
+		return tags
+	}
+	sources := append([]v1alpha1.SourceSpec{result.Source.Spec.Source}, result.Source.Spec.Sources...)
+	for _, source := range sources {
+		for name, scenarioTags := range gherkin.ScenarioTags(source.Content) {
+			if _, ok := tags[name]; !ok {
+				tags[name] = scenarioTags
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,89 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+const taggedFeature = `@TC-1
+Feature: Greetings
+
+  @TC-2 @smoke
+  Scenario: Say hello
+    Given a greeting
+
+  Scenario: Say goodbye
+    Given a farewell
+    """
+    @TC-9
+    Scenario: not a scenario
+    """
+`
+
+func TestPrintTestCases(t *testing.T) {
+	summary := NewSummary(
+		TestResult{
+			Name:  "greetings",
+			Phase: v1alpha1.TestPhaseFailed,
+			Scenarios: []v1alpha1.ScenarioResult{
+				{Name: "Say hello", Status: v1alpha1.ScenarioStatusPassed},
+				{Name: "Say goodbye", Status: v1alpha1.ScenarioStatusFailed, Message: "no farewell"},
+			},
+			Source: &TestSource{
+				Spec: v1alpha1.TestSpec{
+					Source: v1alpha1.SourceSpec{Name: "greetings.feature", Content: taggedFeature},
+				},
+			},
+		},
+		TestResult{
+			Name:    "broken",
+			Phase:   v1alpha1.TestPhaseError,
+			Reason:  v1alpha1.TestReasonImagePullError,
+			Message: "image cannot be pulled",
+		},
+	)
+
+	var out bytes.Buffer
+	assert.Nil(t, summary.PrintTestCases(&out, CaseIDOptions{}))
+	report := out.String()
+	assert.Contains(t, report, `<testsuite name="yaks" tests="3" failures="1" errors="1" skipped="0" time="0">`)
+	assert.Contains(t, report, `<testcase name="Say hello" classname="yaks.greetings" time="0">
+    <properties>
+      <property name="test_id" value="TC-1"></property>
+      <property name="test_id" value="TC-2"></property>
+    </properties>
+  </testcase>`)
+	assert.Contains(t, report, `<property name="test_id" value="TC-1"></property>
+    </properties>
+    <failure message="no farewell">no farewell</failure>`)
+	assert.NotContains(t, report, "TC-9")
+	assert.Contains(t, report, `<testcase name="broken" classname="yaks" time="0">`)
+	assert.Contains(t, report, `<error message="image cannot be pulled" type="ImagePullError">image cannot be pulled</error>`)
+
+	out.Reset()
+	options := CaseIDOptions{Tag: regexp.MustCompile(`^@smoke$`), Property: "test_key"}
+	assert.Nil(t, summary.PrintTestCases(&out, options))
+	assert.Contains(t, out.String(), `<property name="test_key" value="smoke"></property>`)
+	assert.NotContains(t, out.String(), "TC-")
+}
@@ -20,6 +20,8 @@ package digest
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
 	"github.com/jboss-fuse/yaks/version"
@@ -42,6 +44,52 @@ func ComputeForTest(test *v1alpha1.Test) (string, error) {
 	if _, err := hash.Write([]byte(test.Spec.Source.Name)); err != nil {
 		return "", err
 	}
+	if filter := test.Spec.Source.Filter; filter != nil {
+		if _, err := hash.Write([]byte(fmt.Sprintf("%s:%d", filter.Scenario, filter.Line))); err != nil {
+			return "", err
+		}
+	}
+	for _, source := range test.Spec.Sources {
+		if _, err := hash.Write([]byte(source.Name)); err != nil {
+			return "", err
+		}
+		if _, err := hash.Write([]byte(source.Content)); err != nil {
+			return "", err
+		}
+	}
+	// Runtime settings are relevant
+	runtime, err := json.Marshal(test.Spec.Runtime)
+	if err != nil {
+		return "", err
+	}
+	if _, err := hash.Write(runtime); err != nil {
+		return "", err
+	}
+	// Endpoints are relevant
+	endpoints, err := json.Marshal(test.Spec.Endpoints)
+	if err != nil {
+		return "", err
+	}
+	if _, err := hash.Write(endpoints); err != nil {
+		return "", err
+	}
+
+	// Target namespace is relevant
+	if _, err := hash.Write([]byte(test.Spec.Namespace)); err != nil {
+		return "", err
+	}
+	// Requirements are relevant
+	for _, requirement := range test.Spec.Requires {
+		if _, err := hash.Write([]byte(requirement)); err != nil {
+			return "", err
+		}
+	}
+	// Dependencies are relevant
+	for _, dependency := range test.Spec.Dependencies {
+		if _, err := hash.Write([]byte(string(dependency.Kind) + "/" + dependency.Name)); err != nil {
+			return "", err
+		}
+	}
 
 	// Add a letter at the beginning and use URL safe encoding
 	digest := "v" + base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
@@ -0,0 +1,440 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gherkin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severity --
+type Severity string
+
+const (
+	// SeverityError is reported for input the runner cannot parse
+	SeverityError Severity = "error"
+	// SeverityWarning is reported for input that parses but is likely wrong
+	SeverityWarning Severity = "warning"
+)
+
+// Issue --
+type Issue struct {
+	Line     int
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d: %s: %s", i.Line, i.Severity, i.Message)
+}
+
+// HasErrors returns true when any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// StepCatalog holds the patterns of the known steps
+type StepCatalog []*regexp.Regexp
+
+// Matches returns true when the step text matches one of the patterns of the catalog
+func (c StepCatalog) Matches(text string) bool {
+	for _, pattern := range c {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+var cucumberParameters = strings.NewReplacer(
+	`\{string\}`, `("[^"]*"|'[^']*')`,
+	`\{int\}`, `-?\d+`,
+	`\{float\}`, `-?\d*\.?\d+`,
+	`\{word\}`, `[^\s]+`,
+	`\{\}`, `.*`,
+)
+
+// ParseStepCatalog reads one step pattern per line, blank lines and lines starting with # being ignored. Patterns
+// anchored with ^ or $ are regular expressions, the others Cucumber expressions supporting the {string}, {int},
+// {float}, {word} and {} parameters.
+func ParseStepCatalog(r io.Reader) (StepCatalog, error) {
+	catalog := make(StepCatalog, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expression := line
+		if !strings.HasPrefix(line, "^") && !strings.HasSuffix(line, "$") {
+			expression = "^" + cucumberParameters.Replace(regexp.QuoteMeta(line)) + "$"
+		}
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid step pattern %q", line)
+		}
+		catalog = append(catalog, pattern)
+	}
+	return catalog, scanner.Err()
+}
+
+// LoadStepCatalog reads the step catalog of the given file
+func LoadStepCatalog(fileName string) (StepCatalog, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseStepCatalog(file)
+}
+
+type section int
+
+const (
+	sectionNone section = iota
+	sectionFeature
+	sectionRule
+	sectionBackground
+	sectionScenario
+	sectionOutline
+	sectionExamples
+)
+
+var (
+	stepKeywords     = []string{"Given ", "When ", "Then ", "And ", "But ", "* "}
+	outlineKeywords  = []string{"Scenario Outline:", "Scenario Template:"}
+	scenarioKeywords = []string{"Scenario:", "Example:"}
+	examplesKeywords = []string{"Examples:", "Scenarios:"}
+	placeholder      = regexp.MustCompile(`<[^<>]+>`)
+	language         = regexp.MustCompile(`^#\s*language\s*:\s*(\S+)`)
+)
+
+// lineKind tells apart the lines of a feature by their keyword
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineComment
+	lineTags
+	lineTableRow
+	lineDocString
+	lineFeature
+	lineRule
+	lineBackground
+	lineOutline
+	lineScenario
+	lineExamples
+	lineStep
+	lineOther
+)
+
+type linter struct {
+	catalog    StepCatalog
+	issues     []Issue
+	section    section
+	header     int
+	steps      int
+	hasFeature bool
+	background bool
+	scenarios  bool
+	outline    int
+	examples   bool
+	table      int
+	columns    int
+	afterStep  bool
+	describing bool
+}
+
+// Lint checks the Gherkin syntax of a feature, reporting the steps that do not match the catalog as warnings when
+// one is given. Only the English keywords are supported.
+func Lint(content string, catalog StepCatalog) []Issue {
+	l := linter{catalog: catalog, issues: make([]Issue, 0)}
+	supported := true
+	docStringLine := scanLines(content, func(number int, kind lineKind, line string) bool {
+		if kind != lineTableRow && kind != lineDocString {
+			l.table = 0
+		}
+
+		switch kind {
+		case lineDocString:
+			if !l.afterStep {
+				l.errorf(number, "doc string must follow a step")
+			}
+			l.afterStep = false
+		case lineComment:
+			if match := language.FindStringSubmatch(line); match != nil && !l.hasFeature && match[1] != "en" {
+				l.warnf(number, "language %q is not supported by the linter, the feature is not checked", match[1])
+				supported = false
+			}
+		case lineTags:
+			for _, tag := range tagsOf(line) {
+				if !strings.HasPrefix(tag, "@") || tag == "@" {
+					l.errorf(number, "invalid tag %q", tag)
+				}
+			}
+		case lineTableRow:
+			l.tableRow(number, line)
+		case lineFeature:
+			if l.hasFeature {
+				l.errorf(number, "only one Feature is allowed per file")
+			}
+			l.hasFeature = true
+			l.enter(number, sectionFeature)
+		case lineRule:
+			l.requireFeature(number, "Rule")
+			l.background, l.scenarios = false, false
+			l.enter(number, sectionRule)
+		case lineBackground:
+			l.requireFeature(number, "Background")
+			if l.background {
+				l.errorf(number, "only one Background is allowed")
+			} else if l.scenarios {
+				l.errorf(number, "Background must come before the scenarios")
+			}
+			l.background = true
+			l.enter(number, sectionBackground)
+		case lineOutline:
+			l.requireFeature(number, "Scenario Outline")
+			l.scenarios = true
+			l.enter(number, sectionOutline)
+			l.outline, l.examples = number, false
+		case lineScenario:
+			l.requireFeature(number, "Scenario")
+			l.scenarios = true
+			l.enter(number, sectionScenario)
+		case lineExamples:
+			if l.section != sectionOutline && l.section != sectionExamples {
+				l.errorf(number, "Examples must belong to a Scenario Outline")
+			}
+			l.closeScenario(sectionExamples)
+			l.examples = true
+			l.section, l.header, l.describing, l.afterStep = sectionExamples, number, true, false
+		case lineStep:
+			l.step(number, line)
+		case lineOther:
+			if !l.describing {
+				l.errorf(number, "unexpected line %q, expected a step, a keyword or a comment", line)
+			}
+		}
+		return supported
+	})
+	if !supported {
+		return l.issues
+	}
+	if docStringLine != 0 {
+		l.errorf(docStringLine, "doc string is not closed")
+	}
+	l.closeScenario(sectionNone)
+	if !l.hasFeature {
+		l.errorf(1, "no Feature found")
+	}
+	return l.issues
+}
+
+// scanLines calls visit with the number, the kind and the trimmed content of each line of the feature, skipping the
+// content of the doc strings, until visit returns false. It returns the line opening a doc string left unclosed.
+func scanLines(content string, visit func(number int, kind lineKind, line string) bool) int {
+	docString, docStringLine := "", 0
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i, raw := range lines {
+		number := i + 1
+		line := strings.TrimSpace(raw)
+
+		if docString != "" {
+			if strings.HasPrefix(line, docString) {
+				docString, docStringLine = "", 0
+			}
+			continue
+		}
+		kind := kindOf(line)
+		if kind == lineDocString {
+			docString, docStringLine = line[:3], number
+		}
+		if !visit(number, kind, line) {
+			return 0
+		}
+	}
+	return docStringLine
+}
+
+func kindOf(line string) lineKind {
+	switch {
+	case line == "":
+		return lineBlank
+	case strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "```"):
+		return lineDocString
+	case strings.HasPrefix(line, "#"):
+		return lineComment
+	case strings.HasPrefix(line, "@"):
+		return lineTags
+	case strings.HasPrefix(line, "|"):
+		return lineTableRow
+	case strings.HasPrefix(line, "Feature:"):
+		return lineFeature
+	case strings.HasPrefix(line, "Rule:"):
+		return lineRule
+	case strings.HasPrefix(line, "Background:"):
+		return lineBackground
+	case hasKeyword(line, outlineKeywords):
+		return lineOutline
+	case hasKeyword(line, scenarioKeywords):
+		return lineScenario
+	case hasKeyword(line, examplesKeywords):
+		return lineExamples
+	case isStep(line):
+		return lineStep
+	default:
+		return lineOther
+	}
+}
+
+func isStep(line string) bool {
+	return hasKeyword(line, stepKeywords)
+}
+
+func hasKeyword(line string, keywords []string) bool {
+	_, ok := trimKeyword(line, keywords)
+	return ok
+}
+
+// trimKeyword returns the line without the first of the keywords it starts with
+func trimKeyword(line string, keywords []string) (string, bool) {
+	for _, keyword := range keywords {
+		if strings.HasPrefix(line, keyword) {
+			return strings.TrimSpace(strings.TrimPrefix(line, keyword)), true
+		}
+	}
+	return line, false
+}
+
+// tagsOf returns the tags of a line, up to the comment ending it if any
+func tagsOf(line string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Fields(line) {
+		if strings.HasPrefix(tag, "#") {
+			break
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func (l *linter) enter(number int, s section) {
+	l.closeScenario(s)
+	l.section, l.header, l.steps, l.describing, l.afterStep = s, number, 0, true, false
+}
+
+// closeScenario reports the issues of the section being left for the next one
+func (l *linter) closeScenario(next section) {
+	switch l.section {
+	case sectionScenario, sectionOutline, sectionBackground:
+		if l.steps == 0 {
+			l.warnf(l.header, "no step defined")
+		}
+	}
+	if l.outline != 0 && next != sectionExamples {
+		if !l.examples {
+			l.warnf(l.outline, "Scenario Outline has no Examples, it does not run")
+		}
+		l.outline = 0
+	}
+}
+
+func (l *linter) requireFeature(number int, keyword string) {
+	if !l.hasFeature {
+		l.errorf(number, "%s must belong to a Feature", keyword)
+	}
+}
+
+func (l *linter) step(number int, line string) {
+	switch l.section {
+	case sectionBackground, sectionScenario, sectionOutline:
+	default:
+		l.errorf(number, "step must belong to a Scenario or a Background")
+	}
+	l.steps++
+	l.describing, l.afterStep = false, true
+	if len(l.catalog) == 0 {
+		return
+	}
+	text, _ := trimKeyword(line, stepKeywords)
+	if l.section == sectionOutline && placeholder.MatchString(text) {
+		return
+	}
+	if !l.catalog.Matches(text) {
+		l.warnf(number, "unknown step %q", text)
+	}
+}
+
+func (l *linter) tableRow(number int, line string) {
+	if l.table == 0 && !l.afterStep && l.section != sectionExamples {
+		l.errorf(number, "table must follow a step or Examples")
+	}
+	if !strings.HasSuffix(line, "|") {
+		l.errorf(number, "table row must end with |")
+		return
+	}
+	columns := len(splitCells(line))
+	if l.table == 0 {
+		l.table, l.columns = number, columns
+	} else if columns != l.columns {
+		l.errorf(number, "table row has %d cells, expected %d as in line %d", columns, l.columns, l.table)
+	}
+	l.describing, l.afterStep = false, false
+}
+
+// splitCells splits a table row into its cells, honoring escaped pipes
+func splitCells(line string) []string {
+	cells := make([]string, 0)
+	inner := line[1 : len(line)-1]
+	cell := strings.Builder{}
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' && i+1 < len(inner) {
+			cell.WriteByte(inner[i])
+			cell.WriteByte(inner[i+1])
+			i++
+			continue
+		}
+		if inner[i] == '|' {
+			cells = append(cells, cell.String())
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(inner[i])
+	}
+	return append(cells, cell.String())
+}
+
+func (l *linter) errorf(number int, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{Line: number, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(number int, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{Line: number, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gherkin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validFeature = `# language: en
+@smoke
+Feature: Greetings
+  A description of the feature
+
+  Background:
+    Given a greeting "hello"
+
+  Scenario: Say hello
+    When I say hello 3 times
+    Then the replies are
+      | name | greeting |
+      | joe  | hello    |
+    And the log contains
+      """
+      Feature: not a keyword inside a doc string
+      """
+
+  Scenario Outline: Say hello to <name>
+    When I say hello to <name>
+    Examples:
+      | name |
+      | joe  |
+`
+
+func TestLintValidFeature(t *testing.T) {
+	assert.Empty(t, Lint(validFeature, nil))
+}
+
+func TestLintUnknownSteps(t *testing.T) {
+	catalog, err := ParseStepCatalog(strings.NewReader("# steps\na greeting {string}\nI say hello {int} times\n^the (replies are|log contains)$\n"))
+	assert.Nil(t, err)
+	assert.Len(t, catalog, 3)
+
+	issues := Lint(validFeature+"\n  Scenario: Other\n    Given an unknown step\n", catalog)
+	assert.False(t, HasErrors(issues))
+	assert.Equal(t, []Issue{{Line: 26, Severity: SeverityWarning, Message: `unknown step "an unknown step"`}}, issues)
+}
+
+func TestLintErrors(t *testing.T) {
+	issues := Lint(`Scenario: Orphan
+Feature: Broken
+  Scenario: First
+    Given a step
+    not a step
+      | a | b |
+      | 1 |
+  Background:
+    Given a late background
+  Scenario: Open doc string
+    Given a step
+    """
+`, nil)
+	assert.True(t, HasErrors(issues))
+
+	lines := make([]int, 0)
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			lines = append(lines, issue.Line)
+		}
+	}
+	assert.Equal(t, []int{1, 5, 7, 8, 12}, lines)
+}
+
+func TestLintWarnings(t *testing.T) {
+	issues := Lint("Feature: Empty\n  Scenario: No step\n  Scenario Outline: No examples\n    Given <x>\n", nil)
+	assert.Equal(t, []Issue{
+		{Line: 2, Severity: SeverityWarning, Message: "no step defined"},
+		{Line: 3, Severity: SeverityWarning, Message: "Scenario Outline has no Examples, it does not run"},
+	}, issues)
+
+	assert.Equal(t, SeverityError, Lint("", nil)[0].Severity)
+}
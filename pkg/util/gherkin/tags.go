@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gherkin
+
+// ScenarioTags returns the tags of the scenarios of the feature by scenario name, including the tags inherited from
+// the feature and from the rule they belong to. The tags of the examples of scenario outlines are ignored.
+func ScenarioTags(content string) map[string][]string {
+	tags := make(map[string][]string)
+	var pending, feature, rule []string
+	scanLines(content, func(_ int, kind lineKind, line string) bool {
+		switch kind {
+		case lineBlank, lineComment:
+		case lineTags:
+			pending = append(pending, tagsOf(line)...)
+		case lineFeature:
+			feature, rule, pending = pending, nil, nil
+		case lineRule:
+			rule, pending = pending, nil
+		case lineOutline:
+			name, _ := trimKeyword(line, outlineKeywords)
+			tags[name] = appendMissing(tags[name], feature, rule, pending)
+			pending = nil
+		case lineScenario:
+			name, _ := trimKeyword(line, scenarioKeywords)
+			tags[name] = appendMissing(tags[name], feature, rule, pending)
+			pending = nil
+		default:
+			pending = nil
+		}
+		return true
+	})
+	return tags
+}
+
+func appendMissing(values []string, others ...[]string) []string {
+	for _, other := range others {
+		for _, value := range other {
+			found := false
+			for _, existing := range values {
+				found = found || existing == value
+			}
+			if !found {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
@@ -19,16 +19,16 @@ package customclient
 
 import (
 	"github.com/jboss-fuse/yaks/pkg/apis/yaks/v1alpha1"
+	"github.com/jboss-fuse/yaks/pkg/client"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 // GetClientFor returns a RESTClient for the given group and version
 func GetClientFor(c kubernetes.Interface, group string, version string) (*rest.RESTClient, error) {
-	inConfig, err := config.GetConfig()
+	inConfig, err := client.GetConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +38,10 @@ func GetClientFor(c kubernetes.Interface, group string, version string) (*rest.R
 		Version: version,
 	}
 	conf.APIPath = "/apis"
+	if group == "" {
+		// The core group is served under the legacy path
+		conf.APIPath = "/api"
+	}
 	conf.AcceptContentTypes = "application/json"
 	conf.ContentType = "application/json"
 
@@ -52,7 +56,7 @@ func GetClientFor(c kubernetes.Interface, group string, version string) (*rest.R
 
 // GetDynamicClientFor returns a dynamic client for a given kind
 func GetDynamicClientFor(group string, version string, kind string, namespace string) (dynamic.ResourceInterface, error) {
-	conf, err := config.GetConfig()
+	conf, err := client.GetConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +73,7 @@ func GetDynamicClientFor(group string, version string, kind string, namespace st
 
 // GetDefaultDynamicClientFor returns a dynamic client for a given kind
 func GetDefaultDynamicClientFor(kind string, namespace string) (dynamic.ResourceInterface, error) {
-	conf, err := config.GetConfig()
+	conf, err := client.GetConfig()
 	if err != nil {
 		return nil, err
 	}
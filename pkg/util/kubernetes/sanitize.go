@@ -18,6 +18,8 @@ limitations under the License.
 package kubernetes
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"path"
 	"regexp"
 	"strings"
@@ -54,6 +56,23 @@ func SanitizeLabel(name string) string {
 	return name
 }
 
+// ShortHash returns a short hash of the given value, that tells apart values sanitized to the same name
+func ShortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// TruncateName limits the name to the given length, replacing its end with a hash of the whole name when it is
+// longer so that two truncated names stay distinct
+func TruncateName(name string, length int) string {
+	if len(name) <= length {
+		return name
+	}
+	hash := ShortHash(name)
+	prefix := strings.TrimRightFunc(name[:length-len(hash)-1], isDisallowedStartEndChar)
+	return prefix + "-" + hash
+}
+
 func isDisallowedStartEndChar(rune rune) bool {
 	return !unicode.IsLetter(rune) && !unicode.IsNumber(rune)
 }
@@ -39,6 +39,9 @@ const (
 	sleepTime = 400 * time.Millisecond
 )
 
+// ErrWaitTimeout is returned by WaitCondition when the condition is not satisfied in time
+var ErrWaitTimeout = errors.New("timeout while waiting condition")
+
 // WaitCondition --
 func WaitCondition(ctx context.Context, c client.Client, obj runtime.Object, condition ResourceCheckFunction, maxDuration time.Duration) error {
 	start := time.Now()
@@ -47,6 +50,11 @@ func WaitCondition(ctx context.Context, c client.Client, obj runtime.Object, con
 		return err
 	}
 	for start.Add(maxDuration).After(time.Now()) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
 		err := c.Get(ctx, key, obj)
 		if err != nil {
 			if k8serrors.IsNotFound(err) {
@@ -68,5 +76,5 @@ func WaitCondition(ctx context.Context, c client.Client, obj runtime.Object, con
 
 		return nil
 	}
-	return errors.New("timeout while waiting condition")
+	return ErrWaitTimeout
 }
@@ -0,0 +1,331 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrManifestNotFound is returned when the registry does not have the manifest of the image
+	ErrManifestNotFound = errors.New("manifest not found")
+	// ErrUnauthorized is returned when the registry denies the access to the manifest of the image
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+const (
+	dockerHub         = "docker.io"
+	dockerHubEndpoint = "registry-1.docker.io"
+	// dockerHubRealm is the host of the authorization server of Docker Hub
+	dockerHubRealm = "auth.docker.io"
+)
+
+// manifestMediaTypes are the manifest formats accepted when looking up an image, including the multi-platform ones
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// Reference is an image name split into the registry hosting it, its repository and its tag or digest
+type Reference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or the digest of the image
+	Reference string
+}
+
+// ParseReference parses an image name, normalized as the container runtimes do, e.g. yaks/yaks being
+// docker.io/yaks/yaks:latest
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	if ref.Reference == "" {
+		ref.Reference = "latest"
+	}
+
+	ref.Registry = dockerHub
+	if i := strings.Index(name, "/"); i >= 0 {
+		if host := name[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, name = host, name[i+1:]
+		}
+	}
+	if ref.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || name != strings.ToLower(name) || strings.ContainsAny(name, " \t@:") {
+		return Reference{}, errors.New(fmt.Sprintf("invalid image name %q", image))
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// Credentials --
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// CredentialsFor returns the credentials of the registry from the content of an image pull secret, either in the
+// .dockerconfigjson or in the legacy .dockercfg format, or nil when it has none for the registry
+func CredentialsFor(dockerConfig []byte, registry string) (*Credentials, error) {
+	config := struct {
+		Auths map[string]dockerConfigEntry `json:"auths"`
+	}{}
+	if err := json.Unmarshal(dockerConfig, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid docker config")
+	}
+	if config.Auths == nil {
+		// Legacy format, the entries being at the top level
+		if err := json.Unmarshal(dockerConfig, &config.Auths); err != nil {
+			return nil, errors.Wrap(err, "invalid docker config")
+		}
+	}
+
+	for key, entry := range config.Auths {
+		if !matchesRegistry(key, registry) {
+			continue
+		}
+		credentials := Credentials{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid auth of registry %s", key)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, errors.New(fmt.Sprintf("invalid auth of registry %s", key))
+			}
+			credentials.Username, credentials.Password = parts[0], parts[1]
+		}
+		return &credentials, nil
+	}
+	return nil, nil
+}
+
+// matchesRegistry tells whether a key of a docker config, that may be a URL, designates the registry
+func matchesRegistry(key string, registry string) bool {
+	host := key
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.SplitN(host, "/", 2)[0]
+	if registry == dockerHub {
+		return host == dockerHub || host == "index.docker.io" || host == dockerHubEndpoint
+	}
+	return host == registry
+}
+
+// Client looks up image manifests with the Docker Registry HTTP API V2
+type Client struct {
+	HTTPClient *http.Client
+	// TrustedRealms are the hosts of the authorization servers the credentials of the registries are sent to, in
+	// addition to the registries themselves and to the authorization server of Docker Hub
+	TrustedRealms []string
+}
+
+// CheckManifest checks that the registry has the manifest of the image, with a HEAD request authenticated with the given
+// credentials, if any, e.g. to make sure the image can be pulled. It returns ErrManifestNotFound or ErrUnauthorized,
+// wrapped, when the registry answers it does not have or denies access to the manifest, and other errors when the
+// registry cannot be reached.
+func (c *Client) CheckManifest(ctx context.Context, ref Reference, credentials *Credentials) error {
+	endpoint := ref.Registry
+	if endpoint == dockerHub {
+		endpoint = dockerHubEndpoint
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", endpoint, ref.Repository, ref.Reference)
+
+	response, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return err
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorize(ctx, endpoint, response.Header.Get("WWW-Authenticate"), credentials)
+		if err != nil {
+			return err
+		}
+		if authorization != "" {
+			if response, err = c.headManifest(ctx, manifestURL, authorization); err != nil {
+				return err
+			}
+		}
+	}
+
+	name := ref.Registry + "/" + ref.Repository + ":" + ref.Reference
+	switch {
+	case response.StatusCode/100 == 2:
+		return nil
+	case response.StatusCode == http.StatusNotFound:
+		return errors.Wrap(ErrManifestNotFound, name)
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+		return errors.Wrap(ErrUnauthorized, name)
+	default:
+		return errors.New(fmt.Sprintf("cannot look up %s: %s", name, response.Status))
+	}
+}
+
+func (c *Client) headManifest(ctx context.Context, manifestURL string, authorization string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	response, err := c.httpClient().Do(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	return response, nil
+}
+
+// authorize returns the authorization answering the challenge of the registry, obtaining a token from its
+// authorization server for the Bearer scheme, or an empty authorization when the challenge cannot be answered
+func (c *Client) authorize(ctx context.Context, endpoint string, challenge string, credentials *Credentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch {
+	case strings.EqualFold(scheme, "Basic") && credentials != nil:
+		return "Basic " + basicAuth(credentials), nil
+	case strings.EqualFold(scheme, "Bearer") && params["realm"] != "":
+		token, err := c.token(ctx, endpoint, params, credentials)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", nil
+	}
+}
+
+// token obtains a token from the authorization server given by the realm of the challenge of the registry. The
+// credentials are only sent to a trusted authorization server over https, an anonymous token being requested otherwise.
+func (c *Client) token(ctx context.Context, endpoint string, params map[string]string, credentials *Credentials) (string, error) {
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid authorization realm")
+	}
+	if credentials != nil && !c.trustsRealm(tokenURL, endpoint) {
+		credentials = nil
+	}
+	query := tokenURL.Query()
+	for _, name := range []string{"service", "scope"} {
+		if value := params[name]; value != "" {
+			query.Set(name, value)
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	request, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	request = request.WithContext(ctx)
+	if credentials != nil {
+		request.Header.Set("Authorization", "Basic "+basicAuth(credentials))
+	}
+	response, err := c.httpClient().Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return "", errors.Wrap(ErrUnauthorized, "cannot get a registry token")
+	} else if response.StatusCode/100 != 2 {
+		return "", errors.New(fmt.Sprintf("cannot get a registry token: %s", response.Status))
+	}
+
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", errors.Wrap(err, "invalid registry token")
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// trustsRealm tells whether the credentials of the registry at the given endpoint can be sent to the authorization
+// server, i.e. whether it is reached over https on the host of the registry or on a trusted host
+func (c *Client) trustsRealm(realm *url.URL, endpoint string) bool {
+	if realm.Scheme != "https" {
+		return false
+	}
+	trusted := append([]string{endpoint}, c.TrustedRealms...)
+	if endpoint == dockerHubEndpoint {
+		trusted = append(trusted, dockerHubRealm)
+	}
+	for _, host := range trusted {
+		if strings.EqualFold(realm.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseChallenge splits a WWW-Authenticate header into its scheme and its parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	params := make(map[string]string)
+	if len(parts) == 2 {
+		for _, match := range challengeParam.FindAllStringSubmatch(parts[1], -1) {
+			params[strings.ToLower(match[1])] = match[2]
+		}
+	}
+	return parts[0], params
+}
+
+func basicAuth(credentials *Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(credentials.Username + ":" + credentials.Password))
+}
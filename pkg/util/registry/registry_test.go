@@ -0,0 +1,160 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	cases := map[string]Reference{
+		"yaks":                               {Registry: "docker.io", Repository: "library/yaks", Reference: "latest"},
+		"yaks/yaks:0.0.1":                    {Registry: "docker.io", Repository: "yaks/yaks", Reference: "0.0.1"},
+		"quay.io/yaks/yaks":                  {Registry: "quay.io", Repository: "yaks/yaks", Reference: "latest"},
+		"localhost:5000/yaks:dev":            {Registry: "localhost:5000", Repository: "yaks", Reference: "dev"},
+		"registry.local/team/yaks@sha256:ab": {Registry: "registry.local", Repository: "team/yaks", Reference: "sha256:ab"},
+	}
+	for image, expected := range cases {
+		ref, err := ParseReference(image)
+		assert.Nil(t, err, image)
+		assert.Equal(t, expected, ref, image)
+	}
+
+	_, err := ParseReference("quay.io/Yaks/yaks")
+	assert.NotNil(t, err)
+}
+
+func TestCredentialsFor(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+	config := []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth + `"},"quay.io":{"username":"user","password":"pass"}}}`)
+
+	credentials, err := CredentialsFor(config, "docker.io")
+	assert.Nil(t, err)
+	assert.Equal(t, &Credentials{Username: "robot", Password: "secret"}, credentials)
+
+	credentials, err = CredentialsFor(config, "quay.io")
+	assert.Nil(t, err)
+	assert.Equal(t, &Credentials{Username: "user", Password: "pass"}, credentials)
+
+	credentials, err = CredentialsFor(config, "gcr.io")
+	assert.Nil(t, err)
+	assert.Nil(t, credentials)
+
+	legacy := []byte(`{"quay.io":{"auth":"` + auth + `"}}`)
+	credentials, err = CredentialsFor(legacy, "quay.io")
+	assert.Nil(t, err)
+	assert.Equal(t, &Credentials{Username: "robot", Password: "secret"}, credentials)
+}
+
+func TestCheckManifest(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if username, password, ok := r.BasicAuth(); !ok || username != "robot" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "repository:team/yaks:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"t0k3n"}`))
+		case r.Header.Get("Authorization") != "Bearer t0k3n":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:team/yaks:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/team/yaks/manifests/0.0.1":
+			assert.True(t, strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json"))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := Client{HTTPClient: server.Client()}
+	host := strings.TrimPrefix(server.URL, "https://")
+	credentials := &Credentials{Username: "robot", Password: "secret"}
+
+	ref, err := ParseReference(host + "/team/yaks:0.0.1")
+	assert.Nil(t, err)
+	assert.Nil(t, c.CheckManifest(context.TODO(), ref, credentials))
+
+	err = c.CheckManifest(context.TODO(), ref, nil)
+	assert.Equal(t, ErrUnauthorized, errors.Cause(err))
+
+	ref.Reference = "missing"
+	err = c.CheckManifest(context.TODO(), ref, credentials)
+	assert.Equal(t, ErrManifestNotFound, errors.Cause(err))
+	assert.Contains(t, err.Error(), host+"/team/yaks:missing")
+}
+
+func TestCredentialsOnlySentToTrustedRealm(t *testing.T) {
+	// The authorization server advertised by the registry, on another host
+	var sentCredentials bool
+	realm := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, sentCredentials = r.BasicAuth()
+		_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+	}))
+	defer realm.Close()
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm.URL+`/token",service="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer registry.Close()
+
+	c := Client{HTTPClient: realm.Client()}
+	ref, err := ParseReference(strings.TrimPrefix(registry.URL, "https://") + "/team/yaks:0.0.1")
+	assert.Nil(t, err)
+	credentials := &Credentials{Username: "robot", Password: "secret"}
+
+	err = c.CheckManifest(context.TODO(), ref, credentials)
+	assert.Equal(t, ErrUnauthorized, errors.Cause(err))
+	assert.False(t, sentCredentials)
+
+	c.TrustedRealms = []string{strings.TrimPrefix(realm.URL, "https://")}
+	err = c.CheckManifest(context.TODO(), ref, credentials)
+	assert.Equal(t, ErrUnauthorized, errors.Cause(err))
+	assert.True(t, sentCredentials)
+}
+
+func TestTrustsRealm(t *testing.T) {
+	c := Client{TrustedRealms: []string{"auth.example.com"}}
+	cases := map[string]bool{
+		"https://quay.io/v2/auth":          true,
+		"http://quay.io/v2/auth":           false,
+		"https://auth.example.com/token":   true,
+		"https://attacker.example.com/tok": false,
+	}
+	for realm, trusted := range cases {
+		u, err := url.Parse(realm)
+		assert.Nil(t, err)
+		assert.Equal(t, trusted, c.trustsRealm(u, "quay.io"), realm)
+	}
+
+	u, err := url.Parse("https://auth.docker.io/token")
+	assert.Nil(t, err)
+	assert.True(t, c.trustsRealm(u, dockerHubEndpoint))
+	assert.False(t, c.trustsRealm(u, "quay.io"))
+}
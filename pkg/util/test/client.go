@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"github.com/jboss-fuse/yaks/pkg/apis"
+	"github.com/jboss-fuse/yaks/pkg/client"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientscheme "k8s.io/client-go/kubernetes/scheme"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// NewFakeClient returns an in-memory client, serving the given objects of the Kubernetes and Yaks schemes
+func NewFakeClient(objects ...runtime.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := clientscheme.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return &fakeClient{
+		Client:    fakeclient.NewFakeClientWithScheme(scheme, objects...),
+		Clientset: fakeclientset.NewSimpleClientset(),
+		scheme:    scheme,
+	}
+}
+
+type fakeClient struct {
+	k8sclient.Client
+	*fakeclientset.Clientset
+	scheme *runtime.Scheme
+}
+
+func (c *fakeClient) GetScheme() *runtime.Scheme {
+	return c.scheme
+}